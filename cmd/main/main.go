@@ -24,6 +24,9 @@ const (
 // @version 1.0
 // @description orders API
 // @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
 func main() {
 	bootstrapLogger, err := zap.NewProduction()
 	if err != nil {
@@ -0,0 +1,140 @@
+/////////////////////////////////////
+//
+// Утилита удаления персональных данных покупателя(GDPR)
+//
+// go run cmd/tools/forget_customer/main.go -customer <customer_id> -reason "..." -actor <кто выполняет>
+//
+/////////////////////////////////////
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+
+	"wb_tech_level_zero/internal/cache"
+	"wb_tech_level_zero/internal/config"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/internal/repository"
+	"wb_tech_level_zero/internal/service"
+	"wb_tech_level_zero/pkg/db"
+	"wb_tech_level_zero/pkg/envelope"
+	appLogger "wb_tech_level_zero/pkg/logger"
+	"wb_tech_level_zero/pkg/redisclient"
+)
+
+func main() {
+	customerID := flag.String("customer", "", "ID покупателя")
+	reason := flag.String("reason", "", "основание удаления")
+	actor := flag.String("actor", os.Getenv("USER"), "кто выполняет удаление")
+	flag.Parse()
+
+	if *customerID == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	_ = godotenv.Load()
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	zapLogger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer zapLogger.Sync()
+	logger := appLogger.New(zapLogger, "forget_customer")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pgPool, err := db.NewPostgresPool(ctx, db.PostgresConfig{
+		Host:     cfg.PostgresHost,
+		Port:     cfg.PostgresPort,
+		User:     cfg.PostgresUser,
+		Password: cfg.PostgresPassword,
+		DBName:   cfg.PostgresDB,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to Postgres: %v", err)
+	}
+	defer pgPool.Close()
+
+	redisClient, err := redisclient.New(ctx, redisclient.RedisConfig{
+		Mode:               cfg.RedisMode,
+		Host:               cfg.RedisHost,
+		Port:               cfg.RedisPort,
+		Username:           cfg.RedisUsername,
+		Password:           cfg.RedisPassword,
+		DB:                 cfg.RedisDB,
+		SentinelMasterName: cfg.RedisSentinelMaster,
+		SentinelAddrs:      cfg.RedisSentinelAddrs,
+		SentinelUsername:   cfg.RedisSentinelUsername,
+		SentinelPassword:   cfg.RedisSentinelPassword,
+		ClusterNodes:       cfg.RedisClusterNodes,
+		TLS: redisclient.TLSConfig{
+			Enabled:            cfg.RedisTLSEnabled,
+			CAFile:             cfg.RedisTLSCAFile,
+			CertFile:           cfg.RedisTLSCertFile,
+			KeyFile:            cfg.RedisTLSKeyFile,
+			ServerName:         cfg.RedisTLSServerName,
+			InsecureSkipVerify: cfg.RedisTLSInsecureSkipVerify,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	defer redisClient.Close()
+
+	var (
+		repoCipher  repository.FieldCipher
+		cacheCipher cache.FieldCipher
+	)
+	keyring, err := envelope.OpenConfig(ctx, repository.NewKeyStore(pgPool), envelope.Config{
+		MasterKeyID:        cfg.EncryptionMasterKeyID,
+		MasterKeyFile:      cfg.EncryptionMasterKeyFile,
+		MasterKey:          cfg.EncryptionMasterKey,
+		PreviousMasterKeys: cfg.EncryptionPreviousMasterKeys,
+	})
+	switch {
+	case errors.Is(err, envelope.ErrNoMasterKey):
+		// шифрование выключено
+	case err != nil:
+		log.Fatalf("Failed to open encryption keys: %v", err)
+	default:
+		repoCipher, cacheCipher = keyring, keyring
+	}
+
+	orderCache, err := cache.NewOrdersCache(redisClient, cache.CacheConfig{
+		TTL:            cfg.OrderTTLMinutes,
+		ListTTLSeconds: cfg.OrdersListTTLSec,
+		Codec:          cfg.CacheCodec,
+		Compression:    cfg.CacheCompression,
+		Cipher:         cacheCipher,
+	})
+	if err != nil {
+		log.Fatalf("Failed to init cache: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	svc := service.NewOrdersService(cfg, repository.NewOrdersRepository(pgPool, repoCipher), orderCache, nil, &wg, logger)
+	defer svc.Close(ctx)
+
+	ctx = orders.WithChangeSource(ctx, orders.ChangeSource{Kind: orders.SourceCLI, Actor: *actor})
+	erasure, err := svc.ForgetCustomer(ctx, *customerID, *reason)
+	if err != nil {
+		log.Fatalf("Failed to erase customer data: %v", err)
+	}
+
+	log.Printf("Erasure #%d completed: customer_ref=%s, orders=%d", erasure.ID, erasure.CustomerRef, len(erasure.OrderUIDs))
+}
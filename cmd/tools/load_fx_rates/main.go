@@ -0,0 +1,130 @@
+/////////////////////////////////////
+//
+// Утилита загрузки курсов валют из файла
+//
+// go run cmd/tools/load_fx_rates/main.go -file rates.csv [-source ecb]
+//
+// CSV: заголовок date,base,quote,rate и строки вида 2026-10-18,EUR,USD,1.0712(1 base = rate quote)
+// JSON: объект или массив объектов {"date":"2026-10-18","base":"EUR","rates":{"USD":"1.0712"}} - формат топика KAFKA_FX_TOPIC
+//
+// Повторная загрузка курса на ту же дату перезаписывает его
+//
+/////////////////////////////////////
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"wb_tech_level_zero/internal/config"
+	"wb_tech_level_zero/internal/delivery/kafkadelivery"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/internal/repository"
+	"wb_tech_level_zero/pkg/db"
+)
+
+func main() {
+	file := flag.String("file", "", "файл с курсами(.csv или .json)")
+	source := flag.String("source", "", "источник курсов, по умолчанию - имя файла")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *source == "" {
+		*source = "file:" + filepath.Base(*file)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("Failed to read rates file: %v", err)
+	}
+
+	var rates []orders.FXRate
+	if strings.EqualFold(filepath.Ext(*file), ".csv") {
+		rates, err = parseCSV(bytes.NewReader(data), *source)
+	} else {
+		rates, err = kafkadelivery.ParseAndValidateFXRates(data, *source)
+	}
+	if err != nil {
+		log.Fatalf("Failed to parse rates file: %v", err)
+	}
+
+	_ = godotenv.Load()
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	pgPool, err := db.NewPostgresPool(ctx, db.PostgresConfig{
+		Host:     cfg.PostgresHost,
+		Port:     cfg.PostgresPort,
+		User:     cfg.PostgresUser,
+		Password: cfg.PostgresPassword,
+		DBName:   cfg.PostgresDB,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to Postgres: %v", err)
+	}
+	defer pgPool.Close()
+
+	if err := repository.NewOrdersRepository(pgPool, nil).SaveFXRates(ctx, rates); err != nil {
+		log.Fatalf("Failed to save rates: %v", err)
+	}
+	log.Printf("FX rates loaded from %s: %d", *file, len(rates))
+}
+
+func parseCSV(r io.Reader, source string) ([]orders.FXRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(strings.Join(header, ",")) != "date,base,quote,rate" {
+		return nil, errors.New("expected header date,base,quote,rate")
+	}
+
+	var rates []orders.FXRate
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		date, err := time.Parse(time.DateOnly, rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line, rec[0])
+		}
+		rate, err := orders.NewFXRate(date, rec[1], rec[2], rec[3], source)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+}
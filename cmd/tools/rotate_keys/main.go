@@ -0,0 +1,96 @@
+/////////////////////////////////////
+//
+// Утилита ротации ключей шифрования данных доставки
+//
+// go run cmd/tools/rotate_keys/main.go [-new-key=false] [-batch 500]
+//
+// 1. Ключи данных, обернутые прежними мастер-ключами(ENCRYPTION_PREVIOUS_MASTER_KEYS),
+//    перешифровываются текущим мастер-ключом
+// 2. Создается новый активный ключ данных(-new-key)
+// 3. Данные доставки и снимки версий перешифровываются активным ключом; открытые значения,
+//    записанные до включения шифрования, шифруются, для них заполняются слепые индексы
+//
+/////////////////////////////////////
+
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+
+	"wb_tech_level_zero/internal/config"
+	"wb_tech_level_zero/internal/repository"
+	"wb_tech_level_zero/pkg/db"
+	"wb_tech_level_zero/pkg/envelope"
+)
+
+func main() {
+	newKey := flag.Bool("new-key", true, "создать новый активный ключ данных")
+	batchSize := flag.Int("batch", 500, "размер пачки при перешифровании")
+	flag.Parse()
+
+	_ = godotenv.Load()
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	pgPool, err := db.NewPostgresPool(ctx, db.PostgresConfig{
+		Host:     cfg.PostgresHost,
+		Port:     cfg.PostgresPort,
+		User:     cfg.PostgresUser,
+		Password: cfg.PostgresPassword,
+		DBName:   cfg.PostgresDB,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to Postgres: %v", err)
+	}
+	defer pgPool.Close()
+
+	keyring, err := envelope.OpenConfig(ctx, repository.NewKeyStore(pgPool), envelope.Config{
+		MasterKeyID:        cfg.EncryptionMasterKeyID,
+		MasterKeyFile:      cfg.EncryptionMasterKeyFile,
+		MasterKey:          cfg.EncryptionMasterKey,
+		PreviousMasterKeys: cfg.EncryptionPreviousMasterKeys,
+	})
+	if err != nil {
+		log.Fatalf("Failed to open encryption keys: %v", err)
+	}
+
+	rewrapped, err := keyring.RewrapKeys(ctx)
+	if err != nil {
+		log.Fatalf("Failed to rewrap data keys: %v", err)
+	}
+	log.Printf("Data keys rewrapped with master key %q: %d", cfg.EncryptionMasterKeyID, rewrapped)
+
+	if *newKey {
+		id, err := keyring.CreateDataKey(ctx)
+		if err != nil {
+			log.Fatalf("Failed to create data key: %v", err)
+		}
+		log.Printf("New active data key: %d", id)
+	}
+
+	repo := repository.NewOrdersRepository(pgPool, keyring)
+	activeID := keyring.ActiveKeyID()
+
+	deliveries, err := repo.ReencryptDeliveries(ctx, activeID, *batchSize)
+	if err != nil {
+		log.Fatalf("Failed to re-encrypt deliveries (%d updated): %v", deliveries, err)
+	}
+	log.Printf("Deliveries re-encrypted: %d", deliveries)
+
+	versions, err := repo.ReencryptVersions(ctx, activeID, *batchSize)
+	if err != nil {
+		log.Fatalf("Failed to re-encrypt order versions (%d updated): %v", versions, err)
+	}
+	log.Printf("Order versions re-encrypted: %d", versions)
+}
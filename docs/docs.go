@@ -15,16 +15,314 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/order/{uid}": {
+        "/admin/customers/{customer_id}/erase": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Anonymizing delivery data of all customer orders, keeping financial records",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Erasing customer personal data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID покупателя",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Основание удаления",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErasureRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErasureDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/customers/{customer_id}/orders": {
             "get": {
-                "description": "Getting orders by UID",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Getting orders of the customer, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "customers"
                 ],
-                "summary": "Getting orders by UID",
+                "summary": "Getting customer orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID покупателя",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы(не больше MAX_PAGE_LIMIT)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting",
+                        "name": "reporting_currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrdersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/customers/{customer_id}/summary": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Getting order count, spend per currency, first/last order date and favourite brands of the customer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customers"
+                ],
+                "summary": "Getting customer summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID покупателя",
+                        "name": "customer_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CustomerSummaryDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/orders/search": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Searching orders by exact match of order, payment, item and delivery contact fields; criteria are combined with AND",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Searching orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Трек-номер",
+                        "name": "track_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID транзакции оплаты",
+                        "name": "transaction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "request_id оплаты",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "rid позиции",
+                        "name": "rid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "nm_id позиции",
+                        "name": "nm_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Бренд позиции(без учета регистра)",
+                        "name": "brand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Телефон доставки",
+                        "name": "phone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email доставки",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы(не больше MAX_PAGE_LIMIT)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting",
+                        "name": "reporting_currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrdersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/orders/{uid}/status": {
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Changing order status according to the order lifecycle",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Changing order status",
                 "parameters": [
                     {
                         "type": "string",
@@ -32,6 +330,15 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Новый статус",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ChangeStatusRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -41,167 +348,1869 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.OrderDTO"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "string"
-                        }
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Getting webhook subscriptions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebhookDTO"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Subscribing an external URL to order events. Deliveries are signed with HMAC-SHA256 of \"\u003ct\u003e.\u003cbody\u003e\" in the X-Webhook-Signature header (\"t=\u003cunix\u003e,v1=\u003chex\u003e\"). The secret is generated if omitted and returned only in this response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Creating webhook subscription",
+                "parameters": [
+                    {
+                        "description": "Подписка",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Getting webhook subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Replacing URL, filter and state of the subscription. Re-enabling resets the failure counter; a non-empty secret rotates it and is returned in the response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Updating webhook subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Подписка",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Deleting the subscription together with its delivery log",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Deleting webhook subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Getting the latest delivery attempts of the subscription, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Getting webhook delivery log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей(по умолчанию 50, не больше 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebhookDeliveryDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "post": {
+                "description": "Querying orders with GraphQL: order(order_uid), orders(filter, sort, order, page, limit, cursor),\ncustomer_orders(customer_id, page, limit) and orders_by_uids(order_uids). Field names match the JSON of the REST API.\ncustomer_orders requires the admin token in the Authorization: Bearer header, otherwise the field returns an error.\nQueries exceeding GRAPHQL_MAX_DEPTH or GRAPHQL_MAX_COMPLEXITY are rejected before execution.\nGET accepts query, variables(JSON) and operationName query parameters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "graphql"
+                ],
+                "summary": "GraphQL query",
+                "parameters": [
+                    {
+                        "description": "Запрос",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.GraphQLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GraphQLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GraphQLResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{uid}": {
+            "get": {
+                "description": "Getting orders by UID. With the admin token the response includes the fraud score assigned on ingestion",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Getting orders by UID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UID заказа",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Состояние заказа на момент времени(RFC3339)",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting",
+                        "name": "reporting_currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{uid}/history": {
+            "get": {
+                "description": "Getting all versions of the order with the source of each change",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Getting order change history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UID заказа",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderHistoryResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/order/{uid}/refunds": {
+            "get": {
+                "description": "Getting refunds recorded for the order payment transaction",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Getting order refunds",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UID заказа",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.RefundDTO"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "description": "Getting orders page with optional filters and sorting; filters are combined with AND.\nPass next_cursor/prev_cursor from the response as cursor for keyset pagination(date sort only); page is ignored then",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Getting orders list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы(не больше MAX_PAGE_LIMIT)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата создания от(RFC3339 или YYYY-MM-DD)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата создания до(RFC3339 или YYYY-MM-DD включительно)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Служба доставки",
+                        "name": "delivery_service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локаль",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта оплаты",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Платежный провайдер",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Банк",
+                        "name": "bank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID покупателя",
+                        "name": "customer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Город доставки",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Регион доставки",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Минимальная сумма оплаты, десятичное число(10.50)",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Максимальная сумма оплаты, десятичное число(10.50)",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Бренд позиции(без учета регистра)",
+                        "name": "brand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Статус позиции",
+                        "name": "item_status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "date",
+                            "amount",
+                            "items"
+                        ],
+                        "type": "string",
+                        "description": "Поле сортировки",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор страницы из next_cursor/prev_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "estimate",
+                            "none"
+                        ],
+                        "type": "string",
+                        "description": "Подсчет total(по умолчанию exact без курсора и none с курсором)",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting",
+                        "name": "reporting_currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrdersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/lookup": {
+            "post": {
+                "description": "Getting up to ORDERS_LOOKUP_MAX_UIDS orders in one request: cached orders are read with a single MGET,\nthe rest with a single database query. Orders are returned in request order, unknown UIDs - in missing.\nThe request body is limited to 1 MiB",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Looking up orders by UIDs",
+                "parameters": [
+                    {
+                        "description": "UID заказов",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrdersLookupRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting",
+                        "name": "reporting_currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrdersLookupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/search": {
+            "get": {
+                "description": "Searching orders by words in item names and brands, delivery city and address, ranked by relevance.\nSupports \"quoted phrases\", OR and -exclusion; falls back to fuzzy trigram search when no word matches",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Full-text searching orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Текст запроса",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы(не больше MAX_PAGE_LIMIT)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting",
+                        "name": "reporting_currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TextSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/stream": {
+            "get": {
+                "description": "Server-Sent Events stream of order summaries pushed as orders are ingested. Each event has \"id\" (sequence number shared by all instances), \"event\" (event type) and \"data\" (dto.StreamOrderDTO). Reconnecting with the Last-Event-ID header (or last_event_id query parameter) replays buffered events after that id. Heartbeat comments are sent every SSE_HEARTBEAT_SECONDS",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Streaming newly processed orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Типы событий через запятую(по умолчанию order.created)",
+                        "name": "event_types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Службы доставки через запятую",
+                        "name": "delivery_service",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID покупателей через запятую",
+                        "name": "customer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер последнего полученного события(вместо заголовка Last-Event-ID)",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Токен доступа(если нельзя передать заголовок Authorization)",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StreamOrderDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/ws": {
+            "get": {
+                "description": "WebSocket endpoint. The client sends {\"action\":\"subscribe\"|\"unsubscribe\",\"order_uids\":[...]} and receives dto.WSServerMessage: the current order (\"order\") on subscribe and an \"update\" with the event on order.status_changed, order.cancelled, order.items_cancelled, order.refunded and order.pii_erased. Other changes of delivery or payment data are not published. The token from WS_AUTH_TOKENS or ADMIN_TOKEN is passed in the Authorization header; the access_token query parameter accepts only WS_AUTH_TOKENS and requires the service origin or one of WS_ALLOWED_ORIGINS. The server pings every WS_PING_SECONDS; the number of orders per connection is limited by WS_MAX_SUBSCRIPTIONS",
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Subscribing to order updates over WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен доступа(если нельзя передать заголовок Authorization)",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WSServerMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/orders": {
+            "get": {
+                "description": "Getting order counts and revenue per currency from daily rollups, grouped by period and dimensions.\nGroups are ordered by period and dimension values; revenue is the payment amount at creation, refunded - recorded refunds",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Getting order statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Измерения через запятую: day|week|month, delivery_service, provider, bank, currency, region, brand(по умолчанию day)",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Первый день(YYYY-MM-DD, по умолчанию 30 дней до date_to)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний день включительно(YYYY-MM-DD, по умолчанию сегодня)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отчетности для итогов выручки(ISO 4217)",
+                        "name": "reporting_currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.BrandCountDTO": {
+            "type": "object",
+            "properties": {
+                "brand": {
+                    "type": "string",
+                    "example": "Vivienne Sabo"
+                },
+                "items": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ChangeStatusRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "payment confirmed"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "paid"
+                }
+            }
+        },
+        "dto.CurrencyAmountDTO": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 1817.5
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "dto.CustomerSummaryDTO": {
+            "type": "object",
+            "properties": {
+                "customer_id": {
+                    "type": "string"
+                },
+                "favourite_brands": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BrandCountDTO"
+                    }
+                },
+                "first_order_at": {
+                    "type": "string"
+                },
+                "last_order_at": {
+                    "type": "string"
+                },
+                "orders_count": {
+                    "type": "integer"
+                },
+                "spend": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CurrencyAmountDTO"
+                    }
+                }
+            }
+        },
+        "dto.DeliveryDTO": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "zip": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ErasureDTO": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "customer_ref": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_uids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ErasureRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "GDPR request #123"
+                }
+            }
+        },
+        "dto.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "An unexpected error occurred."
+                }
+            }
+        },
+        "dto.FraudDTO": {
+            "type": "object",
+            "properties": {
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FraudRuleDTO"
+                    }
+                },
+                "score": {
+                    "type": "integer",
+                    "example": 80
+                }
+            }
+        },
+        "dto.FraudRuleDTO": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "transaction is used by orders b563feb7b2b84b6test"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "duplicate_transaction"
+                },
+                "score": {
+                    "type": "integer",
+                    "example": 60
+                }
+            }
+        },
+        "dto.GraphQLError": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "query depth 8 exceeds limit 6"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {}
+                }
+            }
+        },
+        "dto.GraphQLRequest": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "{ order(order_uid: \"b563feb7b2b84b6test\") { track_number items { status } } }"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "dto.GraphQLResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GraphQLError"
+                    }
+                }
+            }
+        },
+        "dto.HighlightDTO": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "items.brand"
+                },
+                "fragment": {
+                    "type": "string",
+                    "example": "\u003cmark\u003eVivienne\u003c/mark\u003e Sabo"
+                }
+            }
+        },
+        "dto.ItemDTO": {
+            "type": "object",
+            "properties": {
+                "brand": {
+                    "type": "string"
+                },
+                "cancelled": {
+                    "type": "boolean"
+                },
+                "chrt_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nm_id": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number",
+                    "example": 453
+                },
+                "rid": {
+                    "type": "string"
+                },
+                "sale": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "total_price": {
+                    "type": "number",
+                    "example": 317.1
+                },
+                "track_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OrderDTO": {
+            "type": "object",
+            "properties": {
+                "customer_id": {
+                    "type": "string"
+                },
+                "date_created": {
+                    "type": "string"
+                },
+                "delivery": {
+                    "$ref": "#/definitions/dto.DeliveryDTO"
+                },
+                "delivery_service": {
+                    "type": "string"
+                },
+                "entry": {
+                    "type": "string"
+                },
+                "fraud": {
+                    "$ref": "#/definitions/dto.FraudDTO"
+                },
+                "internal_signature": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ItemDTO"
+                    }
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "oof_shard": {
+                    "type": "string"
+                },
+                "order_uid": {
+                    "description": "ID          uuid.UUID ` + "`" + `json:\"id\"` + "`" + `",
+                    "type": "string"
+                },
+                "payment": {
+                    "$ref": "#/definitions/dto.PaymentDTO"
+                },
+                "reporting": {
+                    "$ref": "#/definitions/dto.ReportingDTO"
+                },
+                "shardkey": {
+                    "type": "string"
+                },
+                "sm_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "created"
+                },
+                "track_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OrderHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "order_uid": {
+                    "type": "string"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderVersionDTO"
+                    }
+                }
+            }
+        },
+        "dto.OrderStatsResponse": {
+            "type": "object",
+            "properties": {
+                "date_from": {
+                    "type": "string",
+                    "example": "2026-01-01"
+                },
+                "date_to": {
+                    "type": "string",
+                    "example": "2026-10-18"
+                },
+                "group_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "month",
+                        "currency"
+                    ]
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.StatsGroupDTO"
+                    }
+                }
+            }
+        },
+        "dto.OrderVersionDTO": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "change": {
+                    "type": "string",
+                    "example": "order.status_changed"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "order": {
+                    "$ref": "#/definitions/dto.OrderDTO"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "kafka"
+                },
+                "source_ref": {
+                    "type": "string",
+                    "example": "orders/0/42"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.OrdersLookupRequest": {
+            "type": "object",
+            "properties": {
+                "order_uids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "b563feb7b2b84b6test",
+                        "unknown"
+                    ]
+                }
+            }
+        },
+        "dto.OrdersLookupResponse": {
+            "type": "object",
+            "properties": {
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "unknown"
+                    ]
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderDTO"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.DeliveryDTO": {
+        },
+        "dto.OrdersResponse": {
             "type": "object",
             "properties": {
-                "address": {
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
                     "type": "string"
                 },
-                "city": {
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderDTO"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "prev_cursor": {
                     "type": "string"
                 },
-                "email": {
+                "total": {
+                    "type": "integer"
+                },
+                "total_estimated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.PaymentDTO": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 1817.5
+                },
+                "bank": {
                     "type": "string"
                 },
-                "name": {
+                "currency": {
                     "type": "string"
                 },
-                "phone": {
+                "custom_fee": {
+                    "type": "number",
+                    "example": 0
+                },
+                "delivery_cost": {
+                    "type": "number",
+                    "example": 1500
+                },
+                "goods_total": {
+                    "type": "number",
+                    "example": 317.5
+                },
+                "paid": {
+                    "type": "number",
+                    "example": 1817.5
+                },
+                "payment_dt": {
+                    "type": "integer"
+                },
+                "provider": {
                     "type": "string"
                 },
-                "region": {
+                "refunded": {
+                    "type": "number",
+                    "example": 0
+                },
+                "request_id": {
                     "type": "string"
                 },
-                "zip": {
+                "transaction": {
                     "type": "string"
                 }
             }
         },
-        "dto.ItemDTO": {
+        "dto.RefundDTO": {
             "type": "object",
             "properties": {
-                "brand": {
+                "amount": {
+                    "type": "number",
+                    "example": 100
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "chrt_id": {
+                "id": {
                     "type": "integer"
                 },
-                "name": {
+                "reason": {
                     "type": "string"
                 },
-                "nm_id": {
+                "transaction": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ReportingDTO": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 18.35
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "custom_fee": {
+                    "type": "number",
+                    "example": 0
+                },
+                "delivery_cost": {
+                    "type": "number",
+                    "example": 15.15
+                },
+                "goods_total": {
+                    "type": "number",
+                    "example": 3.21
+                },
+                "paid": {
+                    "type": "number",
+                    "example": 18.35
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "0.0101"
+                },
+                "rate_date": {
+                    "type": "string",
+                    "example": "2026-10-18"
+                },
+                "refunded": {
+                    "type": "number",
+                    "example": 0
+                }
+            }
+        },
+        "dto.StatsGroupDTO": {
+            "type": "object",
+            "properties": {
+                "items": {
                     "type": "integer"
                 },
-                "price": {
+                "keys": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "orders": {
                     "type": "integer"
                 },
-                "rid": {
+                "period": {
+                    "type": "string",
+                    "example": "2026-10-01"
+                },
+                "refunded": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CurrencyAmountDTO"
+                    }
+                },
+                "reporting": {
+                    "$ref": "#/definitions/dto.StatsReportingDTO"
+                },
+                "revenue": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CurrencyAmountDTO"
+                    }
+                }
+            }
+        },
+        "dto.StatsReportingDTO": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "EUR"
+                },
+                "refunded": {
+                    "type": "number",
+                    "example": 120
+                },
+                "revenue": {
+                    "type": "number",
+                    "example": 15230.4
+                }
+            }
+        },
+        "dto.StreamOrderDTO": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 1817.5
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "customer_id": {
+                    "type": "string",
+                    "example": "test"
+                },
+                "delivery_service": {
+                    "type": "string",
+                    "example": "meest"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "created"
+                },
+                "occurred_at": {
                     "type": "string"
                 },
-                "sale": {
-                    "type": "integer"
+                "order_uid": {
+                    "type": "string",
+                    "example": "b563feb7b2b84b6test"
                 },
-                "size": {
+                "reason": {
                     "type": "string"
                 },
                 "status": {
+                    "type": "string",
+                    "example": "paid"
+                },
+                "track_number": {
+                    "type": "string",
+                    "example": "WBILMTESTTRACK"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "order.created"
+                }
+            }
+        },
+        "dto.TextHitDTO": {
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.HighlightDTO"
+                    }
+                },
+                "order": {
+                    "$ref": "#/definitions/dto.OrderDTO"
+                },
+                "rank": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.TextSearchResponse": {
+            "type": "object",
+            "properties": {
+                "fuzzy": {
+                    "type": "boolean"
+                },
+                "hits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.TextHitDTO"
+                    }
+                },
+                "limit": {
                     "type": "integer"
                 },
-                "total_price": {
+                "page": {
                     "type": "integer"
                 },
-                "track_number": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.OrderDTO": {
+        "dto.WSServerMessage": {
             "type": "object",
             "properties": {
-                "customer_id": {
-                    "type": "string"
+                "event": {
+                    "$ref": "#/definitions/dto.StreamOrderDTO"
                 },
-                "date_created": {
+                "event_id": {
+                    "type": "integer"
+                },
+                "message": {
                     "type": "string"
                 },
-                "delivery": {
-                    "$ref": "#/definitions/dto.DeliveryDTO"
+                "order": {
+                    "$ref": "#/definitions/dto.OrderDTO"
                 },
-                "delivery_service": {
-                    "type": "string"
+                "order_uids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "entry": {
-                    "type": "string"
+                "type": {
+                    "type": "string",
+                    "example": "update"
+                }
+            }
+        },
+        "dto.WebhookDTO": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
                 },
-                "internal_signature": {
+                "created_at": {
                     "type": "string"
                 },
-                "items": {
+                "customer_ids": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.ItemDTO"
+                        "type": "string"
                     }
                 },
-                "locale": {
-                    "type": "string"
-                },
-                "oof_shard": {
-                    "type": "string"
+                "delivery_services": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "order_uid": {
-                    "description": "ID          uuid.UUID ` + "`" + `json:\"id\"` + "`" + `",
+                "disabled_at": {
                     "type": "string"
                 },
-                "payment": {
-                    "$ref": "#/definitions/dto.PaymentDTO"
+                "disabled_reason": {
+                    "type": "string",
+                    "example": "10 consecutive failed deliveries"
                 },
-                "shardkey": {
-                    "type": "string"
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "sm_id": {
+                "failures": {
                     "type": "integer"
                 },
-                "track_number": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "whsec_0123456789abcdef"
+                },
+                "updated_at": {
                     "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/orders"
                 }
             }
         },
-        "dto.PaymentDTO": {
+        "dto.WebhookDeliveryDTO": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "integer"
+                "attempt": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "bank": {
+                "created_at": {
                     "type": "string"
                 },
-                "currency": {
+                "delivery_id": {
+                    "type": "string",
+                    "example": "6f1c2b9e-3d4a-4f8e-9a51-0c7d2e8b1f44"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 84
+                },
+                "error": {
                     "type": "string"
                 },
-                "custom_fee": {
-                    "type": "integer"
+                "event_type": {
+                    "type": "string",
+                    "example": "order.created"
                 },
-                "delivery_cost": {
+                "id": {
                     "type": "integer"
                 },
-                "goods_total": {
-                    "type": "integer"
+                "order_uid": {
+                    "type": "string",
+                    "example": "b563feb7b2b84b6test"
                 },
-                "payment_dt": {
-                    "type": "integer"
+                "status_code": {
+                    "type": "integer",
+                    "example": 200
                 },
-                "provider": {
-                    "type": "string"
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.WebhookRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
                 },
-                "request_id": {
-                    "type": "string"
+                "customer_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "test"
+                    ]
                 },
-                "transaction": {
-                    "type": "string"
+                "delivery_services": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "meest"
+                    ]
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "order.created",
+                        "order.status_changed"
+                    ]
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "whsec_0123456789abcdef"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/orders"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
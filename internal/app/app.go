@@ -2,18 +2,26 @@ package app
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"wb_tech_level_zero/internal/config"
 	"wb_tech_level_zero/internal/gateway"
 
 	"wb_tech_level_zero/internal/cache"
 	"wb_tech_level_zero/internal/delivery/kafkadelivery"
+	"wb_tech_level_zero/internal/delivery/stream"
+	"wb_tech_level_zero/internal/delivery/webhook"
+	"wb_tech_level_zero/internal/events"
+	"wb_tech_level_zero/internal/orders"
 	"wb_tech_level_zero/internal/repository"
 	"wb_tech_level_zero/internal/service"
 	"wb_tech_level_zero/pkg/db"
+	"wb_tech_level_zero/pkg/envelope"
 	"wb_tech_level_zero/pkg/logger"
 
 	"wb_tech_level_zero/pkg/redisclient"
@@ -29,9 +37,15 @@ type App struct {
 	cfg           *config.Config
 	logger        logger.Logger
 	httpServer    *gateway.Server
+	grpcServer    *gateway.GRPCServer
 	kafkaConsumer *kafkadelivery.Consumer
+	fxConsumer    *kafkadelivery.Consumer
+	kafkaProducer *kafkadelivery.Producer
+	alertProducer *kafkadelivery.Producer
+	webhooks      *webhook.Dispatcher
+	orderStream   *stream.Broker
 	pgPool        *pgxpool.Pool
-	redisClient   *redis.Client
+	redisClient   redis.UniversalClient
 	orderService  service.OrdersService
 	wg            sync.WaitGroup
 }
@@ -49,13 +63,47 @@ func New(ctx context.Context, cfg *config.Config, logger logger.Logger) (*App, e
 		return nil, err
 	}
 
-	orderRepo := repository.NewOrdersRepository(pgPool)
+	var (
+		repoCipher  repository.FieldCipher
+		cacheCipher cache.FieldCipher
+	)
+	keyring, err := envelope.OpenConfig(ctx, repository.NewKeyStore(pgPool), envelope.Config{
+		MasterKeyID:        cfg.EncryptionMasterKeyID,
+		MasterKeyFile:      cfg.EncryptionMasterKeyFile,
+		MasterKey:          cfg.EncryptionMasterKey,
+		PreviousMasterKeys: cfg.EncryptionPreviousMasterKeys,
+	})
+	switch {
+	case errors.Is(err, envelope.ErrNoMasterKey):
+		logger.Warn(ctx, "Encryption master key is not configured, delivery data is stored in plaintext")
+	case err != nil:
+		return nil, err
+	default:
+		repoCipher, cacheCipher = keyring, keyring
+	}
+
+	orderRepo := repository.NewOrdersRepository(pgPool, repoCipher)
 
 	redisCfg := redisclient.RedisConfig{
-		Host:     cfg.RedisHost,
-		Port:     cfg.RedisPort,
-		Password: cfg.RedisPassword,
-		DB:       cfg.RedisDB,
+		Mode:               cfg.RedisMode,
+		Host:               cfg.RedisHost,
+		Port:               cfg.RedisPort,
+		Username:           cfg.RedisUsername,
+		Password:           cfg.RedisPassword,
+		DB:                 cfg.RedisDB,
+		SentinelMasterName: cfg.RedisSentinelMaster,
+		SentinelAddrs:      cfg.RedisSentinelAddrs,
+		SentinelUsername:   cfg.RedisSentinelUsername,
+		SentinelPassword:   cfg.RedisSentinelPassword,
+		ClusterNodes:       cfg.RedisClusterNodes,
+		TLS: redisclient.TLSConfig{
+			Enabled:            cfg.RedisTLSEnabled,
+			CAFile:             cfg.RedisTLSCAFile,
+			CertFile:           cfg.RedisTLSCertFile,
+			KeyFile:            cfg.RedisTLSKeyFile,
+			ServerName:         cfg.RedisTLSServerName,
+			InsecureSkipVerify: cfg.RedisTLSInsecureSkipVerify,
+		},
 	}
 	redisClient, err := redisclient.New(ctx, redisCfg)
 	if err != nil {
@@ -63,9 +111,16 @@ func New(ctx context.Context, cfg *config.Config, logger logger.Logger) (*App, e
 	}
 
 	cacheCfg := cache.CacheConfig{
-		TTL: cfg.OrderTTLMinutes,
+		TTL:            cfg.OrderTTLMinutes,
+		ListTTLSeconds: cfg.OrdersListTTLSec,
+		Codec:          cfg.CacheCodec,
+		Compression:    cfg.CacheCompression,
+		Cipher:         cacheCipher,
+	}
+	orderCache, err := cache.NewOrdersCache(redisClient, cacheCfg)
+	if err != nil {
+		return nil, err
 	}
-	orderCache := cache.NewOrdersCache(redisClient, cacheCfg)
 
 	app := &App{
 		cfg:         cfg,
@@ -74,17 +129,58 @@ func New(ctx context.Context, cfg *config.Config, logger logger.Logger) (*App, e
 		redisClient: redisClient,
 	}
 
-	app.orderService = service.NewOrdersService(cfg, orderRepo, orderCache, &app.wg, logger)
+	brokers := strings.Split(cfg.KafkaBroker, ",")
+	app.kafkaProducer = kafkadelivery.NewProducer(brokers, cfg.KafkaEventsTopic, logger)
+
+	// алерты антифрода идут в отдельный топик, в топик событий жизненного цикла они не попадают
+	isAlert := func(e orders.Event) bool { return e.Type == orders.EventOrderFraudAlert }
+	eventBus := events.NewBus(events.Filter(app.kafkaProducer, func(e orders.Event) bool { return !isAlert(e) }))
+	if cfg.KafkaAlertsTopic != "" {
+		app.alertProducer = kafkadelivery.NewProducer(brokers, cfg.KafkaAlertsTopic, logger)
+		eventBus.AddSink(events.Filter(app.alertProducer, isAlert))
+	}
+
+	app.webhooks = webhook.NewDispatcher(webhook.Config{
+		Workers:         cfg.WebhookWorkers,
+		QueueSize:       cfg.WebhookQueueSize,
+		Timeout:         time.Duration(cfg.WebhookTimeoutMs) * time.Millisecond,
+		MaxAttempts:     cfg.WebhookMaxAttempts,
+		Backoff:         time.Duration(cfg.WebhookBackoffMs) * time.Millisecond,
+		MaxBackoff:      time.Duration(cfg.WebhookMaxBackoffMs) * time.Millisecond,
+		DisableAfter:    cfg.WebhookDisableAfter,
+		RefreshInterval: time.Duration(cfg.WebhookRefreshSeconds) * time.Second,
+	}, orderRepo, nil, logger)
+	eventBus.AddSink(events.Filter(app.webhooks, func(e orders.Event) bool { return !isAlert(e) }))
+	expvar.Publish("webhooks", expvar.Func(func() any {
+		return app.webhooks.Stats()
+	}))
+
+	app.orderStream = stream.NewBroker(stream.Config{
+		Channel:      cfg.StreamRedisChannel,
+		ReplaySize:   cfg.SSEReplaySize,
+		ClientBuffer: cfg.SSEClientBuffer,
+		MaxClients:   cfg.SSEMaxClients,
+	}, redisClient, logger)
+	eventBus.AddSink(events.Filter(app.orderStream, func(e orders.Event) bool { return !isAlert(e) }))
+	expvar.Publish("order_stream", expvar.Func(func() any {
+		return app.orderStream.Stats()
+	}))
+
+	app.orderService = service.NewOrdersService(cfg, orderRepo, orderCache, eventBus, &app.wg, logger)
+	expvar.Publish("cache_writer", expvar.Func(func() any {
+		return app.orderService.CacheWriterStats()
+	}))
 
-	app.httpServer, err = gateway.NewServer(ctx, cfg, app.orderService)
+	app.httpServer, err = gateway.NewServer(ctx, cfg, app.orderService, app.orderStream)
 	if err != nil {
 		logger.Fatal(ctx, "failed to init gateway", zap.Error(err))
 		return nil, err
 	}
+	app.grpcServer = gateway.NewGRPCServer(cfg, app.orderService, app.orderStream, logger)
 
 	kafkaHandler := kafkadelivery.NewHandler(app.orderService, logger)
 	kafkaCfg := kafkadelivery.KafkaConfig{
-		Brokers:      strings.Split(cfg.KafkaBroker, ","),
+		Brokers:      brokers,
 		GroupID:      cfg.KafkaGroupID,
 		Topic:        cfg.KafkaTopic,
 		ConsumerCnt:  cfg.KafkaConsumerCount,
@@ -94,6 +190,13 @@ func New(ctx context.Context, cfg *config.Config, logger logger.Logger) (*App, e
 	}
 	app.kafkaConsumer = kafkadelivery.NewConsumer(kafkaCfg, kafkaHandler, logger)
 
+	// курсы валют читаются отдельной группой, если задан топик
+	if cfg.KafkaFXTopic != "" {
+		fxCfg := kafkaCfg
+		fxCfg.GroupID, fxCfg.Topic, fxCfg.ConsumerCnt = cfg.KafkaFXGroupID, cfg.KafkaFXTopic, 1
+		app.fxConsumer = kafkadelivery.NewConsumer(fxCfg, kafkadelivery.NewFXRatesHandler(app.orderService, logger), logger)
+	}
+
 	return app, nil
 }
 
@@ -118,6 +221,31 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}()
 
+	if a.fxConsumer != nil {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			a.logger.Info(ctx, "Starting Kafka FX rates consumer...")
+			if err := a.fxConsumer.Start(ctx); err != nil {
+				a.logger.Error(ctx, "Kafka FX rates consumer failed", zap.Error(err))
+			}
+		}()
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		_ = a.webhooks.Run(ctx)
+	}()
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		if err := a.orderStream.Run(ctx); err != nil {
+			a.logger.Error(ctx, "Order stream subscription failed", zap.Error(err))
+		}
+	}()
+
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
@@ -127,26 +255,72 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}()
 
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.logger.Info(ctx, "Starting gRPC server on "+a.cfg.GRPCServerAddress+":"+strconv.Itoa(a.cfg.GRPCServerPort))
+		if err := a.grpcServer.Run(ctx); err != nil {
+			a.logger.Error(ctx, "gRPC server stopped with error", zap.Error(err))
+		}
+	}()
+
 	return nil
 }
 
 func (a *App) Stop(ctx context.Context) error {
 
-	a.logger.Info(ctx, "Closing Redis connection")
-	if err := a.redisClient.Close(); err != nil {
-		a.logger.Error(ctx, "Redis close error", zap.Error(err))
-	}
+	// открытые SSE-потоки и WatchOrders иначе задержат остановку серверов до таймаута
+	a.orderStream.Close()
 
 	a.logger.Info(ctx, "Stopping HTTP server")
 	if err := a.httpServer.Shutdown(ctx); err != nil {
 		a.logger.Error(ctx, "HTTP server shutdown error", zap.Error(err))
 	}
 
+	a.logger.Info(ctx, "Stopping gRPC server")
+	if err := a.grpcServer.Shutdown(ctx); err != nil {
+		a.logger.Error(ctx, "gRPC server shutdown error", zap.Error(err))
+	}
+
 	a.logger.Info(ctx, "Stopping Kafka consumer")
 	if err := a.kafkaConsumer.Close(); err != nil {
 		a.logger.Error(ctx, "Kafka consumer shutdown error", zap.Error(err))
 	}
 
+	if a.fxConsumer != nil {
+		a.logger.Info(ctx, "Stopping Kafka FX rates consumer")
+		if err := a.fxConsumer.Close(); err != nil {
+			a.logger.Error(ctx, "Kafka FX rates consumer shutdown error", zap.Error(err))
+		}
+	}
+
+	a.logger.Info(ctx, "Stopping Kafka producer")
+	if err := a.kafkaProducer.Close(); err != nil {
+		a.logger.Error(ctx, "Kafka producer shutdown error", zap.Error(err))
+	}
+	if a.alertProducer != nil {
+		if err := a.alertProducer.Close(); err != nil {
+			a.logger.Error(ctx, "Kafka alerts producer shutdown error", zap.Error(err))
+		}
+	}
+
+	// журнал доставки вебхуков пишется в Postgres
+	a.logger.Info(ctx, "Stopping webhook dispatcher")
+	if err := a.webhooks.Close(ctx); err != nil {
+		a.logger.Error(ctx, "Webhook dispatcher shutdown error", zap.Error(err))
+	}
+
+	// отложенные записи в кэш должны завершиться до закрытия соединения с Redis
+	a.logger.Info(ctx, "Flushing pending cache writes")
+	if err := a.orderService.Close(ctx); err != nil {
+		a.logger.Error(ctx, "Cache writer flush error", zap.Error(err))
+	}
+
+	a.logger.Info(ctx, "Closing Redis connection")
+	if err := a.redisClient.Close(); err != nil {
+		a.logger.Error(ctx, "Redis close error", zap.Error(err))
+	}
+
 	a.logger.Info(ctx, "Closing DB connection")
 	a.pgPool.Close()
 
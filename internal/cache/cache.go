@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 	"wb_tech_level_zero/internal/orders"
 
@@ -11,23 +12,67 @@ import (
 )
 
 type OrdersCache struct {
-	cacheClient *redis.Client
+	cacheClient redis.UniversalClient
 	ttl         time.Duration
+	listTTL     time.Duration
+	codec       orderCodec
+	cipher      FieldCipher
 }
 
 type CacheConfig struct {
-	TTL int
+	TTL            int
+	ListTTLSeconds int
+	Codec          string
+	Compression    string
+	// Cipher шифрует персональные данные доставки в закэшированных заказах; nil - без шифрования
+	Cipher FieldCipher
 }
 
-func NewOrdersCache(client *redis.Client, cfg CacheConfig) *OrdersCache {
+type FieldCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ctx context.Context, value string) (string, error)
+}
+
+func NewOrdersCache(client redis.UniversalClient, cfg CacheConfig) (*OrdersCache, error) {
+	codec, err := newOrderCodec(cfg.Codec, cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+
 	return &OrdersCache{
 		cacheClient: client,
 		ttl:         time.Duration(cfg.TTL) * time.Minute,
+		listTTL:     time.Duration(cfg.ListTTLSeconds) * time.Second,
+		codec:       codec,
+		cipher:      cfg.Cipher,
+	}, nil
+}
+
+// encode шифрует данные доставки в копии заказа, исходный заказ не изменяется
+func (r *OrdersCache) encode(order *orders.Order) ([]byte, error) {
+	if r.cipher == nil {
+		return r.codec.encode(order)
 	}
+
+	sealed := *order
+	if err := sealed.Delivery.MapPII(r.cipher.Encrypt); err != nil {
+		return nil, err
+	}
+	return r.codec.encode(&sealed)
+}
+
+func (r *OrdersCache) decode(ctx context.Context, data []byte, order *orders.Order) error {
+	if err := r.codec.decode(data, order); err != nil {
+		return err
+	}
+	if r.cipher == nil {
+		return nil
+	}
+	return order.Delivery.MapPII(func(v string) (string, error) { return r.cipher.Decrypt(ctx, v) })
 }
 
 func (r *OrdersCache) Get(ctx context.Context, key string) (*orders.Order, error) {
-	val, err := r.cacheClient.Get(ctx, key).Result()
+	val, err := r.cacheClient.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil
@@ -36,7 +81,7 @@ func (r *OrdersCache) Get(ctx context.Context, key string) (*orders.Order, error
 	}
 
 	var order orders.Order
-	if err := json.Unmarshal([]byte(val), &order); err != nil {
+	if err := r.decode(ctx, val, &order); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cached order: %w", err)
 	}
 
@@ -44,7 +89,7 @@ func (r *OrdersCache) Get(ctx context.Context, key string) (*orders.Order, error
 }
 
 func (r *OrdersCache) Set(ctx context.Context, key string, order *orders.Order) error {
-	data, err := json.Marshal(order)
+	data, err := r.encode(order)
 	if err != nil {
 		return fmt.Errorf("failed to marshal order for cache: %w", err)
 	}
@@ -54,3 +99,235 @@ func (r *OrdersCache) Set(ctx context.Context, key string, order *orders.Order)
 	}
 	return nil
 }
+
+// GetMany читает заказы одним MGET; отсутствующие и нечитаемые ключи в результат не попадают
+func (r *OrdersCache) GetMany(ctx context.Context, keys []string) (map[string]*orders.Order, error) {
+	result := make(map[string]*orders.Order, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	vals, err := r.mget(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, val := range vals {
+		raw, ok := val.(string)
+		if !ok {
+			continue
+		}
+		var order orders.Order
+		if err := r.decode(ctx, []byte(raw), &order); err != nil {
+			continue
+		}
+		result[keys[i]] = &order
+	}
+	return result, nil
+}
+
+// mget в режиме кластера заменяется пайплайном GET: ключи заказов лежат в разных слотах,
+// и MGET по ним завершился бы ошибкой CROSSSLOT
+func (r *OrdersCache) mget(ctx context.Context, keys []string) ([]interface{}, error) {
+	if _, ok := r.cacheClient.(*redis.ClusterClient); !ok {
+		vals, err := r.cacheClient.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis mget error: %w", err)
+		}
+		return vals, nil
+	}
+
+	pipe := r.cacheClient.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("redis pipeline get error: %w", err)
+	}
+
+	vals := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		if v, err := cmd.Result(); err == nil {
+			vals[i] = v
+		}
+	}
+	return vals, nil
+}
+
+// Delete удаляет ключи; в режиме кластера - пайплайном, т.к. ключи лежат в разных слотах
+func (r *OrdersCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if _, ok := r.cacheClient.(*redis.ClusterClient); !ok {
+		if err := r.cacheClient.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("redis del error: %w", err)
+		}
+		return nil
+	}
+
+	pipe := r.cacheClient.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis pipeline del error: %w", err)
+	}
+	return nil
+}
+
+// SetMany записывает заказы одним пайплайном
+func (r *OrdersCache) SetMany(ctx context.Context, items map[string]*orders.Order) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	pipe := r.cacheClient.Pipeline()
+	for key, order := range items {
+		data, err := r.encode(order)
+		if err != nil {
+			return fmt.Errorf("failed to marshal order for cache: %w", err)
+		}
+		pipe.Set(ctx, key, data, r.ttl)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis pipeline set error: %w", err)
+	}
+	return nil
+}
+
+const listVersionKey = "orders:list:version"
+
+// GetListVersion возвращает текущую версию списка заказов; версия увеличивается при каждом новом заказе
+func (r *OrdersCache) GetListVersion(ctx context.Context) (int64, error) {
+	v, err := r.cacheClient.Get(ctx, listVersionKey).Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("redis get list version error: %w", err)
+	}
+	return v, nil
+}
+
+func (r *OrdersCache) BumpListVersion(ctx context.Context) error {
+	if err := r.cacheClient.Incr(ctx, listVersionKey).Err(); err != nil {
+		return fmt.Errorf("redis incr list version error: %w", err)
+	}
+	return nil
+}
+
+func (r *OrdersCache) GetListPage(ctx context.Context, key string) (*orders.ListPage, error) {
+	val, err := r.cacheClient.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("redis get list page error: %w", err)
+	}
+
+	var page orders.ListPage
+	if err := json.Unmarshal(val, &page); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached list page: %w", err)
+	}
+	return &page, nil
+}
+
+func (r *OrdersCache) SetListPage(ctx context.Context, key string, page *orders.ListPage) error {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return fmt.Errorf("failed to marshal list page for cache: %w", err)
+	}
+
+	if err := r.cacheClient.Set(ctx, key, data, r.listTTL).Err(); err != nil {
+		return fmt.Errorf("redis set list page error: %w", err)
+	}
+	return nil
+}
+
+// Ключи счетчиков обращений объединяются в ZUNION, поэтому содержат общий hash tag
+// и в режиме кластера попадают в один слот
+const (
+	accessKeyPrefix     = "{orders:access}:"
+	accessBucketFormat  = "2006010215"
+	accessBucketSize    = time.Hour
+	accessRetention     = 7 * 24 * time.Hour
+	checkpointKeyPrefix = "warmup:checkpoint:"
+)
+
+// IncrAccess учитывает обращение к заказу в почасовом счетчике
+func (r *OrdersCache) IncrAccess(ctx context.Context, orderUID string) error {
+	key := accessKeyPrefix + time.Now().UTC().Format(accessBucketFormat)
+
+	pipe := r.cacheClient.TxPipeline()
+	pipe.ZIncrBy(ctx, key, 1, orderUID)
+	pipe.Expire(ctx, key, accessRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis access counter error: %w", err)
+	}
+	return nil
+}
+
+// TopAccessed возвращает UID наиболее запрашиваемых заказов за окно window
+func (r *OrdersCache) TopAccessed(ctx context.Context, window time.Duration, limit int) ([]string, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	buckets := int(window / accessBucketSize)
+	if window%accessBucketSize != 0 || buckets == 0 {
+		buckets++
+	}
+
+	keys := make([]string, 0, buckets)
+	for i := 0; i < buckets; i++ {
+		keys = append(keys, accessKeyPrefix+now.Add(-time.Duration(i)*accessBucketSize).Format(accessBucketFormat))
+	}
+
+	scored, err := r.cacheClient.ZUnionWithScores(ctx, redis.ZStore{Keys: keys, Aggregate: "SUM"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis access union error: %w", err)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+
+	uids := make([]string, 0, len(scored))
+	for _, z := range scored {
+		if uid, ok := z.Member.(string); ok {
+			uids = append(uids, uid)
+		}
+	}
+	return uids, nil
+}
+
+func (r *OrdersCache) GetCheckpoint(ctx context.Context, name string) (string, error) {
+	val, err := r.cacheClient.Get(ctx, checkpointKeyPrefix+name).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", nil
+		}
+		return "", fmt.Errorf("redis get checkpoint error: %w", err)
+	}
+	return val, nil
+}
+
+func (r *OrdersCache) SetCheckpoint(ctx context.Context, name, value string) error {
+	if err := r.cacheClient.Set(ctx, checkpointKeyPrefix+name, value, accessRetention).Err(); err != nil {
+		return fmt.Errorf("redis set checkpoint error: %w", err)
+	}
+	return nil
+}
+
+func (r *OrdersCache) DeleteCheckpoint(ctx context.Context, name string) error {
+	if err := r.cacheClient.Del(ctx, checkpointKeyPrefix+name).Err(); err != nil {
+		return fmt.Errorf("redis delete checkpoint error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"wb_tech_level_zero/internal/orders"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	CodecJSON    = "json"
+	CodecMsgpack = "msgpack"
+
+	CompressionNone = "none"
+	CompressionZstd = "zstd"
+)
+
+// Формат значения в кэше: [headerMagic][codec id][compression id][payload].
+// Значения без заголовка(записанные до появления кодеков) считаются JSON.
+const (
+	headerMagic byte = 0xFE
+	headerSize       = 3
+
+	codecIDJSON    byte = 1
+	codecIDMsgpack byte = 2
+
+	compressionIDNone byte = 0
+	compressionIDZstd byte = 1
+)
+
+var (
+	ErrUnknownCodec       = errors.New("unknown cache codec")
+	ErrUnknownCompression = errors.New("unknown cache compression")
+)
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+type orderCodec struct {
+	codecID       byte
+	compressionID byte
+}
+
+func newOrderCodec(codec, compression string) (orderCodec, error) {
+	c := orderCodec{}
+
+	switch codec {
+	case "", CodecJSON:
+		c.codecID = codecIDJSON
+	case CodecMsgpack:
+		c.codecID = codecIDMsgpack
+	default:
+		return c, fmt.Errorf("%w: %q", ErrUnknownCodec, codec)
+	}
+
+	switch compression {
+	case "", CompressionNone:
+		c.compressionID = compressionIDNone
+	case CompressionZstd:
+		c.compressionID = compressionIDZstd
+	default:
+		return c, fmt.Errorf("%w: %q", ErrUnknownCompression, compression)
+	}
+
+	return c, nil
+}
+
+func (c orderCodec) encode(order *orders.Order) ([]byte, error) {
+	var (
+		payload []byte
+		err     error
+	)
+	switch c.codecID {
+	case codecIDMsgpack:
+		payload, err = msgpack.Marshal(order)
+	default:
+		payload, err = orders.MarshalStoredJSON(order)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.compressionID == compressionIDZstd {
+		payload = zstdEncoder.EncodeAll(payload, make([]byte, 0, len(payload)))
+	}
+
+	data := make([]byte, 0, headerSize+len(payload))
+	data = append(data, headerMagic, c.codecID, c.compressionID)
+	return append(data, payload...), nil
+}
+
+// decode читает значение, записанное любым из поддерживаемых кодеков, независимо от текущей настройки
+func (c orderCodec) decode(data []byte, order *orders.Order) error {
+	if len(data) < headerSize || data[0] != headerMagic {
+		return json.Unmarshal(data, order)
+	}
+
+	codecID, compressionID, payload := data[1], data[2], data[headerSize:]
+
+	switch compressionID {
+	case compressionIDNone:
+	case compressionIDZstd:
+		var err error
+		if payload, err = zstdDecoder.DecodeAll(payload, nil); err != nil {
+			return fmt.Errorf("zstd decode: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: id %d", ErrUnknownCompression, compressionID)
+	}
+
+	switch codecID {
+	case codecIDJSON:
+		return json.Unmarshal(payload, order)
+	case codecIDMsgpack:
+		return msgpack.Unmarshal(payload, order)
+	}
+	return fmt.Errorf("%w: id %d", ErrUnknownCodec, codecID)
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+)
+
+func TestOrderCodec(t *testing.T) {
+	created := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	order := &orders.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		DateCreated: &created,
+		Delivery:    orders.Delivery{Name: "Test Testov", City: "Kiryat Mozkin"},
+		Payment:     orders.Payment{Currency: "KWD", Amount: orders.NewMoney(1817125, 3, "KWD")},
+		Items: []orders.Item{{
+			ChrtID: 9934930, Name: "Mascaras", Brand: "Vivienne Sabo", Price: orders.NewMoney(453505, 3, "KWD"),
+		}},
+		Fraud: &orders.FraudScore{Score: 60, Rules: []orders.FraudHit{{Rule: orders.FraudDuplicateTransaction, Score: 60}}},
+	}
+
+	cases := []struct{ codec, compression string }{
+		{CodecJSON, CompressionNone},
+		{CodecJSON, CompressionZstd},
+		{CodecMsgpack, CompressionNone},
+		{CodecMsgpack, CompressionZstd},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.codec+"/"+tc.compression, func(t *testing.T) {
+			codec, err := newOrderCodec(tc.codec, tc.compression)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := codec.encode(order)
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+
+			// значение читается кодеком с любыми настройками
+			reader, _ := newOrderCodec(CodecJSON, CompressionNone)
+			var got orders.Order
+			if err := reader.decode(data, &got); err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			if got.OrderUID != order.OrderUID || got.Delivery.City != order.Delivery.City ||
+				len(got.Items) != 1 || got.Items[0].Brand != "Vivienne Sabo" || !got.DateCreated.Equal(created) {
+				t.Errorf("order mismatch after round-trip: %+v", got)
+			}
+			if got.Payment.Amount != order.Payment.Amount || got.Items[0].Price != order.Items[0].Price {
+				t.Errorf("amounts changed after round-trip: %v, %v", got.Payment.Amount, got.Items[0].Price)
+			}
+			if got.Fraud == nil || got.Fraud.Score != 60 || len(got.Fraud.Rules) != 1 {
+				t.Errorf("fraud score lost after round-trip: %+v", got.Fraud)
+			}
+		})
+	}
+
+	t.Run("legacy: plain JSON without header", func(t *testing.T) {
+		data, _ := json.Marshal(order)
+		codec, _ := newOrderCodec(CodecMsgpack, CompressionZstd)
+
+		var got orders.Order
+		if err := codec.decode(data, &got); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		if got.OrderUID != order.OrderUID {
+			t.Errorf("expected order_uid %s, got %s", order.OrderUID, got.OrderUID)
+		}
+	})
+
+	t.Run("error: unknown codec", func(t *testing.T) {
+		if _, err := newOrderCodec("xml", CompressionNone); !errors.Is(err, ErrUnknownCodec) {
+			t.Errorf("expected ErrUnknownCodec, got %v", err)
+		}
+	})
+}
+
+type prefixCipher struct{}
+
+func (prefixCipher) Encrypt(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	return "enc:" + v, nil
+}
+
+func (prefixCipher) Decrypt(_ context.Context, v string) (string, error) {
+	if len(v) < 4 || v[:4] != "enc:" {
+		return "", errors.New("not encrypted")
+	}
+	return v[4:], nil
+}
+
+func TestOrdersCacheEncryptsDelivery(t *testing.T) {
+	c, err := NewOrdersCache(nil, CacheConfig{Codec: CodecJSON, Compression: CompressionNone, Cipher: prefixCipher{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := &orders.Order{
+		OrderUID: "b563feb7b2b84b6test",
+		Delivery: orders.Delivery{Name: "Test Testov", Phone: "+9720000000", Email: "test@gmail.com", Address: "Ploshad Mira 15"},
+	}
+	data, err := c.encode(order)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var raw orders.Order
+	if err := json.Unmarshal(data[headerSize:], &raw); err != nil {
+		t.Fatalf("unexpected payload: %v", err)
+	}
+	if raw.Delivery.Phone != "enc:+9720000000" || raw.Delivery.Email != "enc:test@gmail.com" ||
+		raw.Delivery.Address != "enc:Ploshad Mira 15" || raw.Delivery.Name != "Test Testov" {
+		t.Errorf("delivery is not encrypted in cache: %+v", raw.Delivery)
+	}
+	if order.Delivery.Phone != "+9720000000" {
+		t.Error("encode must not modify the original order")
+	}
+
+	var got orders.Order
+	if err := c.decode(context.Background(), data, &got); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if got.Delivery != order.Delivery {
+		t.Errorf("delivery mismatch after round-trip: %+v", got.Delivery)
+	}
+}
@@ -8,21 +8,116 @@ type Config struct {
 	HTTPServerAddress string `env:"HTTP_SERVER_ADDRESS" env-default:"localhost"`
 	HTTPServerPort    int    `env:"HTTP_SERVER_PORT" env-default:"8080"`
 
+	GRPCServerAddress string `env:"GRPC_SERVER_ADDRESS" env-default:"localhost"`
+	GRPCServerPort    int    `env:"GRPC_SERVER_PORT" env-default:"9090"`
+	GRPCMaxBatchSize  int    `env:"GRPC_MAX_BATCH_SIZE" env-default:"500"`
+
+	GRPCAuthTokens []string `env:"GRPC_AUTH_TOKENS" env-separator:","`
+	GRPCReflection bool     `env:"GRPC_REFLECTION" env-default:"false"`
+
+	GraphQLMaxDepth      int `env:"GRAPHQL_MAX_DEPTH" env-default:"6"`
+	GraphQLMaxComplexity int `env:"GRAPHQL_MAX_COMPLEXITY" env-default:"5000"`
+
 	PostgresHost     string `env:"POSTGRES_HOST" env-default:"localhost"`
 	PostgresPort     int    `env:"POSTGRES_PORT" env-default:"6432"`
 	PostgresUser     string `env:"POSTGRES_USER" env-default:"pguser"`
 	PostgresPassword string `env:"POSTGRES_PASSWORD" env-default:"pgpass"`
 	PostgresDB       string `env:"POSTGRES_DB" env-default:"wbdb"`
 
+	RedisMode     string `env:"REDIS_MODE" env-default:"single"`
 	RedisHost     string `env:"REDIS_HOST" env-default:"localhost"`
 	RedisPort     int    `env:"REDIS_PORT" env-default:"6379"`
+	RedisUsername string `env:"REDIS_USERNAME" env-default:""`
 	RedisPassword string `env:"REDIS_PASSWORD" env-default:""`
 	RedisDB       int    `env:"REDIS_DB" env-default:"0"`
 
-	OrderTTLMinutes int `env:"ORDER_CACHE_TTL_MINUTES" env-default:"5"`
+	RedisSentinelMaster   string   `env:"REDIS_SENTINEL_MASTER" env-default:""`
+	RedisSentinelAddrs    []string `env:"REDIS_SENTINEL_ADDRS" env-separator:","`
+	RedisSentinelUsername string   `env:"REDIS_SENTINEL_USERNAME" env-default:""`
+	RedisSentinelPassword string   `env:"REDIS_SENTINEL_PASSWORD" env-default:""`
+
+	RedisClusterNodes []string `env:"REDIS_CLUSTER_NODES" env-separator:","`
+
+	RedisTLSEnabled            bool   `env:"REDIS_TLS_ENABLED" env-default:"false"`
+	RedisTLSCAFile             string `env:"REDIS_TLS_CA_FILE" env-default:""`
+	RedisTLSCertFile           string `env:"REDIS_TLS_CERT_FILE" env-default:""`
+	RedisTLSKeyFile            string `env:"REDIS_TLS_KEY_FILE" env-default:""`
+	RedisTLSServerName         string `env:"REDIS_TLS_SERVER_NAME" env-default:""`
+	RedisTLSInsecureSkipVerify bool   `env:"REDIS_TLS_INSECURE_SKIP_VERIFY" env-default:"false"`
+
+	OrderTTLMinutes  int    `env:"ORDER_CACHE_TTL_MINUTES" env-default:"5"`
+	OrdersListTTLSec int    `env:"ORDERS_LIST_CACHE_TTL_SECONDS" env-default:"60"`
+	CacheCodec       string `env:"CACHE_CODEC" env-default:"json"`
+	CacheCompression string `env:"CACHE_COMPRESSION" env-default:"none"`
+
+	CacheWriterWorkers      int    `env:"CACHE_WRITER_WORKERS" env-default:"4"`
+	CacheWriterQueueSize    int    `env:"CACHE_WRITER_QUEUE_SIZE" env-default:"1000"`
+	CacheWriterPolicy       string `env:"CACHE_WRITER_POLICY" env-default:"drop_oldest"`
+	CacheWriterCoalesce     bool   `env:"CACHE_WRITER_COALESCE" env-default:"true"`
+	CacheWriterMaxRetries   int    `env:"CACHE_WRITER_MAX_RETRIES" env-default:"2"`
+	CacheWriterRetryDelayMs int    `env:"CACHE_WRITER_RETRY_DELAY_MS" env-default:"100"`
+	CacheWriterTimeoutMs    int    `env:"CACHE_WRITER_TIMEOUT_MS" env-default:"2000"`
 
 	DefaultPageLimit int `env:"DEFAULT_PAGE_LIMIT" env-default:"50"`
-	CacheWarmupSize  int `env:"CACHE_WARMUP_SIZE" env-default:"100"`
+	MaxPageLimit     int `env:"MAX_PAGE_LIMIT" env-default:"500"`
+
+	OrdersLookupMaxUIDs int `env:"ORDERS_LOOKUP_MAX_UIDS" env-default:"1000"`
+
+	CustomerSummaryTopBrands int `env:"CUSTOMER_SUMMARY_TOP_BRANDS" env-default:"5"`
+
+	FXMaxRateAgeDays int `env:"FX_MAX_RATE_AGE_DAYS" env-default:"7"`
+
+	StatsMaxDays int `env:"STATS_MAX_DAYS" env-default:"366"`
+
+	FraudAlertScore         int               `env:"FRAUD_ALERT_SCORE" env-default:"70"`
+	FraudAmountFactor       float64           `env:"FRAUD_AMOUNT_FACTOR" env-default:"5"`
+	FraudAmountMinOrders    int               `env:"FRAUD_AMOUNT_MIN_ORDERS" env-default:"3"`
+	FraudAmountWeight       int               `env:"FRAUD_AMOUNT_WEIGHT" env-default:"40"`
+	FraudLocaleRegions      map[string]string `env:"FRAUD_LOCALE_REGIONS" env-separator:";"`
+	FraudLocaleWeight       int               `env:"FRAUD_LOCALE_WEIGHT" env-default:"20"`
+	FraudPhoneWindowMinutes int               `env:"FRAUD_PHONE_WINDOW_MINUTES" env-default:"60"`
+	FraudPhoneMaxOrders     int               `env:"FRAUD_PHONE_MAX_ORDERS" env-default:"5"`
+	FraudPhoneWeight        int               `env:"FRAUD_PHONE_WEIGHT" env-default:"30"`
+	FraudDuplicateWeight    int               `env:"FRAUD_DUPLICATE_WEIGHT" env-default:"60"`
+
+	WebhookWorkers        int `env:"WEBHOOK_WORKERS" env-default:"4"`
+	WebhookQueueSize      int `env:"WEBHOOK_QUEUE_SIZE" env-default:"1000"`
+	WebhookTimeoutMs      int `env:"WEBHOOK_TIMEOUT_MS" env-default:"5000"`
+	WebhookMaxAttempts    int `env:"WEBHOOK_MAX_ATTEMPTS" env-default:"5"`
+	WebhookBackoffMs      int `env:"WEBHOOK_BACKOFF_MS" env-default:"1000"`
+	WebhookMaxBackoffMs   int `env:"WEBHOOK_MAX_BACKOFF_MS" env-default:"60000"`
+	WebhookDisableAfter   int `env:"WEBHOOK_DISABLE_AFTER" env-default:"10"`
+	WebhookRefreshSeconds int `env:"WEBHOOK_REFRESH_SECONDS" env-default:"10"`
+
+	StreamRedisChannel  string `env:"STREAM_REDIS_CHANNEL" env-default:"orders:stream"`
+	SSEReplaySize       int    `env:"SSE_REPLAY_SIZE" env-default:"1000"`
+	SSEClientBuffer     int    `env:"SSE_CLIENT_BUFFER" env-default:"64"`
+	SSEMaxClients       int    `env:"SSE_MAX_CLIENTS" env-default:"1000"`
+	SSEHeartbeatSeconds int    `env:"SSE_HEARTBEAT_SECONDS" env-default:"15"`
+	SSERetryMs          int    `env:"SSE_RETRY_MS" env-default:"3000"`
+
+	WSAuthTokens       []string `env:"WS_AUTH_TOKENS" env-separator:","`
+	WSAllowedOrigins   []string `env:"WS_ALLOWED_ORIGINS" env-separator:","`
+	WSMaxSubscriptions int      `env:"WS_MAX_SUBSCRIPTIONS" env-default:"20"`
+	WSPingSeconds      int      `env:"WS_PING_SECONDS" env-default:"30"`
+	WSMaxMessageBytes  int64    `env:"WS_MAX_MESSAGE_BYTES" env-default:"4096"`
+
+	AdminToken string `env:"ADMIN_TOKEN" env-default:""`
+
+	ErasurePseudonymKey string `env:"ERASURE_PSEUDONYM_KEY" env-default:""`
+
+	EncryptionMasterKeyID        string   `env:"ENCRYPTION_MASTER_KEY_ID" env-default:"master-1"`
+	EncryptionMasterKeyFile      string   `env:"ENCRYPTION_MASTER_KEY_FILE" env-default:""`
+	EncryptionMasterKey          string   `env:"ENCRYPTION_MASTER_KEY" env-default:""`
+	EncryptionPreviousMasterKeys []string `env:"ENCRYPTION_PREVIOUS_MASTER_KEYS" env-separator:","`
+
+	CacheWarmupStrategy      string   `env:"CACHE_WARMUP_STRATEGY" env-default:"recent"`
+	CacheWarmupSize          int      `env:"CACHE_WARMUP_SIZE" env-default:"100"`
+	CacheWarmupPageSize      int      `env:"CACHE_WARMUP_PAGE_SIZE" env-default:"50"`
+	CacheWarmupConcurrency   int      `env:"CACHE_WARMUP_CONCURRENCY" env-default:"4"`
+	CacheWarmupWindowMinutes int      `env:"CACHE_WARMUP_WINDOW_MINUTES" env-default:"1440"`
+	CacheWarmupCustomers     []string `env:"CACHE_WARMUP_CUSTOMERS" env-separator:","`
+	CacheWarmupSince         string   `env:"CACHE_WARMUP_SINCE" env-default:"24h"`
 
 	KafkaBroker        string `env:"KAFKA_BROKER" env-default:"localhost:9094"`
 	KafkaTopic         string `env:"KAFKA_TOPIC" env-default:"orders"`
@@ -32,6 +127,12 @@ type Config struct {
 	KafkaMaxRetries   int    `env:"KAFKA_MAX_RETRIES" env-default:"3"`
 	KafkaRetryDelayMs int    `env:"KAFKA_RETRY_DELAY_MS" env-default:"600"`
 	KafkaTopicDLQ     string `env:"KAFKA_DLQ_TOPIC" env-default:"orders-dlq"`
+
+	KafkaEventsTopic string `env:"KAFKA_EVENTS_TOPIC" env-default:"order-events"`
+	KafkaAlertsTopic string `env:"KAFKA_ALERTS_TOPIC" env-default:"order-alerts"`
+
+	KafkaFXTopic   string `env:"KAFKA_FX_TOPIC" env-default:""`
+	KafkaFXGroupID string `env:"KAFKA_FX_GROUP_ID" env-default:"fx-rates-consumer"`
 }
 
 func New() (*Config, error) {
@@ -0,0 +1,164 @@
+package graphqlapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"wb_tech_level_zero/internal/config"
+	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/internal/service"
+	"wb_tech_level_zero/pkg/logger"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"go.uber.org/zap"
+)
+
+const maxRequestBody = 1 << 20
+
+var errAdminRequired = errors.New("admin token required")
+
+type privilegedKey struct{}
+
+type OrdersService interface {
+	GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error)
+	GetOrders(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error)
+	GetCustomerOrders(ctx context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error)
+}
+
+type Handler struct {
+	orderService OrdersService
+	schema       graphql.Schema
+	limits       queryLimits
+	adminToken   string
+}
+
+func NewHandler(cfg *config.Config, orderService OrdersService) (*Handler, error) {
+	schema, err := newSchema(&resolver{orderService: orderService, defaultLimit: cfg.DefaultPageLimit, maxLimit: cfg.MaxPageLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build graphql schema: %w", err)
+	}
+	return &Handler{
+		orderService: orderService,
+		schema:       schema,
+		limits: queryLimits{
+			maxDepth:      cfg.GraphQLMaxDepth,
+			maxComplexity: cfg.GraphQLMaxComplexity,
+			defaultLimit:  cfg.DefaultPageLimit,
+			maxLimit:      cfg.MaxPageLimit,
+		},
+		adminToken: cfg.AdminToken,
+	}, nil
+}
+
+// @Summary GraphQL query
+// @Description Querying orders with GraphQL: order(order_uid), orders(filter, sort, order, page, limit, cursor),
+// @Description customer_orders(customer_id, page, limit) and orders_by_uids(order_uids). Field names match the JSON of the REST API.
+// @Description customer_orders requires the admin token in the Authorization: Bearer header, otherwise the field returns an error.
+// @Description Queries exceeding GRAPHQL_MAX_DEPTH or GRAPHQL_MAX_COMPLEXITY are rejected before execution.
+// @Description GET accepts query, variables(JSON) and operationName query parameters
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body dto.GraphQLRequest true "Запрос"
+// @Success 200 {object} dto.GraphQLResponse
+// @Failure 400 {object} dto.GraphQLResponse
+// @Router /graphql [post]
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := readRequest(w, r)
+	if err != nil {
+		writeErrors(ctx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Query == "" {
+		writeErrors(ctx, w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query)})})
+	if err != nil {
+		writeErrors(ctx, w, http.StatusBadRequest, gqlerrors.FormatError(err).Message)
+		return
+	}
+	if err := h.limits.check(doc, req.OperationName, req.Variables); err != nil {
+		writeErrors(ctx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// загрузчик заказов живет в пределах одного запроса
+	loader := newOrderLoader(h.orderService.GetOrdersByUIDs)
+	res := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        context.WithValue(withLoader(ctx, loader), privilegedKey{}, h.privileged(r)),
+	})
+
+	resp := dto.GraphQLResponse{Data: res.Data}
+	for _, e := range res.Errors {
+		resp.Errors = append(resp.Errors, dto.GraphQLError{Message: e.Message, Path: e.Path})
+	}
+	status := http.StatusOK
+	if res.Data == nil && len(res.Errors) > 0 {
+		// запрос не прошел валидацию схемы и не выполнялся
+		status = http.StatusBadRequest
+	}
+	writeJSON(ctx, w, status, resp)
+}
+
+// privileged - передан ли токен администратора("Authorization: Bearer <ADMIN_TOKEN>")
+func (h *Handler) privileged(r *http.Request) bool {
+	if h.adminToken == "" {
+		return false
+	}
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(h.adminToken)) == 1
+}
+
+func privileged(ctx context.Context) bool {
+	ok, _ := ctx.Value(privilegedKey{}).(bool)
+	return ok
+}
+
+func readRequest(w http.ResponseWriter, r *http.Request) (dto.GraphQLRequest, error) {
+	var req dto.GraphQLRequest
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, errors.New("invalid variables")
+			}
+		}
+		return req, nil
+	}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+		return req, errors.New("invalid request body")
+	}
+	return req, nil
+}
+
+func writeErrors(ctx context.Context, w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(ctx, w, statusCode, dto.GraphQLResponse{Errors: []dto.GraphQLError{{Message: message}}})
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log := logger.GetLoggerFromCtx(ctx)
+		log.Error(ctx, "Failed to encode and write GraphQL response", zap.Error(err))
+	}
+}
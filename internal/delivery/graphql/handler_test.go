@@ -0,0 +1,388 @@
+package graphqlapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"wb_tech_level_zero/internal/config"
+	graphqlapi "wb_tech_level_zero/internal/delivery/graphql"
+	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/internal/service"
+	"wb_tech_level_zero/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type mockOrderService struct {
+	GetOrdersByUIDsFunc   func(ctx context.Context, uids []string) ([]*orders.Order, error)
+	GetOrdersFunc         func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error)
+	GetCustomerOrdersFunc func(ctx context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error)
+}
+
+func (m *mockOrderService) GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error) {
+	return m.GetOrdersByUIDsFunc(ctx, uids)
+}
+
+func (m *mockOrderService) GetOrders(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+	return m.GetOrdersFunc(ctx, params)
+}
+
+func (m *mockOrderService) GetCustomerOrders(ctx context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+	return m.GetCustomerOrdersFunc(ctx, customerID, params)
+}
+
+func testConfig() *config.Config {
+	return &config.Config{DefaultPageLimit: 50, MaxPageLimit: 200, GraphQLMaxDepth: 6, GraphQLMaxComplexity: 5000, AdminToken: "secret"}
+}
+
+func testOrder(uid string) *orders.Order {
+	created := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	return &orders.Order{
+		OrderUID:    uid,
+		TrackNumber: "WBILMTESTTRACK",
+		Status:      orders.StatusCreated,
+		Delivery:    orders.Delivery{City: "Kiryat Mozkin"},
+		Payment:     orders.Payment{Currency: "USD", Amount: orders.NewMoney(181750, 2, "USD")},
+		Items: []orders.Item{
+			{ChrtID: 9934930, Price: orders.NewMoney(45300, 2, "USD"), Name: "Mascaras", Status: 202},
+			{ChrtID: 9934931, Price: orders.NewMoney(10000, 2, "USD"), Name: "Lipstick", Status: 200},
+		},
+		DateCreated: &created,
+	}
+}
+
+// ordersFromStore имитирует GetOrdersByUIDs: найденные заказы из набора uid
+func ordersFromStore(known ...string) func(ctx context.Context, uids []string) ([]*orders.Order, error) {
+	return func(_ context.Context, uids []string) ([]*orders.Order, error) {
+		var list []*orders.Order
+		for _, uid := range uids {
+			if slices.Contains(known, uid) {
+				list = append(list, testOrder(uid))
+			}
+		}
+		return list, nil
+	}
+}
+
+func newHandler(t *testing.T, cfg *config.Config, svc *mockOrderService) *graphqlapi.Handler {
+	t.Helper()
+	h, err := graphqlapi.NewHandler(cfg, svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []dto.GraphQLError         `json:"errors"`
+}
+
+func doQuery(t *testing.T, h http.Handler, query string, variables map[string]any) (int, response) {
+	t.Helper()
+	return doQueryWithToken(t, h, "", query, variables)
+}
+
+func doQueryWithToken(t *testing.T, h http.Handler, token, query string, variables map[string]any) (int, response) {
+	t.Helper()
+	body, _ := json.Marshal(dto.GraphQLRequest{Query: query, Variables: variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req = req.WithContext(logger.ContextWithLogger(req.Context(), logger.New(zap.NewNop(), "test")))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	var resp response
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rr.Body.String(), err)
+	}
+	return rr.Code, resp
+}
+
+func TestOrderQuery(t *testing.T) {
+	svc := &mockOrderService{GetOrdersByUIDsFunc: ordersFromStore("b563feb7b2b84b6test")}
+	h := newHandler(t, testConfig(), svc)
+
+	t.Run("Only requested fields", func(t *testing.T) {
+		code, resp := doQuery(t, h, `{ order(order_uid: "b563feb7b2b84b6test") { track_number items { status } } }`, nil)
+		if code != http.StatusOK || len(resp.Errors) != 0 {
+			t.Fatalf("unexpected response %d %+v", code, resp.Errors)
+		}
+		want := `{"items":[{"status":202},{"status":200}],"track_number":"WBILMTESTTRACK"}`
+		if got := string(resp.Data["order"]); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	})
+
+	t.Run("Money and dates", func(t *testing.T) {
+		_, resp := doQuery(t, h, `query($uid: String!) { order(order_uid: $uid) { date_created payment { amount currency } } }`,
+			map[string]any{"uid": "b563feb7b2b84b6test"})
+		want := `{"date_created":"2026-10-18T12:00:00Z","payment":{"amount":"1817.50","currency":"USD"}}`
+		if got := string(resp.Data["order"]); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	})
+
+	t.Run("Not found is null", func(t *testing.T) {
+		code, resp := doQuery(t, h, `{ order(order_uid: "missing") { track_number } }`, nil)
+		if code != http.StatusOK || len(resp.Errors) != 0 || string(resp.Data["order"]) != "null" {
+			t.Errorf("expected null order, got %d %s %+v", code, resp.Data["order"], resp.Errors)
+		}
+	})
+
+	t.Run("GET request", func(t *testing.T) {
+		q := url.Values{"query": {`{ order(order_uid: "b563feb7b2b84b6test") { order_uid } }`}}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql?"+q.Encode(), nil))
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"order_uid":"b563feb7b2b84b6test"`) {
+			t.Errorf("unexpected response %d %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Internal error is hidden", func(t *testing.T) {
+		svc := &mockOrderService{GetOrdersByUIDsFunc: func(context.Context, []string) ([]*orders.Order, error) {
+			return nil, errors.New("connection refused")
+		}}
+		_, resp := doQuery(t, newHandler(t, testConfig(), svc), `{ order(order_uid: "b563feb7b2b84b6test") { order_uid } }`, nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != "internal server error" {
+			t.Errorf("expected internal server error, got %+v", resp.Errors)
+		}
+	})
+}
+
+func TestOrderLoaderBatching(t *testing.T) {
+	var calls [][]string
+	store := ordersFromStore("a", "b", "c")
+	svc := &mockOrderService{GetOrdersByUIDsFunc: func(ctx context.Context, uids []string) ([]*orders.Order, error) {
+		calls = append(calls, slices.Clone(uids))
+		return store(ctx, uids)
+	}}
+	h := newHandler(t, testConfig(), svc)
+
+	query := `{
+		first: order(order_uid: "a") { order_uid items { name } }
+		second: order(order_uid: "b") { order_uid }
+		again: order(order_uid: "a") { track_number }
+		many: orders_by_uids(order_uids: ["c", "missing", "b"]) { order_uid items { status } }
+	}`
+	code, resp := doQuery(t, h, query, nil)
+	if code != http.StatusOK || len(resp.Errors) != 0 {
+		t.Fatalf("unexpected response %d %+v", code, resp.Errors)
+	}
+
+	if len(calls) != 1 {
+		t.Fatalf("expected one batch, got %v", calls)
+	}
+	// порядок полей одного уровня при выполнении не определен
+	slices.Sort(calls[0])
+	if want := []string{"a", "b", "c", "missing"}; !slices.Equal(calls[0], want) {
+		t.Errorf("expected batch %v, got %v", want, calls[0])
+	}
+
+	var many []dto.OrderDTO
+	if err := json.Unmarshal(resp.Data["many"], &many); err != nil {
+		t.Fatal(err)
+	}
+	if len(many) != 2 || many[0].OrderUID != "c" || many[1].OrderUID != "b" {
+		t.Errorf("expected found orders in request order, got %+v", many)
+	}
+}
+
+func TestOrdersQuery(t *testing.T) {
+	var got service.GetOrdersParams
+	svc := &mockOrderService{
+		GetOrdersFunc: func(_ context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+			got = params
+			total := orders.TotalUnknown
+			if params.Total == orders.TotalExact {
+				total = 42
+			}
+			return &orders.ListResult{
+				Orders: []*orders.Order{testOrder("a"), testOrder("b")},
+				Total:  total,
+				Next:   &orders.Cursor{ID: 2},
+			}, nil
+		},
+		GetOrdersByUIDsFunc: func(context.Context, []string) ([]*orders.Order, error) {
+			t.Error("orders from the list must not be fetched again")
+			return nil, nil
+		},
+	}
+	h := newHandler(t, testConfig(), svc)
+
+	t.Run("Filters and sorting", func(t *testing.T) {
+		query := `query($filter: OrdersFilter) {
+			orders(filter: $filter, sort: "amount", order: "asc", page: 2, limit: 10) { total page limit orders { order_uid } }
+			order(order_uid: "a") { order_uid }
+		}`
+		code, resp := doQuery(t, h, query, map[string]any{"filter": map[string]any{
+			"city": "Kiryat Mozkin", "min_amount": "10.50", "item_status": 202, "date_to": "2026-10-18",
+		}})
+		if code != http.StatusOK || len(resp.Errors) != 0 {
+			t.Fatalf("unexpected response %d %+v", code, resp.Errors)
+		}
+
+		f := got.Filter
+		if f.City != "Kiryat Mozkin" || f.MinAmount == nil || !f.MinAmount.Equal(orders.NewMoney(1050, 2, "")) || f.ItemStatus == nil || *f.ItemStatus != 202 {
+			t.Errorf("unexpected filter %+v", f)
+		}
+		if f.DateTo == nil || !f.DateTo.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected date_to to include the whole day, got %v", f.DateTo)
+		}
+		if got.Sort != (orders.ListSort{Field: orders.SortByAmount, Asc: true}) || got.Page != 2 || got.Limit != 10 {
+			t.Errorf("unexpected params %+v", got)
+		}
+		if got.Total != orders.TotalExact {
+			t.Errorf("expected exact total when total is requested, got %q", got.Total)
+		}
+
+		var page dto.OrdersResponse
+		if err := json.Unmarshal(resp.Data["orders"], &page); err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 42 || page.Page != 2 || page.Limit != 10 || len(page.Orders) != 2 {
+			t.Errorf("unexpected page %+v", page)
+		}
+	})
+
+	t.Run("Total is not counted unless requested", func(t *testing.T) {
+		_, resp := doQuery(t, h, `{ orders { next_cursor prev_cursor orders { order_uid } } }`, nil)
+		if got.Total != orders.TotalNone || got.Limit != 50 || got.Page != 1 {
+			t.Errorf("unexpected params %+v", got)
+		}
+		if !strings.Contains(string(resp.Data["orders"]), `"prev_cursor":null`) ||
+			strings.Contains(string(resp.Data["orders"]), `"next_cursor":null`) {
+			t.Errorf("unexpected cursors %s", resp.Data["orders"])
+		}
+	})
+
+	t.Run("Limit is capped", func(t *testing.T) {
+		_, resp := doQuery(t, h, `{ orders(limit: 100000) { limit } }`, nil)
+		if got.Limit != 200 || string(resp.Data["orders"]) != `{"limit":200}` {
+			t.Errorf("expected limit capped to 200, got %d %s", got.Limit, resp.Data["orders"])
+		}
+	})
+
+	t.Run("Invalid sort", func(t *testing.T) {
+		_, resp := doQuery(t, h, `{ orders(sort: "name") { total } }`, nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != orders.ErrInvalidSort.Error() {
+			t.Errorf("expected invalid sort error, got %+v", resp.Errors)
+		}
+	})
+}
+
+func TestCustomerOrdersQuery(t *testing.T) {
+	svc := &mockOrderService{
+		GetCustomerOrdersFunc: func(_ context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+			if customerID == "" {
+				return nil, 0, orders.ErrInvalidCustomerID
+			}
+			return []*orders.Order{testOrder("a")}, 1, nil
+		},
+	}
+	h := newHandler(t, testConfig(), svc)
+
+	_, resp := doQueryWithToken(t, h, "secret", `{ customer_orders(customer_id: "test", limit: 5) { total limit orders { order_uid } } }`, nil)
+	want := `{"limit":5,"orders":[{"order_uid":"a"}],"total":1}`
+	if got := string(resp.Data["customer_orders"]); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		_, resp = doQueryWithToken(t, h, token, `{ customer_orders(customer_id: "test") { total } }`, nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != "admin token required" {
+			t.Errorf("token %q: expected admin token error, got %+v", token, resp.Errors)
+		}
+	}
+
+	_, resp = doQueryWithToken(t, h, "secret", `{ customer_orders(customer_id: "") { total } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != orders.ErrInvalidCustomerID.Error() {
+		t.Errorf("expected invalid customer error, got %+v", resp.Errors)
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	svc := &mockOrderService{
+		GetOrdersByUIDsFunc: func(context.Context, []string) ([]*orders.Order, error) {
+			t.Error("rejected query must not be executed")
+			return nil, nil
+		},
+		GetOrdersFunc: func(context.Context, service.GetOrdersParams) (*orders.ListResult, error) {
+			t.Error("rejected query must not be executed")
+			return &orders.ListResult{}, nil
+		},
+	}
+	cfg := testConfig()
+	cfg.GraphQLMaxDepth, cfg.GraphQLMaxComplexity = 3, 100
+	cfg.MaxPageLimit = 150
+	h := newHandler(t, cfg, svc)
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      string
+	}{
+		{
+			name:  "Depth",
+			query: `{ orders { orders { items { status } } } }`,
+			want:  "query depth 4 exceeds limit 3",
+		},
+		{
+			name:  "Depth through fragments",
+			query: `{ ...q } fragment q on Query { orders { orders { ...o } } } fragment o on Order { items { status } }`,
+			want:  "query depth 4 exceeds limit 3",
+		},
+		{
+			name:  "Complexity of page size",
+			query: `{ orders(limit: 30) { orders { order_uid track_number status } } }`,
+			want:  "query complexity 121 exceeds limit 100",
+		},
+		{
+			name:      "Complexity from variables",
+			query:     `query($n: Int) { orders(limit: $n) { orders { order_uid } } }`,
+			variables: map[string]any{"n": 100},
+			want:      "query complexity 201 exceeds limit 100",
+		},
+		{
+			name:  "Page size above maximum",
+			query: `{ orders(limit: 100000) { orders { order_uid } } }`,
+			want:  "query complexity 301 exceeds limit 100",
+		},
+		{
+			name:  "Default page size",
+			query: `{ customer_orders(customer_id: "test") { orders { order_uid } } }`,
+			want:  "query complexity 101 exceeds limit 100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := doQuery(t, h, tt.query, tt.variables)
+			if code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, code)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Message != tt.want {
+				t.Errorf("expected %q, got %+v", tt.want, resp.Errors)
+			}
+		})
+	}
+
+	t.Run("Invalid query", func(t *testing.T) {
+		code, resp := doQuery(t, h, `{ order(order_uid: "a") { unknown } }`, nil)
+		if code != http.StatusBadRequest || len(resp.Errors) == 0 {
+			t.Errorf("expected validation error, got %d %+v", code, resp.Errors)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// queryLimits проверяет запрос до выполнения. Глубина - вложенность полей, сложность - количество
+// запрошенных полей, где поля внутри списков заказов умножаются на размер списка: limit у orders и
+// customer_orders(по умолчанию DEFAULT_PAGE_LIMIT, не больше MAX_PAGE_LIMIT) и количество order_uids у orders_by_uids.
+// Служебные поля интроспекции(__schema, __type, __typename) не учитываются
+type queryLimits struct {
+	maxDepth      int
+	maxComplexity int
+	defaultLimit  int
+	maxLimit      int
+}
+
+func (q queryLimits) check(doc *ast.Document, operationName string, variables map[string]any) error {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, def := range doc.Definitions {
+		if f, ok := def.(*ast.FragmentDefinition); ok && f.Name != nil {
+			fragments[f.Name.Value] = f
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok || (operationName != "" && (op.Name == nil || op.Name.Value != operationName)) {
+			continue
+		}
+		w := &queryWalker{limits: q, fragments: fragments, variables: variables, visiting: map[string]bool{}}
+		depth, complexity := w.walk(op.SelectionSet, 0)
+		if q.maxDepth > 0 && depth > q.maxDepth {
+			return fmt.Errorf("query depth %d exceeds limit %d", depth, q.maxDepth)
+		}
+		if q.maxComplexity > 0 && complexity > q.maxComplexity {
+			return fmt.Errorf("query complexity %d exceeds limit %d", complexity, q.maxComplexity)
+		}
+	}
+	return nil
+}
+
+type queryWalker struct {
+	limits    queryLimits
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	// visiting защищает от циклических фрагментов: запрос проверяется до валидации graphql-go
+	visiting map[string]bool
+}
+
+// walk возвращает глубину и сложность набора полей; level - уровень набора(0 - корневые поля запроса)
+func (w *queryWalker) walk(set *ast.SelectionSet, level int) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, sel := range set.Selections {
+		var d, c int
+		switch s := sel.(type) {
+		case *ast.Field:
+			if s.Name == nil || strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			d, c = w.walk(s.SelectionSet, level+1)
+			d++
+			c = 1 + c*w.multiplier(s, level)
+		case *ast.InlineFragment:
+			d, c = w.walk(s.SelectionSet, level)
+		case *ast.FragmentSpread:
+			if s.Name == nil {
+				continue
+			}
+			name := s.Name.Value
+			f, ok := w.fragments[name]
+			if !ok || w.visiting[name] {
+				continue
+			}
+			w.visiting[name] = true
+			d, c = w.walk(f.SelectionSet, level)
+			delete(w.visiting, name)
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+// multiplier - размер списка заказов, который возвращает корневое поле запроса
+func (w *queryWalker) multiplier(f *ast.Field, level int) int {
+	if level != 0 {
+		return 1
+	}
+	switch f.Name.Value {
+	case "orders", "customer_orders":
+		if n := w.intArg(f, "limit"); n > 0 {
+			if w.limits.maxLimit > 0 {
+				return min(n, w.limits.maxLimit)
+			}
+			return n
+		}
+		return max(w.limits.defaultLimit, 1)
+	case "orders_by_uids":
+		return max(w.listArgLen(f, "order_uids"), 1)
+	}
+	return 1
+}
+
+func (w *queryWalker) argValue(f *ast.Field, name string) any {
+	for _, arg := range f.Arguments {
+		if arg.Name == nil || arg.Name.Value != name {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.Variable:
+			if v.Name != nil {
+				return w.variables[v.Name.Value]
+			}
+		case *ast.IntValue:
+			n, _ := strconv.Atoi(v.Value)
+			return n
+		case *ast.ListValue:
+			return len(v.Values)
+		}
+	}
+	return nil
+}
+
+func (w *queryWalker) intArg(f *ast.Field, name string) int {
+	switch v := w.argValue(f, name).(type) {
+	case int:
+		return v
+	case float64:
+		// числа из JSON переменных
+		return int(v)
+	}
+	return 0
+}
+
+func (w *queryWalker) listArgLen(f *ast.Field, name string) int {
+	switch v := w.argValue(f, name).(type) {
+	case int:
+		return v
+	case []any:
+		return len(v)
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+	"wb_tech_level_zero/internal/orders"
+)
+
+const maxLoaderBatch = 500
+
+type loaderKey struct{}
+
+// orderResult - результат загрузки одного заказа; order == nil без ошибки - заказ не найден
+type orderResult struct {
+	order *orders.Order
+	err   error
+	done  bool
+}
+
+// orderLoader собирает заказы, запрошенные полями одного уровня запроса, и загружает их одним вызовом
+// GetOrdersByUIDs(MGET кэша и один запрос к БД для промахов, позиции - тем же запросом по ANY).
+// Резолверы возвращают thunk: graphql-go сначала вызывает резолверы всех полей уровня, затем thunk-и,
+// и первый thunk загружает весь накопленный пакет. Загрузчик создается на каждый запрос и кэширует
+// результаты до его завершения
+type orderLoader struct {
+	fetch func(ctx context.Context, uids []string) ([]*orders.Order, error)
+
+	mu      sync.Mutex
+	results map[string]*orderResult
+	pending []string
+	batches int
+}
+
+func newOrderLoader(fetch func(ctx context.Context, uids []string) ([]*orders.Order, error)) *orderLoader {
+	return &orderLoader{fetch: fetch, results: make(map[string]*orderResult)}
+}
+
+func withLoader(ctx context.Context, l *orderLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+func loaderFromCtx(ctx context.Context) *orderLoader {
+	l, _ := ctx.Value(loaderKey{}).(*orderLoader)
+	return l
+}
+
+// Load откладывает загрузку заказа до вызова возвращенной функции
+func (l *orderLoader) Load(ctx context.Context, uid string) func() (*orders.Order, error) {
+	l.mu.Lock()
+	r, ok := l.results[uid]
+	if !ok {
+		r = &orderResult{}
+		l.results[uid] = r
+		l.pending = append(l.pending, uid)
+	}
+	l.mu.Unlock()
+
+	return func() (*orders.Order, error) {
+		l.dispatch(ctx)
+		return r.order, r.err
+	}
+}
+
+// LoadMany - найденные заказы в порядке uids
+func (l *orderLoader) LoadMany(ctx context.Context, uids []string) func() ([]*orders.Order, error) {
+	thunks := make([]func() (*orders.Order, error), len(uids))
+	for i, uid := range uids {
+		thunks[i] = l.Load(ctx, uid)
+	}
+	return func() ([]*orders.Order, error) {
+		list := make([]*orders.Order, 0, len(uids))
+		for _, thunk := range thunks {
+			o, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			if o != nil {
+				list = append(list, o)
+			}
+		}
+		return list, nil
+	}
+}
+
+// Prime сохраняет заказы, уже полученные списком(orders, customer_orders), как результаты загрузки. Это экономит
+// вызов GetOrdersByUIDs, когда тот же запрос спрашивает эти заказы через order или orders_by_uids, например
+// { orders { orders { order_uid } } order(order_uid: "a") { items { name } } }: списки резолвятся сразу, а order -
+// thunk-ом после них, и загружаются только заказы, которых не было в списках. Заказы, уже ожидающие загрузки,
+// тоже помечаются загруженными
+func (l *orderLoader) Prime(list []*orders.Order) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, o := range list {
+		r, ok := l.results[o.OrderUID]
+		if !ok {
+			l.results[o.OrderUID] = &orderResult{order: o, done: true}
+		} else if !r.done {
+			// заказ уже ожидает загрузки: thunk держит этот же результат
+			r.order, r.done = o, true
+		}
+	}
+}
+
+// Batches - количество обращений к сервису
+func (l *orderLoader) Batches() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.batches
+}
+
+func (l *orderLoader) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var uids []string
+	for _, uid := range l.pending {
+		if !l.results[uid].done {
+			uids = append(uids, uid)
+		}
+	}
+	l.pending = nil
+
+	for start := 0; start < len(uids); start += maxLoaderBatch {
+		chunk := uids[start:min(start+maxLoaderBatch, len(uids))]
+		l.batches++
+		list, err := l.fetch(ctx, chunk)
+
+		found := make(map[string]*orders.Order, len(list))
+		for _, o := range list {
+			found[o.OrderUID] = o
+		}
+		for _, uid := range chunk {
+			r := l.results[uid]
+			r.order, r.err, r.done = found[uid], err, true
+		}
+	}
+}
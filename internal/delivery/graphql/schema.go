@@ -0,0 +1,422 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/internal/service"
+	"wb_tech_level_zero/pkg/logger"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"go.uber.org/zap"
+)
+
+// Имена полей совпадают с JSON HTTP API(dto.OrderDTO), поэтому значения читаются из DTO
+// стандартным резолвером по тегам json; суммы передаются десятичными строками
+
+func moneyField() *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.String),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			v, err := graphql.DefaultResolveFn(p)
+			if err != nil {
+				return nil, err
+			}
+			m, _ := v.(orders.Money)
+			return m.Decimal(), nil
+		},
+	}
+}
+
+// optionalStringField - пустая строка(omitempty в JSON) возвращается как null
+func optionalStringField() *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.String,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			v, err := graphql.DefaultResolveFn(p)
+			if s, _ := v.(string); s == "" {
+				return nil, err
+			}
+			return v, err
+		},
+	}
+}
+
+func stringFields(names ...string) graphql.Fields {
+	fields := graphql.Fields{}
+	for _, name := range names {
+		fields[name] = &graphql.Field{Type: graphql.NewNonNull(graphql.String)}
+	}
+	return fields
+}
+
+var deliveryType = graphql.NewObject(graphql.ObjectConfig{
+	Name:   "Delivery",
+	Fields: stringFields("name", "phone", "zip", "city", "address", "region", "email"),
+})
+
+var paymentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Payment",
+	Fields: func() graphql.Fields {
+		fields := stringFields("transaction", "request_id", "currency", "provider", "bank")
+		fields["payment_dt"] = &graphql.Field{Type: graphql.NewNonNull(graphql.Int)}
+		for _, name := range []string{"amount", "delivery_cost", "goods_total", "custom_fee", "paid", "refunded"} {
+			fields[name] = moneyField()
+		}
+		return fields
+	}(),
+})
+
+var itemType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Item",
+	Fields: func() graphql.Fields {
+		fields := stringFields("track_number", "rid", "name", "size", "brand")
+		for _, name := range []string{"chrt_id", "sale", "nm_id", "status"} {
+			fields[name] = &graphql.Field{Type: graphql.NewNonNull(graphql.Int)}
+		}
+		fields["cancelled"] = &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)}
+		fields["price"] = moneyField()
+		fields["total_price"] = moneyField()
+		return fields
+	}(),
+})
+
+var orderType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Order",
+	Fields: func() graphql.Fields {
+		fields := stringFields("order_uid", "track_number", "entry", "status", "locale", "internal_signature",
+			"customer_id", "delivery_service", "shardkey", "oof_shard")
+		fields["sm_id"] = &graphql.Field{Type: graphql.NewNonNull(graphql.Int)}
+		fields["date_created"] = &graphql.Field{Type: graphql.DateTime}
+		fields["delivery"] = &graphql.Field{Type: graphql.NewNonNull(deliveryType)}
+		fields["payment"] = &graphql.Field{Type: graphql.NewNonNull(paymentType)}
+		fields["items"] = &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType)))}
+		return fields
+	}(),
+})
+
+// ordersPageType повторяет dto.OrdersResponse; total равен -1, если total не запрошен
+var ordersPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "OrdersPage",
+	Fields: graphql.Fields{
+		"orders":          &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(orderType)))},
+		"total":           &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"total_estimated": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"page":            &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"limit":           &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"next_cursor":     optionalStringField(),
+		"prev_cursor":     optionalStringField(),
+	},
+})
+
+var ordersFilterType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "OrdersFilter",
+	Description: "Фильтры списка заказов, как у GET /orders. Даты - RFC3339 или YYYY-MM-DD, date_to в виде даты включает весь день",
+	Fields: func() graphql.InputObjectConfigFieldMap {
+		fields := graphql.InputObjectConfigFieldMap{}
+		for _, name := range []string{"date_from", "date_to", "delivery_service", "locale", "currency", "provider",
+			"bank", "customer_id", "city", "region", "brand"} {
+			fields[name] = &graphql.InputObjectFieldConfig{Type: graphql.String}
+		}
+		// суммы, как и в ответах, - десятичные строки
+		for _, name := range []string{"min_amount", "max_amount"} {
+			fields[name] = &graphql.InputObjectFieldConfig{Type: graphql.String}
+		}
+		fields["item_status"] = &graphql.InputObjectFieldConfig{Type: graphql.Int}
+		return fields
+	}(),
+})
+
+type resolver struct {
+	orderService OrdersService
+	defaultLimit int
+	maxLimit     int
+}
+
+func newSchema(r *resolver) (graphql.Schema, error) {
+	pageArgs := graphql.FieldConfigArgument{
+		"page":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+		"limit": &graphql.ArgumentConfig{Type: graphql.Int},
+	}
+	listArgs := graphql.FieldConfigArgument{
+		"filter": &graphql.ArgumentConfig{Type: ordersFilterType},
+		"sort":   &graphql.ArgumentConfig{Type: graphql.String, Description: "date, amount или items"},
+		"order":  &graphql.ArgumentConfig{Type: graphql.String, Description: "asc или desc"},
+		"cursor": &graphql.ArgumentConfig{Type: graphql.String},
+	}
+	for name, arg := range pageArgs {
+		listArgs[name] = arg
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"order": &graphql.Field{
+				Type:    orderType,
+				Args:    graphql.FieldConfigArgument{"order_uid": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: r.order,
+			},
+			"orders_by_uids": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(orderType))),
+				Description: "Найденные заказы в порядке order_uids",
+				Args: graphql.FieldConfigArgument{
+					"order_uids": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				},
+				Resolve: r.ordersByUIDs,
+			},
+			"orders": &graphql.Field{
+				Type:    graphql.NewNonNull(ordersPageType),
+				Args:    listArgs,
+				Resolve: r.orders,
+			},
+			"customer_orders": &graphql.Field{
+				Type: graphql.NewNonNull(ordersPageType),
+				Args: graphql.FieldConfigArgument{
+					"customer_id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"page":        pageArgs["page"],
+					"limit":       pageArgs["limit"],
+				},
+				Resolve: r.customerOrders,
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+func (r *resolver) order(p graphql.ResolveParams) (any, error) {
+	uid, _ := p.Args["order_uid"].(string)
+	load := loaderFromCtx(p.Context).Load(p.Context, strings.TrimSpace(uid))
+	return func() (any, error) {
+		o, err := load()
+		if err != nil {
+			return nil, r.fieldError(p.Context, "Failed to get order", err)
+		}
+		if o == nil {
+			// ненайденный заказ - null, как принято в GraphQL
+			return nil, nil
+		}
+		return dto.OrderToDTO(o), nil
+	}, nil
+}
+
+func (r *resolver) ordersByUIDs(p graphql.ResolveParams) (any, error) {
+	raw, _ := p.Args["order_uids"].([]any)
+	var uids []string
+	for _, v := range raw {
+		if uid, _ := v.(string); strings.TrimSpace(uid) != "" {
+			uids = append(uids, strings.TrimSpace(uid))
+		}
+	}
+	load := loaderFromCtx(p.Context).LoadMany(p.Context, uids)
+	return func() (any, error) {
+		list, err := load()
+		if err != nil {
+			return nil, r.fieldError(p.Context, "Failed to get orders", err)
+		}
+		return dto.OrdersToDTO(list), nil
+	}, nil
+}
+
+func (r *resolver) orders(p graphql.ResolveParams) (any, error) {
+	params := r.pageParams(p)
+
+	var err error
+	if params.Filter, err = listFilter(p.Args["filter"]); err != nil {
+		return nil, err
+	}
+	sort, _ := p.Args["sort"].(string)
+	if params.Sort.Field, err = orders.ParseSortField(sort); err != nil {
+		return nil, err
+	}
+	switch order, _ := p.Args["order"].(string); strings.ToLower(order) {
+	case "", "desc":
+	case "asc":
+		params.Sort.Asc = true
+	default:
+		return nil, errors.New("order must be asc or desc")
+	}
+	if cursor, _ := p.Args["cursor"].(string); cursor != "" {
+		if params.Cursor, err = orders.DecodeCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+	// общее количество считается, только если его запросили
+	params.Total = orders.TotalNone
+	if selects(p.Info, "total") || selects(p.Info, "total_estimated") {
+		params.Total = orders.TotalExact
+	}
+
+	res, err := r.orderService.GetOrders(p.Context, params)
+	if err != nil {
+		return nil, r.fieldError(p.Context, "Failed to get orders", err)
+	}
+	loaderFromCtx(p.Context).Prime(res.Orders)
+
+	page := dto.OrdersResponse{
+		Orders:         dto.OrdersToDTO(res.Orders),
+		Total:          res.Total,
+		TotalEstimated: res.TotalEstimated,
+		Page:           params.Page,
+		Limit:          params.Limit,
+	}
+	if res.Next != nil {
+		page.NextCursor = res.Next.Encode()
+	}
+	if res.Prev != nil {
+		page.PrevCursor = res.Prev.Encode()
+	}
+	return page, nil
+}
+
+// customerOrders раскрывает историю заказов покупателя, поэтому, как и GET /admin/customers/{customer_id}/orders,
+// доступен только с токеном администратора
+func (r *resolver) customerOrders(p graphql.ResolveParams) (any, error) {
+	if !privileged(p.Context) {
+		return nil, errAdminRequired
+	}
+	customerID, _ := p.Args["customer_id"].(string)
+	params := r.pageParams(p)
+
+	list, total, err := r.orderService.GetCustomerOrders(p.Context, customerID, params)
+	if err != nil {
+		return nil, r.fieldError(p.Context, "Failed to get customer orders", err)
+	}
+	loaderFromCtx(p.Context).Prime(list)
+
+	return dto.OrdersResponse{
+		Orders: dto.OrdersToDTO(list),
+		Total:  total,
+		Page:   params.Page,
+		Limit:  params.Limit,
+	}, nil
+}
+
+// pageParams - page и limit; некорректные значения заменяются первой страницей и лимитом по умолчанию,
+// limit больше MAX_PAGE_LIMIT уменьшается до него(как в HTTP API)
+func (r *resolver) pageParams(p graphql.ResolveParams) service.GetOrdersParams {
+	page, _ := p.Args["page"].(int)
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := p.Args["limit"].(int)
+	if limit < 1 {
+		limit = r.defaultLimit
+	}
+	if r.maxLimit > 0 {
+		limit = min(limit, r.maxLimit)
+	}
+	return service.GetOrdersParams{Page: page, Limit: limit}
+}
+
+// fieldError возвращает клиенту ошибки запроса; внутренние ошибки логируются и не раскрываются
+func (r *resolver) fieldError(ctx context.Context, msg string, err error) error {
+	for _, target := range []error{
+		orders.ErrInvalidCustomerID, orders.ErrInvalidCursor, orders.ErrCursorSort, orders.ErrInvalidSort,
+	} {
+		if errors.Is(err, target) {
+			return target
+		}
+	}
+	logger.GetLoggerFromCtx(ctx).Error(ctx, msg, zap.Error(err))
+	return errors.New("internal server error")
+}
+
+func listFilter(arg any) (orders.ListFilter, error) {
+	var f orders.ListFilter
+	m, _ := arg.(map[string]any)
+	str := func(name string) string {
+		s, _ := m[name].(string)
+		return s
+	}
+	num := func(name string) *int {
+		if n, ok := m[name].(int); ok {
+			return &n
+		}
+		return nil
+	}
+	money := func(name string) (*orders.Money, error) {
+		if str(name) == "" {
+			return nil, nil
+		}
+		v, err := orders.ParseMoney(str(name), "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return &v, nil
+	}
+
+	f.DeliveryService, f.Locale, f.Currency = str("delivery_service"), str("locale"), str("currency")
+	f.Provider, f.Bank, f.CustomerID = str("provider"), str("bank"), str("customer_id")
+	f.City, f.Region, f.Brand = str("city"), str("region"), str("brand")
+	f.ItemStatus = num("item_status")
+
+	var err error
+	if f.MinAmount, err = money("min_amount"); err != nil {
+		return f, err
+	}
+	if f.MaxAmount, err = money("max_amount"); err != nil {
+		return f, err
+	}
+	if f.DateFrom, err = parseDate(str("date_from"), false); err != nil {
+		return f, fmt.Errorf("invalid date_from: %w", err)
+	}
+	if f.DateTo, err = parseDate(str("date_to"), true); err != nil {
+		return f, fmt.Errorf("invalid date_to: %w", err)
+	}
+	return f, nil
+}
+
+func parseDate(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, errors.New("expected RFC3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// selects - запрошено ли поле name непосредственно в выборке текущего поля(с учетом фрагментов)
+func selects(info graphql.ResolveInfo, name string) bool {
+	var walk func(set *ast.SelectionSet) bool
+	walk = func(set *ast.SelectionSet) bool {
+		if set == nil {
+			return false
+		}
+		for _, sel := range set.Selections {
+			switch s := sel.(type) {
+			case *ast.Field:
+				if s.Name != nil && s.Name.Value == name {
+					return true
+				}
+			case *ast.InlineFragment:
+				if walk(s.SelectionSet) {
+					return true
+				}
+			case *ast.FragmentSpread:
+				if f, ok := info.Fragments[s.Name.Value].(*ast.FragmentDefinition); ok && walk(f.SelectionSet) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, f := range info.FieldASTs {
+		if walk(f.SelectionSet) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package grpcapi
+
+import (
+	ordersv1 "wb_tech_level_zero/api/orders/v1"
+	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Сообщения gRPC строятся из DTO HTTP API, чтобы оба API отдавали заказ в одном представлении
+
+func orderToProto(d dto.OrderDTO) *ordersv1.Order {
+	o := &ordersv1.Order{
+		OrderUid:          d.OrderUID,
+		TrackNumber:       d.TrackNumber,
+		Entry:             d.Entry,
+		Status:            d.Status,
+		Delivery:          deliveryToProto(d.Delivery),
+		Payment:           paymentToProto(d.Payment),
+		Items:             make([]*ordersv1.Item, 0, len(d.Items)),
+		Locale:            d.Locale,
+		InternalSignature: d.InternalSignature,
+		CustomerId:        d.CustomerID,
+		DeliveryService:   d.DeliveryService,
+		Shardkey:          d.Shardkey,
+		SmId:              int64(d.SmID),
+		OofShard:          d.OofShard,
+		Reporting:         reportingToProto(d.Reporting),
+		Fraud:             fraudToProto(d.Fraud),
+	}
+	for _, item := range d.Items {
+		o.Items = append(o.Items, itemToProto(item))
+	}
+	if d.DateCreated != nil {
+		o.DateCreated = timestamppb.New(*d.DateCreated)
+	}
+	return o
+}
+
+func deliveryToProto(d dto.DeliveryDTO) *ordersv1.Delivery {
+	return &ordersv1.Delivery{
+		Name:    d.Name,
+		Phone:   d.Phone,
+		Zip:     d.Zip,
+		City:    d.City,
+		Address: d.Address,
+		Region:  d.Region,
+		Email:   d.Email,
+	}
+}
+
+func paymentToProto(p dto.PaymentDTO) *ordersv1.Payment {
+	return &ordersv1.Payment{
+		Transaction:  p.Transaction,
+		RequestId:    p.RequestID,
+		Currency:     p.Currency,
+		Provider:     p.Provider,
+		Amount:       p.Amount.Decimal(),
+		PaymentDt:    p.PaymentDT,
+		Bank:         p.Bank,
+		DeliveryCost: p.DeliveryCost.Decimal(),
+		GoodsTotal:   p.GoodsTotal.Decimal(),
+		CustomFee:    p.CustomFee.Decimal(),
+		Paid:         p.Paid.Decimal(),
+		Refunded:     p.Refunded.Decimal(),
+	}
+}
+
+func itemToProto(i dto.ItemDTO) *ordersv1.Item {
+	return &ordersv1.Item{
+		ChrtId:      int64(i.ChrtID),
+		TrackNumber: i.TrackNumber,
+		Price:       i.Price.Decimal(),
+		Rid:         i.Rid,
+		Name:        i.Name,
+		Sale:        int64(i.Sale),
+		Size:        i.Size,
+		TotalPrice:  i.TotalPrice.Decimal(),
+		NmId:        int64(i.NmID),
+		Brand:       i.Brand,
+		Status:      int64(i.Status),
+		Cancelled:   i.Cancelled,
+	}
+}
+
+func reportingToProto(r *dto.ReportingDTO) *ordersv1.Reporting {
+	if r == nil {
+		return nil
+	}
+	return &ordersv1.Reporting{
+		Currency:     r.Currency,
+		Rate:         r.Rate,
+		RateDate:     r.RateDate,
+		Amount:       r.Amount.Decimal(),
+		DeliveryCost: r.DeliveryCost.Decimal(),
+		GoodsTotal:   r.GoodsTotal.Decimal(),
+		CustomFee:    r.CustomFee.Decimal(),
+		Paid:         r.Paid.Decimal(),
+		Refunded:     r.Refunded.Decimal(),
+	}
+}
+
+func fraudToProto(f *dto.FraudDTO) *ordersv1.Fraud {
+	if f == nil {
+		return nil
+	}
+	res := &ordersv1.Fraud{Score: int64(f.Score), Rules: make([]*ordersv1.FraudRule, 0, len(f.Rules))}
+	for _, r := range f.Rules {
+		res.Rules = append(res.Rules, &ordersv1.FraudRule{Rule: r.Rule, Score: int64(r.Score), Detail: r.Detail})
+	}
+	return res
+}
+
+func eventToProto(id uint64, e orders.Event) *ordersv1.OrderEvent {
+	d := dto.StreamOrderToDTO(e)
+	ev := &ordersv1.OrderEvent{
+		Id:              id,
+		Type:            d.Type,
+		OrderUid:        d.OrderUID,
+		TrackNumber:     d.TrackNumber,
+		CustomerId:      d.CustomerID,
+		DeliveryService: d.DeliveryService,
+		From:            d.From,
+		Status:          d.Status,
+		Reason:          d.Reason,
+		Currency:        d.Currency,
+		OccurredAt:      timestamppb.New(d.OccurredAt),
+	}
+	if d.Amount != nil {
+		ev.Amount = d.Amount.Decimal()
+	}
+	return ev
+}
@@ -0,0 +1,363 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	ordersv1 "wb_tech_level_zero/api/orders/v1"
+	"wb_tech_level_zero/internal/config"
+	"wb_tech_level_zero/internal/delivery/stream"
+	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/internal/service"
+	"wb_tech_level_zero/pkg/logger"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const defaultMaxBatchSize = 500
+
+type OrdersService interface {
+	GetOrderByUID(ctx context.Context, orderUID string) (*orders.Order, error)
+	GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error)
+	GetOrders(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error)
+	ConvertOrders(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error)
+}
+
+// OrderStream - поток событий заказов для WatchOrders
+type OrderStream interface {
+	Subscribe(filter orders.EventFilter, lastID uint64) (*stream.Subscription, []stream.Message, error)
+}
+
+// Handlers реализует ordersv1.OrdersServiceServer поверх того же сервиса заказов, что и HTTP API
+type Handlers struct {
+	ordersv1.UnimplementedOrdersServiceServer
+
+	cfg          *config.Config
+	orderService OrdersService
+	orderStream  OrderStream
+}
+
+func NewHandlers(cfg *config.Config, orderService OrdersService, orderStream OrderStream) *Handlers {
+	return &Handlers{
+		cfg:          cfg,
+		orderService: orderService,
+		orderStream:  orderStream,
+	}
+}
+
+func (h *Handlers) GetOrder(ctx context.Context, req *ordersv1.GetOrderRequest) (*ordersv1.GetOrderResponse, error) {
+	uid := strings.TrimSpace(req.GetOrderUid())
+	if uid == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_uid is required")
+	}
+
+	order, err := h.orderService.GetOrderByUID(ctx, uid)
+	if err != nil {
+		return nil, h.statusError(ctx, "Failed to get order", err)
+	}
+
+	list, err := h.ordersToProto(ctx, []*orders.Order{order}, req.GetReportingCurrency())
+	if err != nil {
+		return nil, err
+	}
+	return &ordersv1.GetOrderResponse{Order: list[0]}, nil
+}
+
+// ListOrders читает список по курсору страницами page_size и передает заказы по одному,
+// пока список не закончится или не будет передано limit заказов
+func (h *Handlers) ListOrders(req *ordersv1.ListOrdersRequest, srv ordersv1.OrdersService_ListOrdersServer) error {
+	ctx := srv.Context()
+
+	filter, err := listFilter(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = h.cfg.DefaultPageLimit
+	}
+	if h.cfg.MaxPageLimit > 0 {
+		pageSize = min(pageSize, h.cfg.MaxPageLimit)
+	}
+	limit := int(req.GetLimit())
+
+	params := service.GetOrdersParams{
+		Page:   1,
+		Limit:  pageSize,
+		Filter: filter,
+		Sort:   orders.ListSort{Field: orders.SortByDate, Asc: req.GetAsc()},
+		Total:  orders.TotalNone,
+	}
+	sent := 0
+	for {
+		res, err := h.orderService.GetOrders(ctx, params)
+		if err != nil {
+			return h.statusError(ctx, "Failed to get orders", err)
+		}
+		list := res.Orders
+		if limit > 0 && sent+len(list) > limit {
+			list = list[:limit-sent]
+		}
+
+		msgs, err := h.ordersToProto(ctx, list, req.GetReportingCurrency())
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if err := srv.Send(m); err != nil {
+				return err
+			}
+		}
+		sent += len(msgs)
+
+		if res.Next == nil || len(res.Orders) == 0 || (limit > 0 && sent >= limit) {
+			return nil
+		}
+		params.Cursor = res.Next
+	}
+}
+
+func (h *Handlers) BatchGetOrders(ctx context.Context, req *ordersv1.BatchGetOrdersRequest) (*ordersv1.BatchGetOrdersResponse, error) {
+	uids := compactList(req.GetOrderUids())
+	if len(uids) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "order_uids is required")
+	}
+	maxSize := h.cfg.GRPCMaxBatchSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxBatchSize
+	}
+	if len(uids) > maxSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d order_uids per request", maxSize)
+	}
+
+	list, err := h.orderService.GetOrdersByUIDs(ctx, uids)
+	if err != nil {
+		return nil, h.statusError(ctx, "Failed to get orders", err)
+	}
+	msgs, err := h.ordersToProto(ctx, list, req.GetReportingCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ordersv1.BatchGetOrdersResponse{Orders: msgs, NotFound: []string{}}
+	for _, uid := range uids {
+		if !slices.ContainsFunc(list, func(o *orders.Order) bool { return o.OrderUID == uid }) {
+			resp.NotFound = append(resp.NotFound, uid)
+		}
+	}
+	return resp, nil
+}
+
+// WatchOrders передает сохраненные события после last_event_id и затем новые события. Если клиент
+// не успевает читать события, поток завершается с UNAVAILABLE: клиент переподключается с последним id
+func (h *Handlers) WatchOrders(req *ordersv1.WatchOrdersRequest, srv ordersv1.OrdersService_WatchOrdersServer) error {
+	ctx := srv.Context()
+
+	if h.orderStream == nil {
+		return status.Error(codes.Unavailable, "order stream is not available")
+	}
+	filter, err := watchFilter(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sub, replay, err := h.orderStream.Subscribe(filter, req.GetLastEventId())
+	if err != nil {
+		switch {
+		case errors.Is(err, stream.ErrTooManyClients):
+			return status.Error(codes.ResourceExhausted, err.Error())
+		case errors.Is(err, stream.ErrClosed):
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		return h.statusError(ctx, "Failed to subscribe to order stream", err)
+	}
+	defer sub.Close()
+
+	for _, m := range replay {
+		if err := srv.Send(eventToProto(m.ID, m.Event)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case m := <-sub.C():
+			if err := srv.Send(eventToProto(m.ID, m.Event)); err != nil {
+				return err
+			}
+		case <-sub.Done():
+			return status.Error(codes.Unavailable, "subscription dropped, reconnect with last_event_id")
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// ordersToProto добавляет к заказам пересчет в валюту отчетности и оценку риска для администратора
+func (h *Handlers) ordersToProto(ctx context.Context, list []*orders.Order, reportingCurrency string) ([]*ordersv1.Order, error) {
+	dtos := dto.OrdersToDTO(list)
+
+	if reportingCurrency != "" && len(list) > 0 {
+		converted, err := h.orderService.ConvertOrders(ctx, list, reportingCurrency)
+		if err != nil {
+			return nil, h.statusError(ctx, "Failed to convert orders to reporting currency", err)
+		}
+		for i, c := range converted {
+			dtos[i].Reporting = dto.ReportingToDTO(c)
+		}
+	}
+	if h.privileged(ctx) {
+		for i, o := range list {
+			dtos[i].Fraud = dto.FraudToDTO(o.Fraud)
+		}
+	}
+
+	res := make([]*ordersv1.Order, 0, len(dtos))
+	for _, d := range dtos {
+		res = append(res, orderToProto(d))
+	}
+	return res, nil
+}
+
+// privileged - передан ли токен администратора в метаданных "authorization: Bearer <ADMIN_TOKEN>"
+func (h *Handlers) privileged(ctx context.Context) bool {
+	return h.cfg != nil && Authorized(ctx, h.cfg.AdminToken)
+}
+
+// Authorized - передан ли в метаданных "authorization: Bearer <token>" один из tokens; пустые токены не учитываются
+func Authorized(ctx context.Context, tokens ...string) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		provided, ok := strings.CutPrefix(v, "Bearer ")
+		if !ok || provided == "" {
+			continue
+		}
+		for _, t := range tokens {
+			if t != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(t)) == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// statusError переводит ошибку сервиса в статус gRPC; внутренние ошибки логируются и не раскрываются клиенту
+func (h *Handlers) statusError(ctx context.Context, msg string, err error) error {
+	switch {
+	case errors.Is(err, orders.ErrOrderNotFound):
+		return status.Error(codes.NotFound, orders.ErrOrderNotFound.Error())
+	case errors.Is(err, orders.ErrInvalidCurrency), errors.Is(err, orders.ErrCursorSort):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, orders.ErrRateNotFound):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	logger.GetLoggerFromCtx(ctx).Error(ctx, msg, zap.Error(err))
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func listFilter(req *ordersv1.ListOrdersRequest) (orders.ListFilter, error) {
+	f := orders.ListFilter{
+		DeliveryService: req.GetDeliveryService(),
+		Locale:          req.GetLocale(),
+		Currency:        req.GetCurrency(),
+		Provider:        req.GetProvider(),
+		Bank:            req.GetBank(),
+		CustomerID:      req.GetCustomerId(),
+		City:            req.GetCity(),
+		Region:          req.GetRegion(),
+		Brand:           req.GetBrand(),
+	}
+
+	var err error
+	if f.DateFrom, err = parseDate(req.GetDateFrom(), false); err != nil {
+		return f, fmt.Errorf("invalid date_from: %w", err)
+	}
+	if f.DateTo, err = parseDate(req.GetDateTo(), true); err != nil {
+		return f, fmt.Errorf("invalid date_to: %w", err)
+	}
+	if f.MinAmount, err = optionalMoney(req.GetMinAmount()); err != nil {
+		return f, fmt.Errorf("invalid min_amount: %w", err)
+	}
+	if f.MaxAmount, err = optionalMoney(req.GetMaxAmount()); err != nil {
+		return f, fmt.Errorf("invalid max_amount: %w", err)
+	}
+	f.ItemStatus = optionalInt(req.ItemStatus)
+	return f, nil
+}
+
+// watchFilter - фильтр WatchOrders; без event_types передаются все события жизненного цикла заказа
+func watchFilter(req *ordersv1.WatchOrdersRequest) (orders.EventFilter, error) {
+	filter := orders.EventFilter{
+		EventTypes:       orders.WebhookEventTypes,
+		OrderUIDs:        compactList(req.GetOrderUids()),
+		DeliveryServices: compactList(req.GetDeliveryServices()),
+		CustomerIDs:      compactList(req.GetCustomerIds()),
+	}
+	if types := compactList(req.GetEventTypes()); len(types) > 0 {
+		filter.EventTypes = make([]orders.EventType, 0, len(types))
+		for _, t := range types {
+			et := orders.EventType(strings.ToLower(t))
+			if !slices.Contains(orders.WebhookEventTypes, et) {
+				return filter, fmt.Errorf("event_types: unknown event type %q", t)
+			}
+			filter.EventTypes = append(filter.EventTypes, et)
+		}
+	}
+	return filter, nil
+}
+
+func parseDate(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, errors.New("expected RFC3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func optionalInt(v *int64) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
+
+// optionalMoney разбирает десятичную сумму; пустая строка - фильтр не задан
+func optionalMoney(v string) (*orders.Money, error) {
+	if v == "" {
+		return nil, nil
+	}
+	m, err := orders.ParseMoney(v, "")
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func compactList(list []string) []string {
+	var res []string
+	for _, uid := range list {
+		if uid = strings.TrimSpace(uid); uid != "" && !slices.Contains(res, uid) {
+			res = append(res, uid)
+		}
+	}
+	return res
+}
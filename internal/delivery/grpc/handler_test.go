@@ -0,0 +1,391 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+	ordersv1 "wb_tech_level_zero/api/orders/v1"
+	"wb_tech_level_zero/internal/config"
+	"wb_tech_level_zero/internal/delivery/stream"
+	"wb_tech_level_zero/internal/gateway"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/internal/service"
+	"wb_tech_level_zero/pkg/logger"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type mockOrderService struct {
+	GetOrderByUIDFunc   func(ctx context.Context, orderUID string) (*orders.Order, error)
+	GetOrdersByUIDsFunc func(ctx context.Context, uids []string) ([]*orders.Order, error)
+	GetOrdersFunc       func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error)
+	ConvertFunc         func(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error)
+}
+
+func (m *mockOrderService) GetOrderByUID(ctx context.Context, orderUID string) (*orders.Order, error) {
+	return m.GetOrderByUIDFunc(ctx, orderUID)
+}
+
+func (m *mockOrderService) GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error) {
+	return m.GetOrdersByUIDsFunc(ctx, uids)
+}
+
+func (m *mockOrderService) GetOrders(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+	return m.GetOrdersFunc(ctx, params)
+}
+
+func (m *mockOrderService) ConvertOrders(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error) {
+	return m.ConvertFunc(ctx, list, currency)
+}
+
+const serviceToken = "svc-token"
+
+// tokenAuth передает токен сервиса в метаданных каждого вызова
+type tokenAuth string
+
+func (a tokenAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(a)}, nil
+}
+
+func (tokenAuth) RequireTransportSecurity() bool {
+	return false
+}
+
+// startServer запускает gRPC-сервер шлюза(с интерцепторами, health и reflection) на bufconn
+// и возвращает соединение с токеном сервиса
+func startServer(t *testing.T, cfg *config.Config, svc *mockOrderService, orderStream *stream.Hub) *grpc.ClientConn {
+	t.Helper()
+	if len(cfg.GRPCAuthTokens) == 0 {
+		cfg.GRPCAuthTokens = []string{serviceToken}
+	}
+	return dial(t, listen(t, cfg, svc, orderStream), grpc.WithPerRPCCredentials(tokenAuth(serviceToken)))
+}
+
+func listen(t *testing.T, cfg *config.Config, svc *mockOrderService, orderStream *stream.Hub) *bufconn.Listener {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	var srv *gateway.GRPCServer
+	if orderStream != nil {
+		srv = gateway.NewGRPCServer(cfg, svc, orderStream, logger.New(zap.NewNop(), "test"))
+	} else {
+		srv = gateway.NewGRPCServer(cfg, svc, nil, logger.New(zap.NewNop(), "test"))
+	}
+	go srv.Serve(lis)
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+	return lis
+}
+
+func dial(t *testing.T, lis *bufconn.Listener, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient("passthrough:///bufnet", append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func testOrder(uid string) *orders.Order {
+	created := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	return &orders.Order{
+		OrderUID:    uid,
+		TrackNumber: "WBILMTESTTRACK",
+		Status:      orders.StatusCreated,
+		Payment:     orders.Payment{Currency: "USD", Amount: orders.NewMoney(181750, 2, "USD")},
+		Items:       []orders.Item{{ChrtID: 9934930, Price: orders.NewMoney(45300, 2, "USD"), Name: "Mascaras"}},
+		DateCreated: &created,
+		Fraud:       &orders.FraudScore{Score: 80},
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	svc := &mockOrderService{
+		GetOrderByUIDFunc: func(ctx context.Context, orderUID string) (*orders.Order, error) {
+			if orderUID == "o1" {
+				return testOrder("o1"), nil
+			}
+			return nil, orders.ErrOrderNotFound
+		},
+	}
+	conn := startServer(t, &config.Config{AdminToken: "secret"}, svc, nil)
+	client := ordersv1.NewOrdersServiceClient(conn)
+	ctx := context.Background()
+
+	var header metadata.MD
+	reqCtx := metadata.AppendToOutgoingContext(ctx, "x-request-id", "req-1")
+	resp, err := client.GetOrder(reqCtx, &ordersv1.GetOrderRequest{OrderUid: "o1"}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	o := resp.GetOrder()
+	if o.GetOrderUid() != "o1" || o.GetStatus() != "created" || o.GetPayment().GetAmount() != "1817.50" {
+		t.Errorf("unexpected order %+v", o)
+	}
+	if len(o.GetItems()) != 1 || o.GetItems()[0].GetPrice() != "453.00" || o.GetItems()[0].GetChrtId() != 9934930 {
+		t.Errorf("unexpected items %+v", o.GetItems())
+	}
+	if !o.GetDateCreated().AsTime().Equal(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date_created %v", o.GetDateCreated())
+	}
+	if o.GetFraud() != nil {
+		t.Error("fraud score must not be returned without admin token")
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("expected request id from metadata to be returned, got %v", got)
+	}
+
+	adminCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+	resp, err = client.GetOrder(adminCtx, &ordersv1.GetOrderRequest{OrderUid: "o1"}, grpc.Header(&header))
+	if err != nil || resp.GetOrder().GetFraud().GetScore() != 80 {
+		t.Errorf("expected fraud score for admin, got %v, %v", resp.GetOrder().GetFraud(), err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] == "" {
+		t.Errorf("expected generated request id, got %v", got)
+	}
+
+	for uid, code := range map[string]codes.Code{"missing": codes.NotFound, " ": codes.InvalidArgument} {
+		if _, err := client.GetOrder(ctx, &ordersv1.GetOrderRequest{OrderUid: uid}); status.Code(err) != code {
+			t.Errorf("%q: expected %s, got %v", uid, code, err)
+		}
+	}
+}
+
+func TestListOrders(t *testing.T) {
+	var calls []service.GetOrdersParams
+	svc := &mockOrderService{
+		GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+			calls = append(calls, params)
+			page := len(calls)
+			res := &orders.ListResult{Orders: []*orders.Order{testOrder(fmt.Sprintf("o%d", 2*page-1)), testOrder(fmt.Sprintf("o%d", 2*page))}}
+			if page < 3 {
+				res.Next = &orders.Cursor{DateCreated: time.Now(), ID: page}
+			}
+			return res, nil
+		},
+	}
+	client := ordersv1.NewOrdersServiceClient(startServer(t, &config.Config{DefaultPageLimit: 2}, svc, nil))
+
+	recv := func(req *ordersv1.ListOrdersRequest) ([]string, error) {
+		s, err := client.ListOrders(context.Background(), req)
+		if err != nil {
+			return nil, err
+		}
+		var uids []string
+		for {
+			o, err := s.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return uids, nil
+				}
+				return uids, err
+			}
+			uids = append(uids, o.GetOrderUid())
+		}
+	}
+
+	uids, err := recv(&ordersv1.ListOrdersRequest{DeliveryService: "meest", Limit: 3, Asc: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(uids, []string{"o1", "o2", "o3"}) {
+		t.Errorf("expected first 3 orders, got %v", uids)
+	}
+	if len(calls) != 2 || calls[0].Cursor != nil || calls[1].Cursor == nil || calls[1].Cursor.ID != 1 {
+		t.Errorf("expected second page to be read by cursor, got %+v", calls)
+	}
+	if p := calls[0]; p.Limit != 2 || !p.Sort.Asc || p.Filter.DeliveryService != "meest" || p.Total != orders.TotalNone {
+		t.Errorf("unexpected list params %+v", p)
+	}
+
+	calls = nil
+	if uids, err = recv(&ordersv1.ListOrdersRequest{}); err != nil || len(uids) != 6 || len(calls) != 3 {
+		t.Errorf("expected all 6 orders from 3 pages, got %v, %v", uids, err)
+	}
+
+	calls = nil
+	if _, err := recv(&ordersv1.ListOrdersRequest{MinAmount: "10.50", Limit: 1}); err != nil || len(calls) != 1 {
+		t.Fatalf("unexpected result: %v, %d calls", err, len(calls))
+	}
+	if f := calls[0].Filter; f.MinAmount == nil || !f.MinAmount.Equal(orders.NewMoney(1050, 2, "")) || f.MaxAmount != nil {
+		t.Errorf("expected decimal min_amount, got %+v", f)
+	}
+
+	if _, err := recv(&ordersv1.ListOrdersRequest{DateFrom: "yesterday"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for invalid date, got %v", err)
+	}
+	if _, err := recv(&ordersv1.ListOrdersRequest{MaxAmount: "ten"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for invalid max_amount, got %v", err)
+	}
+}
+
+func TestBatchGetOrders(t *testing.T) {
+	var requested []string
+	svc := &mockOrderService{
+		GetOrdersByUIDsFunc: func(ctx context.Context, uids []string) ([]*orders.Order, error) {
+			requested = uids
+			return []*orders.Order{testOrder("o2"), testOrder("o1")}, nil
+		},
+	}
+	client := ordersv1.NewOrdersServiceClient(startServer(t, &config.Config{GRPCMaxBatchSize: 3}, svc, nil))
+	ctx := context.Background()
+
+	resp, err := client.BatchGetOrders(ctx, &ordersv1.BatchGetOrdersRequest{OrderUids: []string{"o2", "missing", "o2", "o1"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(requested, []string{"o2", "missing", "o1"}) {
+		t.Errorf("expected deduplicated uids, got %v", requested)
+	}
+	if len(resp.GetOrders()) != 2 || resp.GetOrders()[0].GetOrderUid() != "o2" {
+		t.Errorf("unexpected orders %v", resp.GetOrders())
+	}
+	if !slices.Equal(resp.GetNotFound(), []string{"missing"}) {
+		t.Errorf("expected not_found [missing], got %v", resp.GetNotFound())
+	}
+
+	for _, uids := range [][]string{nil, {"a", "b", "c", "d"}} {
+		if _, err := client.BatchGetOrders(ctx, &ordersv1.BatchGetOrdersRequest{OrderUids: uids}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: expected InvalidArgument, got %v", uids, err)
+		}
+	}
+}
+
+func TestWatchOrders(t *testing.T) {
+	hub := stream.NewHub(10, 10, 0)
+	amount := orders.NewMoney(181750, 2, "USD")
+	hub.Broadcast(stream.Message{ID: 1, Event: orders.Event{Type: orders.EventOrderCreated, OrderUID: "o1"}})
+	hub.Broadcast(stream.Message{ID: 2, Event: orders.Event{Type: orders.EventOrderCreated, OrderUID: "o1", Amount: &amount, Currency: "USD"}})
+	hub.Broadcast(stream.Message{ID: 3, Event: orders.Event{Type: orders.EventOrderCreated, OrderUID: "o2"}})
+
+	client := ordersv1.NewOrdersServiceClient(startServer(t, &config.Config{}, &mockOrderService{}, hub))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if s, err := client.WatchOrders(ctx, &ordersv1.WatchOrdersRequest{EventTypes: []string{"order.deleted"}}); err == nil {
+		if _, err = s.Recv(); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument for unknown event type, got %v", err)
+		}
+	}
+
+	s, err := client.WatchOrders(ctx, &ordersv1.WatchOrdersRequest{OrderUids: []string{"o1"}, LastEventId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := s.Recv()
+	if err != nil || ev.GetId() != 2 || ev.GetAmount() != "1817.50" || ev.GetType() != "order.created" {
+		t.Fatalf("expected replayed event 2, got %v, %v", ev, err)
+	}
+
+	// подписка оформляется на сервере асинхронно: ждем ее перед рассылкой новых событий
+	for hub.Clients() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	hub.Broadcast(stream.Message{ID: 4, Event: orders.Event{Type: orders.EventOrderStatusChanged, OrderUID: "o2", To: orders.StatusPaid}})
+	hub.Broadcast(stream.Message{ID: 5, Event: orders.Event{Type: orders.EventOrderStatusChanged, OrderUID: "o1", From: orders.StatusCreated, To: orders.StatusPaid}})
+
+	ev, err = s.Recv()
+	if err != nil || ev.GetId() != 5 || ev.GetFrom() != "created" || ev.GetStatus() != "paid" {
+		t.Fatalf("expected status change of o1, got %v, %v", ev, err)
+	}
+
+	// отставший клиент или остановленный поток завершают вызов с UNAVAILABLE
+	hub.Close()
+	if _, err := s.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable after stream is closed, got %v", err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	svc := &mockOrderService{
+		GetOrderByUIDFunc: func(ctx context.Context, orderUID string) (*orders.Order, error) { return testOrder(orderUID), nil },
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lis := listen(t, &config.Config{GRPCAuthTokens: []string{serviceToken}, AdminToken: "secret"}, svc, stream.NewHub(10, 10, 0))
+	for token, code := range map[string]codes.Code{
+		"":           codes.Unauthenticated,
+		"wrong":      codes.Unauthenticated,
+		serviceToken: codes.OK,
+		"secret":     codes.OK,
+	} {
+		var opts []grpc.DialOption
+		if token != "" {
+			opts = append(opts, grpc.WithPerRPCCredentials(tokenAuth(token)))
+		}
+		client := ordersv1.NewOrdersServiceClient(dial(t, lis, opts...))
+		if _, err := client.GetOrder(ctx, &ordersv1.GetOrderRequest{OrderUid: "o1"}); status.Code(err) != code {
+			t.Errorf("token %q: expected %s for GetOrder, got %v", token, code, err)
+		}
+		s, err := client.WatchOrders(ctx, &ordersv1.WatchOrdersRequest{})
+		if err == nil && code != codes.OK {
+			_, err = s.Recv()
+		}
+		if code != codes.OK && status.Code(err) != code {
+			t.Errorf("token %q: expected %s for WatchOrders, got %v", token, code, err)
+		}
+	}
+
+	disabled := dial(t, listen(t, &config.Config{}, svc, nil), grpc.WithPerRPCCredentials(tokenAuth(serviceToken)))
+	if _, err := ordersv1.NewOrdersServiceClient(disabled).GetOrder(ctx, &ordersv1.GetOrderRequest{OrderUid: "o1"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied without configured tokens, got %v", err)
+	}
+	if _, err := healthpb.NewHealthClient(disabled).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("health must be available without token, got %v", err)
+	}
+	s, err := reflectionpb.NewServerReflectionClient(disabled).ServerReflectionInfo(ctx)
+	if err == nil {
+		_, err = s.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied && status.Code(err) != codes.Unimplemented {
+		t.Errorf("reflection must not be available by default, got %v", err)
+	}
+}
+
+func TestHealthAndReflection(t *testing.T) {
+	conn := startServer(t, &config.Config{GRPCReflection: true}, &mockOrderService{}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "orders.v1.OrdersService"})
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING, got %v, %v", resp.GetStatus(), err)
+	}
+
+	s, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, svc := range r.GetListServicesResponse().GetService() {
+		names = append(names, svc.GetName())
+	}
+	if !slices.Contains(names, "orders.v1.OrdersService") || !slices.Contains(names, "grpc.health.v1.Health") {
+		t.Errorf("expected orders and health services in reflection, got %v", names)
+	}
+}
@@ -2,10 +2,14 @@ package httpapi
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 	"wb_tech_level_zero/internal/config"
+	"wb_tech_level_zero/internal/delivery/stream"
 	"wb_tech_level_zero/internal/dto"
 	"wb_tech_level_zero/internal/orders"
 	"wb_tech_level_zero/internal/service"
@@ -15,30 +19,62 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxLookupBody - ограничение тела POST /orders/lookup(ORDERS_LOOKUP_MAX_UIDS UID с запасом)
+const maxLookupBody = 1 << 20
+
 type OrdersService interface {
 	GetOrderByUID(ctx context.Context, orderUID string) (*orders.Order, error)
-	GetOrders(ctx context.Context, params service.GetOrdersParams) ([]*orders.Order, int, error)
+	GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error)
+	GetOrders(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error)
+	SearchOrders(ctx context.Context, criteria orders.SearchCriteria, params service.GetOrdersParams) ([]*orders.Order, int, error)
+	SearchOrdersText(ctx context.Context, text string, params service.GetOrdersParams) (*orders.TextSearchResult, error)
+	GetCustomerOrders(ctx context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error)
+	GetCustomerSummary(ctx context.Context, customerID string) (*orders.CustomerSummary, error)
+	ChangeOrderStatus(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error)
+	ForgetCustomer(ctx context.Context, customerID, reason string) (*orders.Erasure, error)
+	GetOrderRefunds(ctx context.Context, orderUID string) ([]orders.Refund, error)
+	GetOrderHistory(ctx context.Context, orderUID string) ([]orders.OrderVersion, error)
+	GetOrderAsOf(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error)
+	ConvertOrders(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error)
+	GetOrderStats(ctx context.Context, params service.StatsParams) (*orders.StatsResult, error)
+	CreateWebhook(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*orders.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*orders.Webhook, error)
+	UpdateWebhook(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetWebhookDeliveries(ctx context.Context, id int64, limit int) ([]orders.WebhookDelivery, error)
+}
+
+// OrderStream - поток событий заказов для SSE-клиентов
+type OrderStream interface {
+	Subscribe(filter orders.EventFilter, lastID uint64) (*stream.Subscription, []stream.Message, error)
 }
 
 type Handlers struct {
 	cfg          *config.Config
 	orderService OrdersService
+	orderStream  OrderStream
 }
 
-func NewHandlers(cfg *config.Config, orderService OrdersService) *Handlers {
+func NewHandlers(cfg *config.Config, orderService OrdersService, orderStream OrderStream) *Handlers {
 	return &Handlers{
 		cfg:          cfg,
 		orderService: orderService,
+		orderStream:  orderStream,
 	}
 }
 
 // @Summary Getting orders by UID
-// @Description Getting orders by UID
+// @Description Getting orders by UID. With the admin token the response includes the fraud score assigned on ingestion
 // @Tags orders
 // @Produce json
 // @Param uid path string true "UID заказа"
+// @Param as_of query string false "Состояние заказа на момент времени(RFC3339)"
+// @Param reporting_currency query string false "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting"
 // @Success 200 {object} dto.OrderDTO
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {string} string "Not Found"
+// @Failure 422 {object} dto.ErrorResponse
 // @Router /order/{uid} [get]
 func (h *Handlers) GetOrderByUID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -52,6 +88,11 @@ func (h *Handlers) GetOrderByUID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+		h.getOrderAsOf(w, r, orderUID, asOf)
+		return
+	}
+
 	dbOrder, err := h.orderService.GetOrderByUID(ctx, orderUID)
 	if err != nil {
 		if errors.Is(err, orders.ErrOrderNotFound) {
@@ -68,49 +109,614 @@ func (h *Handlers) GetOrderByUID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	orderDTO := dto.OrderToDTO(dbOrder)
+	if !h.withReporting(w, r, []*orders.Order{dbOrder}, func(_ int, rep *dto.ReportingDTO) { orderDTO.Reporting = rep }) {
+		return
+	}
+	h.withFraud(r, []*orders.Order{dbOrder}, func(_ int, fraud *dto.FraudDTO) { orderDTO.Fraud = fraud })
 	h.writeJSONResponse(ctx, w, http.StatusOK, orderDTO)
 
 }
 
-func (h *Handlers) GetOrders(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) getOrderAsOf(w http.ResponseWriter, r *http.Request, orderUID, rawAsOf string) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	asOf, err := time.Parse(time.RFC3339, rawAsOf)
+	if err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "as_of must be RFC3339 timestamp")
+		return
+	}
+
+	version, err := h.orderService.GetOrderAsOf(ctx, orderUID, asOf)
+	if err != nil {
+		if errors.Is(err, orders.ErrOrderNotFound) {
+			h.writeErrorResponse(ctx, w, http.StatusNotFound, "Order not found")
+			return
+		}
+		log.Error(ctx, "Failed to get order version",
+			zap.Error(err),
+			zap.String("order_uid", orderUID),
+		)
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	orderDTO := dto.OrderToDTO(version.Order)
+	if !h.withReporting(w, r, []*orders.Order{version.Order}, func(_ int, rep *dto.ReportingDTO) { orderDTO.Reporting = rep }) {
+		return
+	}
+	h.withFraud(r, []*orders.Order{version.Order}, func(_ int, fraud *dto.FraudDTO) { orderDTO.Fraud = fraud })
+	w.Header().Set("X-Order-Version", strconv.Itoa(version.Version))
+	h.writeJSONResponse(ctx, w, http.StatusOK, orderDTO)
+}
+
+// @Summary Getting order change history
+// @Description Getting all versions of the order with the source of each change
+// @Tags orders
+// @Produce json
+// @Param uid path string true "UID заказа"
+// @Success 200 {object} dto.OrderHistoryResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /order/{uid}/history [get]
+func (h *Handlers) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	log := logger.GetLoggerFromCtx(ctx)
 
-	queryParams := r.URL.Query()
-	page, err := strconv.Atoi(queryParams.Get("page"))
+	orderUID := mux.Vars(r)["order_uid"]
+	if orderUID == "" {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "order_uid path parameter is required")
+		return
+	}
+
+	history, err := h.orderService.GetOrderHistory(ctx, orderUID)
+	if err != nil {
+		if errors.Is(err, orders.ErrOrderNotFound) {
+			h.writeErrorResponse(ctx, w, http.StatusNotFound, "Order not found")
+			return
+		}
+		log.Error(ctx, "Failed to get order history",
+			zap.Error(err),
+			zap.String("order_uid", orderUID),
+		)
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
 
-	if err != nil || page < 1 {
-		page = 1
+	resp := dto.OrderHistoryResponse{
+		OrderUID: orderUID,
+		Versions: make([]dto.OrderVersionDTO, 0, len(history)),
 	}
+	for _, v := range history {
+		resp.Versions = append(resp.Versions, dto.VersionToDTO(v))
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
+}
 
-	limit, err := strconv.Atoi(queryParams.Get("limit"))
+// @Summary Getting order refunds
+// @Description Getting refunds recorded for the order payment transaction
+// @Tags orders
+// @Produce json
+// @Param uid path string true "UID заказа"
+// @Success 200 {array} dto.RefundDTO
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /order/{uid}/refunds [get]
+func (h *Handlers) GetOrderRefunds(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	orderUID := mux.Vars(r)["order_uid"]
+	if orderUID == "" {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "order_uid path parameter is required")
+		return
+	}
+
+	refunds, err := h.orderService.GetOrderRefunds(ctx, orderUID)
+	if err != nil {
+		if errors.Is(err, orders.ErrOrderNotFound) {
+			h.writeErrorResponse(ctx, w, http.StatusNotFound, "Order not found")
+			return
+		}
+		log.Error(ctx, "Failed to get order refunds",
+			zap.Error(err),
+			zap.String("order_uid", orderUID),
+		)
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
 
-	if err != nil || limit < 1 {
-		limit = h.cfg.DefaultPageLimit
+	resp := make([]dto.RefundDTO, 0, len(refunds))
+	for _, rf := range refunds {
+		resp = append(resp, dto.RefundDTO(rf))
 	}
 
-	params := service.GetOrdersParams{
-		Page:  page,
-		Limit: limit,
+	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// @Summary Getting orders list
+// @Description Getting orders page with optional filters and sorting; filters are combined with AND.
+// @Description Pass next_cursor/prev_cursor from the response as cursor for keyset pagination(date sort only); page is ignored then
+// @Tags orders
+// @Produce json
+// @Param page query int false "Номер страницы"
+// @Param limit query int false "Размер страницы(не больше MAX_PAGE_LIMIT)"
+// @Param date_from query string false "Дата создания от(RFC3339 или YYYY-MM-DD)"
+// @Param date_to query string false "Дата создания до(RFC3339 или YYYY-MM-DD включительно)"
+// @Param delivery_service query string false "Служба доставки"
+// @Param locale query string false "Локаль"
+// @Param currency query string false "Валюта оплаты"
+// @Param provider query string false "Платежный провайдер"
+// @Param bank query string false "Банк"
+// @Param customer_id query string false "ID покупателя"
+// @Param city query string false "Город доставки"
+// @Param region query string false "Регион доставки"
+// @Param min_amount query string false "Минимальная сумма оплаты, десятичное число(10.50)"
+// @Param max_amount query string false "Максимальная сумма оплаты, десятичное число(10.50)"
+// @Param brand query string false "Бренд позиции(без учета регистра)"
+// @Param item_status query int false "Статус позиции"
+// @Param sort query string false "Поле сортировки" Enums(date, amount, items)
+// @Param order query string false "Направление сортировки" Enums(asc, desc)
+// @Param cursor query string false "Курсор страницы из next_cursor/prev_cursor"
+// @Param total query string false "Подсчет total(по умолчанию exact без курсора и none с курсором)" Enums(exact, estimate, none)
+// @Param reporting_currency query string false "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting"
+// @Success 200 {object} dto.OrdersResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Router /orders [get]
+func (h *Handlers) GetOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	params := h.pageParams(r)
+	filter, sort, err := listFilterParams(r)
+	if err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params.Filter, params.Sort = filter, sort
+	if params.Cursor, params.Total, err = cursorParams(r); err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	ordersList, total, err := h.orderService.GetOrders(ctx, params)
+	res, err := h.orderService.GetOrders(ctx, params)
 	if err != nil {
+		if errors.Is(err, orders.ErrCursorSort) {
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
 		log.Error(ctx, "Failed to get orders", zap.Error(err))
 		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	ordersDTO := make([]dto.OrderDTO, 0, len(ordersList))
-	for _, o := range ordersList {
-		ordersDTO = append(ordersDTO, dto.OrderToDTO(o))
+	resp := &dto.OrdersResponse{
+		Orders:         dto.OrdersToDTO(res.Orders),
+		Total:          res.Total,
+		TotalEstimated: res.TotalEstimated,
+		Page:           params.Page,
+		Limit:          params.Limit,
+	}
+	if !h.withReporting(w, r, res.Orders, func(i int, rep *dto.ReportingDTO) { resp.Orders[i].Reporting = rep }) {
+		return
+	}
+	h.withFraud(r, res.Orders, func(i int, fraud *dto.FraudDTO) { resp.Orders[i].Fraud = fraud })
+	if res.Next != nil {
+		resp.NextCursor = res.Next.Encode()
+	}
+	if res.Prev != nil {
+		resp.PrevCursor = res.Prev.Encode()
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// @Summary Looking up orders by UIDs
+// @Description Getting up to ORDERS_LOOKUP_MAX_UIDS orders in one request: cached orders are read with a single MGET,
+// @Description the rest with a single database query. Orders are returned in request order, unknown UIDs - in missing.
+// @Description The request body is limited to 1 MiB
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param request body dto.OrdersLookupRequest true "UID заказов"
+// @Param reporting_currency query string false "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting"
+// @Success 200 {object} dto.OrdersLookupResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Router /orders/lookup [post]
+func (h *Handlers) LookupOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	var req dto.OrdersLookupRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLookupBody)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.writeErrorResponse(ctx, w, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	uids := compactUIDs(req.OrderUIDs)
+	if len(uids) == 0 {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "order_uids is required")
+		return
+	}
+	if h.cfg.OrdersLookupMaxUIDs > 0 && len(uids) > h.cfg.OrdersLookupMaxUIDs {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, fmt.Sprintf("at most %d order_uids per request", h.cfg.OrdersLookupMaxUIDs))
+		return
+	}
+
+	list, err := h.orderService.GetOrdersByUIDs(ctx, uids)
+	if err != nil {
+		log.Error(ctx, "Failed to look up orders", zap.Error(err), zap.Int("order_uids", len(uids)))
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	resp := &dto.OrdersLookupResponse{Orders: dto.OrdersToDTO(list), Missing: []string{}}
+	if !h.withReporting(w, r, list, func(i int, rep *dto.ReportingDTO) { resp.Orders[i].Reporting = rep }) {
+		return
+	}
+	h.withFraud(r, list, func(i int, fraud *dto.FraudDTO) { resp.Orders[i].Fraud = fraud })
+
+	found := make(map[string]bool, len(list))
+	for _, o := range list {
+		found[o.OrderUID] = true
+	}
+	for _, uid := range uids {
+		if !found[uid] {
+			resp.Missing = append(resp.Missing, uid)
+		}
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// @Summary Searching orders
+// @Description Searching orders by exact match of order, payment, item and delivery contact fields; criteria are combined with AND
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param track_number query string false "Трек-номер"
+// @Param transaction query string false "ID транзакции оплаты"
+// @Param request_id query string false "request_id оплаты"
+// @Param rid query string false "rid позиции"
+// @Param nm_id query int false "nm_id позиции"
+// @Param brand query string false "Бренд позиции(без учета регистра)"
+// @Param phone query string false "Телефон доставки"
+// @Param email query string false "Email доставки"
+// @Param page query int false "Номер страницы"
+// @Param limit query int false "Размер страницы(не больше MAX_PAGE_LIMIT)"
+// @Param reporting_currency query string false "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting"
+// @Success 200 {object} dto.OrdersResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Router /admin/orders/search [get]
+func (h *Handlers) SearchOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	q := r.URL.Query()
+	criteria := orders.SearchCriteria{
+		TrackNumber: q.Get("track_number"),
+		Transaction: q.Get("transaction"),
+		RequestID:   q.Get("request_id"),
+		Rid:         q.Get("rid"),
+		Brand:       q.Get("brand"),
+		Phone:       q.Get("phone"),
+		Email:       q.Get("email"),
+	}
+	if v := q.Get("nm_id"); v != "" {
+		nmID, err := strconv.Atoi(v)
+		if err != nil || nmID <= 0 {
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, "nm_id must be a positive integer")
+			return
+		}
+		criteria.NmID = nmID
+	}
+	params := h.pageParams(r)
+
+	ordersList, total, err := h.orderService.SearchOrders(ctx, criteria, params)
+	if err != nil {
+		if errors.Is(err, orders.ErrEmptySearch) {
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error(ctx, "Failed to search orders", zap.Error(err))
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	resp := &dto.OrdersResponse{
+		Orders: dto.OrdersToDTO(ordersList),
+		Total:  total,
+		Page:   params.Page,
+		Limit:  params.Limit,
+	}
+	if !h.withReporting(w, r, ordersList, func(i int, rep *dto.ReportingDTO) { resp.Orders[i].Reporting = rep }) {
+		return
+	}
+	h.withFraud(r, ordersList, func(i int, fraud *dto.FraudDTO) { resp.Orders[i].Fraud = fraud })
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// @Summary Full-text searching orders
+// @Description Searching orders by words in item names and brands, delivery city and address, ranked by relevance.
+// @Description Supports "quoted phrases", OR and -exclusion; falls back to fuzzy trigram search when no word matches
+// @Tags orders
+// @Produce json
+// @Param q query string true "Текст запроса"
+// @Param page query int false "Номер страницы"
+// @Param limit query int false "Размер страницы(не больше MAX_PAGE_LIMIT)"
+// @Param reporting_currency query string false "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting"
+// @Success 200 {object} dto.TextSearchResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Router /orders/search [get]
+func (h *Handlers) SearchOrdersText(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	params := h.pageParams(r)
+	res, err := h.orderService.SearchOrdersText(ctx, r.URL.Query().Get("q"), params)
+	if err != nil {
+		if errors.Is(err, orders.ErrEmptyTextQuery) || errors.Is(err, orders.ErrTextQueryTooLong) {
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error(ctx, "Failed to search orders text", zap.Error(err))
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	resp := &dto.TextSearchResponse{
+		Hits:  dto.TextSearchToDTO(res),
+		Total: res.Total,
+		Fuzzy: res.Fuzzy,
+		Page:  params.Page,
+		Limit: params.Limit,
+	}
+	hitOrders := make([]*orders.Order, len(res.Hits))
+	for i, hit := range res.Hits {
+		hitOrders[i] = hit.Order
+	}
+	if !h.withReporting(w, r, hitOrders, func(i int, rep *dto.ReportingDTO) { resp.Hits[i].Order.Reporting = rep }) {
+		return
+	}
+	h.withFraud(r, hitOrders, func(i int, fraud *dto.FraudDTO) { resp.Hits[i].Order.Fraud = fraud })
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// @Summary Getting customer orders
+// @Description Getting orders of the customer, newest first
+// @Tags customers
+// @Produce json
+// @Security AdminToken
+// @Param customer_id path string true "ID покупателя"
+// @Param page query int false "Номер страницы"
+// @Param limit query int false "Размер страницы(не больше MAX_PAGE_LIMIT)"
+// @Param reporting_currency query string false "Валюта отчетности для пересчета сумм оплаты(ISO 4217); заказы без курса возвращаются без reporting"
+// @Success 200 {object} dto.OrdersResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Router /admin/customers/{customer_id}/orders [get]
+func (h *Handlers) GetCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	customerID := mux.Vars(r)["customer_id"]
+	if customerID == "" {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "customer_id path parameter is required")
+		return
+	}
+	params := h.pageParams(r)
+
+	ordersList, total, err := h.orderService.GetCustomerOrders(ctx, customerID, params)
+	if err != nil {
+		if errors.Is(err, orders.ErrInvalidCustomerID) {
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error(ctx, "Failed to get customer orders", zap.Error(err))
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
 	}
 
 	resp := &dto.OrdersResponse{
-		Orders: ordersDTO,
+		Orders: dto.OrdersToDTO(ordersList),
 		Total:  total,
-		Page:   page,
+		Page:   params.Page,
+		Limit:  params.Limit,
+	}
+	if !h.withReporting(w, r, ordersList, func(i int, rep *dto.ReportingDTO) { resp.Orders[i].Reporting = rep }) {
+		return
 	}
+	h.withFraud(r, ordersList, func(i int, fraud *dto.FraudDTO) { resp.Orders[i].Fraud = fraud })
 
 	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
 }
+
+// @Summary Getting customer summary
+// @Description Getting order count, spend per currency, first/last order date and favourite brands of the customer
+// @Tags customers
+// @Produce json
+// @Security AdminToken
+// @Param customer_id path string true "ID покупателя"
+// @Success 200 {object} dto.CustomerSummaryDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/customers/{customer_id}/summary [get]
+func (h *Handlers) GetCustomerSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	customerID := mux.Vars(r)["customer_id"]
+	if customerID == "" {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "customer_id path parameter is required")
+		return
+	}
+
+	summary, err := h.orderService.GetCustomerSummary(ctx, customerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, orders.ErrInvalidCustomerID):
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orders.ErrCustomerNotFound):
+			h.writeErrorResponse(ctx, w, http.StatusNotFound, "Customer not found")
+		default:
+			log.Error(ctx, "Failed to get customer summary", zap.Error(err))
+			h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, dto.CustomerSummaryToDTO(summary))
+}
+
+// @Summary Getting order statistics
+// @Description Getting order counts and revenue per currency from daily rollups, grouped by period and dimensions.
+// @Description Groups are ordered by period and dimension values; revenue is the payment amount at creation, refunded - recorded refunds
+// @Tags stats
+// @Produce json
+// @Param group_by query string false "Измерения через запятую: day|week|month, delivery_service, provider, bank, currency, region, brand(по умолчанию day)"
+// @Param date_from query string false "Первый день(YYYY-MM-DD, по умолчанию 30 дней до date_to)"
+// @Param date_to query string false "Последний день включительно(YYYY-MM-DD, по умолчанию сегодня)"
+// @Param reporting_currency query string false "Валюта отчетности для итогов выручки(ISO 4217)"
+// @Success 200 {object} dto.OrderStatsResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Router /stats/orders [get]
+func (h *Handlers) GetOrderStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	params, err := statsParams(r)
+	if err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.orderService.GetOrderStats(ctx, params)
+	if err != nil {
+		switch {
+		case errors.Is(err, orders.ErrInvalidStatsRange), errors.Is(err, orders.ErrInvalidCurrency):
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orders.ErrRateNotFound):
+			h.writeErrorResponse(ctx, w, http.StatusUnprocessableEntity, err.Error())
+		default:
+			log.Error(ctx, "Failed to get order stats", zap.Error(err))
+			h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, dto.OrderStatsToDTO(res))
+}
+
+// @Summary Changing order status
+// @Description Changing order status according to the order lifecycle
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param uid path string true "UID заказа"
+// @Param request body dto.ChangeStatusRequest true "Новый статус"
+// @Success 200 {object} dto.OrderDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/orders/{uid}/status [patch]
+func (h *Handlers) ChangeOrderStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := h.withChangeSource(r)
+	log := logger.GetLoggerFromCtx(ctx)
+
+	orderUID := mux.Vars(r)["order_uid"]
+	if orderUID == "" {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "order_uid path parameter is required")
+		return
+	}
+
+	var req dto.ChangeStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	status, err := orders.ParseStatus(req.Status)
+	if err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order, err := h.orderService.ChangeOrderStatus(ctx, orderUID, status, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, orders.ErrOrderNotFound):
+			h.writeErrorResponse(ctx, w, http.StatusNotFound, "Order not found")
+		case errors.Is(err, orders.ErrInvalidTransition), errors.Is(err, orders.ErrStatusConflict):
+			h.writeErrorResponse(ctx, w, http.StatusConflict, err.Error())
+		default:
+			log.Error(ctx, "Failed to change order status",
+				zap.Error(err),
+				zap.String("order_uid", orderUID),
+			)
+			h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, dto.OrderToDTO(order))
+}
+
+// @Summary Erasing customer personal data
+// @Description Anonymizing delivery data of all customer orders, keeping financial records
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param customer_id path string true "ID покупателя"
+// @Param request body dto.ErasureRequest false "Основание удаления"
+// @Success 200 {object} dto.ErasureDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /admin/customers/{customer_id}/erase [post]
+func (h *Handlers) ForgetCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := h.withChangeSource(r)
+	log := logger.GetLoggerFromCtx(ctx)
+
+	customerID := mux.Vars(r)["customer_id"]
+	if customerID == "" {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "customer_id path parameter is required")
+		return
+	}
+
+	var req dto.ErasureRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	erasure, err := h.orderService.ForgetCustomer(ctx, customerID, req.Reason)
+	if err != nil {
+		if errors.Is(err, orders.ErrInvalidCustomerID) {
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error(ctx, "Failed to erase customer data", zap.Error(err))
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, dto.ErasureToDTO(erasure))
+}
@@ -1,34 +1,130 @@
 package httpapi_test
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 	"wb_tech_level_zero/internal/config"
 	httpapi "wb_tech_level_zero/internal/delivery/http"
+	"wb_tech_level_zero/internal/delivery/stream"
 	"wb_tech_level_zero/internal/dto"
 	"wb_tech_level_zero/internal/orders"
 	"wb_tech_level_zero/internal/service"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 )
 
 type mockOrderService struct {
-	GetOrderByUIDFunc func(ctx context.Context, orderUID string) (*orders.Order, error)
-	GetOrdersFunc     func(ctx context.Context, params service.GetOrdersParams) ([]*orders.Order, int, error)
+	GetOrderByUIDFunc   func(ctx context.Context, orderUID string) (*orders.Order, error)
+	GetOrdersByUIDsFunc func(ctx context.Context, uids []string) ([]*orders.Order, error)
+	GetOrdersFunc       func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error)
+	ChangeStatusFunc    func(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error)
+	ForgetFunc          func(ctx context.Context, customerID, reason string) (*orders.Erasure, error)
+	RefundsFunc         func(ctx context.Context, orderUID string) ([]orders.Refund, error)
+	HistoryFunc         func(ctx context.Context, orderUID string) ([]orders.OrderVersion, error)
+	AsOfFunc            func(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error)
+	SearchFunc          func(ctx context.Context, criteria orders.SearchCriteria, params service.GetOrdersParams) ([]*orders.Order, int, error)
+	SearchTextFunc      func(ctx context.Context, text string, params service.GetOrdersParams) (*orders.TextSearchResult, error)
+	CustomerOrdersFunc  func(ctx context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error)
+	CustomerSummaryFunc func(ctx context.Context, customerID string) (*orders.CustomerSummary, error)
+	ConvertFunc         func(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error)
+	StatsFunc           func(ctx context.Context, params service.StatsParams) (*orders.StatsResult, error)
+	CreateWebhookFunc   func(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error)
+	GetWebhooksFunc     func(ctx context.Context) ([]*orders.Webhook, error)
+	GetWebhookFunc      func(ctx context.Context, id int64) (*orders.Webhook, error)
+	UpdateWebhookFunc   func(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error)
+	DeleteWebhookFunc   func(ctx context.Context, id int64) error
+	DeliveriesFunc      func(ctx context.Context, id int64, limit int) ([]orders.WebhookDelivery, error)
+}
+
+func (m *mockOrderService) GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error) {
+	return m.GetOrdersByUIDsFunc(ctx, uids)
+}
+
+func (m *mockOrderService) CreateWebhook(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error) {
+	return m.CreateWebhookFunc(ctx, w)
+}
+
+func (m *mockOrderService) GetWebhooks(ctx context.Context) ([]*orders.Webhook, error) {
+	return m.GetWebhooksFunc(ctx)
+}
+
+func (m *mockOrderService) GetWebhook(ctx context.Context, id int64) (*orders.Webhook, error) {
+	return m.GetWebhookFunc(ctx, id)
+}
+
+func (m *mockOrderService) UpdateWebhook(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error) {
+	return m.UpdateWebhookFunc(ctx, w)
+}
+
+func (m *mockOrderService) DeleteWebhook(ctx context.Context, id int64) error {
+	return m.DeleteWebhookFunc(ctx, id)
+}
+
+func (m *mockOrderService) GetWebhookDeliveries(ctx context.Context, id int64, limit int) ([]orders.WebhookDelivery, error) {
+	return m.DeliveriesFunc(ctx, id, limit)
+}
+
+func (m *mockOrderService) GetOrderStats(ctx context.Context, params service.StatsParams) (*orders.StatsResult, error) {
+	return m.StatsFunc(ctx, params)
+}
+
+func (m *mockOrderService) ConvertOrders(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error) {
+	return m.ConvertFunc(ctx, list, currency)
+}
+
+func (m *mockOrderService) SearchOrders(ctx context.Context, criteria orders.SearchCriteria, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+	return m.SearchFunc(ctx, criteria, params)
+}
+
+func (m *mockOrderService) SearchOrdersText(ctx context.Context, text string, params service.GetOrdersParams) (*orders.TextSearchResult, error) {
+	return m.SearchTextFunc(ctx, text, params)
+}
+
+func (m *mockOrderService) GetCustomerOrders(ctx context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+	return m.CustomerOrdersFunc(ctx, customerID, params)
+}
+
+func (m *mockOrderService) GetCustomerSummary(ctx context.Context, customerID string) (*orders.CustomerSummary, error) {
+	return m.CustomerSummaryFunc(ctx, customerID)
+}
+
+func (m *mockOrderService) ForgetCustomer(ctx context.Context, customerID, reason string) (*orders.Erasure, error) {
+	return m.ForgetFunc(ctx, customerID, reason)
+}
+
+func (m *mockOrderService) GetOrderRefunds(ctx context.Context, orderUID string) ([]orders.Refund, error) {
+	return m.RefundsFunc(ctx, orderUID)
+}
+
+func (m *mockOrderService) GetOrderHistory(ctx context.Context, orderUID string) ([]orders.OrderVersion, error) {
+	return m.HistoryFunc(ctx, orderUID)
+}
+
+func (m *mockOrderService) GetOrderAsOf(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error) {
+	return m.AsOfFunc(ctx, orderUID, asOf)
 }
 
 func (m *mockOrderService) GetOrderByUID(ctx context.Context, orderUID string) (*orders.Order, error) {
 	return m.GetOrderByUIDFunc(ctx, orderUID)
 }
 
-func (m *mockOrderService) GetOrders(ctx context.Context, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+func (m *mockOrderService) GetOrders(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
 	return m.GetOrdersFunc(ctx, params)
 }
 
+func (m *mockOrderService) ChangeOrderStatus(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error) {
+	return m.ChangeStatusFunc(ctx, orderUID, to, reason)
+}
+
 func TestGetOrderByUID(t *testing.T) {
 	cfg := &config.Config{}
 
@@ -39,7 +135,7 @@ func TestGetOrderByUID(t *testing.T) {
 				return &orders.Order{OrderUID: "test-uid"}, nil
 			},
 		}
-		handler := httpapi.NewHandlers(cfg, mockService)
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
 		req := httptest.NewRequest(http.MethodGet, "/order/test-uid", nil)
 		rr := httptest.NewRecorder()
 
@@ -74,7 +170,7 @@ func TestGetOrderByUID(t *testing.T) {
 				return nil, orders.ErrOrderNotFound
 			},
 		}
-		handler := httpapi.NewHandlers(cfg, mockService)
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
 		req := httptest.NewRequest(http.MethodGet, "/order/not-found-uid", nil)
 		rr := httptest.NewRecorder()
 
@@ -105,7 +201,7 @@ func TestGetOrderByUID(t *testing.T) {
 				return nil, errors.New("database is down")
 			},
 		}
-		handler := httpapi.NewHandlers(cfg, mockService)
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
 		req := httptest.NewRequest(http.MethodGet, "/order/any-uid", nil)
 		rr := httptest.NewRecorder()
 
@@ -136,14 +232,14 @@ func TestGetOrders(t *testing.T) {
 	t.Run("success - 200 OK with params", func(t *testing.T) {
 		// Arrange
 		mockService := &mockOrderService{
-			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
 				if params.Page != 2 || params.Limit != 5 {
 					t.Errorf("expected page 2, limit 5, got page %d, limit %d", params.Page, params.Limit)
 				}
-				return []*orders.Order{{OrderUID: "o1"}, {OrderUID: "o2"}}, 2, nil
+				return &orders.ListResult{Orders: []*orders.Order{{OrderUID: "o1"}, {OrderUID: "o2"}}, Total: 2}, nil
 			},
 		}
-		handler := httpapi.NewHandlers(cfg, mockService)
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
 		req := httptest.NewRequest(http.MethodGet, "/orders?page=2&limit=5", nil)
 		rr := httptest.NewRecorder()
 
@@ -180,14 +276,14 @@ func TestGetOrders(t *testing.T) {
 	t.Run("success - 200 OK with default params", func(t *testing.T) {
 		// Arrange
 		mockService := &mockOrderService{
-			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
 				if params.Page != 1 || params.Limit != 10 {
 					t.Errorf("expected default page 1, limit 10, got page %d, limit %d", params.Page, params.Limit)
 				}
-				return []*orders.Order{}, 0, nil
+				return &orders.ListResult{}, nil
 			},
 		}
-		handler := httpapi.NewHandlers(cfg, mockService)
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
 		req := httptest.NewRequest(http.MethodGet, "/orders?page=invalid&limit=0", nil)
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
@@ -202,13 +298,147 @@ func TestGetOrders(t *testing.T) {
 		}
 	})
 
+	t.Run("success - 200 OK with limit capped", func(t *testing.T) {
+		mockService := &mockOrderService{
+			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+				if params.Limit != 100 {
+					t.Errorf("expected limit capped to 100, got %d", params.Limit)
+				}
+				return &orders.ListResult{}, nil
+			},
+		}
+		handler := httpapi.NewHandlers(&config.Config{DefaultPageLimit: 10, MaxPageLimit: 100}, mockService, nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/orders", handler.GetOrders)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders?limit=100000", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("success - 200 OK with filters and sort", func(t *testing.T) {
+		mockService := &mockOrderService{
+			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+				f := params.Filter
+				if f.Currency != "USD" || f.Brand != "Vivienne Sabo" || f.MinAmount == nil || !f.MinAmount.Equal(orders.NewMoney(1050, 2, "")) ||
+					f.ItemStatus == nil || *f.ItemStatus != 202 || f.MaxAmount != nil {
+					t.Errorf("unexpected filter: %+v", f)
+				}
+				wantTo := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+				if f.DateFrom == nil || f.DateTo == nil || !f.DateTo.Equal(wantTo) {
+					t.Errorf("expected date_to to include the whole day, got %v - %v", f.DateFrom, f.DateTo)
+				}
+				if params.Sort.Field != orders.SortByAmount || !params.Sort.Asc {
+					t.Errorf("unexpected sort: %+v", params.Sort)
+				}
+				return &orders.ListResult{}, nil
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/orders", handler.GetOrders)
+
+		req := httptest.NewRequest(http.MethodGet,
+			"/orders?currency=USD&brand=Vivienne+Sabo&min_amount=10.50&item_status=202&date_from=2025-01-01T00:00:00Z&date_to=2025-01-31&sort=amount&order=asc", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("invalid filters - 400 Bad Request", func(t *testing.T) {
+		mockService := &mockOrderService{
+			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+				t.Error("service must not be called with invalid filters")
+				return nil, nil
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/orders", handler.GetOrders)
+
+		for _, query := range []string{"sort=price", "order=up", "min_amount=ten", "max_amount=1,5", "date_from=yesterday", "cursor=garbage", "total=maybe"} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders?"+query, nil))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, rr.Code)
+			}
+		}
+	})
+
+	t.Run("cursor - 200 OK with next and prev cursors", func(t *testing.T) {
+		at := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+		cursor := &orders.Cursor{DateCreated: at, ID: 7}
+		mockService := &mockOrderService{
+			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+				if params.Cursor == nil || params.Cursor.ID != 7 || !params.Cursor.DateCreated.Equal(at) {
+					t.Errorf("unexpected cursor: %+v", params.Cursor)
+				}
+				if params.Total != orders.TotalEstimate {
+					t.Errorf("expected total mode estimate, got %q", params.Total)
+				}
+				return &orders.ListResult{
+					Orders:         []*orders.Order{{OrderUID: "o1"}},
+					Total:          100,
+					TotalEstimated: true,
+					Next:           &orders.Cursor{DateCreated: at.Add(-time.Hour), ID: 6},
+					Prev:           &orders.Cursor{DateCreated: at.Add(-time.Hour), ID: 6, Prev: true},
+				}, nil
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/orders", handler.GetOrders)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders?total=estimate&cursor="+cursor.Encode(), nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		var resp dto.OrdersResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if !resp.TotalEstimated || resp.NextCursor == "" || resp.PrevCursor == "" {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+		next, err := orders.DecodeCursor(resp.NextCursor)
+		if err != nil || next.ID != 6 || next.Prev {
+			t.Errorf("unexpected next cursor: %+v, %v", next, err)
+		}
+	})
+
+	t.Run("cursor with non-date sort - 400 Bad Request", func(t *testing.T) {
+		mockService := &mockOrderService{
+			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+				return nil, orders.ErrCursorSort
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/orders", handler.GetOrders)
+
+		cursor := &orders.Cursor{DateCreated: time.Now(), ID: 1}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders?sort=amount&cursor="+cursor.Encode(), nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
 	t.Run("internal server error - 500", func(t *testing.T) {
 		mockService := &mockOrderService{
-			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) ([]*orders.Order, int, error) {
-				return nil, 0, errors.New("db is down")
+			GetOrdersFunc: func(ctx context.Context, params service.GetOrdersParams) (*orders.ListResult, error) {
+				return nil, errors.New("db is down")
 			},
 		}
-		handler := httpapi.NewHandlers(cfg, mockService)
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
 		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
 		rr := httptest.NewRecorder()
 		router := mux.NewRouter()
@@ -221,3 +451,1119 @@ func TestGetOrders(t *testing.T) {
 		}
 	})
 }
+
+func TestChangeOrderStatus(t *testing.T) {
+	cfg := &config.Config{}
+
+	serve := func(svc *mockOrderService, body string) *httptest.ResponseRecorder {
+		handler := httpapi.NewHandlers(cfg, svc, nil)
+		req := httptest.NewRequest(http.MethodPatch, "/admin/orders/test-uid/status", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/admin/orders/{order_uid}/status", handler.ChangeOrderStatus)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("success - 200 OK", func(t *testing.T) {
+		mockService := &mockOrderService{
+			ChangeStatusFunc: func(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error) {
+				if orderUID != "test-uid" || to != orders.StatusPaid || reason != "ok" {
+					t.Errorf("unexpected arguments: %s %s %s", orderUID, to, reason)
+				}
+				return &orders.Order{OrderUID: orderUID, Status: to}, nil
+			},
+		}
+
+		rr := serve(mockService, `{"status":"paid","reason":"ok"}`)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var resp dto.OrderDTO
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Status != "paid" {
+			t.Errorf("expected status 'paid', got '%s'", resp.Status)
+		}
+	})
+
+	t.Run("unknown status - 400", func(t *testing.T) {
+		rr := serve(&mockOrderService{}, `{"status":"lost"}`)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("invalid transition - 409", func(t *testing.T) {
+		mockService := &mockOrderService{
+			ChangeStatusFunc: func(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error) {
+				return nil, &orders.TransitionError{From: orders.StatusDelivered, To: to}
+			},
+		}
+
+		rr := serve(mockService, `{"status":"paid"}`)
+
+		if rr.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, rr.Code)
+		}
+	})
+
+	t.Run("not found - 404", func(t *testing.T) {
+		mockService := &mockOrderService{
+			ChangeStatusFunc: func(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error) {
+				return nil, orders.ErrOrderNotFound
+			},
+		}
+
+		rr := serve(mockService, `{"status":"paid"}`)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	cfg := &config.Config{}
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("success - 200 OK", func(t *testing.T) {
+		mockService := &mockOrderService{
+			HistoryFunc: func(ctx context.Context, orderUID string) ([]orders.OrderVersion, error) {
+				return []orders.OrderVersion{
+					{Version: 1, Change: orders.EventOrderCreated, CreatedAt: created,
+						Source: orders.ChangeSource{Kind: orders.SourceKafka, Ref: "orders/0/1"},
+						Order:  &orders.Order{OrderUID: orderUID, Status: orders.StatusCreated}},
+					{Version: 2, Change: orders.EventOrderStatusChanged, CreatedAt: created.Add(time.Hour),
+						Source: orders.ChangeSource{Kind: orders.SourceHTTP, Ref: "req-1", Actor: "admin"},
+						Order:  &orders.Order{OrderUID: orderUID, Status: orders.StatusPaid}},
+				}, nil
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		req := httptest.NewRequest(http.MethodGet, "/order/test-uid/history", nil)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/order/{order_uid}/history", handler.GetOrderHistory)
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var resp dto.OrderHistoryResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Versions) != 2 {
+			t.Fatalf("expected 2 versions, got %d", len(resp.Versions))
+		}
+		if v := resp.Versions[1]; v.Source != "http" || v.SourceRef != "req-1" || v.Actor != "admin" || v.Order.Status != "paid" {
+			t.Errorf("unexpected second version: %+v", v)
+		}
+	})
+
+	t.Run("not found - 404", func(t *testing.T) {
+		mockService := &mockOrderService{
+			HistoryFunc: func(ctx context.Context, orderUID string) ([]orders.OrderVersion, error) {
+				return nil, orders.ErrOrderNotFound
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		req := httptest.NewRequest(http.MethodGet, "/order/missing/history", nil)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/order/{order_uid}/history", handler.GetOrderHistory)
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestGetOrderByUIDAsOf(t *testing.T) {
+	cfg := &config.Config{}
+
+	serve := func(svc *mockOrderService, target string) *httptest.ResponseRecorder {
+		handler := httpapi.NewHandlers(cfg, svc, nil)
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/order/{order_uid}", handler.GetOrderByUID)
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("success - 200 OK", func(t *testing.T) {
+		mockService := &mockOrderService{
+			AsOfFunc: func(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error) {
+				if !asOf.Equal(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)) {
+					t.Errorf("unexpected as_of: %v", asOf)
+				}
+				return &orders.OrderVersion{Version: 3, Order: &orders.Order{OrderUID: orderUID, Status: orders.StatusShipped}}, nil
+			},
+		}
+
+		rr := serve(mockService, "/order/test-uid?as_of=2025-01-01T12:00:00Z")
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if v := rr.Header().Get("X-Order-Version"); v != "3" {
+			t.Errorf("expected X-Order-Version 3, got %q", v)
+		}
+		var resp dto.OrderDTO
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Status != "shipped" {
+			t.Errorf("expected status 'shipped', got '%s'", resp.Status)
+		}
+	})
+
+	t.Run("invalid as_of - 400", func(t *testing.T) {
+		rr := serve(&mockOrderService{}, "/order/test-uid?as_of=yesterday")
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("order did not exist yet - 404", func(t *testing.T) {
+		mockService := &mockOrderService{
+			AsOfFunc: func(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error) {
+				return nil, orders.ErrOrderNotFound
+			},
+		}
+
+		rr := serve(mockService, "/order/test-uid?as_of=2000-01-01T00:00:00Z")
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestGetOrderRefunds(t *testing.T) {
+	cfg := &config.Config{}
+	mockService := &mockOrderService{
+		RefundsFunc: func(ctx context.Context, orderUID string) ([]orders.Refund, error) {
+			if orderUID == "missing" {
+				return nil, orders.ErrOrderNotFound
+			}
+			return []orders.Refund{{ID: 1, Transaction: "tx1", Amount: orders.MoneyFromMajor(100, "USD")}}, nil
+		},
+	}
+	handler := httpapi.NewHandlers(cfg, mockService, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/order/{order_uid}/refunds", handler.GetOrderRefunds)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/order/test-uid/refunds", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var resp []dto.RefundDTO
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp) != 1 || resp[0].Transaction != "tx1" || resp[0].Amount.Decimal() != "100.00" {
+		t.Errorf("unexpected refunds: %+v", resp)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/order/missing/refunds", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestForgetCustomer(t *testing.T) {
+	cfg := &config.Config{AdminToken: "secret"}
+
+	serve := func(token, actor string) (orders.ChangeSource, *httptest.ResponseRecorder) {
+		var src orders.ChangeSource
+		mockService := &mockOrderService{
+			ForgetFunc: func(ctx context.Context, customerID, reason string) (*orders.Erasure, error) {
+				src = orders.ChangeSourceFromContext(ctx)
+				return &orders.Erasure{
+					ID:          7,
+					CustomerRef: orders.CustomerPseudonym("secret", customerID),
+					OrderUIDs:   []string{"uid1"},
+					Reason:      reason,
+					Source:      src,
+				}, nil
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/admin/customers/{customer_id}/erase", handler.ForgetCustomer)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/customers/test/erase", strings.NewReader(`{"reason":"gdpr"}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if actor != "" {
+			req.Header.Set("X-Actor", actor)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return src, rr
+	}
+
+	t.Run("success - actor from admin request", func(t *testing.T) {
+		src, rr := serve("secret", "dpo")
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if src.Kind != orders.SourceHTTP || src.Actor != "dpo" {
+			t.Errorf("unexpected change source: %+v", src)
+		}
+		var resp dto.ErasureDTO
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.ID != 7 || resp.Reason != "gdpr" || len(resp.OrderUIDs) != 1 || resp.CustomerRef == "test" {
+			t.Errorf("unexpected erasure response: %+v", resp)
+		}
+	})
+
+	t.Run("admin without X-Actor is recorded as admin", func(t *testing.T) {
+		if src, _ := serve("secret", ""); src.Actor != "admin" {
+			t.Errorf("expected actor admin, got %q", src.Actor)
+		}
+	})
+
+	t.Run("X-Actor without admin token is ignored", func(t *testing.T) {
+		if src, _ := serve("", "dpo"); src.Actor != "" {
+			t.Errorf("expected no actor, got %q", src.Actor)
+		}
+		if src, _ := serve("wrong", "dpo"); src.Actor != "" {
+			t.Errorf("expected no actor, got %q", src.Actor)
+		}
+	})
+}
+
+func TestGetCustomerOrders(t *testing.T) {
+	cfg := &config.Config{DefaultPageLimit: 20}
+	mockService := &mockOrderService{
+		CustomerOrdersFunc: func(ctx context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+			if customerID != "customer1" || params.Page != 2 || params.Limit != 20 {
+				t.Errorf("unexpected arguments: %s %+v", customerID, params)
+			}
+			return []*orders.Order{{OrderUID: "uid1", CustomerID: customerID}}, 21, nil
+		},
+	}
+	handler := httpapi.NewHandlers(cfg, mockService, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/customers/{customer_id}/orders", handler.GetCustomerOrders)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/customers/customer1/orders?page=2", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var resp dto.OrdersResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 21 || resp.Page != 2 || resp.Limit != 20 || len(resp.Orders) != 1 || resp.Orders[0].OrderUID != "uid1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestGetCustomerSummary(t *testing.T) {
+	cfg := &config.Config{}
+	first := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("success - 200 OK", func(t *testing.T) {
+		mockService := &mockOrderService{
+			CustomerSummaryFunc: func(ctx context.Context, customerID string) (*orders.CustomerSummary, error) {
+				return &orders.CustomerSummary{
+					CustomerID:  customerID,
+					OrdersCount: 3,
+					Spend: []orders.CurrencyAmount{
+						{Currency: "RUB", Amount: orders.MoneyFromMajor(500, "RUB")},
+						{Currency: "USD", Amount: orders.NewMoney(2050, 2, "USD")},
+					},
+					FirstOrderAt:    &first,
+					LastOrderAt:     &first,
+					FavouriteBrands: []orders.BrandCount{{Brand: "Vivienne Sabo", Items: 2}},
+				}, nil
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/admin/customers/{customer_id}/summary", handler.GetCustomerSummary)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/customers/customer1/summary", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var resp dto.CustomerSummaryDTO
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.OrdersCount != 3 || len(resp.Spend) != 2 || resp.Spend[1].Currency != "USD" || resp.Spend[1].Amount.Decimal() != "20.50" ||
+			len(resp.FavouriteBrands) != 1 || !resp.FirstOrderAt.Equal(first) {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+
+	t.Run("unknown customer - 404 Not Found", func(t *testing.T) {
+		mockService := &mockOrderService{
+			CustomerSummaryFunc: func(ctx context.Context, customerID string) (*orders.CustomerSummary, error) {
+				return nil, orders.ErrCustomerNotFound
+			},
+		}
+		handler := httpapi.NewHandlers(cfg, mockService, nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/admin/customers/{customer_id}/summary", handler.GetCustomerSummary)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/customers/unknown/summary", nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestSearchOrders(t *testing.T) {
+	cfg := &config.Config{DefaultPageLimit: 20}
+	mockService := &mockOrderService{
+		SearchFunc: func(ctx context.Context, criteria orders.SearchCriteria, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+			if criteria.Empty() {
+				return nil, 0, orders.ErrEmptySearch
+			}
+			if criteria.NmID != 2389212 || criteria.Email != "test@gmail.com" {
+				t.Errorf("unexpected criteria: %+v", criteria)
+			}
+			return []*orders.Order{{OrderUID: "uid1"}}, 1, nil
+		},
+	}
+	handler := httpapi.NewHandlers(cfg, mockService, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/orders/search", handler.SearchOrders)
+
+	cases := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"success - 200 OK", "?nm_id=2389212&email=test@gmail.com", http.StatusOK},
+		{"no criteria - 400 Bad Request", "?page=2", http.StatusBadRequest},
+		{"invalid nm_id - 400 Bad Request", "?nm_id=abc", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/orders/search"+tc.query, nil))
+			if rr.Code != tc.status {
+				t.Fatalf("expected status %d, got %d", tc.status, rr.Code)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+			var resp dto.OrdersResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Total != 1 || len(resp.Orders) != 1 || resp.Limit != 20 {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestSearchOrdersText(t *testing.T) {
+	cfg := &config.Config{DefaultPageLimit: 20}
+	mockService := &mockOrderService{
+		SearchTextFunc: func(ctx context.Context, text string, params service.GetOrdersParams) (*orders.TextSearchResult, error) {
+			if text == "" {
+				return nil, orders.ErrEmptyTextQuery
+			}
+			if text != "vivienne mascara" || params.Page != 2 {
+				t.Errorf("unexpected query %q, page %d", text, params.Page)
+			}
+			return &orders.TextSearchResult{
+				Hits: []orders.TextHit{{
+					Order:      &orders.Order{OrderUID: "uid1"},
+					Rank:       0.5,
+					Highlights: []orders.Highlight{{Field: orders.TextFieldItemBrand, Fragment: "<mark>Vivienne</mark> Sabo"}},
+				}},
+				Total: 1,
+			}, nil
+		},
+	}
+	handler := httpapi.NewHandlers(cfg, mockService, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/search", handler.SearchOrdersText)
+
+	t.Run("success - 200 OK", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders/search?q=vivienne+mascara&page=2", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		var resp dto.TextSearchResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Total != 1 || len(resp.Hits) != 1 || resp.Hits[0].Order.OrderUID != "uid1" || resp.Fuzzy {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+		if hl := resp.Hits[0].Highlights; len(hl) != 1 || hl[0].Field != "items.brand" || hl[0].Fragment != "<mark>Vivienne</mark> Sabo" {
+			t.Errorf("unexpected highlights: %+v", hl)
+		}
+	})
+
+	t.Run("empty query - 400 Bad Request", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders/search", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+}
+
+func TestLookupOrders(t *testing.T) {
+	cfg := &config.Config{OrdersLookupMaxUIDs: 3}
+	var calls [][]string
+	mockService := &mockOrderService{
+		GetOrdersByUIDsFunc: func(ctx context.Context, uids []string) ([]*orders.Order, error) {
+			calls = append(calls, uids)
+			var list []*orders.Order
+			for _, uid := range uids {
+				if uid != "unknown" {
+					list = append(list, &orders.Order{OrderUID: uid})
+				}
+			}
+			return list, nil
+		},
+	}
+	handler := httpapi.NewHandlers(cfg, mockService, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/lookup", handler.LookupOrders)
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/orders/lookup", strings.NewReader(body)))
+		return rr
+	}
+
+	t.Run("success - 200 OK", func(t *testing.T) {
+		calls = nil
+		rr := serve(`{"order_uids":["uid2"," uid1 ","unknown","uid2",""]}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		var resp dto.OrdersLookupResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(calls) != 1 || !slices.Equal(calls[0], []string{"uid2", "uid1", "unknown"}) {
+			t.Errorf("expected one lookup of unique uids, got %v", calls)
+		}
+		if len(resp.Orders) != 2 || resp.Orders[0].OrderUID != "uid2" || resp.Orders[1].OrderUID != "uid1" {
+			t.Errorf("unexpected orders: %+v", resp.Orders)
+		}
+		if !slices.Equal(resp.Missing, []string{"unknown"}) {
+			t.Errorf("expected missing [unknown], got %v", resp.Missing)
+		}
+	})
+
+	t.Run("nothing found - empty orders and all missing", func(t *testing.T) {
+		rr := serve(`{"order_uids":["unknown"]}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		if body := strings.TrimSpace(rr.Body.String()); body != `{"orders":[],"missing":["unknown"]}` {
+			t.Errorf("unexpected body: %s", body)
+		}
+	})
+
+	t.Run("bad request - 400", func(t *testing.T) {
+		calls = nil
+		for _, body := range []string{`{"order_uids":[]}`, `{"order_uids":[" "]}`, `not json`, `{"order_uids":["a","b","c","d"]}`} {
+			if rr := serve(body); rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", body, http.StatusBadRequest, rr.Code)
+			}
+		}
+		if len(calls) != 0 {
+			t.Errorf("service must not be called, got %v", calls)
+		}
+	})
+
+	t.Run("body too large - 413", func(t *testing.T) {
+		calls = nil
+		rr := serve(`{"order_uids":["` + strings.Repeat("a", 2<<20) + `"]}`)
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected status %d, got %d", http.StatusRequestEntityTooLarge, rr.Code)
+		}
+		if len(calls) != 0 {
+			t.Errorf("service must not be called, got %v", calls)
+		}
+	})
+
+	t.Run("service error - 500", func(t *testing.T) {
+		handler := httpapi.NewHandlers(cfg, &mockOrderService{
+			GetOrdersByUIDsFunc: func(ctx context.Context, uids []string) ([]*orders.Order, error) {
+				return nil, errors.New("db is down")
+			},
+		}, nil)
+		rr := httptest.NewRecorder()
+		handler.LookupOrders(rr, httptest.NewRequest(http.MethodPost, "/orders/lookup", strings.NewReader(`{"order_uids":["uid1"]}`)))
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+	})
+}
+
+func TestReportingCurrency(t *testing.T) {
+	cfg := &config.Config{DefaultPageLimit: 20}
+	rateDate := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	mockService := &mockOrderService{
+		CustomerOrdersFunc: func(ctx context.Context, customerID string, params service.GetOrdersParams) ([]*orders.Order, int, error) {
+			return []*orders.Order{
+				{OrderUID: "uid1", Payment: orders.Payment{Currency: "RUB", Amount: orders.NewMoney(181750, 2, "RUB")}},
+				{OrderUID: "uid2", Payment: orders.Payment{Currency: "KZT"}},
+			}, 2, nil
+		},
+		ConvertFunc: func(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error) {
+			switch currency {
+			case "XXX":
+				return nil, orders.ErrRateNotFound
+			case "bad":
+				return nil, orders.ErrInvalidCurrency
+			}
+			rate, _ := orders.ParseRate("0.01")
+			res := make([]*orders.ConvertedPayment, len(list))
+			for i := range list {
+				res[i] = &orders.ConvertedPayment{
+					Conversion: orders.Conversion{From: list[i].Payment.Currency, To: currency, Rate: rate, RateDate: rateDate},
+					Amount:     orders.NewMoney(1818, 2, currency),
+				}
+			}
+			return res, nil
+		},
+	}
+	handler := httpapi.NewHandlers(cfg, mockService, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/customers/{customer_id}/orders", handler.GetCustomerOrders)
+
+	t.Run("without reporting currency", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/customers/c1/orders", nil))
+		if rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), `"reporting"`) {
+			t.Errorf("expected plain response, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("converted amounts", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/customers/c1/orders?reporting_currency=EUR", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var resp dto.OrdersResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		for _, o := range resp.Orders {
+			rep := o.Reporting
+			if rep == nil || rep.Currency != "EUR" || rep.Rate != "0.01" || rep.RateDate != "2026-10-18" || rep.Amount.Decimal() != "18.18" {
+				t.Errorf("unexpected reporting amounts of %s: %+v", o.OrderUID, rep)
+			}
+		}
+		if resp.Orders[0].Payment.Amount.Decimal() != "1817.50" {
+			t.Errorf("original amount must be kept, got %s", resp.Orders[0].Payment.Amount)
+		}
+	})
+
+	for query, status := range map[string]int{
+		"reporting_currency=XXX": http.StatusUnprocessableEntity,
+		"reporting_currency=bad": http.StatusBadRequest,
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/customers/c1/orders?"+query, nil))
+		if rr.Code != status {
+			t.Errorf("%s: expected status %d, got %d", query, status, rr.Code)
+		}
+	}
+}
+
+func TestGetOrderStats(t *testing.T) {
+	month := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var got service.StatsParams
+	mockService := &mockOrderService{
+		StatsFunc: func(ctx context.Context, params service.StatsParams) (*orders.StatsResult, error) {
+			got = params
+			if params.ReportingCurrency == "XXX" {
+				return nil, orders.ErrRateNotFound
+			}
+			if params.DateFrom != nil && params.DateTo != nil && params.DateFrom.After(*params.DateTo) {
+				return nil, orders.ErrInvalidStatsRange
+			}
+			return &orders.StatsResult{
+				GroupBy:  params.GroupBy,
+				DateFrom: month,
+				DateTo:   month.AddDate(0, 1, -1),
+				Groups: []*orders.StatsGroup{{
+					Period:   &month,
+					Keys:     map[orders.StatsDimension]string{orders.StatsByCurrency: "USD"},
+					Orders:   3,
+					Revenue:  []orders.CurrencyAmount{{Currency: "USD", Amount: orders.NewMoney(181750, 2, "USD")}},
+					Refunded: []orders.CurrencyAmount{},
+				}},
+			}, nil
+		},
+	}
+	handler := httpapi.NewHandlers(&config.Config{}, mockService, nil)
+
+	rr := httptest.NewRecorder()
+	handler.GetOrderStats(rr, httptest.NewRequest(http.MethodGet, "/stats/orders?group_by=month,currency&date_from=2026-10-01&date_to=2026-10-31", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if len(got.GroupBy) != 2 || got.GroupBy[0] != orders.StatsByMonth || got.DateFrom == nil || !got.DateFrom.Equal(month) ||
+		got.DateTo == nil || got.DateTo.Day() != 31 {
+		t.Errorf("unexpected params: %+v", got)
+	}
+	var resp dto.OrderStatsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Groups) != 1 || resp.Groups[0].Period != "2026-10-01" || resp.Groups[0].Keys["currency"] != "USD" ||
+		resp.Groups[0].Revenue[0].Amount.Decimal() != "1817.50" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	errorCases := []struct {
+		query string
+		code  int
+	}{
+		{"group_by=day,month", http.StatusBadRequest},
+		{"group_by=country", http.StatusBadRequest},
+		{"date_from=01.10.2026", http.StatusBadRequest},
+		{"date_from=2026-10-31&date_to=2026-10-01", http.StatusBadRequest},
+		{"reporting_currency=XXX", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range errorCases {
+		rr := httptest.NewRecorder()
+		handler.GetOrderStats(rr, httptest.NewRequest(http.MethodGet, "/stats/orders?"+tt.query, nil))
+		if rr.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.query, tt.code, rr.Code)
+		}
+	}
+}
+
+func TestOrderFraudVisibility(t *testing.T) {
+	order := &orders.Order{
+		OrderUID: "uid1",
+		Fraud: &orders.FraudScore{Score: 60, Rules: []orders.FraudHit{
+			{Rule: orders.FraudDuplicateTransaction, Score: 60, Detail: "transaction is used by orders uid0"},
+		}},
+	}
+	mockService := &mockOrderService{
+		GetOrderByUIDFunc: func(ctx context.Context, orderUID string) (*orders.Order, error) { return order, nil },
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/order/{order_uid}", httpapi.NewHandlers(&config.Config{AdminToken: "secret"}, mockService, nil).GetOrderByUID)
+
+	get := func(auth string) dto.OrderDTO {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/order/uid1", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var resp dto.OrderDTO
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	for _, auth := range []string{"", "Bearer wrong", "secret"} {
+		if resp := get(auth); resp.Fraud != nil {
+			t.Errorf("%q: fraud score must be hidden, got %+v", auth, resp.Fraud)
+		}
+	}
+
+	resp := get("Bearer secret")
+	if resp.Fraud == nil || resp.Fraud.Score != 60 || len(resp.Fraud.Rules) != 1 || resp.Fraud.Rules[0].Rule != "duplicate_transaction" {
+		t.Errorf("expected fraud score for admin, got %+v", resp.Fraud)
+	}
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	created := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	hooks := map[int64]*orders.Webhook{}
+	mockService := &mockOrderService{
+		CreateWebhookFunc: func(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error) {
+			if err := w.Validate(); err != nil {
+				return nil, err
+			}
+			w.ID, w.Secret, w.Active, w.CreatedAt = 1, "whsec_generated", true, created
+			hooks[w.ID] = w
+			return w, nil
+		},
+		GetWebhooksFunc: func(ctx context.Context) ([]*orders.Webhook, error) {
+			return []*orders.Webhook{hooks[1]}, nil
+		},
+		GetWebhookFunc: func(ctx context.Context, id int64) (*orders.Webhook, error) {
+			if w, ok := hooks[id]; ok {
+				return w, nil
+			}
+			return nil, orders.ErrWebhookNotFound
+		},
+		UpdateWebhookFunc: func(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error) {
+			if _, ok := hooks[w.ID]; !ok {
+				return nil, orders.ErrWebhookNotFound
+			}
+			hooks[w.ID] = w
+			return w, nil
+		},
+		DeleteWebhookFunc: func(ctx context.Context, id int64) error {
+			if _, ok := hooks[id]; !ok {
+				return orders.ErrWebhookNotFound
+			}
+			delete(hooks, id)
+			return nil
+		},
+		DeliveriesFunc: func(ctx context.Context, id int64, limit int) ([]orders.WebhookDelivery, error) {
+			return []orders.WebhookDelivery{{ID: 7, WebhookID: id, DeliveryID: "d1", EventType: orders.EventOrderCreated, Attempt: 2, StatusCode: 500, Error: "unexpected response status 500", Duration: 84 * time.Millisecond}}, nil
+		},
+	}
+	handler := httpapi.NewHandlers(&config.Config{}, mockService, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/webhooks", handler.CreateWebhook).Methods(http.MethodPost)
+	router.HandleFunc("/admin/webhooks", handler.GetWebhooks).Methods(http.MethodGet)
+	router.HandleFunc("/admin/webhooks/{id}", handler.GetWebhook).Methods(http.MethodGet)
+	router.HandleFunc("/admin/webhooks/{id}", handler.UpdateWebhook).Methods(http.MethodPut)
+	router.HandleFunc("/admin/webhooks/{id}", handler.DeleteWebhook).Methods(http.MethodDelete)
+	router.HandleFunc("/admin/webhooks/{id}/deliveries", handler.GetWebhookDeliveries).Methods(http.MethodGet)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/admin/webhooks", `{"url":"https://partner.example.com/hooks","event_types":["order.created"]}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var hook dto.WebhookDTO
+	if err := json.NewDecoder(rr.Body).Decode(&hook); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if hook.ID != 1 || hook.Secret != "whsec_generated" || !hook.Active || len(hook.EventTypes) != 1 {
+		t.Errorf("unexpected created webhook: %+v", hook)
+	}
+
+	if rr := do(http.MethodGet, "/admin/webhooks/1", ""); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "whsec_") {
+		t.Errorf("secret must not be returned on read, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/admin/webhooks", ""); rr.Code != http.StatusOK || strings.Contains(rr.Body.String(), "whsec_") {
+		t.Errorf("secret must not be returned in list, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPut, "/admin/webhooks/1", `{"url":"https://partner.example.com/v2","active":false}`)
+	if rr.Code != http.StatusOK || hooks[1].Active || hooks[1].URL != "https://partner.example.com/v2" {
+		t.Errorf("unexpected update result %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodGet, "/admin/webhooks/1/deliveries?limit=10", "")
+	var deliveries []dto.WebhookDeliveryDTO
+	if err := json.NewDecoder(rr.Body).Decode(&deliveries); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("unexpected deliveries response %d: %v", rr.Code, err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Attempt != 2 || deliveries[0].StatusCode != 500 || deliveries[0].DurationMs != 84 {
+		t.Errorf("unexpected deliveries: %+v", deliveries)
+	}
+
+	if rr := do(http.MethodDelete, "/admin/webhooks/1", ""); rr.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+
+	for _, tc := range []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodPost, "/admin/webhooks", `{"url":"ftp://example.com"}`, http.StatusBadRequest},
+		{http.MethodPost, "/admin/webhooks", `{`, http.StatusBadRequest},
+		{http.MethodGet, "/admin/webhooks/1", "", http.StatusNotFound},
+		{http.MethodGet, "/admin/webhooks/abc", "", http.StatusBadRequest},
+		{http.MethodPut, "/admin/webhooks/1", `{"url":"https://example.com"}`, http.StatusNotFound},
+		{http.MethodDelete, "/admin/webhooks/1", "", http.StatusNotFound},
+		{http.MethodGet, "/admin/webhooks/1/deliveries?limit=-1", "", http.StatusBadRequest},
+	} {
+		if rr := do(tc.method, tc.target, tc.body); rr.Code != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.target, tc.status, rr.Code)
+		}
+	}
+}
+
+func TestStreamOrders(t *testing.T) {
+	hub := stream.NewHub(10, 10, 1)
+	amount := orders.NewMoney(181750, 2, "USD")
+	created := func(id uint64, uid, deliveryService string) stream.Message {
+		return stream.Message{ID: id, Event: orders.Event{
+			Type: orders.EventOrderCreated, OrderUID: uid, DeliveryService: deliveryService, Amount: &amount, Currency: "USD",
+		}}
+	}
+	hub.Broadcast(created(1, "o1", "meest"))
+	hub.Broadcast(created(2, "o2", "meest"))
+	hub.Broadcast(stream.Message{ID: 3, Event: orders.Event{Type: orders.EventOrderStatusChanged, OrderUID: "o1"}})
+
+	cfg := &config.Config{SSEHeartbeatSeconds: 1, SSERetryMs: 500, WSAuthTokens: []string{"agent-token"}}
+	handler := httpapi.NewHandlers(cfg, &mockOrderService{}, hub)
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/stream", handler.StreamOrders)
+	router.HandleFunc("/disabled/stream", httpapi.NewHandlers(&config.Config{}, &mockOrderService{}, hub).StreamOrders)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	if resp, err := http.Get(srv.URL + "/disabled/stream?access_token=agent-token"); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected %d without configured tokens, got %v %v", http.StatusForbidden, resp.StatusCode, err)
+	} else {
+		resp.Body.Close()
+	}
+
+	for query, status := range map[string]int{
+		"delivery_service=meest":                             http.StatusUnauthorized,
+		"access_token=wrong":                                 http.StatusUnauthorized,
+		"access_token=agent-token&event_types=order.deleted": http.StatusBadRequest,
+		"access_token=agent-token&last_event_id=abc":         http.StatusBadRequest,
+	} {
+		resp, err := http.Get(srv.URL + "/orders/stream?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%s: expected status %d, got %d", query, status, resp.StatusCode)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/orders/stream?delivery_service=meest", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	req.Header.Set("Authorization", "Bearer agent-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// второе подключение сверх SSE_MAX_CLIENTS отклоняется
+	if extra, err := http.Get(srv.URL + "/orders/stream?access_token=agent-token"); err != nil || extra.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected %d for extra client, got %v %v", http.StatusServiceUnavailable, extra.StatusCode, err)
+	} else {
+		extra.Body.Close()
+	}
+
+	hub.Broadcast(created(4, "o4", "cdek"))
+	hub.Broadcast(created(5, "o5", "meest"))
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout reading stream")
+			return ""
+		}
+	}
+
+	var ids []string
+	var heartbeat bool
+	for len(ids) < 2 || !heartbeat {
+		line := next()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			ids = append(ids, strings.TrimPrefix(line, "id: "))
+			if ev := next(); ev != "event: order.created" {
+				t.Errorf("unexpected event line %q", ev)
+			}
+			var data dto.StreamOrderDTO
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(next(), "data: ")), &data); err != nil {
+				t.Fatalf("failed to decode event data: %v", err)
+			}
+			if data.DeliveryService != "meest" || data.Amount.Decimal() != "1817.50" || data.Currency != "USD" {
+				t.Errorf("unexpected event data: %+v", data)
+			}
+		case line == ": heartbeat":
+			heartbeat = true
+		}
+	}
+	if ids[0] != "2" || ids[1] != "5" {
+		t.Errorf("expected replayed event 2 and live event 5, got %v", ids)
+	}
+}
+
+func TestOrdersWebSocket(t *testing.T) {
+	hub := stream.NewHub(10, 10, 0)
+	mockService := &mockOrderService{
+		GetOrderByUIDFunc: func(ctx context.Context, orderUID string) (*orders.Order, error) {
+			if orderUID == "missing" {
+				return nil, orders.ErrOrderNotFound
+			}
+			return &orders.Order{OrderUID: orderUID, Status: orders.StatusCreated}, nil
+		},
+	}
+	cfg := &config.Config{
+		WSAuthTokens: []string{"agent-token"}, WSAllowedOrigins: []string{"https://app.example.com"},
+		AdminToken: "admin-token", WSMaxSubscriptions: 2, WSPingSeconds: 1,
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/ws", httpapi.NewHandlers(cfg, mockService, hub).OrdersWebSocket)
+	router.HandleFunc("/disabled/ws", httpapi.NewHandlers(&config.Config{}, mockService, hub).OrdersWebSocket)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	for target, status := range map[string]int{
+		"/orders/ws":                      http.StatusUnauthorized,
+		"/orders/ws?access_token=wrong":   http.StatusUnauthorized,
+		"/disabled/ws?access_token=token": http.StatusForbidden,
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(wsURL+target, nil)
+		if err == nil || resp == nil || resp.StatusCode != status {
+			t.Errorf("%s: expected status %d, got %v", target, status, resp)
+		}
+	}
+
+	// токен в параметре: только WS_AUTH_TOKENS и только с разрешенного origin
+	for origin, status := range map[string]int{
+		"https://evil.example.com": http.StatusForbidden,
+		"https://app.example.com":  http.StatusSwitchingProtocols,
+	} {
+		c, resp, _ := websocket.DefaultDialer.Dial(wsURL+"/orders/ws?access_token=agent-token", http.Header{"Origin": {origin}})
+		if resp == nil || resp.StatusCode != status {
+			t.Errorf("%s: expected status %d, got %v", origin, status, resp)
+		}
+		if c != nil {
+			c.Close()
+		}
+	}
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL+"/orders/ws?access_token=admin-token", nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("admin token must not be accepted in query, got %v", resp)
+	}
+	admin, _, err := websocket.DefaultDialer.Dial(wsURL+"/orders/ws", http.Header{"Authorization": {"Bearer admin-token"}})
+	if err != nil {
+		t.Fatalf("admin token must be accepted in header: %v", err)
+	}
+	admin.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"/orders/ws", http.Header{"Authorization": {"Bearer agent-token"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	read := func() dto.WSServerMessage {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg dto.WSServerMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("failed to read message: %v", err)
+		}
+		return msg
+	}
+	send := func(action string, uids ...string) {
+		t.Helper()
+		if err := conn.WriteJSON(dto.WSClientMessage{Action: action, OrderUIDs: uids}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send("subscribe", "o1", "missing")
+	if msg := read(); msg.Type != "order" || msg.Order == nil || msg.Order.OrderUID != "o1" {
+		t.Errorf("expected current order o1, got %+v", msg)
+	}
+	if msg := read(); msg.Type != "error" || !strings.Contains(msg.Message, "missing") {
+		t.Errorf("expected error for missing order, got %+v", msg)
+	}
+	if msg := read(); msg.Type != "subscribed" || len(msg.OrderUIDs) != 1 || msg.OrderUIDs[0] != "o1" {
+		t.Errorf("expected subscription to o1, got %+v", msg)
+	}
+
+	hub.Broadcast(stream.Message{ID: 1, Event: orders.Event{Type: orders.EventOrderStatusChanged, OrderUID: "o3", To: orders.StatusPaid}})
+	hub.Broadcast(stream.Message{ID: 2, Event: orders.Event{Type: orders.EventOrderStatusChanged, OrderUID: "o1", From: orders.StatusCreated, To: orders.StatusPaid}})
+	if msg := read(); msg.Type != "update" || msg.EventID != 2 || msg.Event == nil || msg.Event.Status != "paid" || msg.Event.From != "created" {
+		t.Errorf("expected status update of o1, got %+v", msg)
+	}
+
+	send("subscribe", "o2", "o3")
+	if msg := read(); msg.Type != "error" || !strings.Contains(msg.Message, "limit") {
+		t.Errorf("expected subscription limit error, got %+v", msg)
+	}
+	send("refresh", "o1")
+	if msg := read(); msg.Type != "error" {
+		t.Errorf("expected unknown action error, got %+v", msg)
+	}
+
+	send("unsubscribe", "o1")
+	if msg := read(); msg.Type != "unsubscribed" || len(msg.OrderUIDs) != 0 {
+		t.Errorf("expected empty subscription, got %+v", msg)
+	}
+	if hub.Clients() != 0 {
+		t.Errorf("stream subscription must be released, got %d clients", hub.Clients())
+	}
+
+	// сервер отправляет ping каждые WS_PING_SECONDS
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-pinged:
+	case <-time.After(3 * time.Second):
+		t.Error("expected ping from server")
+	}
+}
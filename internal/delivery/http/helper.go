@@ -2,9 +2,17 @@ package httpapi
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/internal/service"
 	"wb_tech_level_zero/pkg/logger"
 
 	"go.uber.org/zap"
@@ -25,6 +33,192 @@ func (h *Handlers) writeJSONResponse(ctx context.Context, w http.ResponseWriter,
 	}
 }
 
+// pageParams читает page и limit из query; некорректные значения заменяются первой страницей и лимитом по умолчанию,
+// limit больше MAX_PAGE_LIMIT уменьшается до него
+func (h *Handlers) pageParams(r *http.Request) service.GetOrdersParams {
+	queryParams := r.URL.Query()
+
+	page, err := strconv.Atoi(queryParams.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = h.cfg.DefaultPageLimit
+	}
+	if h.cfg.MaxPageLimit > 0 {
+		limit = min(limit, h.cfg.MaxPageLimit)
+	}
+
+	return service.GetOrdersParams{Page: page, Limit: limit}
+}
+
+// listFilterParams читает фильтры и сортировку списка заказов из query.
+// Даты принимаются в RFC3339 или как YYYY-MM-DD; date_to в виде даты включает весь день
+func listFilterParams(r *http.Request) (orders.ListFilter, orders.ListSort, error) {
+	q := r.URL.Query()
+	f := orders.ListFilter{
+		DeliveryService: q.Get("delivery_service"),
+		Locale:          q.Get("locale"),
+		Currency:        q.Get("currency"),
+		Provider:        q.Get("provider"),
+		Bank:            q.Get("bank"),
+		CustomerID:      q.Get("customer_id"),
+		City:            q.Get("city"),
+		Region:          q.Get("region"),
+		Brand:           q.Get("brand"),
+	}
+
+	var err error
+	if f.DateFrom, err = parseDateParam(q.Get("date_from"), false); err != nil {
+		return f, orders.ListSort{}, fmt.Errorf("invalid date_from: %w", err)
+	}
+	if f.DateTo, err = parseDateParam(q.Get("date_to"), true); err != nil {
+		return f, orders.ListSort{}, fmt.Errorf("invalid date_to: %w", err)
+	}
+	for name, dst := range map[string]**orders.Money{
+		"min_amount": &f.MinAmount,
+		"max_amount": &f.MaxAmount,
+	} {
+		if *dst, err = parseMoneyParam(q.Get(name)); err != nil {
+			return f, orders.ListSort{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	if f.ItemStatus, err = parseIntParam(q.Get("item_status")); err != nil {
+		return f, orders.ListSort{}, fmt.Errorf("invalid item_status: %w", err)
+	}
+
+	var sort orders.ListSort
+	if sort.Field, err = orders.ParseSortField(q.Get("sort")); err != nil {
+		return f, sort, err
+	}
+	switch strings.ToLower(q.Get("order")) {
+	case "", "desc":
+	case "asc":
+		sort.Asc = true
+	default:
+		return f, sort, errors.New("order must be asc or desc")
+	}
+	return f, sort, nil
+}
+
+// cursorParams читает курсор keyset-пагинации и режим подсчета total
+func cursorParams(r *http.Request) (*orders.Cursor, orders.TotalMode, error) {
+	q := r.URL.Query()
+	total, err := orders.ParseTotalMode(q.Get("total"))
+	if err != nil {
+		return nil, "", err
+	}
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := orders.DecodeCursor(v)
+		if err != nil {
+			return nil, "", err
+		}
+		return cursor, total, nil
+	}
+	return nil, total, nil
+}
+
+// statsParams читает измерения, период(YYYY-MM-DD, оба дня включительно) и валюту отчетности статистики
+func statsParams(r *http.Request) (service.StatsParams, error) {
+	q := r.URL.Query()
+	params := service.StatsParams{ReportingCurrency: q.Get("reporting_currency")}
+
+	var err error
+	if params.GroupBy, err = orders.ParseStatsGroupBy(q.Get("group_by")); err != nil {
+		return params, err
+	}
+	for name, dst := range map[string]**time.Time{"date_from": &params.DateFrom, "date_to": &params.DateTo} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return params, fmt.Errorf("invalid %s: expected YYYY-MM-DD", name)
+		}
+		*dst = &t
+	}
+	return params, nil
+}
+
+func parseDateParam(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, errors.New("expected RFC3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// compactUIDs убирает пробелы, пустые значения и повторы, сохраняя порядок
+func compactUIDs(list []string) []string {
+	res := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, uid := range list {
+		if uid = strings.TrimSpace(uid); uid != "" && !seen[uid] {
+			seen[uid] = true
+			res = append(res, uid)
+		}
+	}
+	return res
+}
+
+func parseIntParam(v string) (*int, error) {
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, errors.New("expected integer")
+	}
+	return &n, nil
+}
+
+// adminActor - инициатор изменений по токену администратора, если сотрудник не указан в X-Actor
+const adminActor = "admin"
+
+// parseMoneyParam разбирает десятичную сумму в основных единицах валюты("10.50")
+func parseMoneyParam(v string) (*orders.Money, error) {
+	if v == "" {
+		return nil, nil
+	}
+	m, err := orders.ParseMoney(v, "")
+	if err != nil {
+		return nil, errors.New("expected decimal number")
+	}
+	return &m, nil
+}
+
+// withChangeSource помечает изменения, сделанные в рамках запроса, его request id и инициатором.
+// Инициатор известен только для запросов с токеном администратора: заголовок X-Actor без токена не учитывается
+func (h *Handlers) withChangeSource(r *http.Request) context.Context {
+	ctx := r.Context()
+	requestID, _ := ctx.Value(logger.RequestIDKey).(string)
+
+	var actor string
+	if h.privileged(r) {
+		actor = strings.TrimSpace(r.Header.Get("X-Actor"))
+		if actor == "" {
+			actor = adminActor
+		}
+	}
+	return orders.WithChangeSource(ctx, orders.ChangeSource{
+		Kind:  orders.SourceHTTP,
+		Ref:   requestID,
+		Actor: actor,
+	})
+}
+
 func (h *Handlers) writeErrorResponse(ctx context.Context, w http.ResponseWriter, statusCode int, message string) {
 	// log := logger.GetLoggerFromCtx(ctx)
 	// log.Info(ctx, message)
@@ -32,3 +226,51 @@ func (h *Handlers) writeErrorResponse(ctx context.Context, w http.ResponseWriter
 	response := dto.ErrorResponse{Message: message}
 	h.writeJSONResponse(ctx, w, statusCode, response)
 }
+
+// withReporting добавляет в заказы ответа суммы в валюте reporting_currency, если параметр передан.
+// set получает пересчет для заказа list[i]; false - ответ с ошибкой уже записан
+func (h *Handlers) withReporting(w http.ResponseWriter, r *http.Request, list []*orders.Order, set func(i int, rep *dto.ReportingDTO)) bool {
+	ctx := r.Context()
+	currency := r.URL.Query().Get("reporting_currency")
+	if currency == "" {
+		return true
+	}
+
+	converted, err := h.orderService.ConvertOrders(ctx, list, currency)
+	if err != nil {
+		switch {
+		case errors.Is(err, orders.ErrInvalidCurrency):
+			h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orders.ErrRateNotFound):
+			h.writeErrorResponse(ctx, w, http.StatusUnprocessableEntity, err.Error())
+		default:
+			logger.GetLoggerFromCtx(ctx).Error(ctx, "Failed to convert orders to reporting currency", zap.Error(err))
+			h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		}
+		return false
+	}
+	for i, c := range converted {
+		set(i, dto.ReportingToDTO(c))
+	}
+	return true
+}
+
+// privileged - передан ли токен администратора("Authorization: Bearer <ADMIN_TOKEN>"). Без заданного токена
+// привилегированных запросов нет
+func (h *Handlers) privileged(r *http.Request) bool {
+	if h.cfg == nil || h.cfg.AdminToken == "" {
+		return false
+	}
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(h.cfg.AdminToken)) == 1
+}
+
+// withFraud добавляет в заказы ответа оценку риска, если запрос привилегированный
+func (h *Handlers) withFraud(r *http.Request, list []*orders.Order, set func(i int, fraud *dto.FraudDTO)) {
+	if !h.privileged(r) {
+		return
+	}
+	for i, o := range list {
+		set(i, dto.FraudToDTO(o.Fraud))
+	}
+}
@@ -0,0 +1,179 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"wb_tech_level_zero/internal/delivery/stream"
+	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// @Summary Streaming newly processed orders
+// @Description Server-Sent Events stream of order summaries pushed as orders are ingested. Each event has "id" (sequence number shared by all instances), "event" (event type) and "data" (dto.StreamOrderDTO). Reconnecting with the Last-Event-ID header (or last_event_id query parameter) replays buffered events after that id. Heartbeat comments are sent every SSE_HEARTBEAT_SECONDS
+// @Tags orders
+// @Produce text/event-stream
+// @Param event_types query string false "Типы событий через запятую(по умолчанию order.created)"
+// @Param delivery_service query string false "Службы доставки через запятую"
+// @Param customer_id query string false "ID покупателей через запятую"
+// @Param last_event_id query int false "Номер последнего полученного события(вместо заголовка Last-Event-ID)"
+// @Param Last-Event-ID header int false "Номер последнего полученного события"
+// @Param access_token query string false "Токен доступа(если нельзя передать заголовок Authorization)"
+// @Success 200 {object} dto.StreamOrderDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Router /orders/stream [get]
+func (h *Handlers) StreamOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	if h.orderStream == nil {
+		h.writeErrorResponse(ctx, w, http.StatusServiceUnavailable, "Order stream is not available")
+		return
+	}
+	if !h.authorizeStream(w, r) {
+		return
+	}
+
+	filter, err := streamFilterParams(r)
+	if err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lastID, err := lastEventID(r)
+	if err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub, replay, err := h.orderStream.Subscribe(filter, lastID)
+	if err != nil {
+		if errors.Is(err, stream.ErrTooManyClients) || errors.Is(err, stream.ErrClosed) {
+			h.writeErrorResponse(ctx, w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		log.Error(ctx, "Failed to subscribe to order stream", zap.Error(err))
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// поток открыт неограниченно долго, общий таймаут записи сервера к нему не применяется
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if h.cfg.SSERetryMs > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", h.cfg.SSERetryMs)
+	}
+	for _, m := range replay {
+		if err := writeStreamEvent(w, m); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		log.Warn(ctx, "Order stream flush failed", zap.Error(err))
+		return
+	}
+
+	heartbeat := time.Duration(h.cfg.SSEHeartbeatSeconds) * time.Second
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case m := <-sub.C():
+			err = writeStreamEvent(w, m)
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case <-sub.Done():
+			// клиент отстал или поток остановлен: клиент переподключится с Last-Event-ID
+			return
+		case <-ctx.Done():
+			return
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, m stream.Message) error {
+	data, err := json.Marshal(dto.StreamOrderToDTO(m.Event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", m.ID, m.Event.Type, data)
+	return err
+}
+
+// streamFilterParams читает фильтр потока; без event_types передаются только новые заказы
+func streamFilterParams(r *http.Request) (orders.EventFilter, error) {
+	q := r.URL.Query()
+	filter := orders.EventFilter{
+		EventTypes:       []orders.EventType{orders.EventOrderCreated},
+		DeliveryServices: splitList(q.Get("delivery_service")),
+		CustomerIDs:      splitList(q.Get("customer_id")),
+	}
+	if types := splitList(q.Get("event_types")); len(types) > 0 {
+		filter.EventTypes = filter.EventTypes[:0]
+		for _, t := range types {
+			et := orders.EventType(strings.ToLower(t))
+			if !slices.Contains(orders.WebhookEventTypes, et) {
+				return filter, fmt.Errorf("event_types: unknown event type %q", t)
+			}
+			filter.EventTypes = append(filter.EventTypes, et)
+		}
+	}
+	return filter, nil
+}
+
+// lastEventID - номер последнего полученного клиентом события: заголовок Last-Event-ID, который браузер
+// передает при переподключении, или параметр last_event_id для первого подключения
+func lastEventID(r *http.Request) (uint64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+	if err != nil {
+		return 0, errors.New("Last-Event-ID: expected event number")
+	}
+	return id, nil
+}
+
+func splitList(v string) []string {
+	var list []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
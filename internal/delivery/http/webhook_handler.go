@@ -0,0 +1,222 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// @Summary Creating webhook subscription
+// @Description Subscribing an external URL to order events. Deliveries are signed with HMAC-SHA256 of "<t>.<body>" in the X-Webhook-Signature header ("t=<unix>,v1=<hex>"). The secret is generated if omitted and returned only in this response
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body dto.WebhookRequest true "Подписка"
+// @Success 201 {object} dto.WebhookDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /admin/webhooks [post]
+func (h *Handlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req dto.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	hook, err := h.orderService.CreateWebhook(ctx, req.ToWebhook())
+	if err != nil {
+		h.writeWebhookError(ctx, w, err, "Failed to create webhook")
+		return
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusCreated, dto.WebhookToDTO(hook, true))
+}
+
+// @Summary Getting webhook subscriptions
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} dto.WebhookDTO
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /admin/webhooks [get]
+func (h *Handlers) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	list, err := h.orderService.GetWebhooks(ctx)
+	if err != nil {
+		h.writeWebhookError(ctx, w, err, "Failed to get webhooks")
+		return
+	}
+
+	resp := make([]dto.WebhookDTO, 0, len(list))
+	for _, hook := range list {
+		resp = append(resp, dto.WebhookToDTO(hook, false))
+	}
+	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// @Summary Getting webhook subscription
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "ID подписки"
+// @Success 200 {object} dto.WebhookDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/webhooks/{id} [get]
+func (h *Handlers) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	hook, err := h.orderService.GetWebhook(ctx, id)
+	if err != nil {
+		h.writeWebhookError(ctx, w, err, "Failed to get webhook")
+		return
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, dto.WebhookToDTO(hook, false))
+}
+
+// @Summary Updating webhook subscription
+// @Description Replacing URL, filter and state of the subscription. Re-enabling resets the failure counter; a non-empty secret rotates it and is returned in the response
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "ID подписки"
+// @Param request body dto.WebhookRequest true "Подписка"
+// @Success 200 {object} dto.WebhookDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/webhooks/{id} [put]
+func (h *Handlers) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	hook := req.ToWebhook()
+	hook.ID = id
+	hook, err := h.orderService.UpdateWebhook(ctx, hook)
+	if err != nil {
+		h.writeWebhookError(ctx, w, err, "Failed to update webhook")
+		return
+	}
+
+	h.writeJSONResponse(ctx, w, http.StatusOK, dto.WebhookToDTO(hook, req.Secret != ""))
+}
+
+// @Summary Deleting webhook subscription
+// @Description Deleting the subscription together with its delivery log
+// @Tags admin
+// @Security AdminToken
+// @Param id path int true "ID подписки"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/webhooks/{id} [delete]
+func (h *Handlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.orderService.DeleteWebhook(ctx, id); err != nil {
+		h.writeWebhookError(ctx, w, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Getting webhook delivery log
+// @Description Getting the latest delivery attempts of the subscription, newest first
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "ID подписки"
+// @Param limit query int false "Количество записей(по умолчанию 50, не больше 500)"
+// @Success 200 {array} dto.WebhookDeliveryDTO
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (h *Handlers) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := h.webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := parseIntParam(r.URL.Query().Get("limit"))
+	if err != nil || (limit != nil && *limit <= 0) {
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, "limit: expected positive integer")
+		return
+	}
+
+	var n int
+	if limit != nil {
+		n = *limit
+	}
+	list, err := h.orderService.GetWebhookDeliveries(ctx, id, n)
+	if err != nil {
+		h.writeWebhookError(ctx, w, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	resp := make([]dto.WebhookDeliveryDTO, 0, len(list))
+	for _, d := range list {
+		resp = append(resp, dto.WebhookDeliveryToDTO(d))
+	}
+	h.writeJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+func (h *Handlers) webhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		h.writeErrorResponse(r.Context(), w, http.StatusBadRequest, "id: expected positive integer")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handlers) writeWebhookError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, orders.ErrInvalidWebhook):
+		h.writeErrorResponse(ctx, w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, orders.ErrWebhookNotFound):
+		h.writeErrorResponse(ctx, w, http.StatusNotFound, err.Error())
+	default:
+		logger.GetLoggerFromCtx(ctx).Error(ctx, msg, zap.Error(err))
+		h.writeErrorResponse(ctx, w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,348 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"wb_tech_level_zero/internal/delivery/stream"
+	"wb_tech_level_zero/internal/dto"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	wsWriteWait          = 10 * time.Second
+	defaultWSPingPeriod  = 30 * time.Second
+	defaultWSMessageSize = 4096
+)
+
+// origin проверяется в authorizeStream: клиенты с токеном в заголовке - не браузеры,
+// а для токена в параметре разрешены только origin сервиса и WS_ALLOWED_ORIGINS
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// @Summary Subscribing to order updates over WebSocket
+// @Description WebSocket endpoint. The client sends {"action":"subscribe"|"unsubscribe","order_uids":[...]} and receives dto.WSServerMessage: the current order ("order") on subscribe and an "update" with the event on order.status_changed, order.cancelled, order.items_cancelled, order.refunded and order.pii_erased. Other changes of delivery or payment data are not published. The token from WS_AUTH_TOKENS or ADMIN_TOKEN is passed in the Authorization header; the access_token query parameter accepts only WS_AUTH_TOKENS and requires the service origin or one of WS_ALLOWED_ORIGINS. The server pings every WS_PING_SECONDS; the number of orders per connection is limited by WS_MAX_SUBSCRIPTIONS
+// @Tags orders
+// @Param access_token query string false "Токен доступа(если нельзя передать заголовок Authorization)"
+// @Success 101 {object} dto.WSServerMessage
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Router /orders/ws [get]
+func (h *Handlers) OrdersWebSocket(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLoggerFromCtx(ctx)
+
+	if h.orderStream == nil {
+		h.writeErrorResponse(ctx, w, http.StatusServiceUnavailable, "Order stream is not available")
+		return
+	}
+	if !h.authorizeStream(w, r) {
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// ответ с ошибкой уже записан Upgrade
+		log.Warn(ctx, "WebSocket upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	s := &orderSocket{h: h, conn: conn, log: log}
+	s.run(ctx)
+}
+
+// orderSocket - соединение WebSocket с подписками на заказы. Запись выполняется только из run,
+// чтение - из отдельной горутины, как требует gorilla/websocket
+type orderSocket struct {
+	h    *Handlers
+	conn *websocket.Conn
+	log  logger.Logger
+
+	uids []string
+	// sub создается при первой подписке и закрывается, когда заказов в подписке не осталось
+	sub *stream.Subscription
+}
+
+func (s *orderSocket) run(ctx context.Context) {
+	defer func() {
+		if s.sub != nil {
+			s.sub.Close()
+		}
+	}()
+
+	pingPeriod := time.Duration(s.h.cfg.WSPingSeconds) * time.Second
+	if pingPeriod <= 0 {
+		pingPeriod = defaultWSPingPeriod
+	}
+	pongWait := 2 * pingPeriod
+	maxSize := s.h.cfg.WSMaxMessageBytes
+	if maxSize <= 0 {
+		maxSize = defaultWSMessageSize
+	}
+
+	s.conn.SetReadLimit(maxSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	commands := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			_, data, err := s.conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case commands <- data:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		var (
+			updates <-chan stream.Message
+			dropped <-chan struct{}
+		)
+		if s.sub != nil {
+			updates, dropped = s.sub.C(), s.sub.Done()
+		}
+
+		var err error
+		select {
+		case data := <-commands:
+			err = s.handle(ctx, data)
+		case m := <-updates:
+			event := dto.StreamOrderToDTO(m.Event)
+			err = s.write(dto.WSServerMessage{Type: "update", EventID: m.ID, Event: &event})
+		case <-dropped:
+			// клиент не успевает читать обновления или поток остановлен: клиент переподключается и получает
+			// текущее состояние заказов при повторной подписке
+			s.close(websocket.CloseTryAgainLater, "subscription dropped, reconnect")
+			return
+		case <-ticker.C:
+			err = s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+		case err := <-readErr:
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				s.log.Info(ctx, "WebSocket connection closed", zap.Error(err))
+			}
+			return
+		case <-ctx.Done():
+			s.close(websocket.CloseGoingAway, "server is shutting down")
+			return
+		}
+		if err != nil {
+			s.log.Info(ctx, "WebSocket write failed", zap.Error(err))
+			return
+		}
+	}
+}
+
+func (s *orderSocket) handle(ctx context.Context, data []byte) error {
+	var msg dto.WSClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return s.writeError("invalid message: expected JSON command")
+	}
+
+	uids := compactUIDs(msg.OrderUIDs)
+	if len(uids) == 0 {
+		return s.writeError("order_uids is required")
+	}
+
+	switch msg.Action {
+	case "subscribe":
+		return s.subscribe(ctx, uids)
+	case "unsubscribe":
+		s.uids = slices.DeleteFunc(s.uids, func(uid string) bool { return slices.Contains(uids, uid) })
+		s.updateSubscription()
+		return s.write(dto.WSServerMessage{Type: "unsubscribed", OrderUIDs: s.subscribed()})
+	default:
+		return s.writeError(fmt.Sprintf("unknown action %q", msg.Action))
+	}
+}
+
+// subscribe добавляет заказы в подписку и отправляет их текущее состояние. Подписка на события оформляется
+// до чтения заказа, чтобы не пропустить изменение между чтением и подпиской
+func (s *orderSocket) subscribe(ctx context.Context, uids []string) error {
+	var added []string
+	for _, uid := range uids {
+		if !slices.Contains(s.uids, uid) {
+			added = append(added, uid)
+		}
+	}
+	if limit := s.h.cfg.WSMaxSubscriptions; limit > 0 && len(s.uids)+len(added) > limit {
+		return s.writeError(fmt.Sprintf("subscription limit exceeded: at most %d orders per connection", limit))
+	}
+	if len(added) == 0 {
+		return s.write(dto.WSServerMessage{Type: "subscribed", OrderUIDs: s.subscribed()})
+	}
+
+	s.uids = append(s.uids, added...)
+	if err := s.updateSubscription(); err != nil {
+		s.uids = s.uids[:len(s.uids)-len(added)]
+		return s.writeError(err.Error())
+	}
+
+	var missing []string
+	for _, uid := range added {
+		order, err := s.h.orderService.GetOrderByUID(ctx, uid)
+		if err != nil {
+			missing = append(missing, uid)
+			if !errors.Is(err, orders.ErrOrderNotFound) {
+				s.log.Error(ctx, "Failed to get order for WebSocket subscription", zap.String("order_uid", uid), zap.Error(err))
+				err = errors.New("failed to get order")
+			}
+			if werr := s.writeError(fmt.Sprintf("%s: %v", uid, err)); werr != nil {
+				return werr
+			}
+			continue
+		}
+		orderDTO := dto.OrderToDTO(order)
+		if err := s.write(dto.WSServerMessage{Type: "order", Order: &orderDTO}); err != nil {
+			return err
+		}
+	}
+
+	if len(missing) > 0 {
+		s.uids = slices.DeleteFunc(s.uids, func(uid string) bool { return slices.Contains(missing, uid) })
+		s.updateSubscription()
+	}
+	return s.write(dto.WSServerMessage{Type: "subscribed", OrderUIDs: s.subscribed()})
+}
+
+// updateSubscription приводит подписку на поток событий к текущему списку заказов
+func (s *orderSocket) updateSubscription() error {
+	if len(s.uids) == 0 {
+		if s.sub != nil {
+			s.sub.Close()
+			s.sub = nil
+		}
+		return nil
+	}
+
+	filter := orders.EventFilter{EventTypes: orders.WebhookEventTypes, OrderUIDs: slices.Clone(s.uids)}
+	if s.sub != nil {
+		s.sub.SetFilter(filter)
+		return nil
+	}
+	sub, _, err := s.h.orderStream.Subscribe(filter, 0)
+	if err != nil {
+		return err
+	}
+	s.sub = sub
+	return nil
+}
+
+func (s *orderSocket) subscribed() []string {
+	return append([]string{}, s.uids...)
+}
+
+func (s *orderSocket) write(msg dto.WSServerMessage) error {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return s.conn.WriteJSON(msg)
+}
+
+func (s *orderSocket) writeError(message string) error {
+	return s.write(dto.WSServerMessage{Type: "error", Message: message})
+}
+
+func (s *orderSocket) close(code int, text string) {
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(wsWriteWait))
+}
+
+// authorizeStream проверяет доступ к потокам событий(WebSocket и SSE); при отказе ответ уже записан.
+// В заголовке Authorization: Bearer принимаются WS_AUTH_TOKENS и ADMIN_TOKEN. Параметр access_token нужен
+// браузерным WebSocket и EventSource, которые не позволяют задать заголовки: в нем принимаются только
+// WS_AUTH_TOKENS(URL попадает в логи прокси и историю браузера) и только с origin сервиса или WS_ALLOWED_ORIGINS
+func (h *Handlers) authorizeStream(w http.ResponseWriter, r *http.Request) bool {
+	ctx := r.Context()
+	wsTokens, adminToken := h.wsTokens(), ""
+	if h.cfg != nil {
+		adminToken = strings.TrimSpace(h.cfg.AdminToken)
+	}
+	if len(wsTokens) == 0 && adminToken == "" {
+		h.writeErrorResponse(ctx, w, http.StatusForbidden, "Order stream API is disabled")
+		return false
+	}
+
+	if provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		if !tokenMatches(provided, append(wsTokens, adminToken)) {
+			h.writeErrorResponse(ctx, w, http.StatusUnauthorized, "Unauthorized")
+			return false
+		}
+		return true
+	}
+
+	if !tokenMatches(r.URL.Query().Get("access_token"), wsTokens) {
+		h.writeErrorResponse(ctx, w, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
+	if !h.originAllowed(r) {
+		h.writeErrorResponse(ctx, w, http.StatusForbidden, "Origin is not allowed")
+		return false
+	}
+	return true
+}
+
+// wsTokens - токены WS_AUTH_TOKENS
+func (h *Handlers) wsTokens() []string {
+	if h.cfg == nil {
+		return nil
+	}
+	var tokens []string
+	for _, t := range h.cfg.WSAuthTokens {
+		if t = strings.TrimSpace(t); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// originAllowed разрешает запросы без Origin(не из браузера), с origin самого сервиса и из WS_ALLOWED_ORIGINS
+func (h *Handlers) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return h.cfg != nil && slices.Contains(h.cfg.WSAllowedOrigins, origin)
+}
+
+func tokenMatches(provided string, tokens []string) bool {
+	if provided == "" {
+		return false
+	}
+	for _, t := range tokens {
+		if t != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -2,9 +2,13 @@ package kafkadelivery
 
 import (
 	"encoding/json"
+	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+
+	"wb_tech_level_zero/internal/orders"
 )
 
 var validate *validator.Validate
@@ -12,10 +16,53 @@ var validate *validator.Validate
 func init() {
 	validate = validator.New()
 
+	// суммы проверяются тегами gte/gt по знаку
+	validate.RegisterCustomTypeFunc(func(v reflect.Value) interface{} {
+		return v.Interface().(orders.Money).Sign()
+	}, orders.Money{})
 }
 
 /////////////
 
+// Типы входящих сообщений. Сообщение без event_type считается новым заказом(EventOrder)
+const (
+	EventTypeOrderCreated       = "order.created"
+	EventTypeOrderStatusChanged = "order.status_changed"
+	EventTypeOrderCancelled     = "order.cancelled"
+	EventTypeOrderRefunded      = "order.refunded"
+)
+
+const actorHeader = "actor"
+
+type eventEnvelope struct {
+	EventType string `json:"event_type"`
+}
+
+type EventStatusChange struct {
+	OrderUID string `json:"order_uid" validate:"required"`
+	Status   string `json:"status" validate:"required"`
+	Reason   string `json:"reason"`
+}
+
+// EventCancel - отмена заказа; пустой Items означает отмену всего заказа
+type EventCancel struct {
+	OrderUID string         `json:"order_uid" validate:"required"`
+	Items    []EventItemRef `json:"items" validate:"dive"`
+	Reason   string         `json:"reason"`
+}
+
+type EventItemRef struct {
+	Rid    string `json:"rid" validate:"required_without=ChrtID"`
+	ChrtID int    `json:"chrt_id"`
+}
+
+type EventRefund struct {
+	OrderUID    string       `json:"order_uid" validate:"required"`
+	Transaction string       `json:"transaction"`
+	Amount      orders.Money `json:"amount" validate:"gt=0"`
+	Reason      string       `json:"reason"`
+}
+
 type EventOrder struct {
 	OrderUID          string    `json:"order_uid" validate:"required"`
 	TrackNumber       string    `json:"track_number" validate:"required"`
@@ -44,30 +91,30 @@ type Delivery struct {
 }
 
 type Payment struct {
-	Transaction  string `json:"transaction" validate:"required"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency" validate:"required"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount" validate:"gte=0"`
-	PaymentDT    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	Transaction  string       `json:"transaction" validate:"required"`
+	RequestID    string       `json:"request_id"`
+	Currency     string       `json:"currency" validate:"required"`
+	Provider     string       `json:"provider"`
+	Amount       orders.Money `json:"amount" validate:"gte=0"`
+	PaymentDT    int64        `json:"payment_dt"`
+	Bank         string       `json:"bank"`
+	DeliveryCost orders.Money `json:"delivery_cost" validate:"gte=0"`
+	GoodsTotal   orders.Money `json:"goods_total" validate:"gte=0"`
+	CustomFee    orders.Money `json:"custom_fee" validate:"gte=0"`
 }
 
 type Item struct {
-	ChrtID      int    `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price" validate:"gte=0"`
-	Rid         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NmID        int    `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
+	ChrtID      int          `json:"chrt_id"`
+	TrackNumber string       `json:"track_number"`
+	Price       orders.Money `json:"price" validate:"gte=0"`
+	Rid         string       `json:"rid"`
+	Name        string       `json:"name"`
+	Sale        int          `json:"sale"`
+	Size        string       `json:"size"`
+	TotalPrice  orders.Money `json:"total_price" validate:"gte=0"`
+	NmID        int          `json:"nm_id"`
+	Brand       string       `json:"brand"`
+	Status      int          `json:"status"`
 }
 
 /////////////
@@ -82,5 +129,76 @@ func ParseAndValidate(data []byte) (*EventOrder, error) {
 		return nil, err
 	}
 
+	amounts := []orders.Money{eo.Payment.Amount, eo.Payment.DeliveryCost, eo.Payment.GoodsTotal, eo.Payment.CustomFee}
+	for _, it := range eo.Items {
+		amounts = append(amounts, it.Price, it.TotalPrice)
+	}
+	if err := checkPrecision(eo.Payment.Currency, amounts...); err != nil {
+		return nil, err
+	}
+
 	return &eo, nil
 }
+
+// checkPrecision отклоняет суммы, которые нельзя сохранить в БД без округления
+func checkPrecision(currency string, amounts ...orders.Money) error {
+	for _, m := range amounts {
+		if m.WithCurrency(currency).Exponent > orders.MaxMoneyExponent {
+			return fmt.Errorf("%w: %s", orders.ErrMoneyPrecision, m)
+		}
+	}
+	return nil
+}
+
+func ParseEventType(data []byte) (string, error) {
+	var env eventEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return "", err
+	}
+	if env.EventType == "" {
+		return EventTypeOrderCreated, nil
+	}
+	return env.EventType, nil
+}
+
+func ParseAndValidateStatusChange(data []byte) (*EventStatusChange, error) {
+	var ev EventStatusChange
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, err
+	}
+
+	if err := validate.Struct(ev); err != nil {
+		return nil, err
+	}
+
+	return &ev, nil
+}
+
+func ParseAndValidateCancel(data []byte) (*EventCancel, error) {
+	var ev EventCancel
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, err
+	}
+
+	if err := validate.Struct(ev); err != nil {
+		return nil, err
+	}
+
+	return &ev, nil
+}
+
+func ParseAndValidateRefund(data []byte) (*EventRefund, error) {
+	var ev EventRefund
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, err
+	}
+
+	if err := validate.Struct(ev); err != nil {
+		return nil, err
+	}
+	if err := checkPrecision("", ev.Amount); err != nil {
+		return nil, err
+	}
+
+	return &ev, nil
+}
@@ -0,0 +1,65 @@
+package kafkadelivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"wb_tech_level_zero/internal/orders"
+)
+
+// EventFXRates - курсы на дату относительно базовой валюты: 1 Base = Rates[quote] quote.
+// Курс передается числом или строкой: {"date":"2026-10-18","base":"EUR","rates":{"USD":"1.0712","RUB":98.5}}
+type EventFXRates struct {
+	Date   string                     `json:"date" validate:"required,datetime=2006-01-02"`
+	Base   string                     `json:"base" validate:"required,len=3"`
+	Rates  map[string]json.RawMessage `json:"rates" validate:"required,min=1"`
+	Source string                     `json:"source"`
+}
+
+// ParseAndValidateFXRates разбирает сообщение или документ с курсами: объект EventFXRates или массив таких объектов
+func ParseAndValidateFXRates(data []byte, defaultSource string) ([]orders.FXRate, error) {
+	var events []EventFXRates
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, err
+		}
+	} else {
+		var ev EventFXRates
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+
+	var rates []orders.FXRate
+	for _, ev := range events {
+		if err := validate.Struct(ev); err != nil {
+			return nil, err
+		}
+		date, _ := time.Parse(time.DateOnly, ev.Date)
+		source := ev.Source
+		if source == "" {
+			source = defaultSource
+		}
+
+		quotes := make([]string, 0, len(ev.Rates))
+		for quote := range ev.Rates {
+			quotes = append(quotes, quote)
+		}
+		sort.Strings(quotes)
+
+		for _, quote := range quotes {
+			raw := ev.Rates[quote]
+			value := strings.Trim(string(raw), `"`)
+			rate, err := orders.NewFXRate(date, ev.Base, quote, value, source)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s/%s: %w", ev.Date, ev.Base, quote, err)
+			}
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
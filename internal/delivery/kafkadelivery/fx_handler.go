@@ -0,0 +1,43 @@
+package kafkadelivery
+
+import (
+	"context"
+	"fmt"
+
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+type FXRatesService interface {
+	LoadFXRates(ctx context.Context, rates []orders.FXRate) error
+}
+
+// FXRatesHandler сохраняет курсы валют из топика KAFKA_FX_TOPIC
+type FXRatesHandler struct {
+	ratesService FXRatesService
+	logger       logger.Logger
+}
+
+func NewFXRatesHandler(ratesService FXRatesService, logger logger.Logger) *FXRatesHandler {
+	return &FXRatesHandler{
+		ratesService: ratesService,
+		logger:       logger,
+	}
+}
+
+func (h FXRatesHandler) HandleMessage(ctx context.Context, msg kafkaGo.Message) error {
+	rates, err := ParseAndValidateFXRates(msg.Value, "kafka:"+msg.Topic)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to parse or validate fx rates message", zap.Error(err))
+		return ErrKafkaNonRetryable
+	}
+
+	if err := h.ratesService.LoadFXRates(ctx, rates); err != nil {
+		h.logger.Error(ctx, "Failed to load fx rates in service layer", zap.Error(err))
+		return fmt.Errorf("%w: %v", ErrKafkaRetryable, err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package kafkadelivery
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"wb_tech_level_zero/internal/orders"
 	"wb_tech_level_zero/pkg/logger"
@@ -14,6 +15,9 @@ import (
 
 type OrdersService interface {
 	ProcessEventOrder(ctx context.Context, eventOrder *EventOrder) error
+	ChangeOrderStatus(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error)
+	CancelOrder(ctx context.Context, orderUID string, items []orders.ItemRef, reason string) (*orders.Order, error)
+	RefundOrder(ctx context.Context, orderUID string, refund orders.Refund) (*orders.Refund, error)
 }
 
 type Handler struct {
@@ -31,18 +35,33 @@ func NewHandler(orderService OrdersService, logger logger.Logger) *Handler {
 func (h Handler) HandleMessage(ctx context.Context, msg kafkaGo.Message) error {
 	h.logger.Info(ctx, "Received Kafka message: "+string(msg.Value))
 
+	eventType, err := ParseEventType(msg.Value)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to parse message", zap.Error(err))
+		return ErrKafkaNonRetryable
+	}
+
+	ctx = orders.WithChangeSource(ctx, changeSource(msg))
+
+	switch eventType {
+	case EventTypeOrderCreated:
+		return h.handleOrderCreated(ctx, msg)
+	case EventTypeOrderStatusChanged:
+		return h.handleStatusChanged(ctx, msg)
+	case EventTypeOrderCancelled:
+		return h.handleCancelled(ctx, msg)
+	case EventTypeOrderRefunded:
+		return h.handleRefunded(ctx, msg)
+	}
+
+	h.logger.Error(ctx, "Unknown event type", zap.String("event_type", eventType))
+	return ErrKafkaNonRetryable
+}
+
+func (h Handler) handleOrderCreated(ctx context.Context, msg kafkaGo.Message) error {
 	eventOrder, err := ParseAndValidate(msg.Value)
 	if err != nil {
-		h.logger.Error(ctx, "Failed to parse or validate message", zap.Error(err))
-
-		if ve, ok := err.(validator.ValidationErrors); ok {
-			for _, fe := range ve {
-				h.logger.Error(ctx, "Validation error",
-					zap.String("field", fe.Field()),
-					zap.String("tag", fe.Tag()),
-					zap.String("value", fe.Param()))
-			}
-		}
+		h.logValidationError(ctx, err)
 		return ErrKafkaNonRetryable
 	}
 
@@ -62,3 +81,113 @@ func (h Handler) HandleMessage(ctx context.Context, msg kafkaGo.Message) error {
 
 	return nil
 }
+
+func (h Handler) handleStatusChanged(ctx context.Context, msg kafkaGo.Message) error {
+	ev, err := ParseAndValidateStatusChange(msg.Value)
+	if err != nil {
+		h.logValidationError(ctx, err)
+		return ErrKafkaNonRetryable
+	}
+
+	status, err := orders.ParseStatus(ev.Status)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid order status in event", zap.String("order_uid", ev.OrderUID), zap.Error(err))
+		return ErrKafkaNonRetryable
+	}
+
+	if _, err := h.orderService.ChangeOrderStatus(ctx, ev.OrderUID, status, ev.Reason); err != nil {
+		return h.mapServiceError(ctx, ev.OrderUID, err)
+	}
+
+	h.logger.Info(ctx, "Order status changed", zap.String("order_uid", ev.OrderUID), zap.String("status", ev.Status))
+	return nil
+}
+
+func (h Handler) handleCancelled(ctx context.Context, msg kafkaGo.Message) error {
+	ev, err := ParseAndValidateCancel(msg.Value)
+	if err != nil {
+		h.logValidationError(ctx, err)
+		return ErrKafkaNonRetryable
+	}
+
+	refs := make([]orders.ItemRef, len(ev.Items))
+	for i, it := range ev.Items {
+		refs[i] = orders.ItemRef{Rid: it.Rid, ChrtID: it.ChrtID}
+	}
+
+	order, err := h.orderService.CancelOrder(ctx, ev.OrderUID, refs, ev.Reason)
+	if err != nil {
+		return h.mapServiceError(ctx, ev.OrderUID, err)
+	}
+
+	h.logger.Info(ctx, "Order cancelled",
+		zap.String("order_uid", ev.OrderUID),
+		zap.Int("items", len(refs)),
+		zap.String("status", string(order.Status)),
+	)
+	return nil
+}
+
+func (h Handler) handleRefunded(ctx context.Context, msg kafkaGo.Message) error {
+	ev, err := ParseAndValidateRefund(msg.Value)
+	if err != nil {
+		h.logValidationError(ctx, err)
+		return ErrKafkaNonRetryable
+	}
+
+	refund := orders.Refund{Transaction: ev.Transaction, Amount: ev.Amount, Reason: ev.Reason}
+	if _, err := h.orderService.RefundOrder(ctx, ev.OrderUID, refund); err != nil {
+		return h.mapServiceError(ctx, ev.OrderUID, err)
+	}
+
+	h.logger.Info(ctx, "Order refund recorded", zap.String("order_uid", ev.OrderUID), zap.Stringer("amount", ev.Amount))
+	return nil
+}
+
+// mapServiceError: ошибки данных события отправляются в DLQ, остальные - на повтор
+func (h Handler) mapServiceError(ctx context.Context, orderUID string, err error) error {
+	switch {
+	case errors.Is(err, orders.ErrOrderNotFound),
+		errors.Is(err, orders.ErrInvalidTransition),
+		errors.Is(err, orders.ErrUnknownStatus),
+		errors.Is(err, orders.ErrItemNotFound),
+		errors.Is(err, orders.ErrItemAlreadyCancelled),
+		errors.Is(err, orders.ErrInvalidRefundAmount),
+		errors.Is(err, orders.ErrRefundExceedsPaid),
+		errors.Is(err, orders.ErrTransactionMismatch):
+		h.logger.Warn(ctx, "Event rejected by service layer, sending to DLQ",
+			zap.String("order_uid", orderUID), zap.Error(err))
+		return fmt.Errorf("%w: %v", ErrKafkaNonRetryable, err)
+	}
+
+	h.logger.Error(ctx, "Failed to process event in service layer", zap.String("order_uid", orderUID), zap.Error(err))
+	return fmt.Errorf("%w: %v", ErrKafkaRetryable, err)
+}
+
+// changeSource - ссылка на исходное сообщение(topic/partition/offset) для истории версий заказа.
+// Инициатор изменения может быть передан в заголовке actor
+func changeSource(msg kafkaGo.Message) orders.ChangeSource {
+	src := orders.ChangeSource{
+		Kind: orders.SourceKafka,
+		Ref:  fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset),
+	}
+	for _, h := range msg.Headers {
+		if h.Key == actorHeader {
+			src.Actor = string(h.Value)
+		}
+	}
+	return src
+}
+
+func (h Handler) logValidationError(ctx context.Context, err error) {
+	h.logger.Error(ctx, "Failed to parse or validate message", zap.Error(err))
+
+	if ve, ok := err.(validator.ValidationErrors); ok {
+		for _, fe := range ve {
+			h.logger.Error(ctx, "Validation error",
+				zap.String("field", fe.Field()),
+				zap.String("tag", fe.Tag()),
+				zap.String("value", fe.Param()))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package kafkadelivery
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// producerBatchTimeout - сколько writer ждет накопления пакета перед отправкой
+const producerBatchTimeout = 10 * time.Millisecond
+
+// Producer публикует события заказов в Kafka-топик; ключ сообщения - order_uid,
+// поэтому события одного заказа сохраняют порядок внутри партиции.
+// Отправка асинхронная: Publish не ждет Kafka, ошибки доставки пишутся в лог.
+// Топик должен существовать(make kafka-topics), автоматически он не создается
+type Producer struct {
+	writer *kafkaGo.Writer
+}
+
+func NewProducer(brokers []string, topic string, log logger.Logger) *Producer {
+	return &Producer{
+		writer: &kafkaGo.Writer{
+			Addr:         kafkaGo.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafkaGo.Hash{},
+			BatchTimeout: producerBatchTimeout,
+			Async:        true,
+			Completion: func(messages []kafkaGo.Message, err error) {
+				if err != nil {
+					ctx := context.Background()
+					log.Error(ctx, "Failed to publish order events to Kafka",
+						zap.Error(err),
+						zap.String("topic", topic),
+						zap.Int("messages", len(messages)),
+					)
+				}
+			},
+		},
+	}
+}
+
+func (p *Producer) Publish(ctx context.Context, event orders.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.writer.WriteMessages(ctx, kafkaGo.Message{
+		Key:   []byte(event.OrderUID),
+		Value: data,
+	})
+}
+
+// Close отправляет накопленные сообщения и закрывает writer
+func (p *Producer) Close() error {
+	return p.writer.Close()
+}
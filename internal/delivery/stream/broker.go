@@ -0,0 +1,207 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// publishScript присваивает событию следующий номер, сохраняет его в общий буфер и публикует в канал одной
+// атомарной операцией, поэтому все экземпляры получают события в порядке номеров.
+// KEYS[1] - счетчик, KEYS[2] - буфер; ARGV[1] - JSON события, ARGV[2] - размер буфера, ARGV[3] - канал
+var publishScript = redis.NewScript(`
+local id = redis.call('INCR', KEYS[1])
+local msg = '{"id":' .. id .. ',"event":' .. ARGV[1] .. '}'
+redis.call('RPUSH', KEYS[2], msg)
+redis.call('LTRIM', KEYS[2], -tonumber(ARGV[2]), -1)
+redis.call('PUBLISH', ARGV[3], msg)
+return id
+`)
+
+const (
+	subscribeMinBackoff = time.Second
+	subscribeMaxBackoff = 30 * time.Second
+)
+
+var errSubscriptionClosed = errors.New("order stream subscription closed")
+
+type Config struct {
+	// Channel - канал Redis pub/sub; счетчик и буфер хранятся в ключах с тем же hash tag
+	Channel      string
+	ReplaySize   int
+	ClientBuffer int
+	MaxClients   int
+}
+
+type Stats struct {
+	Clients   int    `json:"clients"`
+	LastID    uint64 `json:"last_id"`
+	Published int64  `json:"published"`
+	Received  int64  `json:"received"`
+	Invalid   int64  `json:"invalid"`
+}
+
+// Broker - поток событий заказов между экземплярами сервиса: события публикуются в Redis pub/sub,
+// каждый экземпляр получает их из канала и рассылает своим подписчикам(SSE-клиентам)
+type Broker struct {
+	*Hub
+
+	client    redis.UniversalClient
+	channel   string
+	seqKey    string
+	bufferKey string
+	log       logger.Logger
+
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	published, received, invalid atomic.Int64
+}
+
+func NewBroker(cfg Config, client redis.UniversalClient, log logger.Logger) *Broker {
+	if cfg.Channel == "" {
+		cfg.Channel = "orders:stream"
+	}
+	hub := NewHub(cfg.ReplaySize, cfg.ClientBuffer, cfg.MaxClients)
+	return &Broker{
+		Hub:       hub,
+		client:    client,
+		channel:   cfg.Channel,
+		seqKey:    "{" + cfg.Channel + "}:seq",
+		bufferKey: "{" + cfg.Channel + "}:buffer",
+		log:       log,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Publish публикует событие всем экземплярам; локальные подписчики получают его из канала, как и остальные
+func (b *Broker) Publish(ctx context.Context, event orders.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := publishScript.Run(ctx, b.client, []string{b.seqKey, b.bufferKey}, data, b.replaySize, b.channel).Err(); err != nil {
+		return err
+	}
+	b.published.Add(1)
+	return nil
+}
+
+// Run подписывается на канал, загружает сохраненные события для возобновления потока и рассылает входящие
+// события до остановки брокера или отмены ctx. Если подписка не удалась или прервалась, она повторяется
+// с экспоненциальной задержкой, а пропущенные за это время события догружаются из буфера
+func (b *Broker) Run(ctx context.Context) error {
+	delay := subscribeMinBackoff
+	for {
+		subscribed, err := b.listen(ctx)
+		if err == nil {
+			return nil
+		}
+		if subscribed {
+			delay = subscribeMinBackoff
+		}
+
+		b.log.Warn(ctx, "Order stream subscription failed, retrying", zap.Duration("delay", delay), zap.Error(err))
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-b.stop:
+			timer.Stop()
+			return nil
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+		delay = min(delay*2, subscribeMaxBackoff)
+	}
+}
+
+// listen обслуживает одну подписку. err == nil - брокер остановлен, subscribed - подписка была подтверждена
+func (b *Broker) listen(ctx context.Context) (subscribed bool, err error) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	// буфер читается после подтверждения подписки, чтобы не пропустить события между чтением и подпиской
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := b.catchUp(ctx); err != nil {
+		b.log.Warn(ctx, "Failed to load order stream replay buffer", zap.Error(err))
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return true, errSubscriptionClosed
+			}
+			var m Message
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil || m.ID == 0 {
+				b.invalid.Add(1)
+				b.log.Warn(ctx, "Invalid order stream message", zap.Error(err))
+				continue
+			}
+			b.received.Add(1)
+			b.Broadcast(m)
+		case <-b.stop:
+			return true, nil
+		case <-ctx.Done():
+			return true, nil
+		}
+	}
+}
+
+// catchUp дополняет буфер событиями из Redis: более старые сохраняются для повтора, а более новые, пропущенные
+// во время переподключения, рассылаются подписчикам
+func (b *Broker) catchUp(ctx context.Context) error {
+	list, err := b.client.LRange(ctx, b.bufferKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	msgs := make([]Message, 0, len(list))
+	for _, raw := range list {
+		var m Message
+		if err := json.Unmarshal([]byte(raw), &m); err == nil && m.ID > 0 {
+			msgs = append(msgs, m)
+		}
+	}
+	b.Seed(msgs)
+
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].ID < msgs[j].ID })
+	last := b.LastID()
+	for _, m := range msgs {
+		if m.ID > last {
+			b.Broadcast(m)
+		}
+	}
+	return nil
+}
+
+// Close останавливает прием событий и отключает подписчиков
+func (b *Broker) Close() {
+	b.stopOnce.Do(func() { close(b.stop) })
+	b.Hub.Close()
+}
+
+func (b *Broker) Stats() Stats {
+	return Stats{
+		Clients:   b.Clients(),
+		LastID:    b.LastID(),
+		Published: b.published.Load(),
+		Received:  b.received.Load(),
+		Invalid:   b.invalid.Load(),
+	}
+}
@@ -0,0 +1,223 @@
+package stream
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"wb_tech_level_zero/internal/orders"
+)
+
+const (
+	defaultReplaySize   = 1000
+	defaultClientBuffer = 64
+)
+
+var (
+	ErrTooManyClients = errors.New("too many stream clients")
+	ErrClosed         = errors.New("order stream is closed")
+)
+
+// Message - событие заказа с номером в общей для всех экземпляров сервиса последовательности
+type Message struct {
+	ID    uint64       `json:"id"`
+	Event orders.Event `json:"event"`
+}
+
+// Subscription - подписка клиента на события. Done закрывается, если клиент не успевает читать события
+// или поток остановлен: клиент должен переподключиться с последним полученным ID
+type Subscription struct {
+	hub    *Hub
+	filter orders.EventFilter
+	ch     chan Message
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (s *Subscription) C() <-chan Message {
+	return s.ch
+}
+
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// SetFilter заменяет фильтр подписки; события, уже поставленные в очередь, не отбрасываются
+func (s *Subscription) SetFilter(filter orders.EventFilter) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.filter = filter
+}
+
+// Close отписывает клиента
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+func (s *Subscription) stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Hub рассылает события локальным подписчикам и хранит последние replaySize событий для возобновления
+// потока с Last-Event-ID. События принимаются в порядке ID, повторы отбрасываются
+type Hub struct {
+	replaySize   int
+	clientBuffer int
+	maxClients   int
+
+	mu     sync.Mutex
+	buffer []Message
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub - maxClients <= 0 не ограничивает количество подписчиков
+func NewHub(replaySize, clientBuffer, maxClients int) *Hub {
+	if replaySize <= 0 {
+		replaySize = defaultReplaySize
+	}
+	if clientBuffer <= 0 {
+		clientBuffer = defaultClientBuffer
+	}
+	return &Hub{
+		replaySize:   replaySize,
+		clientBuffer: clientBuffer,
+		maxClients:   maxClients,
+		subs:         make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe подписывает клиента и возвращает сохраненные события с ID больше lastID(lastID = 0 - без повтора).
+// Если часть событий после lastID уже вытеснена из буфера, повторяются только оставшиеся
+func (h *Hub) Subscribe(filter orders.EventFilter, lastID uint64) (*Subscription, []Message, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, ErrClosed
+	}
+	if h.maxClients > 0 && len(h.subs) >= h.maxClients {
+		return nil, nil, ErrTooManyClients
+	}
+
+	var replay []Message
+	if lastID > 0 {
+		i := sort.Search(len(h.buffer), func(i int) bool { return h.buffer[i].ID > lastID })
+		for _, m := range h.buffer[i:] {
+			if filter.Matches(m.Event) {
+				replay = append(replay, m)
+			}
+		}
+	}
+
+	s := &Subscription{
+		hub:    h,
+		filter: filter,
+		ch:     make(chan Message, h.clientBuffer),
+		done:   make(chan struct{}),
+	}
+	h.subs[s] = struct{}{}
+	return s, replay, nil
+}
+
+// Broadcast сохраняет событие в буфер и рассылает подходящим подписчикам. Подписчик с заполненной очередью
+// отключается, чтобы медленный клиент не задерживал остальных.
+// Номер не больше последнего, которого нет в буфере, означает, что счетчик в Redis начался заново(например,
+// после потери данных Redis): буфер старой последовательности сбрасывается, а подписчики отключаются, чтобы
+// переподключиться без Last-Event-ID старой последовательности
+func (h *Hub) Broadcast(m Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	if n := len(h.buffer); n > 0 && m.ID <= h.buffer[n-1].ID {
+		i := sort.Search(n, func(i int) bool { return h.buffer[i].ID >= m.ID })
+		if i < n && h.buffer[i].ID == m.ID {
+			return
+		}
+		h.buffer = nil
+		for s := range h.subs {
+			delete(h.subs, s)
+			s.stop()
+		}
+	}
+	h.buffer = append(h.buffer, m)
+	if len(h.buffer) > h.replaySize {
+		h.buffer = append(h.buffer[:0:0], h.buffer[len(h.buffer)-h.replaySize:]...)
+	}
+
+	for s := range h.subs {
+		if !s.filter.Matches(m.Event) {
+			continue
+		}
+		select {
+		case s.ch <- m:
+		default:
+			delete(h.subs, s)
+			s.stop()
+		}
+	}
+}
+
+// Seed дополняет буфер событиями, опубликованными до запуска экземпляра. В буфер попадают только события
+// старше уже сохраненных
+func (h *Hub) Seed(list []Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var older []Message
+	for _, m := range list {
+		if len(h.buffer) == 0 || m.ID < h.buffer[0].ID {
+			older = append(older, m)
+		}
+	}
+	sort.Slice(older, func(i, j int) bool { return older[i].ID < older[j].ID })
+
+	merged := make([]Message, 0, len(older)+len(h.buffer))
+	for _, m := range older {
+		if len(merged) == 0 || merged[len(merged)-1].ID != m.ID {
+			merged = append(merged, m)
+		}
+	}
+	merged = append(merged, h.buffer...)
+	if len(merged) > h.replaySize {
+		merged = merged[len(merged)-h.replaySize:]
+	}
+	h.buffer = merged
+}
+
+// LastID - номер последнего события в буфере, 0 - буфер пуст
+func (h *Hub) LastID() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.buffer) == 0 {
+		return 0
+	}
+	return h.buffer[len(h.buffer)-1].ID
+}
+
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Close отключает всех подписчиков; новые подписки отклоняются
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for s := range h.subs {
+		delete(h.subs, s)
+		s.stop()
+	}
+}
+
+func (h *Hub) remove(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, s)
+	s.stop()
+}
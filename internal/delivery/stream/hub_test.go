@@ -0,0 +1,135 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+	"wb_tech_level_zero/internal/orders"
+)
+
+func message(id uint64, t orders.EventType, deliveryService string) Message {
+	return Message{ID: id, Event: orders.Event{Type: t, OrderUID: "o", DeliveryService: deliveryService}}
+}
+
+func TestHubReplay(t *testing.T) {
+	h := NewHub(3, 10, 0)
+	for id := uint64(1); id <= 5; id++ {
+		h.Broadcast(message(id, orders.EventOrderCreated, "meest"))
+	}
+	h.Broadcast(message(4, orders.EventOrderCreated, "meest"))
+
+	_, replay, err := h.Subscribe(orders.EventFilter{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay) != 2 || replay[0].ID != 4 || replay[1].ID != 5 {
+		t.Errorf("expected events 4 and 5, got %+v", replay)
+	}
+
+	_, replay, _ = h.Subscribe(orders.EventFilter{}, 1)
+	if len(replay) != 3 || replay[0].ID != 3 {
+		t.Errorf("expected buffered events 3-5, got %+v", replay)
+	}
+
+	if _, replay, _ = h.Subscribe(orders.EventFilter{}, 0); len(replay) != 0 {
+		t.Errorf("expected no replay without last id, got %+v", replay)
+	}
+}
+
+func TestHubFilterAndSlowClient(t *testing.T) {
+	h := NewHub(10, 2, 0)
+	meest, _, _ := h.Subscribe(orders.EventFilter{DeliveryServices: []string{"meest"}}, 0)
+	slow, _, _ := h.Subscribe(orders.EventFilter{}, 0)
+
+	h.Broadcast(message(1, orders.EventOrderCreated, "meest"))
+	h.Broadcast(message(2, orders.EventOrderCreated, "cdek"))
+	h.Broadcast(message(3, orders.EventOrderCreated, "cdek"))
+
+	if m := <-meest.C(); m.ID != 1 {
+		t.Errorf("expected event 1, got %d", m.ID)
+	}
+	select {
+	case m := <-meest.C():
+		t.Errorf("unexpected event %d for filtered subscription", m.ID)
+	default:
+	}
+
+	select {
+	case <-slow.Done():
+	default:
+		t.Error("slow subscriber must be disconnected")
+	}
+	if h.Clients() != 1 {
+		t.Errorf("expected 1 client, got %d", h.Clients())
+	}
+}
+
+func TestHubLimitsAndClose(t *testing.T) {
+	h := NewHub(10, 10, 1)
+	sub, _, err := h.Subscribe(orders.EventFilter{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := h.Subscribe(orders.EventFilter{}, 0); !errors.Is(err, ErrTooManyClients) {
+		t.Errorf("expected ErrTooManyClients, got %v", err)
+	}
+	sub.Close()
+	if _, _, err := h.Subscribe(orders.EventFilter{}, 0); err != nil {
+		t.Errorf("expected slot to be released, got %v", err)
+	}
+
+	h.Close()
+	if _, _, err := h.Subscribe(orders.EventFilter{}, 0); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if h.Clients() != 0 {
+		t.Errorf("expected all clients to be disconnected, got %d", h.Clients())
+	}
+}
+
+func TestHubSeed(t *testing.T) {
+	h := NewHub(4, 10, 0)
+	h.Broadcast(message(5, orders.EventOrderCreated, ""))
+	h.Seed([]Message{
+		message(2, orders.EventOrderCreated, ""),
+		message(4, orders.EventOrderCreated, ""),
+		message(3, orders.EventOrderCreated, ""),
+		message(5, orders.EventOrderCreated, ""),
+		message(1, orders.EventOrderCreated, ""),
+	})
+
+	_, replay, _ := h.Subscribe(orders.EventFilter{}, 1)
+	if len(replay) != 4 || replay[0].ID != 2 || replay[1].ID != 3 || replay[3].ID != 5 {
+		t.Errorf("expected last 4 events 2-5 in order, got %+v", replay)
+	}
+	if h.LastID() != 5 {
+		t.Errorf("expected last id 5, got %d", h.LastID())
+	}
+}
+
+func TestHubSequenceReset(t *testing.T) {
+	h := NewHub(10, 10, 0)
+	for id := uint64(10); id <= 15; id++ {
+		h.Broadcast(message(id, orders.EventOrderCreated, ""))
+	}
+	sub, _, _ := h.Subscribe(orders.EventFilter{}, 0)
+
+	h.Broadcast(message(12, orders.EventOrderCreated, ""))
+	if h.LastID() != 15 {
+		t.Fatalf("duplicate event must be ignored, last id %d", h.LastID())
+	}
+
+	h.Broadcast(message(1, orders.EventOrderStatusChanged, ""))
+	if h.LastID() != 1 {
+		t.Errorf("expected buffer to restart from id 1, got last id %d", h.LastID())
+	}
+	select {
+	case <-sub.Done():
+	default:
+		t.Error("subscribers must be disconnected after sequence reset")
+	}
+
+	_, replay, _ := h.Subscribe(orders.EventFilter{}, 13)
+	if len(replay) != 0 {
+		t.Errorf("events of the old sequence must not be replayed, got %+v", replay)
+	}
+}
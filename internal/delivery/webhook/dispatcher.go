@@ -0,0 +1,433 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultWorkers         = 4
+	defaultQueueSize       = 1000
+	defaultTimeout         = 5 * time.Second
+	defaultMaxAttempts     = 5
+	defaultBackoff         = time.Second
+	defaultMaxBackoff      = time.Minute
+	defaultRefreshInterval = 10 * time.Second
+
+	// storeTimeout - таймаут записи журнала и результата доставки
+	storeTimeout = 5 * time.Second
+	// maxResponseBody - сколько байт ответа получателя читается, чтобы переиспользовать соединение
+	maxResponseBody = 64 << 10
+)
+
+var ErrClosed = errors.New("webhook dispatcher is closed")
+
+// Store - хранилище подписок и журнала доставки
+type Store interface {
+	GetActiveWebhooks(ctx context.Context) ([]*orders.Webhook, error)
+	LogWebhookDelivery(ctx context.Context, d *orders.WebhookDelivery) error
+	RecordWebhookResult(ctx context.Context, id int64, success bool, disableAfter int, reason string) (bool, error)
+}
+
+// Config - настройки доставки. Попытка повторяется при сетевой ошибке, 5xx, 408 и 429 с экспоненциальной
+// задержкой Backoff * 2^(n-1)(не больше MaxBackoff, со случайным разбросом до половины задержки).
+// DisableAfter - после скольких неудачных доставок подряд подписка отключается(0 - не отключать)
+type Config struct {
+	Workers         int
+	QueueSize       int
+	Timeout         time.Duration
+	MaxAttempts     int
+	Backoff         time.Duration
+	MaxBackoff      time.Duration
+	DisableAfter    int
+	RefreshInterval time.Duration
+}
+
+type Stats struct {
+	Enqueued  int64 `json:"enqueued"`
+	Delivered int64 `json:"delivered"`
+	Retried   int64 `json:"retried"`
+	Failed    int64 `json:"failed"`
+	Dropped   int64 `json:"dropped"`
+	Disabled  int64 `json:"disabled"`
+	Queued    int   `json:"queued"`
+	Webhooks  int   `json:"webhooks"`
+}
+
+type job struct {
+	webhook    *orders.Webhook
+	event      orders.Event
+	body       []byte
+	deliveryID string
+	attempt    int
+}
+
+// Dispatcher рассылает события заказов подпискам: подходящие под фильтр доставки ставятся в очередь и
+// отправляются пулом воркеров. Повторная попытка ожидает задержку вне воркера и возвращается в конец очереди,
+// поэтому недоступный получатель не задерживает доставку остальным подпискам. Активные подписки читаются из Store
+// раз в RefreshInterval, поэтому изменения подписок применяются с этой задержкой. Очередь хранится в памяти:
+// при остановке недоставленные события теряются
+type Dispatcher struct {
+	cfg    Config
+	store  Store
+	client *http.Client
+	log    logger.Logger
+
+	queue chan *job
+
+	mu       sync.RWMutex
+	webhooks []*orders.Webhook
+	closed   bool
+
+	stop      chan struct{}
+	stopOnce  sync.Once
+	workersWg sync.WaitGroup
+	retriesWg sync.WaitGroup
+
+	enqueued, delivered, retried, failed, dropped, disabled atomic.Int64
+}
+
+func NewDispatcher(cfg Config, store Store, client *http.Client, log logger.Logger) *Dispatcher {
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultBackoff
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = max(defaultMaxBackoff, cfg.Backoff)
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultRefreshInterval
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	d := &Dispatcher{
+		cfg:    cfg,
+		store:  store,
+		client: client,
+		log:    log,
+		queue:  make(chan *job, cfg.QueueSize),
+		stop:   make(chan struct{}),
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		d.workersWg.Add(1)
+		go d.run()
+	}
+	return d
+}
+
+// Run загружает подписки и обновляет их раз в RefreshInterval до остановки диспетчера или отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) error {
+	if err := d.Refresh(ctx); err != nil {
+		d.log.Error(ctx, "Failed to load webhooks", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(d.cfg.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := d.Refresh(ctx); err != nil {
+				d.log.Warn(ctx, "Failed to refresh webhooks", zap.Error(err))
+			}
+		case <-d.stop:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) Refresh(ctx context.Context) error {
+	list, err := d.store.GetActiveWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.webhooks = list
+	d.mu.Unlock()
+	return nil
+}
+
+// Publish ставит в очередь доставку события всем подходящим подпискам и не ждет отправки
+func (d *Dispatcher) Publish(ctx context.Context, event orders.Event) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrClosed
+	}
+
+	var body []byte
+	for _, w := range d.webhooks {
+		if !w.Filter.Matches(event) {
+			continue
+		}
+		if body == nil {
+			var err error
+			if body, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case d.queue <- &job{webhook: w, event: event, body: body, deliveryID: uuid.NewString()}:
+			d.enqueued.Add(1)
+		default:
+			d.dropped.Add(1)
+			d.log.Warn(ctx, "Webhook queue is full, delivery dropped",
+				zap.Int64("webhook_id", w.ID), zap.String("order_uid", event.OrderUID))
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) run() {
+	defer d.workersWg.Done()
+
+	for {
+		select {
+		case j := <-d.queue:
+			d.process(j)
+		case <-d.stop:
+			// принятые события отправляются одной попыткой, без повторов
+			for {
+				select {
+				case j := <-d.queue:
+					d.process(j)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) process(j *job) {
+	ctx := context.Background()
+
+	j.attempt++
+	status, err := d.send(j, j.attempt)
+	if err == nil {
+		d.delivered.Add(1)
+		d.recordResult(ctx, j, true, "")
+		return
+	}
+
+	if j.attempt < d.cfg.MaxAttempts && retryable(status) {
+		d.retry(j, err)
+		return
+	}
+
+	d.failed.Add(1)
+	d.log.Warn(ctx, "Webhook delivery failed",
+		zap.Int64("webhook_id", j.webhook.ID),
+		zap.String("delivery_id", j.deliveryID),
+		zap.String("order_uid", j.event.OrderUID),
+		zap.Error(err),
+	)
+
+	// причина сохраняется только при отключении подписки
+	var reason string
+	if d.cfg.DisableAfter > 0 {
+		reason = fmt.Sprintf("%d consecutive failed deliveries, last error: %v", d.cfg.DisableAfter, err)
+	}
+	d.recordResult(ctx, j, false, reason)
+}
+
+// retry возвращает доставку в очередь после задержки. Очередь пополняется под d.mu с проверкой closed,
+// как в Publish: после остановки повторы отменяются
+func (d *Dispatcher) retry(j *job, err error) {
+	d.retriesWg.Add(1)
+	go func() {
+		defer d.retriesWg.Done()
+
+		ctx := context.Background()
+		timer := time.NewTimer(d.backoff(j.attempt))
+		select {
+		case <-timer.C:
+		case <-d.stop:
+			timer.Stop()
+			d.log.Warn(ctx, "Webhook dispatcher is stopping, delivery retries cancelled",
+				zap.Int64("webhook_id", j.webhook.ID), zap.String("delivery_id", j.deliveryID), zap.Error(err))
+			return
+		}
+
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+		if d.closed {
+			return
+		}
+		select {
+		case d.queue <- j:
+			d.retried.Add(1)
+		default:
+			d.dropped.Add(1)
+			d.log.Warn(ctx, "Webhook queue is full, delivery retry dropped",
+				zap.Int64("webhook_id", j.webhook.ID), zap.String("delivery_id", j.deliveryID))
+		}
+	}()
+}
+
+// send выполняет одну попытку доставки и записывает ее в журнал. status - код ответа, 0 - ответа нет
+func (d *Dispatcher) send(j *job, attempt int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	status, err := d.post(ctx, j)
+	entry := &orders.WebhookDelivery{
+		WebhookID:  j.webhook.ID,
+		DeliveryID: j.deliveryID,
+		EventType:  j.event.Type,
+		OrderUID:   j.event.OrderUID,
+		Attempt:    attempt,
+		StatusCode: status,
+		Duration:   time.Since(start),
+		Success:    err == nil,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	logCtx, logCancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer logCancel()
+	if logErr := d.store.LogWebhookDelivery(logCtx, entry); logErr != nil {
+		d.log.Warn(logCtx, "Failed to log webhook delivery", zap.Int64("webhook_id", j.webhook.ID), zap.Error(logErr))
+	}
+	return status, err
+}
+
+func (d *Dispatcher) post(ctx context.Context, j *job) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.webhook.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(j.event.Type))
+	req.Header.Set(HeaderDelivery, j.deliveryID)
+	req.Header.Set(HeaderSignature, Sign(j.webhook.Secret, time.Now(), j.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) recordResult(ctx context.Context, j *job, success bool, reason string) {
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	disabled, err := d.store.RecordWebhookResult(ctx, j.webhook.ID, success, d.cfg.DisableAfter, reason)
+	if err != nil {
+		d.log.Warn(ctx, "Failed to record webhook delivery result", zap.Int64("webhook_id", j.webhook.ID), zap.Error(err))
+		return
+	}
+	if disabled {
+		d.disabled.Add(1)
+		d.log.Warn(ctx, "Webhook disabled after consecutive failed deliveries",
+			zap.Int64("webhook_id", j.webhook.ID), zap.String("url", j.webhook.URL))
+		d.forget(j.webhook.ID)
+	}
+}
+
+// forget убирает отключенную подписку из рассылки до следующего обновления списка
+func (d *Dispatcher) forget(id int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	list := make([]*orders.Webhook, 0, len(d.webhooks))
+	for _, w := range d.webhooks {
+		if w.ID != id {
+			list = append(list, w)
+		}
+	}
+	d.webhooks = list
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.Backoff << (attempt - 1)
+	if delay <= 0 || delay > d.cfg.MaxBackoff {
+		delay = d.cfg.MaxBackoff
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryable - стоит ли повторять попытку: нет ответа, ошибка сервера получателя, таймаут или ограничение частоты
+func retryable(status int) bool {
+	return status == 0 || status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
+
+// Close прекращает прием событий и дожидается воркеров: события из очереди отправляются одной попыткой
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.stopOnce.Do(func() {
+		d.mu.Lock()
+		d.closed = true
+		d.mu.Unlock()
+		close(d.stop)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		d.workersWg.Wait()
+		d.retriesWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) Stats() Stats {
+	d.mu.RLock()
+	webhooks := len(d.webhooks)
+	d.mu.RUnlock()
+
+	return Stats{
+		Enqueued:  d.enqueued.Load(),
+		Delivered: d.delivered.Load(),
+		Retried:   d.retried.Load(),
+		Failed:    d.failed.Load(),
+		Dropped:   d.dropped.Load(),
+		Disabled:  d.disabled.Load(),
+		Queued:    len(d.queue),
+		Webhooks:  webhooks,
+	}
+}
@@ -0,0 +1,311 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type fakeStore struct {
+	mu         sync.Mutex
+	webhooks   []*orders.Webhook
+	deliveries []orders.WebhookDelivery
+	failures   map[int64]int
+	disabled   map[int64]string
+	reasons    []string
+	results    chan bool
+}
+
+func newFakeStore(webhooks ...*orders.Webhook) *fakeStore {
+	return &fakeStore{
+		webhooks: webhooks,
+		failures: map[int64]int{},
+		disabled: map[int64]string{},
+		results:  make(chan bool, 100),
+	}
+}
+
+func (s *fakeStore) GetActiveWebhooks(ctx context.Context) ([]*orders.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []*orders.Webhook{}
+	for _, w := range s.webhooks {
+		if _, ok := s.disabled[w.ID]; !ok {
+			list = append(list, w)
+		}
+	}
+	return list, nil
+}
+
+func (s *fakeStore) LogWebhookDelivery(ctx context.Context, d *orders.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, *d)
+	return nil
+}
+
+func (s *fakeStore) RecordWebhookResult(ctx context.Context, id int64, success bool, disableAfter int, reason string) (bool, error) {
+	s.mu.Lock()
+	defer func() {
+		s.mu.Unlock()
+		s.results <- success
+	}()
+	if success {
+		s.failures[id] = 0
+		return false, nil
+	}
+	s.failures[id]++
+	s.reasons = append(s.reasons, reason)
+	if _, ok := s.disabled[id]; !ok && disableAfter > 0 && s.failures[id] >= disableAfter {
+		s.disabled[id] = reason
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *fakeStore) waitResult(t *testing.T) bool {
+	t.Helper()
+	select {
+	case res := <-s.results:
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for delivery result")
+		return false
+	}
+}
+
+func newTestDispatcher(t *testing.T, store *fakeStore, cfg Config) *Dispatcher {
+	t.Helper()
+	if cfg.Backoff == 0 {
+		cfg.Backoff, cfg.MaxBackoff = time.Millisecond, 5*time.Millisecond
+	}
+	d := NewDispatcher(cfg, store, nil, logger.New(zap.NewNop(), "test"))
+	if err := d.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = d.Close(context.Background()) })
+	return d
+}
+
+var testEvent = orders.Event{
+	Type:            orders.EventOrderCreated,
+	OrderUID:        "o1",
+	CustomerID:      "c1",
+	DeliveryService: "meest",
+}
+
+func TestDispatcherSignedDelivery(t *testing.T) {
+	const secret = "whsec_test_secret_value"
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	store := newFakeStore(
+		&orders.Webhook{ID: 1, URL: srv.URL, Secret: secret},
+		&orders.Webhook{ID: 2, URL: srv.URL, Secret: secret, Filter: orders.EventFilter{DeliveryServices: []string{"cdek"}}},
+	)
+	d := newTestDispatcher(t, store, Config{})
+
+	if err := d.Publish(context.Background(), testEvent); err != nil {
+		t.Fatal(err)
+	}
+	if !store.waitResult(t) {
+		t.Fatal("expected successful delivery")
+	}
+
+	r, body := <-received, <-bodies
+	if r.Header.Get(HeaderEvent) != string(orders.EventOrderCreated) || r.Header.Get(HeaderDelivery) == "" {
+		t.Errorf("unexpected headers: %v", r.Header)
+	}
+	if err := Verify(secret, r.Header.Get(HeaderSignature), body, time.Now(), time.Minute); err != nil {
+		t.Errorf("signature must be valid: %v", err)
+	}
+	if err := Verify("other secret", r.Header.Get(HeaderSignature), body, time.Now(), time.Minute); err == nil {
+		t.Error("signature must not match another secret")
+	}
+	if err := Verify(secret, r.Header.Get(HeaderSignature), append(body, ' '), time.Now(), time.Minute); err == nil {
+		t.Error("signature must not match modified body")
+	}
+
+	if st := d.Stats(); st.Enqueued != 1 || st.Delivered != 1 {
+		t.Errorf("filtered subscription must not receive the event, stats %+v", st)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	store := newFakeStore(&orders.Webhook{ID: 1, URL: srv.URL, Secret: "s"})
+	d := newTestDispatcher(t, store, Config{MaxAttempts: 5})
+
+	_ = d.Publish(context.Background(), testEvent)
+	if !store.waitResult(t) {
+		t.Fatal("expected delivery to succeed after retries")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.deliveries) != 3 {
+		t.Fatalf("expected every attempt to be logged, got %d", len(store.deliveries))
+	}
+	for i, entry := range store.deliveries {
+		if entry.Attempt != i+1 || entry.DeliveryID != store.deliveries[0].DeliveryID || entry.OrderUID != "o1" {
+			t.Errorf("unexpected log entry %d: %+v", i, entry)
+		}
+	}
+	if first, last := store.deliveries[0], store.deliveries[2]; first.Success || first.StatusCode != 503 || first.Error == "" ||
+		!last.Success || last.StatusCode != 200 {
+		t.Errorf("unexpected attempts: %+v, %+v", first, last)
+	}
+}
+
+func TestDispatcherRetryDoesNotBlockWorkers(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+
+	store := newFakeStore(
+		&orders.Webhook{ID: 1, URL: failing.URL, Secret: "s"},
+		&orders.Webhook{ID: 2, URL: ok.URL, Secret: "s"},
+	)
+	d := newTestDispatcher(t, store, Config{Workers: 1, MaxAttempts: 5, Backoff: time.Minute, MaxBackoff: time.Minute})
+
+	_ = d.Publish(context.Background(), testEvent)
+	select {
+	case res := <-store.results:
+		if !res {
+			t.Fatal("expected successful delivery to the second subscription")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("retry backoff must not block delivery to other subscriptions")
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- d.Close(context.Background()) }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close must cancel pending retries")
+	}
+	if st := d.Stats(); st.Retried != 0 || st.Delivered != 1 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestDispatcherClientErrorNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	store := newFakeStore(&orders.Webhook{ID: 1, URL: srv.URL, Secret: "s"})
+	d := newTestDispatcher(t, store, Config{MaxAttempts: 5})
+
+	_ = d.Publish(context.Background(), testEvent)
+	if store.waitResult(t) {
+		t.Fatal("expected failed delivery")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("4xx must not be retried, got %d attempts", calls.Load())
+	}
+	if st := d.Stats(); st.Failed != 1 || st.Retried != 0 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.reasons) != 1 || store.reasons[0] != "" {
+		t.Errorf("disable reason must not be built without DisableAfter, got %q", store.reasons)
+	}
+}
+
+func TestDispatcherDisablesFailingWebhook(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store := newFakeStore(&orders.Webhook{ID: 1, URL: srv.URL, Secret: "s"})
+	d := newTestDispatcher(t, store, Config{MaxAttempts: 2, DisableAfter: 2})
+
+	for i := 0; i < 2; i++ {
+		_ = d.Publish(context.Background(), testEvent)
+		if store.waitResult(t) {
+			t.Fatal("expected failed delivery")
+		}
+	}
+
+	store.mu.Lock()
+	reason := store.disabled[1]
+	store.mu.Unlock()
+	if reason == "" {
+		t.Fatal("expected webhook to be disabled")
+	}
+	if st := d.Stats(); st.Disabled != 1 || st.Webhooks != 0 {
+		t.Errorf("disabled webhook must be removed from dispatching, stats %+v", st)
+	}
+
+	_ = d.Publish(context.Background(), testEvent)
+	if calls.Load() != 4 || d.Stats().Enqueued != 2 {
+		t.Errorf("disabled webhook must not receive events, got %d calls", calls.Load())
+	}
+}
+
+func TestDispatcherClosed(t *testing.T) {
+	d := newTestDispatcher(t, newFakeStore(), Config{})
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Publish(context.Background(), testEvent); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestVerifyTolerance(t *testing.T) {
+	body := []byte(`{"type":"order.created"}`)
+	ts := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	header := Sign("secret", ts, body)
+
+	if err := Verify("secret", header, body, ts.Add(4*time.Minute), 5*time.Minute); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+	if err := Verify("secret", header, body, ts.Add(6*time.Minute), 5*time.Minute); err == nil {
+		t.Error("expected expired signature to be rejected")
+	}
+	if err := Verify("secret", "v1=abc", body, ts, 0); err == nil {
+		t.Error("expected header without timestamp to be rejected")
+	}
+}
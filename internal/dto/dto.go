@@ -10,6 +10,7 @@ type OrderDTO struct {
 	OrderUID    string `json:"order_uid"`
 	TrackNumber string `json:"track_number"`
 	Entry       string `json:"entry"`
+	Status      string `json:"status" example:"created"`
 
 	Delivery DeliveryDTO `json:"delivery"`
 	Payment  PaymentDTO  `json:"payment"`
@@ -23,6 +24,35 @@ type OrderDTO struct {
 	SmID              int        `json:"sm_id"`
 	DateCreated       *time.Time `json:"date_created"`
 	OofShard          string     `json:"oof_shard"`
+
+	Reporting *ReportingDTO `json:"reporting,omitempty"`
+	Fraud     *FraudDTO     `json:"fraud,omitempty"`
+}
+
+// FraudDTO - оценка риска заказа при приеме и сработавшие правила. Возвращается только с токеном администратора
+type FraudDTO struct {
+	Score int            `json:"score" example:"80"`
+	Rules []FraudRuleDTO `json:"rules"`
+}
+
+type FraudRuleDTO struct {
+	Rule   string `json:"rule" example:"duplicate_transaction"`
+	Score  int    `json:"score" example:"60"`
+	Detail string `json:"detail" example:"transaction is used by orders b563feb7b2b84b6test"`
+}
+
+// ReportingDTO - суммы оплаты, пересчитанные в валюту отчетности(reporting_currency): 1 валюта заказа = rate currency.
+// rate_date - дата использованного курса, отсутствует при совпадении валют
+type ReportingDTO struct {
+	Currency     string       `json:"currency" example:"EUR"`
+	Rate         string       `json:"rate" example:"0.0101"`
+	RateDate     string       `json:"rate_date,omitempty" example:"2026-10-18"`
+	Amount       orders.Money `json:"amount" swaggertype:"number" example:"18.35"`
+	DeliveryCost orders.Money `json:"delivery_cost" swaggertype:"number" example:"15.15"`
+	GoodsTotal   orders.Money `json:"goods_total" swaggertype:"number" example:"3.21"`
+	CustomFee    orders.Money `json:"custom_fee" swaggertype:"number" example:"0.00"`
+	Paid         orders.Money `json:"paid" swaggertype:"number" example:"18.35"`
+	Refunded     orders.Money `json:"refunded" swaggertype:"number" example:"0.00"`
 }
 
 type DeliveryDTO struct {
@@ -35,38 +65,260 @@ type DeliveryDTO struct {
 	Email   string `json:"email"`
 }
 
+// PaymentDTO - оплата; суммы передаются десятичными числами в основных единицах валюты Currency
 type PaymentDTO struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDT    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	Transaction  string       `json:"transaction"`
+	RequestID    string       `json:"request_id"`
+	Currency     string       `json:"currency"`
+	Provider     string       `json:"provider"`
+	Amount       orders.Money `json:"amount" swaggertype:"number" example:"1817.50"`
+	PaymentDT    int64        `json:"payment_dt"`
+	Bank         string       `json:"bank"`
+	DeliveryCost orders.Money `json:"delivery_cost" swaggertype:"number" example:"1500.00"`
+	GoodsTotal   orders.Money `json:"goods_total" swaggertype:"number" example:"317.50"`
+	CustomFee    orders.Money `json:"custom_fee" swaggertype:"number" example:"0.00"`
+	Paid         orders.Money `json:"paid" swaggertype:"number" example:"1817.50"`
+	Refunded     orders.Money `json:"refunded" swaggertype:"number" example:"0.00"`
 }
 
 type ItemDTO struct {
-	ChrtID      int    `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	Rid         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NmID        int    `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
+	ChrtID      int          `json:"chrt_id"`
+	TrackNumber string       `json:"track_number"`
+	Price       orders.Money `json:"price" swaggertype:"number" example:"453.00"`
+	Rid         string       `json:"rid"`
+	Name        string       `json:"name"`
+	Sale        int          `json:"sale"`
+	Size        string       `json:"size"`
+	TotalPrice  orders.Money `json:"total_price" swaggertype:"number" example:"317.10"`
+	NmID        int          `json:"nm_id"`
+	Brand       string       `json:"brand"`
+	Status      int          `json:"status"`
+	Cancelled   bool         `json:"cancelled"`
 }
 
+// OrdersResponse - страница заказов. Total равен -1, если подсчет не выполнялся(total=none)
 type OrdersResponse struct {
-	Orders []OrderDTO `json:"orders"`
-	Total  int        `json:"total"`
-	Page   int        `json:"page"`
-	Limit  int        `json:"limit"`
+	Orders         []OrderDTO `json:"orders"`
+	Total          int        `json:"total"`
+	TotalEstimated bool       `json:"total_estimated,omitempty"`
+	Page           int        `json:"page"`
+	Limit          int        `json:"limit"`
+	NextCursor     string     `json:"next_cursor,omitempty"`
+	PrevCursor     string     `json:"prev_cursor,omitempty"`
+}
+
+// OrdersLookupRequest - order_uids для POST /orders/lookup; пустые и повторяющиеся значения не учитываются
+type OrdersLookupRequest struct {
+	OrderUIDs []string `json:"order_uids" example:"b563feb7b2b84b6test,unknown"`
+}
+
+// OrdersLookupResponse - найденные заказы в порядке запроса и order_uids, которых нет
+type OrdersLookupResponse struct {
+	Orders  []OrderDTO `json:"orders"`
+	Missing []string   `json:"missing" example:"unknown"`
+}
+
+// TextSearchResponse - результаты полнотекстового поиска по убыванию релевантности.
+// Fuzzy - найдено нечетким поиском(опечатки), т.к. точных совпадений слов нет
+type TextSearchResponse struct {
+	Hits  []TextHitDTO `json:"hits"`
+	Total int          `json:"total"`
+	Fuzzy bool         `json:"fuzzy"`
+	Page  int          `json:"page"`
+	Limit int          `json:"limit"`
+}
+
+type TextHitDTO struct {
+	Order      OrderDTO       `json:"order"`
+	Rank       float64        `json:"rank"`
+	Highlights []HighlightDTO `json:"highlights"`
+}
+
+// HighlightDTO - совпавшее поле; совпадения во fragment обрамлены <mark></mark>, текст поля экранирован как HTML
+type HighlightDTO struct {
+	Field    string `json:"field" example:"items.brand"`
+	Fragment string `json:"fragment" example:"<mark>Vivienne</mark> Sabo"`
+}
+
+type OrderVersionDTO struct {
+	Version   int       `json:"version"`
+	Change    string    `json:"change" example:"order.status_changed"`
+	Source    string    `json:"source" example:"kafka"`
+	SourceRef string    `json:"source_ref,omitempty" example:"orders/0/42"`
+	Actor     string    `json:"actor,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Order     OrderDTO  `json:"order"`
+}
+
+type OrderHistoryResponse struct {
+	OrderUID string            `json:"order_uid"`
+	Versions []OrderVersionDTO `json:"versions"`
+}
+
+type RefundDTO struct {
+	ID          int          `json:"id"`
+	Transaction string       `json:"transaction"`
+	Amount      orders.Money `json:"amount" swaggertype:"number" example:"100.00"`
+	Reason      string       `json:"reason,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type ErasureRequest struct {
+	Reason string `json:"reason" example:"GDPR request #123"`
+}
+
+type ErasureDTO struct {
+	ID          int       `json:"id"`
+	CustomerRef string    `json:"customer_ref"`
+	OrderUIDs   []string  `json:"order_uids"`
+	Reason      string    `json:"reason,omitempty"`
+	Source      string    `json:"source"`
+	Actor       string    `json:"actor,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ChangeStatusRequest struct {
+	Status string `json:"status" example:"paid"`
+	Reason string `json:"reason" example:"payment confirmed"`
+}
+
+type CustomerSummaryDTO struct {
+	CustomerID      string              `json:"customer_id"`
+	OrdersCount     int                 `json:"orders_count"`
+	Spend           []CurrencyAmountDTO `json:"spend"`
+	FirstOrderAt    *time.Time          `json:"first_order_at"`
+	LastOrderAt     *time.Time          `json:"last_order_at"`
+	FavouriteBrands []BrandCountDTO     `json:"favourite_brands"`
+}
+
+type CurrencyAmountDTO struct {
+	Currency string       `json:"currency" example:"USD"`
+	Amount   orders.Money `json:"amount" swaggertype:"number" example:"1817.50"`
+}
+
+// OrderStatsResponse - статистика заказов за дни [date_from, date_to] в порядке периода и значений измерений
+type OrderStatsResponse struct {
+	GroupBy  []string        `json:"group_by" example:"month,currency"`
+	DateFrom string          `json:"date_from" example:"2026-01-01"`
+	DateTo   string          `json:"date_to" example:"2026-10-18"`
+	Groups   []StatsGroupDTO `json:"groups"`
+}
+
+// StatsGroupDTO - группа статистики: period - первый день периода, keys - значения остальных измерений.
+// items заполняется при группировке по брендам, возвраты по брендам не считаются
+type StatsGroupDTO struct {
+	Period    string              `json:"period,omitempty" example:"2026-10-01"`
+	Keys      map[string]string   `json:"keys,omitempty"`
+	Orders    int64               `json:"orders"`
+	Items     int64               `json:"items,omitempty"`
+	Revenue   []CurrencyAmountDTO `json:"revenue"`
+	Refunded  []CurrencyAmountDTO `json:"refunded"`
+	Reporting *StatsReportingDTO  `json:"reporting,omitempty"`
+}
+
+// StatsReportingDTO - выручка и возвраты группы в валюте отчетности по курсам каждого дня
+type StatsReportingDTO struct {
+	Currency string       `json:"currency" example:"EUR"`
+	Revenue  orders.Money `json:"revenue" swaggertype:"number" example:"15230.40"`
+	Refunded orders.Money `json:"refunded" swaggertype:"number" example:"120.00"`
+}
+
+// WebhookRequest - подписка на события заказов. Пустой список фильтра - без ограничения по полю.
+// Если secret не передан при создании, он генерируется; при изменении пустой secret оставляет прежний
+type WebhookRequest struct {
+	URL              string   `json:"url" example:"https://partner.example.com/hooks/orders"`
+	Secret           string   `json:"secret,omitempty" example:"whsec_0123456789abcdef"`
+	EventTypes       []string `json:"event_types,omitempty" example:"order.created,order.status_changed"`
+	DeliveryServices []string `json:"delivery_services,omitempty" example:"meest"`
+	CustomerIDs      []string `json:"customer_ids,omitempty" example:"test"`
+	Active           *bool    `json:"active,omitempty"`
+}
+
+// WebhookDTO - подписка; secret возвращается только при создании и замене секрета
+type WebhookDTO struct {
+	ID               int64      `json:"id" example:"1"`
+	URL              string     `json:"url" example:"https://partner.example.com/hooks/orders"`
+	Secret           string     `json:"secret,omitempty" example:"whsec_0123456789abcdef"`
+	EventTypes       []string   `json:"event_types"`
+	DeliveryServices []string   `json:"delivery_services"`
+	CustomerIDs      []string   `json:"customer_ids"`
+	Active           bool       `json:"active"`
+	Failures         int        `json:"failures"`
+	DisabledReason   string     `json:"disabled_reason,omitempty" example:"10 consecutive failed deliveries"`
+	DisabledAt       *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// WebhookDeliveryDTO - попытка доставки события; delivery_id общий для всех попыток одного события
+type WebhookDeliveryDTO struct {
+	ID         int64     `json:"id"`
+	DeliveryID string    `json:"delivery_id" example:"6f1c2b9e-3d4a-4f8e-9a51-0c7d2e8b1f44"`
+	EventType  string    `json:"event_type" example:"order.created"`
+	OrderUID   string    `json:"order_uid" example:"b563feb7b2b84b6test"`
+	Attempt    int       `json:"attempt" example:"1"`
+	StatusCode int       `json:"status_code,omitempty" example:"200"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms" example:"84"`
+	Success    bool      `json:"success"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StreamOrderDTO - данные события потока /orders/stream(поле data) и обновления WebSocket /orders/ws.
+// amount - сумма заказа, для order.refunded - сумма возврата
+type StreamOrderDTO struct {
+	Type            string        `json:"type" example:"order.created"`
+	OrderUID        string        `json:"order_uid" example:"b563feb7b2b84b6test"`
+	TrackNumber     string        `json:"track_number,omitempty" example:"WBILMTESTTRACK"`
+	CustomerID      string        `json:"customer_id,omitempty" example:"test"`
+	DeliveryService string        `json:"delivery_service,omitempty" example:"meest"`
+	From            string        `json:"from,omitempty" example:"created"`
+	Status          string        `json:"status,omitempty" example:"paid"`
+	Reason          string        `json:"reason,omitempty"`
+	Amount          *orders.Money `json:"amount,omitempty" swaggertype:"number" example:"1817.50"`
+	Currency        string        `json:"currency,omitempty" example:"USD"`
+	OccurredAt      time.Time     `json:"occurred_at"`
+}
+
+// WSClientMessage - команда клиента WebSocket /orders/ws: action subscribe или unsubscribe
+type WSClientMessage struct {
+	Action    string   `json:"action" example:"subscribe"`
+	OrderUIDs []string `json:"order_uids" example:"b563feb7b2b84b6test"`
+}
+
+// WSServerMessage - сообщение сервера WebSocket /orders/ws. type:
+// order - текущее состояние заказа при подписке, update - событие заказа(event_id - номер события потока),
+// subscribed/unsubscribed - заказы, на которые подписан клиент после команды, error - ошибка команды
+type WSServerMessage struct {
+	Type      string          `json:"type" example:"update"`
+	OrderUIDs []string        `json:"order_uids,omitempty"`
+	Order     *OrderDTO       `json:"order,omitempty"`
+	EventID   uint64          `json:"event_id,omitempty"`
+	Event     *StreamOrderDTO `json:"event,omitempty"`
+	Message   string          `json:"message,omitempty"`
+}
+
+// GraphQLRequest - запрос /graphql(POST в теле JSON; GET - параметрами query, variables и operationName)
+type GraphQLRequest struct {
+	Query         string         `json:"query" example:"{ order(order_uid: \"b563feb7b2b84b6test\") { track_number items { status } } }"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+}
+
+// GraphQLResponse - результат /graphql: data с запрошенными полями и errors при ошибках разбора, лимитов или полей
+type GraphQLResponse struct {
+	Data   any            `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message string `json:"message" example:"query depth 8 exceeds limit 6"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+type BrandCountDTO struct {
+	Brand string `json:"brand" example:"Vivienne Sabo"`
+	Items int    `json:"items"`
 }
 
 type ErrorResponse struct {
@@ -90,15 +342,113 @@ func ItemsToDTO(items []orders.Item) []ItemDTO {
 			NmID:        i.NmID,
 			Brand:       i.Brand,
 			Status:      i.Status,
+			Cancelled:   i.Cancelled,
 		})
 	}
 	return result
 }
+
+func ErasureToDTO(e *orders.Erasure) ErasureDTO {
+	return ErasureDTO{
+		ID:          e.ID,
+		CustomerRef: e.CustomerRef,
+		OrderUIDs:   e.OrderUIDs,
+		Reason:      e.Reason,
+		Source:      e.Source.Kind,
+		Actor:       e.Source.Actor,
+		CreatedAt:   e.CreatedAt,
+	}
+}
+
+func VersionToDTO(v orders.OrderVersion) OrderVersionDTO {
+	return OrderVersionDTO{
+		Version:   v.Version,
+		Change:    string(v.Change),
+		Source:    v.Source.Kind,
+		SourceRef: v.Source.Ref,
+		Actor:     v.Source.Actor,
+		CreatedAt: v.CreatedAt,
+		Order:     OrderToDTO(v.Order),
+	}
+}
+
+func CustomerSummaryToDTO(cs *orders.CustomerSummary) CustomerSummaryDTO {
+	resp := CustomerSummaryDTO{
+		CustomerID:      cs.CustomerID,
+		OrdersCount:     cs.OrdersCount,
+		Spend:           make([]CurrencyAmountDTO, 0, len(cs.Spend)),
+		FirstOrderAt:    cs.FirstOrderAt,
+		LastOrderAt:     cs.LastOrderAt,
+		FavouriteBrands: make([]BrandCountDTO, 0, len(cs.FavouriteBrands)),
+	}
+	for _, ca := range cs.Spend {
+		resp.Spend = append(resp.Spend, CurrencyAmountDTO(ca))
+	}
+	for _, bc := range cs.FavouriteBrands {
+		resp.FavouriteBrands = append(resp.FavouriteBrands, BrandCountDTO(bc))
+	}
+	return resp
+}
+
+func OrderStatsToDTO(res *orders.StatsResult) OrderStatsResponse {
+	resp := OrderStatsResponse{
+		GroupBy:  make([]string, 0, len(res.GroupBy)),
+		DateFrom: res.DateFrom.Format(time.DateOnly),
+		DateTo:   res.DateTo.Format(time.DateOnly),
+		Groups:   make([]StatsGroupDTO, 0, len(res.Groups)),
+	}
+	for _, d := range res.GroupBy {
+		resp.GroupBy = append(resp.GroupBy, string(d))
+	}
+
+	for _, g := range res.Groups {
+		group := StatsGroupDTO{
+			Orders:   g.Orders,
+			Items:    g.Items,
+			Revenue:  make([]CurrencyAmountDTO, 0, len(g.Revenue)),
+			Refunded: make([]CurrencyAmountDTO, 0, len(g.Refunded)),
+		}
+		if g.Period != nil {
+			group.Period = g.Period.Format(time.DateOnly)
+		}
+		if len(g.Keys) > 0 {
+			group.Keys = make(map[string]string, len(g.Keys))
+			for d, v := range g.Keys {
+				group.Keys[string(d)] = v
+			}
+		}
+		for _, ca := range g.Revenue {
+			group.Revenue = append(group.Revenue, CurrencyAmountDTO(ca))
+		}
+		for _, ca := range g.Refunded {
+			group.Refunded = append(group.Refunded, CurrencyAmountDTO(ca))
+		}
+		if g.Reporting != nil {
+			group.Reporting = &StatsReportingDTO{
+				Currency: g.Reporting.Currency,
+				Revenue:  g.Reporting.Revenue,
+				Refunded: g.Reporting.Refunded,
+			}
+		}
+		resp.Groups = append(resp.Groups, group)
+	}
+	return resp
+}
+
+func OrdersToDTO(list []*orders.Order) []OrderDTO {
+	result := make([]OrderDTO, 0, len(list))
+	for _, o := range list {
+		result = append(result, OrderToDTO(o))
+	}
+	return result
+}
+
 func OrderToDTO(o *orders.Order) OrderDTO {
 	return OrderDTO{
 		OrderUID:          o.OrderUID,
 		TrackNumber:       o.TrackNumber,
 		Entry:             o.Entry,
+		Status:            string(o.Status),
 		Delivery:          DeliveryDTO(o.Delivery),
 		Payment:           PaymentDTO(o.Payment),
 		Items:             ItemsToDTO(o.Items),
@@ -112,3 +462,126 @@ func OrderToDTO(o *orders.Order) OrderDTO {
 		OofShard:          o.OofShard,
 	}
 }
+
+func FraudToDTO(f *orders.FraudScore) *FraudDTO {
+	if f == nil {
+		return nil
+	}
+	resp := &FraudDTO{Score: f.Score, Rules: make([]FraudRuleDTO, 0, len(f.Rules))}
+	for _, hit := range f.Rules {
+		resp.Rules = append(resp.Rules, FraudRuleDTO{Rule: string(hit.Rule), Score: hit.Score, Detail: hit.Detail})
+	}
+	return resp
+}
+
+func ReportingToDTO(c *orders.ConvertedPayment) *ReportingDTO {
+	if c == nil {
+		return nil
+	}
+	resp := &ReportingDTO{
+		Currency:     c.To,
+		Rate:         orders.RateString(c.Rate),
+		Amount:       c.Amount,
+		DeliveryCost: c.DeliveryCost,
+		GoodsTotal:   c.GoodsTotal,
+		CustomFee:    c.CustomFee,
+		Paid:         c.Paid,
+		Refunded:     c.Refunded,
+	}
+	if !c.RateDate.IsZero() {
+		resp.RateDate = c.RateDate.Format(time.DateOnly)
+	}
+	return resp
+}
+
+func TextSearchToDTO(res *orders.TextSearchResult) []TextHitDTO {
+	hits := make([]TextHitDTO, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		highlights := make([]HighlightDTO, 0, len(hit.Highlights))
+		for _, hl := range hit.Highlights {
+			highlights = append(highlights, HighlightDTO{Field: hl.Field, Fragment: hl.Fragment})
+		}
+		hits = append(hits, TextHitDTO{
+			Order:      OrderToDTO(hit.Order),
+			Rank:       hit.Rank,
+			Highlights: highlights,
+		})
+	}
+	return hits
+}
+
+func (req WebhookRequest) ToWebhook() *orders.Webhook {
+	w := &orders.Webhook{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Filter: orders.EventFilter{
+			DeliveryServices: req.DeliveryServices,
+			CustomerIDs:      req.CustomerIDs,
+		},
+		Active: req.Active == nil || *req.Active,
+	}
+	for _, t := range req.EventTypes {
+		w.Filter.EventTypes = append(w.Filter.EventTypes, orders.EventType(t))
+	}
+	return w
+}
+
+func WebhookToDTO(w *orders.Webhook, withSecret bool) WebhookDTO {
+	resp := WebhookDTO{
+		ID:               w.ID,
+		URL:              w.URL,
+		EventTypes:       make([]string, 0, len(w.Filter.EventTypes)),
+		DeliveryServices: w.Filter.DeliveryServices,
+		CustomerIDs:      w.Filter.CustomerIDs,
+		Active:           w.Active,
+		Failures:         w.Failures,
+		DisabledReason:   w.DisabledReason,
+		DisabledAt:       w.DisabledAt,
+		CreatedAt:        w.CreatedAt,
+		UpdatedAt:        w.UpdatedAt,
+	}
+	if withSecret {
+		resp.Secret = w.Secret
+	}
+	for _, t := range w.Filter.EventTypes {
+		resp.EventTypes = append(resp.EventTypes, string(t))
+	}
+	if resp.DeliveryServices == nil {
+		resp.DeliveryServices = []string{}
+	}
+	if resp.CustomerIDs == nil {
+		resp.CustomerIDs = []string{}
+	}
+	return resp
+}
+
+func WebhookDeliveryToDTO(d orders.WebhookDelivery) WebhookDeliveryDTO {
+	return WebhookDeliveryDTO{
+		ID:         d.ID,
+		DeliveryID: d.DeliveryID,
+		EventType:  string(d.EventType),
+		OrderUID:   d.OrderUID,
+		Attempt:    d.Attempt,
+		StatusCode: d.StatusCode,
+		Error:      d.Error,
+		DurationMs: d.Duration.Milliseconds(),
+		Success:    d.Success,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+func StreamOrderToDTO(e orders.Event) StreamOrderDTO {
+	return StreamOrderDTO{
+		Type:            string(e.Type),
+		OrderUID:        e.OrderUID,
+		TrackNumber:     e.TrackNumber,
+		CustomerID:      e.CustomerID,
+		DeliveryService: e.DeliveryService,
+		From:            string(e.From),
+		Status:          string(e.To),
+		Reason:          e.Reason,
+		Amount:          e.Amount,
+		Currency:        e.Currency,
+		OccurredAt:      e.OccurredAt,
+	}
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"wb_tech_level_zero/internal/orders"
+)
+
+// Sink - получатель событий заказов(Kafka, вебхуки и т.п.)
+type Sink interface {
+	Publish(ctx context.Context, event orders.Event) error
+}
+
+// Bus рассылает событие всем зарегистрированным получателям
+type Bus struct {
+	mu    sync.RWMutex
+	sinks []Sink
+}
+
+func NewBus(sinks ...Sink) *Bus {
+	return &Bus{sinks: sinks}
+}
+
+func (b *Bus) AddSink(sink Sink) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sinks = append(b.sinks, sink)
+}
+
+func (b *Bus) Publish(ctx context.Context, event orders.Event) error {
+	b.mu.RLock()
+	sinks := b.sinks
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Filter передает получателю sink только события, для которых match возвращает true
+func Filter(sink Sink, match func(orders.Event) bool) Sink {
+	return filterSink{sink: sink, match: match}
+}
+
+type filterSink struct {
+	sink  Sink
+	match func(orders.Event) bool
+}
+
+func (f filterSink) Publish(ctx context.Context, event orders.Event) error {
+	if !f.match(event) {
+		return nil
+	}
+	return f.sink.Publish(ctx, event)
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 
 	"wb_tech_level_zero/internal/config"
+	graphqlapi "wb_tech_level_zero/internal/delivery/graphql"
 	httpapi "wb_tech_level_zero/internal/delivery/http"
 	"wb_tech_level_zero/pkg/logger"
 
@@ -21,11 +22,16 @@ type Server struct {
 	ordersHandler *httpapi.Handlers
 }
 
-func NewServer(ctx context.Context, cfg *config.Config, orderService httpapi.OrdersService) (*Server, error) {
+func NewServer(ctx context.Context, cfg *config.Config, orderService httpapi.OrdersService, orderStream httpapi.OrderStream) (*Server, error) {
 
-	ordersHandler := httpapi.NewHandlers(cfg, orderService)
+	ordersHandler := httpapi.NewHandlers(cfg, orderService, orderStream)
 
-	r := NewRouter(ctx, ordersHandler)
+	graphqlHandler, err := graphqlapi.NewHandler(cfg, orderService)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewRouter(ctx, cfg, ordersHandler, graphqlHandler)
 
 	httpServer := &http.Server{
 		Addr:    cfg.HTTPServerAddress + ":" + strconv.Itoa(cfg.HTTPServerPort),
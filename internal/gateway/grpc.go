@@ -0,0 +1,202 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	ordersv1 "wb_tech_level_zero/api/orders/v1"
+	"wb_tech_level_zero/internal/config"
+	grpcapi "wb_tech_level_zero/internal/delivery/grpc"
+	"wb_tech_level_zero/pkg/logger"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader - метаданные с ID запроса: принимается от клиента и возвращается в заголовках ответа
+const RequestIDHeader = "x-request-id"
+
+type GRPCServer struct {
+	cfg    *config.Config
+	server *grpc.Server
+	health *health.Server
+}
+
+func NewGRPCServer(cfg *config.Config, orderService grpcapi.OrdersService, orderStream grpcapi.OrderStream, log logger.Logger) *GRPCServer {
+	tokens := grpcTokens(cfg)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryRequestContextInterceptor(log), unaryAuthInterceptor(tokens)),
+		grpc.ChainStreamInterceptor(streamRequestContextInterceptor(log), streamAuthInterceptor(tokens)),
+	)
+	ordersv1.RegisterOrdersServiceServer(server, grpcapi.NewHandlers(cfg, orderService, orderStream))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(ordersv1.OrdersService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	// описание сервисов для grpcurl и других клиентов без .proto
+	if cfg.GRPCReflection {
+		reflection.Register(server)
+	}
+
+	return &GRPCServer{cfg: cfg, server: server, health: healthServer}
+}
+
+func (g *GRPCServer) Run(ctx context.Context) error {
+	log := logger.GetLoggerFromCtx(ctx)
+	log.Info(ctx, "gRPC server is starting to listen")
+
+	lis, err := net.Listen("tcp", g.cfg.GRPCServerAddress+":"+strconv.Itoa(g.cfg.GRPCServerPort))
+	if err != nil {
+		return fmt.Errorf("grpc server listen error: %w", err)
+	}
+	return g.Serve(lis)
+}
+
+func (g *GRPCServer) Serve(lis net.Listener) error {
+	if err := g.server.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+		return fmt.Errorf("grpc server Serve error: %w", err)
+	}
+	return nil
+}
+
+// Shutdown переводит health в NOT_SERVING и дожидается завершения запросов; по истечении ctx
+// оставшиеся запросы(в том числе потоки WatchOrders) прерываются
+func (g *GRPCServer) Shutdown(ctx context.Context) error {
+	log := logger.GetLoggerFromCtx(ctx)
+	log.Info(ctx, "gRPC server shutdown process initiated")
+
+	g.health.Shutdown()
+	done := make(chan struct{})
+	go func() {
+		g.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		g.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// requestContext добавляет в контекст запроса логгер и ID запроса: из метаданных x-request-id
+// или новый. ID возвращается клиенту в заголовке ответа x-request-id
+func requestContext(ctx context.Context, log logger.Logger, method string) context.Context {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(RequestIDHeader); len(v) > 0 && len(v[0]) <= 128 {
+			requestID = v[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	ctx = logger.ContextWithLogger(context.WithValue(ctx, logger.RequestIDKey, requestID), log)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+	fields := []zap.Field{zap.String("method", method)}
+	if p, ok := peer.FromContext(ctx); ok {
+		fields = append(fields, zap.String("remote_addr", p.Addr.String()))
+	}
+	log.Info(ctx, "Incoming gRPC request", fields...)
+	return ctx
+}
+
+func logRequestDone(ctx context.Context, log logger.Logger, method string, start time.Time, err error) {
+	log.Info(ctx, "gRPC request finished",
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+func unaryRequestContextInterceptor(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = requestContext(ctx, log, info.FullMethod)
+		resp, err := handler(ctx, req)
+		logRequestDone(ctx, log, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func streamRequestContextInterceptor(log logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := requestContext(ss.Context(), log, info.FullMethod)
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		logRequestDone(ctx, log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// grpcTokens - токены доступа к gRPC API: GRPC_AUTH_TOKENS и ADMIN_TOKEN
+func grpcTokens(cfg *config.Config) []string {
+	var tokens []string
+	for _, t := range append(slices.Clone(cfg.GRPCAuthTokens), cfg.AdminToken) {
+		if t = strings.TrimSpace(t); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// authorize проверяет токен в метаданных "authorization: Bearer <token>". Health доступен без токена
+// для проверок оркестратора; без настроенных токенов API отключен
+func authorize(ctx context.Context, method string, tokens []string) error {
+	if strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+		return nil
+	}
+	if len(tokens) == 0 {
+		return status.Error(codes.PermissionDenied, "gRPC API is disabled")
+	}
+	if !grpcapi.Authorized(ctx, tokens...) {
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	return nil
+}
+
+func unaryAuthInterceptor(tokens []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, info.FullMethod, tokens); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuthInterceptor(tokens []string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), info.FullMethod, tokens); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// contextStream подменяет контекст потока контекстом с логгером и ID запроса
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -2,11 +2,18 @@ package gateway
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 
+	"wb_tech_level_zero/internal/config"
+	graphqlapi "wb_tech_level_zero/internal/delivery/graphql"
 	httpapi "wb_tech_level_zero/internal/delivery/http"
+	"wb_tech_level_zero/internal/dto"
 	"wb_tech_level_zero/pkg/logger"
 
 	"go.uber.org/zap"
@@ -15,7 +22,7 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func NewRouter(ctx context.Context, ordersHandler *httpapi.Handlers) *mux.Router {
+func NewRouter(ctx context.Context, cfg *config.Config, ordersHandler *httpapi.Handlers, graphqlHandler *graphqlapi.Handler) *mux.Router {
 
 	r := mux.NewRouter()
 	r.Use(requestContextMiddleware)
@@ -23,7 +30,35 @@ func NewRouter(ctx context.Context, ordersHandler *httpapi.Handlers) *mux.Router
 
 	// - - - - ORDERS
 	r.HandleFunc("/order/{order_uid}", ordersHandler.GetOrderByUID).Methods(http.MethodGet)
+	r.HandleFunc("/order/{order_uid}/history", ordersHandler.GetOrderHistory).Methods(http.MethodGet)
+	r.HandleFunc("/order/{order_uid}/refunds", ordersHandler.GetOrderRefunds).Methods(http.MethodGet)
 	r.HandleFunc("/orders", ordersHandler.GetOrders).Methods(http.MethodGet)
+	r.HandleFunc("/orders/lookup", ordersHandler.LookupOrders).Methods(http.MethodPost)
+	r.HandleFunc("/orders/search", ordersHandler.SearchOrdersText).Methods(http.MethodGet)
+	r.HandleFunc("/orders/stream", ordersHandler.StreamOrders).Methods(http.MethodGet)
+	r.HandleFunc("/orders/ws", ordersHandler.OrdersWebSocket).Methods(http.MethodGet)
+
+	// - - - - GRAPHQL
+	r.Handle("/graphql", graphqlHandler).Methods(http.MethodGet, http.MethodPost)
+
+	// - - - - STATS
+	r.HandleFunc("/stats/orders", ordersHandler.GetOrderStats).Methods(http.MethodGet)
+
+	// - - - - ADMIN
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(adminAuthMiddleware(cfg.AdminToken))
+	admin.HandleFunc("/orders/search", ordersHandler.SearchOrders).Methods(http.MethodGet)
+	admin.HandleFunc("/orders/{order_uid}/status", ordersHandler.ChangeOrderStatus).Methods(http.MethodPatch)
+	admin.HandleFunc("/customers/{customer_id}/orders", ordersHandler.GetCustomerOrders).Methods(http.MethodGet)
+	admin.HandleFunc("/customers/{customer_id}/summary", ordersHandler.GetCustomerSummary).Methods(http.MethodGet)
+	admin.HandleFunc("/customers/{customer_id}/erase", ordersHandler.ForgetCustomer).Methods(http.MethodPost)
+	admin.HandleFunc("/webhooks", ordersHandler.CreateWebhook).Methods(http.MethodPost)
+	admin.HandleFunc("/webhooks", ordersHandler.GetWebhooks).Methods(http.MethodGet)
+	admin.HandleFunc("/webhooks/{id}", ordersHandler.GetWebhook).Methods(http.MethodGet)
+	admin.HandleFunc("/webhooks/{id}", ordersHandler.UpdateWebhook).Methods(http.MethodPut)
+	admin.HandleFunc("/webhooks/{id}", ordersHandler.DeleteWebhook).Methods(http.MethodDelete)
+	admin.HandleFunc("/webhooks/{id}/deliveries", ordersHandler.GetWebhookDeliveries).Methods(http.MethodGet)
+	admin.Handle("/debug/vars", expvar.Handler()).Methods(http.MethodGet)
 
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
@@ -33,7 +68,7 @@ func NewRouter(ctx context.Context, ordersHandler *httpapi.Handlers) *mux.Router
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
@@ -43,6 +78,32 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// adminAuthMiddleware пропускает запросы с заголовком "Authorization: Bearer <ADMIN_TOKEN>".
+// Если токен не задан, административные ручки отключены
+func adminAuthMiddleware(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				writeAuthError(w, http.StatusForbidden, "Admin API is disabled")
+				return
+			}
+
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				writeAuthError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeAuthError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(dto.ErrorResponse{Message: message})
+}
+
 func requestContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
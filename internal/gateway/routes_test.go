@@ -0,0 +1,44 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wb_tech_level_zero/internal/config"
+	httpapi "wb_tech_level_zero/internal/delivery/http"
+)
+
+func TestChangeOrderStatusRequiresAdmin(t *testing.T) {
+	cfg := &config.Config{AdminToken: "secret"}
+	router := NewRouter(context.Background(), cfg, httpapi.NewHandlers(cfg, nil, nil), nil)
+
+	cases := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"public route is gone", "/order/o1/status", "", http.StatusNotFound},
+		{"no token", "/admin/orders/o1/status", "", http.StatusUnauthorized},
+		{"wrong token", "/admin/orders/o1/status", "wrong", http.StatusUnauthorized},
+		// с токеном запрос доходит до обработчика и отклоняется уже проверкой статуса
+		{"admin token", "/admin/orders/o1/status", "secret", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, tc.path, strings.NewReader(`{"status":"unknown"}`))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, rr.Code)
+			}
+		})
+	}
+}
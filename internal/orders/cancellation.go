@@ -0,0 +1,146 @@
+package orders
+
+import (
+	"fmt"
+	"time"
+)
+
+// ItemRef ссылается на позицию заказа по Rid и/или ChrtID; незаполненное поле не участвует в сравнении
+type ItemRef struct {
+	Rid    string `json:"rid,omitempty"`
+	ChrtID int    `json:"chrt_id,omitempty"`
+}
+
+func (r ItemRef) Matches(it Item) bool {
+	if r.Rid == "" && r.ChrtID == 0 {
+		return false
+	}
+	if r.Rid != "" && r.Rid != it.Rid {
+		return false
+	}
+	if r.ChrtID != 0 && r.ChrtID != it.ChrtID {
+		return false
+	}
+	return true
+}
+
+type Refund struct {
+	ID          int       `db:"id"`
+	Transaction string    `db:"transaction"`
+	Amount      Money     `db:"amount"`
+	Reason      string    `db:"reason"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// CancelItems отменяет указанные позиции(пустой refs - все позиции) и пересчитывает суммы заказа.
+// Если после отмены активных позиций не осталось, заказ переходит в статус cancelled.
+func (o *Order) CancelItems(refs []ItemRef) error {
+	if !o.Status.CanTransitionTo(StatusCancelled) {
+		return &TransitionError{From: o.Status, To: StatusCancelled}
+	}
+
+	if len(refs) == 0 {
+		for i := range o.Items {
+			o.Items[i].Cancelled = true
+		}
+	} else {
+		for _, ref := range refs {
+			idx := o.findItem(ref)
+			if idx < 0 {
+				return fmt.Errorf("%w: rid=%q chrt_id=%d", ErrItemNotFound, ref.Rid, ref.ChrtID)
+			}
+			if o.Items[idx].Cancelled {
+				return fmt.Errorf("%w: rid=%q chrt_id=%d", ErrItemAlreadyCancelled, ref.Rid, ref.ChrtID)
+			}
+			o.Items[idx].Cancelled = true
+		}
+	}
+
+	if err := o.Recalculate(); err != nil {
+		return err
+	}
+	if o.activeItems() == 0 {
+		o.Status = StatusCancelled
+	}
+	return nil
+}
+
+// Recalculate пересчитывает стоимость товаров и итоговую сумму по неотмененным позициям.
+// Полностью отмененный заказ не оплачивается, включая доставку.
+func (o *Order) Recalculate() error {
+	zero := MoneyFromMajor(0, o.Payment.Currency)
+	goods := zero
+	for _, it := range o.Items {
+		if it.Cancelled {
+			continue
+		}
+		var err error
+		if goods, err = goods.Add(it.TotalPrice); err != nil {
+			return err
+		}
+	}
+
+	o.Payment.GoodsTotal = goods
+	if o.activeItems() == 0 {
+		o.Payment.Amount = zero
+		return nil
+	}
+
+	amount := goods
+	for _, m := range []Money{o.Payment.DeliveryCost, o.Payment.CustomFee} {
+		var err error
+		if amount, err = amount.Add(m); err != nil {
+			return err
+		}
+	}
+	o.Payment.Amount = amount
+	return nil
+}
+
+// findItem возвращает первую неотмененную позицию по ссылке; если все подходящие позиции отменены - первую из них,
+// чтобы повторная отмена вернула ErrItemAlreadyCancelled
+func (o *Order) findItem(ref ItemRef) int {
+	found := -1
+	for i, it := range o.Items {
+		if !ref.Matches(it) {
+			continue
+		}
+		if !it.Cancelled {
+			return i
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	return found
+}
+
+func (o *Order) activeItems() int {
+	n := 0
+	for _, it := range o.Items {
+		if !it.Cancelled {
+			n++
+		}
+	}
+	return n
+}
+
+// CheckRefund проверяет, что возврат относится к платежу заказа и вместе с предыдущими возвратами не превышает оплаченную сумму
+func (p Payment) CheckRefund(r Refund) error {
+	if r.Amount.Sign() <= 0 {
+		return ErrInvalidRefundAmount
+	}
+	if r.Transaction != "" && r.Transaction != p.Transaction {
+		return ErrTransactionMismatch
+	}
+	refunded, err := p.Refunded.Add(r.Amount)
+	if err != nil {
+		return err
+	}
+	if c, err := refunded.Cmp(p.Paid); err != nil {
+		return err
+	} else if c > 0 {
+		return fmt.Errorf("%w: paid %s, refunded %s, requested %s", ErrRefundExceedsPaid, p.Paid, p.Refunded, r.Amount)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+)
+
+func usd(amount int64) Money {
+	return MoneyFromMajor(amount, "USD")
+}
+
+func newTestOrder() *Order {
+	return &Order{
+		Status: StatusPaid,
+		Payment: Payment{
+			Transaction:  "tx1",
+			Currency:     "USD",
+			Amount:       usd(1800),
+			DeliveryCost: usd(1500),
+			GoodsTotal:   usd(300),
+			Paid:         usd(1800),
+		},
+		Items: []Item{
+			{Rid: "r1", ChrtID: 1, TotalPrice: usd(100)},
+			{Rid: "r2", ChrtID: 2, TotalPrice: usd(200)},
+		},
+	}
+}
+
+func TestCancelItems(t *testing.T) {
+	t.Run("partial cancellation recalculates totals", func(t *testing.T) {
+		o := newTestOrder()
+
+		if err := o.CancelItems([]ItemRef{{Rid: "r2"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !o.Items[1].Cancelled || o.Items[0].Cancelled {
+			t.Errorf("unexpected cancelled flags: %+v", o.Items)
+		}
+		if !o.Payment.GoodsTotal.Equal(usd(100)) || !o.Payment.Amount.Equal(usd(1600)) {
+			t.Errorf("expected goods 100 / amount 1600, got %s / %s", o.Payment.GoodsTotal, o.Payment.Amount)
+		}
+		if o.Status != StatusPaid {
+			t.Errorf("expected status to stay paid, got %q", o.Status)
+		}
+	})
+
+	t.Run("cancelling last item cancels order", func(t *testing.T) {
+		o := newTestOrder()
+
+		if err := o.CancelItems([]ItemRef{{ChrtID: 1}, {Rid: "r2", ChrtID: 2}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if o.Status != StatusCancelled || !o.Payment.Amount.Equal(usd(0)) || !o.Payment.GoodsTotal.Equal(usd(0)) {
+			t.Errorf("expected cancelled order with zero totals, got %q %+v", o.Status, o.Payment)
+		}
+	})
+
+	t.Run("full cancellation", func(t *testing.T) {
+		o := newTestOrder()
+
+		if err := o.CancelItems(nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if o.Status != StatusCancelled || !o.Payment.Paid.Equal(usd(1800)) {
+			t.Errorf("expected cancelled order keeping paid amount, got %q %+v", o.Status, o.Payment)
+		}
+	})
+
+	t.Run("unknown and already cancelled items", func(t *testing.T) {
+		o := newTestOrder()
+
+		if err := o.CancelItems([]ItemRef{{Rid: "missing"}}); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("expected ErrItemNotFound, got %v", err)
+		}
+		if err := o.CancelItems([]ItemRef{{Rid: "r1", ChrtID: 2}}); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("expected ErrItemNotFound for mismatched rid/chrt_id, got %v", err)
+		}
+
+		o.Items[0].Cancelled = true
+		if err := o.CancelItems([]ItemRef{{Rid: "r1"}}); !errors.Is(err, ErrItemAlreadyCancelled) {
+			t.Errorf("expected ErrItemAlreadyCancelled, got %v", err)
+		}
+	})
+
+	t.Run("duplicate items are cancelled one by one", func(t *testing.T) {
+		o := newTestOrder()
+		o.Items = append(o.Items, Item{Rid: "r1", ChrtID: 1, TotalPrice: usd(100)})
+
+		if err := o.CancelItems([]ItemRef{{Rid: "r1"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := o.CancelItems([]ItemRef{{Rid: "r1"}}); err != nil {
+			t.Fatalf("expected second duplicate to be cancelled, got %v", err)
+		}
+		if !o.Items[0].Cancelled || !o.Items[2].Cancelled || o.Items[1].Cancelled {
+			t.Errorf("unexpected cancelled flags: %+v", o.Items)
+		}
+		if err := o.CancelItems([]ItemRef{{Rid: "r1"}}); !errors.Is(err, ErrItemAlreadyCancelled) {
+			t.Errorf("expected ErrItemAlreadyCancelled, got %v", err)
+		}
+	})
+
+	t.Run("shipped order cannot be cancelled", func(t *testing.T) {
+		o := newTestOrder()
+		o.Status = StatusShipped
+
+		if err := o.CancelItems([]ItemRef{{Rid: "r1"}}); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("expected ErrInvalidTransition, got %v", err)
+		}
+	})
+}
+
+func TestCheckRefund(t *testing.T) {
+	p := Payment{Transaction: "tx1", Currency: "USD", Paid: usd(1000), Refunded: usd(600)}
+
+	tests := []struct {
+		name   string
+		refund Refund
+		want   error
+	}{
+		{"within paid amount", Refund{Amount: usd(400)}, nil},
+		{"matching transaction", Refund{Transaction: "tx1", Amount: usd(100)}, nil},
+		{"exceeds paid amount", Refund{Amount: usd(401)}, ErrRefundExceedsPaid},
+		{"exceeds paid amount by a cent", Refund{Amount: NewMoney(40001, 2, "")}, ErrRefundExceedsPaid},
+		{"zero amount", Refund{Amount: usd(0)}, ErrInvalidRefundAmount},
+		{"other transaction", Refund{Transaction: "tx2", Amount: usd(100)}, ErrTransactionMismatch},
+		{"other currency", Refund{Amount: MoneyFromMajor(100, "EUR")}, ErrCurrencyMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.CheckRefund(tt.refund)
+			if tt.want == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package orders
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Cursor - позиция в списке заказов, отсортированном по (date_created, id).
+// Клиенту передается как непрозрачная строка(Encode)
+type Cursor struct {
+	DateCreated time.Time `json:"t"`
+	ID          int       `json:"id"`
+	// Prev - страница перед позицией(для ссылки "назад"), иначе - после нее
+	Prev bool `json:"p,omitempty"`
+	// Asc - направление сортировки, в котором получен курсор
+	Asc bool `json:"a,omitempty"`
+}
+
+func CursorAt(o *Order, prev, asc bool) *Cursor {
+	if o == nil || o.DateCreated == nil {
+		return nil
+	}
+	return &Cursor{DateCreated: *o.DateCreated, ID: o.ID, Prev: prev, Asc: asc}
+}
+
+func (c *Cursor) Encode() string {
+	if c == nil {
+		return ""
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 || c.DateCreated.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
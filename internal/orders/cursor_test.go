@@ -0,0 +1,34 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2025, 1, 10, 12, 30, 0, 123, time.UTC)
+	c := CursorAt(&Order{ID: 42, DateCreated: &at}, true, true)
+
+	got, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != 42 || !got.DateCreated.Equal(at) || !got.Prev || !got.Asc {
+		t.Errorf("unexpected cursor: %+v", got)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, s := range []string{"", "garbage!", (&Cursor{ID: 1}).Encode(), (&Cursor{DateCreated: time.Now()}).Encode()} {
+		if _, err := DecodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%q: expected ErrInvalidCursor, got %v", s, err)
+		}
+	}
+}
+
+func TestCursorAtWithoutDate(t *testing.T) {
+	if c := CursorAt(&Order{ID: 1}, false, false); c != nil {
+		t.Errorf("expected nil cursor for order without date, got %+v", c)
+	}
+}
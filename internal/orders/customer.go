@@ -0,0 +1,25 @@
+package orders
+
+import "time"
+
+// CustomerSummary - сводка по заказам покупателя. Траты считаются в валюте оплаты без конвертации:
+// оплаченная сумма за вычетом возвратов
+type CustomerSummary struct {
+	CustomerID      string
+	OrdersCount     int
+	Spend           []CurrencyAmount
+	FirstOrderAt    *time.Time
+	LastOrderAt     *time.Time
+	FavouriteBrands []BrandCount
+}
+
+type CurrencyAmount struct {
+	Currency string
+	Amount   Money
+}
+
+// BrandCount - количество неотмененных позиций бренда в заказах покупателя
+type BrandCount struct {
+	Brand string
+	Items int
+}
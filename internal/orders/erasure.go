@@ -0,0 +1,31 @@
+package orders
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// ErasedName заменяет имя получателя после удаления персональных данных
+const ErasedName = "[erased]"
+
+// Erasure - запись аудита удаления персональных данных покупателя.
+// Исходный CustomerID не сохраняется: заказы и запись аудита ссылаются на псевдоним CustomerRef
+type Erasure struct {
+	ID          int
+	CustomerRef string
+	OrderUIDs   []string
+	Reason      string
+	Source      ChangeSource
+	CreatedAt   time.Time
+}
+
+// CustomerPseudonym - необратимый псевдоним покупателя, которым заменяется CustomerID в заказах.
+// HMAC с секретным ключом: без ключа псевдоним нельзя сопоставить с CustomerID перебором.
+// Детерминирован, поэтому статистика по покупателю и повторные запросы на удаление остаются согласованными
+func CustomerPseudonym(key, customerID string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(customerID))
+	return "erased-" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
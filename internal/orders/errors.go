@@ -6,4 +6,42 @@ var (
 	ErrOrderNotFound = errors.New("order not found")
 
 	ErrOrderAlreadyExists = errors.New("order already exists")
+
+	ErrInvalidCustomerID = errors.New("customer id is required")
+	ErrCustomerNotFound  = errors.New("customer has no orders")
+	ErrErasureKeyMissing = errors.New("erasure pseudonym key is not configured")
+
+	ErrEmptySearch   = errors.New("at least one search criterion is required")
+	ErrInvalidSort   = errors.New("unknown sort field")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrCursorSort    = errors.New("cursor pagination is supported only for sorting by date")
+	ErrInvalidTotal  = errors.New("unknown total mode")
+
+	ErrInvalidStatsGroup = errors.New("invalid stats group_by")
+	ErrInvalidStatsRange = errors.New("invalid stats date range")
+
+	ErrEmptyTextQuery   = errors.New("search query is required")
+	ErrTextQueryTooLong = errors.New("search query is too long")
+
+	ErrUnknownStatus     = errors.New("unknown order status")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+	ErrStatusConflict    = errors.New("order status was changed concurrently")
+
+	ErrItemNotFound         = errors.New("order item not found")
+	ErrItemAlreadyCancelled = errors.New("order item already cancelled")
+	ErrInvalidRefundAmount  = errors.New("refund amount must be positive")
+	ErrRefundExceedsPaid    = errors.New("refund exceeds paid amount")
+	ErrTransactionMismatch  = errors.New("refund transaction does not match order payment")
+
+	ErrInvalidMoney     = errors.New("invalid money amount")
+	ErrMoneyOverflow    = errors.New("money amount overflow")
+	ErrMoneyPrecision   = errors.New("money amount has too many decimal places")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+
+	ErrInvalidCurrency = errors.New("invalid currency code")
+	ErrInvalidRate     = errors.New("invalid exchange rate")
+	ErrRateNotFound    = errors.New("exchange rate not found")
+
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
 )
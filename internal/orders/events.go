@@ -0,0 +1,75 @@
+package orders
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventOrderCreated        EventType = "order.created"
+	EventOrderStatusChanged  EventType = "order.status_changed"
+	EventOrderCancelled      EventType = "order.cancelled"
+	EventOrderItemsCancelled EventType = "order.items_cancelled"
+	EventOrderRefunded       EventType = "order.refunded"
+	EventOrderPIIErased      EventType = "order.pii_erased"
+
+	// EventOrderFraudAlert публикуется в топик алертов, если оценка риска нового заказа достигла порога
+	EventOrderFraudAlert EventType = "order.fraud_alert"
+)
+
+// Event - событие жизненного цикла заказа, публикуемое после успешного изменения данных
+type Event struct {
+	Type            EventType   `json:"type"`
+	OrderUID        string      `json:"order_uid"`
+	TrackNumber     string      `json:"track_number,omitempty"`
+	CustomerID      string      `json:"customer_id,omitempty"`
+	DeliveryService string      `json:"delivery_service,omitempty"`
+	From            Status      `json:"from,omitempty"`
+	To              Status      `json:"to,omitempty"`
+	Reason          string      `json:"reason,omitempty"`
+	Items           []ItemRef   `json:"items,omitempty"`
+	Amount          *Money      `json:"amount,omitempty"`
+	Currency        string      `json:"currency,omitempty"`
+	Fraud           *FraudScore `json:"fraud,omitempty"`
+	OccurredAt      time.Time   `json:"occurred_at"`
+}
+
+func NewEvent(t EventType, o *Order) Event {
+	return Event{
+		Type:            t,
+		OrderUID:        o.OrderUID,
+		CustomerID:      o.CustomerID,
+		DeliveryService: o.DeliveryService,
+		To:              o.Status,
+		OccurredAt:      time.Now().UTC(),
+	}
+}
+
+// EventFilter - условия отбора событий(подписки на вебхуки, поток событий); пустой список - без ограничения по полю
+type EventFilter struct {
+	EventTypes       []EventType
+	DeliveryServices []string
+	CustomerIDs      []string
+	OrderUIDs        []string
+}
+
+func (f EventFilter) Matches(e Event) bool {
+	if len(f.EventTypes) > 0 && !slices.Contains(f.EventTypes, e.Type) {
+		return false
+	}
+	if len(f.DeliveryServices) > 0 && !slices.ContainsFunc(f.DeliveryServices, func(s string) bool {
+		return strings.EqualFold(s, e.DeliveryService)
+	}) {
+		return false
+	}
+	if len(f.CustomerIDs) > 0 && !slices.Contains(f.CustomerIDs, e.CustomerID) {
+		return false
+	}
+	if len(f.OrderUIDs) > 0 && !slices.Contains(f.OrderUIDs, e.OrderUID) {
+		return false
+	}
+	return true
+}
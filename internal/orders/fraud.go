@@ -0,0 +1,137 @@
+package orders
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// FraudRule - правило оценки риска заказа
+type FraudRule string
+
+const (
+	FraudAmountAboveAverage   FraudRule = "amount_above_average"
+	FraudRegionLocaleMismatch FraudRule = "region_locale_mismatch"
+	FraudPhoneVelocity        FraudRule = "phone_velocity"
+	FraudDuplicateTransaction FraudRule = "duplicate_transaction"
+)
+
+// MaxFraudScore - оценка ограничивается сверху, даже если сумма весов сработавших правил больше
+const MaxFraudScore = 100
+
+// FraudHit - сработавшее правило, его вес и пояснение
+type FraudHit struct {
+	Rule   FraudRule `json:"rule"`
+	Score  int       `json:"score"`
+	Detail string    `json:"detail"`
+}
+
+// FraudScore - оценка риска заказа при приеме: сумма весов сработавших правил
+type FraudScore struct {
+	Score int        `json:"score"`
+	Rules []FraudHit `json:"rules"`
+}
+
+// FraudSignals - данные о предыдущих заказах, по которым проверяются правила. Текущий заказ в них не входит
+type FraudSignals struct {
+	// CustomerOrders и CustomerTotal - количество и сумма заказов покупателя в валюте текущего заказа
+	CustomerOrders int
+	CustomerTotal  Money
+	// PhoneOrders - заказы с тем же телефоном за окно FraudRules.PhoneWindow до создания заказа
+	PhoneOrders int
+	// DuplicateUIDs - другие заказы с той же транзакцией оплаты
+	DuplicateUIDs []string
+}
+
+// FraudRules - настройки правил. Правило с нулевым весом не проверяется
+type FraudRules struct {
+	// сумма заказа больше AmountFactor средних сумм покупателя, у которого не меньше AmountMinOrders заказов
+	AmountFactor    float64
+	AmountMinOrders int
+	AmountWeight    int
+
+	// LocaleRegions - ожидаемые регионы доставки для локали; локали без списка не проверяются
+	LocaleRegions map[string][]string
+	LocaleWeight  int
+
+	// больше PhoneMaxOrders заказов с одного телефона(включая текущий) за PhoneWindow
+	PhoneWindow    time.Duration
+	PhoneMaxOrders int
+	PhoneWeight    int
+
+	DuplicateWeight int
+
+	// AlertScore - оценка, начиная с которой публикуется алерт; 0 - алерты отключены
+	AlertScore int
+}
+
+// ParseLocaleRegions разбирает значения вида {"ru": "Москва|Московская область"} в списки регионов
+func ParseLocaleRegions(m map[string]string) map[string][]string {
+	res := make(map[string][]string, len(m))
+	for locale, regions := range m {
+		var list []string
+		for _, region := range strings.Split(regions, "|") {
+			if region = strings.TrimSpace(region); region != "" {
+				list = append(list, region)
+			}
+		}
+		if len(list) > 0 {
+			res[strings.ToLower(strings.TrimSpace(locale))] = list
+		}
+	}
+	return res
+}
+
+// Enabled - включено ли хотя бы одно правило
+func (r FraudRules) Enabled() bool {
+	return r.AmountWeight > 0 || r.LocaleWeight > 0 || r.PhoneWeight > 0 || r.DuplicateWeight > 0
+}
+
+// IsAlert - достигла ли оценка порога алерта
+func (r FraudRules) IsAlert(f *FraudScore) bool {
+	return f != nil && r.AlertScore > 0 && f.Score >= r.AlertScore
+}
+
+// Score проверяет заказ всеми включенными правилами
+func (r FraudRules) Score(o *Order, s FraudSignals) *FraudScore {
+	res := &FraudScore{Rules: []FraudHit{}}
+	hit := func(rule FraudRule, weight int, format string, args ...any) {
+		res.Rules = append(res.Rules, FraudHit{Rule: rule, Score: weight, Detail: fmt.Sprintf(format, args...)})
+		res.Score += weight
+	}
+
+	if r.AmountWeight > 0 && r.AmountFactor > 0 && s.CustomerOrders > 0 && s.CustomerOrders >= r.AmountMinOrders {
+		avg := new(big.Rat).Quo(s.CustomerTotal.Rat(), big.NewRat(int64(s.CustomerOrders), 1))
+		limit := new(big.Rat).Mul(avg, new(big.Rat).SetFloat64(r.AmountFactor))
+		if avg.Sign() > 0 && o.Payment.Amount.Rat().Cmp(limit) > 0 {
+			ratio, _ := new(big.Rat).Quo(o.Payment.Amount.Rat(), avg).Float64()
+			hit(FraudAmountAboveAverage, r.AmountWeight, "amount %s is %.1fx customer average %s %s",
+				o.Payment.Amount, ratio, avg.FloatString(CurrencyExponent(o.Payment.Currency)), o.Payment.Currency)
+		}
+	}
+
+	if regions := r.LocaleRegions[strings.ToLower(o.Locale)]; r.LocaleWeight > 0 && len(regions) > 0 && o.Delivery.Region != "" {
+		expected := false
+		for _, region := range regions {
+			if strings.EqualFold(region, strings.TrimSpace(o.Delivery.Region)) {
+				expected = true
+				break
+			}
+		}
+		if !expected {
+			hit(FraudRegionLocaleMismatch, r.LocaleWeight, "region %q is not expected for locale %q", o.Delivery.Region, o.Locale)
+		}
+	}
+
+	if r.PhoneWeight > 0 && r.PhoneMaxOrders > 0 && s.PhoneOrders+1 > r.PhoneMaxOrders {
+		hit(FraudPhoneVelocity, r.PhoneWeight, "%d orders from the same phone within %s", s.PhoneOrders+1, r.PhoneWindow)
+	}
+
+	if r.DuplicateWeight > 0 && len(s.DuplicateUIDs) > 0 {
+		hit(FraudDuplicateTransaction, r.DuplicateWeight, "transaction is used by orders %s", strings.Join(s.DuplicateUIDs, ", "))
+	}
+
+	res.Score = min(res.Score, MaxFraudScore)
+	return res
+}
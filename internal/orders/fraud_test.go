@@ -0,0 +1,92 @@
+package orders
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFraudScore(t *testing.T) {
+	rules := FraudRules{
+		AmountFactor: 3, AmountMinOrders: 2, AmountWeight: 40,
+		LocaleRegions: ParseLocaleRegions(map[string]string{"RU": "Москва| Московская область ", "en": ""}),
+		LocaleWeight:  20,
+		PhoneWindow:   time.Hour, PhoneMaxOrders: 3, PhoneWeight: 30,
+		DuplicateWeight: 60,
+		AlertScore:      70,
+	}
+	order := func(amount int64, locale, region string) *Order {
+		return &Order{
+			Locale:   locale,
+			Delivery: Delivery{Region: region},
+			Payment:  Payment{Currency: "RUB", Amount: NewMoney(amount, 2, "RUB")},
+		}
+	}
+	history := FraudSignals{CustomerOrders: 2, CustomerTotal: NewMoney(20000, 2, "RUB")}
+
+	clean := rules.Score(order(30000, "ru", "московская область"), history)
+	if clean.Score != 0 || len(clean.Rules) != 0 || rules.IsAlert(clean) {
+		t.Errorf("expected clean order, got %+v", clean)
+	}
+
+	amount := rules.Score(order(30001, "ru", "Москва"), history)
+	if amount.Score != 40 || amount.Rules[0].Rule != FraudAmountAboveAverage ||
+		!strings.Contains(amount.Rules[0].Detail, "average 100.00 RUB") {
+		t.Errorf("expected amount rule, got %+v", amount)
+	}
+	if s := rules.Score(order(100000, "ru", "Москва"), FraudSignals{CustomerOrders: 1, CustomerTotal: NewMoney(100, 2, "RUB")}); s.Score != 0 {
+		t.Errorf("amount rule requires %d previous orders, got %+v", rules.AmountMinOrders, s)
+	}
+
+	if s := rules.Score(order(100, "ru", "Kraiot"), FraudSignals{}); s.Score != 20 || s.Rules[0].Rule != FraudRegionLocaleMismatch {
+		t.Errorf("expected locale rule, got %+v", s)
+	}
+	if s := rules.Score(order(100, "en", "Kraiot"), FraudSignals{}); s.Score != 0 {
+		t.Errorf("locale without regions must not be checked, got %+v", s)
+	}
+
+	all := rules.Score(order(100000, "ru", "Kraiot"), FraudSignals{
+		CustomerOrders: 2, CustomerTotal: NewMoney(20000, 2, "RUB"),
+		PhoneOrders:   3,
+		DuplicateUIDs: []string{"uid-a", "uid-b"},
+	})
+	if all.Score != MaxFraudScore || len(all.Rules) != 4 || !rules.IsAlert(all) {
+		t.Errorf("expected all rules with capped score, got %+v", all)
+	}
+	if hit := all.Rules[3]; hit.Rule != FraudDuplicateTransaction || !strings.Contains(hit.Detail, "uid-a, uid-b") {
+		t.Errorf("unexpected duplicate rule: %+v", hit)
+	}
+
+	if s := rules.Score(order(100, "", ""), FraudSignals{PhoneOrders: 2}); s.Score != 0 {
+		t.Errorf("%d orders from a phone are allowed, got %+v", rules.PhoneMaxOrders, s)
+	}
+	if (FraudRules{}).Enabled() || (FraudRules{AlertScore: 0}).IsAlert(all) {
+		t.Error("rules without weights and alert score must be disabled")
+	}
+}
+
+func TestOrderJSONOmitsFraud(t *testing.T) {
+	o := &Order{OrderUID: "uid1", Fraud: &FraudScore{Score: 40, Rules: []FraudHit{{Rule: FraudPhoneVelocity, Score: 40}}}}
+
+	plain, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(plain), "Fraud") || strings.Contains(string(plain), "score") {
+		t.Errorf("fraud score must not be serialized with the order: %s", plain)
+	}
+
+	// внутреннее хранение сохраняет оценку, в том числе в формате прежних записей
+	stored, err := MarshalStoredJSON(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Order
+	if err := json.Unmarshal(stored, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.OrderUID != "uid1" || got.Fraud == nil || got.Fraud.Score != 40 || len(got.Fraud.Rules) != 1 {
+		t.Errorf("unexpected stored order: %+v, fraud %+v", got, got.Fraud)
+	}
+}
@@ -0,0 +1,56 @@
+package orders
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTextQueryLength - ограничение длины запроса полнотекстового поиска в символах
+const MaxTextQueryLength = 200
+
+// Поля заказа, по которым идет полнотекстовый поиск
+const (
+	TextFieldItemName        = "items.name"
+	TextFieldItemBrand       = "items.brand"
+	TextFieldDeliveryCity    = "delivery.city"
+	TextFieldDeliveryAddress = "delivery.address"
+)
+
+// TextQuery - запрос полнотекстового поиска по названиям и брендам позиций, городу и адресу доставки
+type TextQuery struct {
+	Text   string
+	Limit  int
+	Offset int
+}
+
+// ParseTextQuery нормализует текст запроса и проверяет его длину
+func ParseTextQuery(text string) (string, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	switch {
+	case text == "":
+		return "", ErrEmptyTextQuery
+	case utf8.RuneCountInString(text) > MaxTextQueryLength:
+		return "", ErrTextQueryTooLong
+	}
+	return text, nil
+}
+
+// Highlight - совпавшее поле заказа; совпадения во Fragment обрамлены тегами <mark></mark>
+type Highlight struct {
+	Field    string
+	Fragment string
+}
+
+type TextHit struct {
+	Order      *Order
+	Rank       float64
+	Highlights []Highlight
+}
+
+// TextSearchResult - страница результатов поиска, отсортированная по релевантности.
+// Fuzzy - результаты найдены нечетким поиском по триграммам, т.к. точных совпадений слов нет
+type TextSearchResult struct {
+	Hits  []TextHit
+	Total int
+	Fuzzy bool
+}
@@ -0,0 +1,213 @@
+package orders
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxRateExponent - максимальное количество знаков после запятой в курсе(NUMERIC(24,10) в БД)
+const MaxRateExponent = 10
+
+// FXRate - курс валют на дату: 1 Base = Rate Quote
+type FXRate struct {
+	Date   time.Time
+	Base   string
+	Quote  string
+	Rate   *big.Rat
+	Source string
+}
+
+// ParseCurrency проверяет код валюты ISO 4217 и приводит его к верхнему регистру
+func ParseCurrency(s string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(s))
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, s)
+	}
+	return code, nil
+}
+
+// ParseRate разбирает десятичную запись курса("1.0712", "98.5"); курс должен быть положительным
+func ParseRate(s string) (*big.Rat, error) {
+	m, err := parseDecimal(strings.TrimSpace(s))
+	if err != nil || m.Sign() <= 0 || m.Exponent > MaxRateExponent {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return m.Rat(), nil
+}
+
+// NewFXRate собирает курс из значений источника(файл, Kafka) и проверяет их
+func NewFXRate(date time.Time, base, quote, rate, source string) (FXRate, error) {
+	r := FXRate{Date: rateDay(date), Source: source}
+	var err error
+	if r.Base, err = ParseCurrency(base); err != nil {
+		return r, err
+	}
+	if r.Quote, err = ParseCurrency(quote); err != nil {
+		return r, err
+	}
+	if r.Base == r.Quote {
+		return r, fmt.Errorf("%w: %s to itself", ErrInvalidRate, r.Base)
+	}
+	if r.Rate, err = ParseRate(rate); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// RateString - курс с MaxRateExponent знаками после запятой без лишних нулей
+func RateString(rate *big.Rat) string {
+	s := rate.FloatString(MaxRateExponent)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// rateDay - курсы действуют целый день(UTC)
+func rateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Conversion - пересчет сумм из валюты From в валюту To: 1 From = Rate To.
+// RateDate - дата использованного курса, нулевая при совпадении валют
+type Conversion struct {
+	From     string
+	To       string
+	Rate     *big.Rat
+	RateDate time.Time
+}
+
+// Convert пересчитывает сумму и округляет ее до числа знаков валюты To(половина - от нуля)
+func (c Conversion) Convert(m Money) (Money, error) {
+	if m.Currency != "" && !strings.EqualFold(m.Currency, c.From) {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, c.From)
+	}
+
+	exponent := CurrencyExponent(c.To)
+	v := new(big.Rat).Mul(m.Rat(), c.Rate)
+	v.Mul(v, new(big.Rat).SetInt(pow10(exponent)))
+
+	units, rem := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if new(big.Int).Abs(new(big.Int).Lsh(rem, 1)).Cmp(v.Denom()) >= 0 {
+		units.Add(units, big.NewInt(int64(v.Sign())))
+	}
+	if !units.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Units: units.Int64(), Exponent: exponent, Currency: c.To}, nil
+}
+
+// ConvertedPayment - суммы оплаты заказа в валюте отчетности. Сам заказ не меняется
+type ConvertedPayment struct {
+	Conversion
+	Amount       Money
+	DeliveryCost Money
+	GoodsTotal   Money
+	CustomFee    Money
+	Paid         Money
+	Refunded     Money
+}
+
+func (c Conversion) ConvertPayment(p Payment) (*ConvertedPayment, error) {
+	res := &ConvertedPayment{Conversion: c}
+	pairs := []struct {
+		dst *Money
+		src Money
+	}{
+		{&res.Amount, p.Amount}, {&res.DeliveryCost, p.DeliveryCost}, {&res.GoodsTotal, p.GoodsTotal},
+		{&res.CustomFee, p.CustomFee}, {&res.Paid, p.Paid}, {&res.Refunded, p.Refunded},
+	}
+	for _, pr := range pairs {
+		var err error
+		if *pr.dst, err = c.Convert(pr.src); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+type ratePair struct {
+	base, quote string
+}
+
+// RateBook - набор курсов для поиска курса на дату. Курс старше MaxAge считается устаревшим
+type RateBook struct {
+	rates  map[ratePair][]FXRate
+	bases  map[string][]string
+	MaxAge time.Duration
+}
+
+func NewRateBook(rates []FXRate, maxAge time.Duration) *RateBook {
+	b := &RateBook{rates: map[ratePair][]FXRate{}, bases: map[string][]string{}, MaxAge: maxAge}
+	for _, r := range rates {
+		p := ratePair{r.Base, r.Quote}
+		if _, ok := b.rates[p]; !ok {
+			b.bases[r.Quote] = append(b.bases[r.Quote], r.Base)
+		}
+		b.rates[p] = append(b.rates[p], r)
+	}
+	for _, list := range b.rates {
+		sort.Slice(list, func(i, j int) bool { return list[i].Date.After(list[j].Date) })
+	}
+	return b
+}
+
+// latest - последний курс пары на дату at, не старше MaxAge
+func (b *RateBook) latest(base, quote string, at time.Time) (FXRate, bool) {
+	for _, r := range b.rates[ratePair{base, quote}] {
+		if r.Date.After(at) {
+			continue
+		}
+		if b.MaxAge > 0 && at.Sub(r.Date) > b.MaxAge {
+			break
+		}
+		return r, true
+	}
+	return FXRate{}, false
+}
+
+// Lookup находит курс from -> to на дату at: прямой, обратный или кросс-курс через общую базовую валюту.
+// Из найденных выбирается курс с самой поздней датой; для кросс-курса датой считается более ранняя из двух
+func (b *RateBook) Lookup(from, to string, at time.Time) (Conversion, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return Conversion{From: from, To: to, Rate: big.NewRat(1, 1)}, nil
+	}
+	at = rateDay(at)
+
+	var (
+		best  Conversion
+		found bool
+	)
+	consider := func(rate *big.Rat, date time.Time) {
+		if !found || date.After(best.RateDate) {
+			best, found = Conversion{From: from, To: to, Rate: rate, RateDate: date}, true
+		}
+	}
+
+	if r, ok := b.latest(from, to, at); ok {
+		consider(r.Rate, r.Date)
+	}
+	if r, ok := b.latest(to, from, at); ok {
+		consider(new(big.Rat).Inv(r.Rate), r.Date)
+	}
+	for _, base := range b.bases[from] {
+		rFrom, okFrom := b.latest(base, from, at)
+		rTo, okTo := b.latest(base, to, at)
+		if !okFrom || !okTo {
+			continue
+		}
+		date := rFrom.Date
+		if rTo.Date.Before(date) {
+			date = rTo.Date
+		}
+		consider(new(big.Rat).Quo(rTo.Rate, rFrom.Rate), date)
+	}
+
+	if !found {
+		return Conversion{}, fmt.Errorf("%w: %s to %s on %s", ErrRateNotFound, from, to, at.Format(time.DateOnly))
+	}
+	return best, nil
+}
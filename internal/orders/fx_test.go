@@ -0,0 +1,123 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustRate(t *testing.T, date, base, quote, rate string) FXRate {
+	t.Helper()
+	d, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewFXRate(d, base, quote, rate, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestNewFXRate(t *testing.T) {
+	r := mustRate(t, "2026-10-18", "eur", "usd", "1.0712")
+	if r.Base != "EUR" || r.Quote != "USD" || RateString(r.Rate) != "1.0712" {
+		t.Errorf("unexpected rate: %+v %s", r, RateString(r.Rate))
+	}
+
+	invalid := []struct{ base, quote, rate string }{
+		{"EU", "USD", "1"},
+		{"EUR", "US1", "1"},
+		{"EUR", "EUR", "1"},
+		{"EUR", "USD", "0"},
+		{"EUR", "USD", "-1.5"},
+		{"EUR", "USD", "1.00000000001"},
+	}
+	for _, tt := range invalid {
+		if _, err := NewFXRate(time.Now(), tt.base, tt.quote, tt.rate, ""); err == nil {
+			t.Errorf("%+v: expected error", tt)
+		}
+	}
+}
+
+func TestRateBookLookup(t *testing.T) {
+	book := NewRateBook([]FXRate{
+		mustRate(t, "2026-10-16", "EUR", "USD", "1.05"),
+		mustRate(t, "2026-10-18", "EUR", "USD", "1.08"),
+		mustRate(t, "2026-10-18", "EUR", "RUB", "90"),
+		mustRate(t, "2026-10-17", "USD", "KZT", "480"),
+	}, 7*24*time.Hour)
+	at := time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name, from, to string
+		at             time.Time
+		rate, date     string
+	}{
+		{"direct", "EUR", "USD", at, "1.08", "2026-10-18"},
+		{"earlier date", "EUR", "USD", at.AddDate(0, 0, -1), "1.05", "2026-10-16"},
+		{"inverse", "USD", "EUR", at, "0.9259259259", "2026-10-18"},
+		{"cross", "RUB", "USD", at, "0.012", "2026-10-18"},
+		{"same currency", "usd", "USD", at, "1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := book.Lookup(tt.from, tt.to, tt.at)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := RateString(c.Rate); got != tt.rate {
+				t.Errorf("expected rate %s, got %s", tt.rate, got)
+			}
+			if tt.date == "" && !c.RateDate.IsZero() || tt.date != "" && c.RateDate.Format(time.DateOnly) != tt.date {
+				t.Errorf("expected rate date %q, got %v", tt.date, c.RateDate)
+			}
+		})
+	}
+
+	// курс KZT есть только к USD, кросс-курс EUR/KZT не строится: нет общей базы
+	if _, err := book.Lookup("EUR", "KZT", at); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("expected ErrRateNotFound, got %v", err)
+	}
+	// курс старше MaxAge не используется
+	if _, err := book.Lookup("EUR", "USD", at.AddDate(0, 0, 10)); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("expected stale rate to be ignored, got %v", err)
+	}
+	// курс на будущую дату не используется
+	if _, err := book.Lookup("EUR", "USD", at.AddDate(0, 0, -5)); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("expected ErrRateNotFound before the first rate, got %v", err)
+	}
+}
+
+func TestConversionConvert(t *testing.T) {
+	rate, _ := ParseRate("0.0125")
+	c := Conversion{From: "RUB", To: "USD", Rate: rate}
+
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{NewMoney(181750, 2, "RUB"), "22.72"}, // 22.71875
+		{NewMoney(20, 0, "RUB"), "0.25"},
+		{NewMoney(-40, 2, "RUB"), "-0.01"}, // -0.005 округляется от нуля
+		{NewMoney(0, 2, ""), "0.00"},
+	}
+	for _, tt := range tests {
+		got, err := c.Convert(tt.in)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Decimal() != tt.want || got.Currency != "USD" {
+			t.Errorf("%s: expected %s USD, got %s", tt.in, tt.want, got)
+		}
+	}
+
+	jpy := Conversion{From: "USD", To: "JPY", Rate: mustRate(t, "2026-10-18", "USD", "JPY", "150.5").Rate}
+	if got, _ := jpy.Convert(NewMoney(1999, 2, "USD")); got.Decimal() != "3008" {
+		t.Errorf("expected 3008 JPY, got %s", got)
+	}
+
+	if _, err := c.Convert(MoneyFromMajor(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package orders
+
+import (
+	"context"
+	"time"
+)
+
+// Источники изменений заказа
+const (
+	SourceKafka  = "kafka"
+	SourceHTTP   = "http"
+	SourceCLI    = "cli"
+	SourceSystem = "system"
+)
+
+// ChangeSource - кто и откуда изменил заказ. Ref - ссылка на исходное сообщение или запрос
+// (topic/partition/offset для Kafka, request id для HTTP)
+type ChangeSource struct {
+	Kind  string
+	Ref   string
+	Actor string
+}
+
+// OrderVersion - состояние заказа после очередного изменения
+type OrderVersion struct {
+	Version   int
+	Change    EventType
+	Source    ChangeSource
+	CreatedAt time.Time
+	Order     *Order
+}
+
+type changeSourceKey struct{}
+
+// WithChangeSource сохраняет источник изменения в контексте; репозиторий записывает его в историю версий
+func WithChangeSource(ctx context.Context, src ChangeSource) context.Context {
+	return context.WithValue(ctx, changeSourceKey{}, src)
+}
+
+func ChangeSourceFromContext(ctx context.Context) ChangeSource {
+	if src, ok := ctx.Value(changeSourceKey{}).(ChangeSource); ok {
+		return src
+	}
+	return ChangeSource{Kind: SourceSystem}
+}
@@ -0,0 +1,89 @@
+package orders
+
+import (
+	"strings"
+	"time"
+)
+
+// ListFilter - фильтры списка заказов; пустые поля не участвуют в отборе.
+// MinAmount и MaxAmount сравниваются с суммой оплаты в валюте заказа. Brand и ItemStatus проверяются по одной позиции заказа
+type ListFilter struct {
+	DateFrom        *time.Time
+	DateTo          *time.Time
+	DeliveryService string
+	Locale          string
+	Currency        string
+	Provider        string
+	Bank            string
+	CustomerID      string
+	City            string
+	Region          string
+	MinAmount       *Money
+	MaxAmount       *Money
+	Brand           string
+	ItemStatus      *int
+}
+
+type SortField string
+
+const (
+	SortByDate   SortField = "date"
+	SortByAmount SortField = "amount"
+	SortByItems  SortField = "items"
+)
+
+// ListSort - сортировка списка заказов; нулевое значение - по дате создания, новые первыми
+type ListSort struct {
+	Field SortField
+	Asc   bool
+}
+
+func ParseSortField(s string) (SortField, error) {
+	switch f := SortField(strings.ToLower(s)); f {
+	case "":
+		return SortByDate, nil
+	case SortByDate, SortByAmount, SortByItems:
+		return f, nil
+	}
+	return "", ErrInvalidSort
+}
+
+// TotalMode - способ подсчета общего количества заказов в списке
+type TotalMode string
+
+const (
+	TotalExact    TotalMode = "exact"
+	TotalEstimate TotalMode = "estimate"
+	TotalNone     TotalMode = "none"
+)
+
+// TotalUnknown - значение Total, если подсчет не выполнялся(TotalNone)
+const TotalUnknown = -1
+
+// ParseTotalMode разбирает режим подсчета; пустая строка - режим по умолчанию
+func ParseTotalMode(s string) (TotalMode, error) {
+	switch m := TotalMode(strings.ToLower(s)); m {
+	case "", TotalExact, TotalEstimate, TotalNone:
+		return m, nil
+	}
+	return "", ErrInvalidTotal
+}
+
+// ListQuery - запрос страницы списка заказов. При заданном Cursor Offset не используется
+type ListQuery struct {
+	Filter ListFilter
+	Sort   ListSort
+	Limit  int
+	Offset int
+	Cursor *Cursor
+	Total  TotalMode
+}
+
+// ListResult - страница списка заказов. Next и Prev заполняются только при сортировке по дате
+type ListResult struct {
+	Orders         []*Order
+	Total          int
+	TotalEstimated bool
+	Next           *Cursor
+	Prev           *Cursor
+}
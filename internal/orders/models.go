@@ -1,6 +1,8 @@
 package orders
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -10,6 +12,7 @@ type Order struct {
 	OrderUID    string `db:"order_uid"`
 	TrackNumber string `db:"track_number"`
 	Entry       string `db:"entry"`
+	Status      Status `db:"status"`
 
 	Delivery Delivery `db:"-"`
 	Payment  Payment  `db:"-"`
@@ -23,6 +26,10 @@ type Order struct {
 	SmID              int        `db:"sm_id"`
 	DateCreated       *time.Time `db:"date_created"`
 	OofShard          string     `db:"oof_shard"`
+
+	// Fraud - оценка риска при приеме заказа, nil - заказ не оценивался. json.Marshal ее не пишет, чтобы
+	// она не попала в ответы и события случайно; кэш и снимки версий пишутся MarshalStoredJSON
+	Fraud *FraudScore `db:"-" json:"-" msgpack:"Fraud,omitempty"`
 }
 
 type Delivery struct {
@@ -35,29 +42,94 @@ type Delivery struct {
 	Email   string `db:"email"`
 }
 
+// Payment - оплата заказа. Все суммы - в валюте Currency
 type Payment struct {
 	Transaction  string `db:"transaction"`
 	RequestID    string `db:"request_id"`
 	Currency     string `db:"currency"`
 	Provider     string `db:"provider"`
-	Amount       int    `db:"amount"`
+	Amount       Money  `db:"amount"`
 	PaymentDT    int64  `db:"payment_dt"`
 	Bank         string `db:"bank"`
-	DeliveryCost int    `db:"delivery_cost"`
-	GoodsTotal   int    `db:"goods_total"`
-	CustomFee    int    `db:"custom_fee"`
+	DeliveryCost Money  `db:"delivery_cost"`
+	GoodsTotal   Money  `db:"goods_total"`
+	CustomFee    Money  `db:"custom_fee"`
+	// Paid - сумма, оплаченная при создании заказа; Refunded - сумма выполненных возвратов
+	Paid     Money `db:"paid_amount"`
+	Refunded Money `db:"refunded_amount"`
 }
 
 type Item struct {
 	ChrtID      int    `db:"chrt_id"`
 	TrackNumber string `db:"track_number"`
-	Price       int    `db:"price"`
+	Price       Money  `db:"price"`
 	Rid         string `db:"rid"`
 	Name        string `db:"name"`
 	Sale        int    `db:"sale"`
 	Size        string `db:"size"`
-	TotalPrice  int    `db:"total_price"`
+	TotalPrice  Money  `db:"total_price"`
 	NmID        int    `db:"nm_id"`
 	Brand       string `db:"brand"`
 	Status      int    `db:"status"`
+	Cancelled   bool   `db:"cancelled"`
+}
+
+// ApplyCurrency проставляет валюту оплаты во все суммы заказа и приводит их к числу знаков валюты.
+// Вызывается после чтения заказа из БД, Kafka или кэша, где суммы хранятся без валюты
+func (o *Order) ApplyCurrency() error {
+	currency := o.Payment.Currency
+	amounts := []*Money{
+		&o.Payment.Amount, &o.Payment.DeliveryCost, &o.Payment.GoodsTotal,
+		&o.Payment.CustomFee, &o.Payment.Paid, &o.Payment.Refunded,
+	}
+	for i := range o.Items {
+		amounts = append(amounts, &o.Items[i].Price, &o.Items[i].TotalPrice)
+	}
+
+	for _, m := range amounts {
+		*m = m.WithCurrency(currency)
+		if m.Exponent > MaxMoneyExponent {
+			return fmt.Errorf("%w: %s", ErrMoneyPrecision, m)
+		}
+	}
+	return nil
+}
+
+// MarshalStoredJSON сериализует заказ для внутреннего хранения(кэш, снимки версий) вместе с оценкой риска
+func MarshalStoredJSON(o *Order) ([]byte, error) {
+	type plain Order
+	return json.Marshal(struct {
+		*plain
+		Fraud *FraudScore `json:"Fraud,omitempty"`
+	}{(*plain)(o), o.Fraud})
+}
+
+// UnmarshalJSON восстанавливает валюту сумм и оценку риска после чтения заказа из JSON(кэш, снимки версий)
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type plain Order
+	stored := struct {
+		*plain
+		Fraud *FraudScore `json:"Fraud"`
+	}{plain: (*plain)(o)}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	o.Fraud = stored.Fraud
+	return o.ApplyCurrency()
+}
+
+type StatusChange struct {
+	From      Status    `db:"from_status"`
+	To        Status    `db:"to_status"`
+	Reason    string    `db:"reason"`
+	ChangedAt time.Time `db:"created_at"`
+}
+
+// ListPage - закэшированная страница списка заказов: только UID, сами заказы берутся из кэша заказов
+type ListPage struct {
+	UIDs           []string `json:"uids"`
+	Total          int      `json:"total"`
+	TotalEstimated bool     `json:"total_estimated,omitempty"`
+	Next           *Cursor  `json:"next,omitempty"`
+	Prev           *Cursor  `json:"prev,omitempty"`
 }
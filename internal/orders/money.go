@@ -0,0 +1,273 @@
+package orders
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money - точная денежная сумма: Units минимальных единиц при Exponent знаках после запятой,
+// например {181750, 2, "USD"} = 1817.50 USD. В JSON записывается числом в основных единицах валюты(1817.50),
+// валюта берется из оплаты заказа(Order.ApplyCurrency). Пустая валюта совместима с любой
+type Money struct {
+	Units    int64
+	Exponent int
+	Currency string
+}
+
+// DefaultCurrencyExponent - количество знаков после запятой для валют, которых нет в currencyExponents
+const DefaultCurrencyExponent = 2
+
+// currencyExponents - валюты ISO 4217, у которых число знаков после запятой отличается от 2
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// MaxMoneyExponent - максимальное количество знаков после запятой, которое хранится в БД
+const MaxMoneyExponent = 4
+
+func CurrencyExponent(currency string) int {
+	if e, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return DefaultCurrencyExponent
+}
+
+func NewMoney(units int64, exponent int, currency string) Money {
+	return Money{Units: units, Exponent: exponent, Currency: currency}
+}
+
+// MoneyFromMajor создает сумму из целого числа основных единиц валюты(рублей, долларов)
+func MoneyFromMajor(amount int64, currency string) Money {
+	m := Money{Units: amount, Currency: currency}
+	if scaled, ok := m.rescale(CurrencyExponent(currency)); ok {
+		return scaled
+	}
+	return m
+}
+
+// ParseMoney разбирает десятичную запись суммы в основных единицах("1817.5", "-0.05", "1.8e3")
+func ParseMoney(s, currency string) (Money, error) {
+	m, err := parseDecimal(s)
+	if err != nil {
+		return Money{}, err
+	}
+	return m.WithCurrency(currency), nil
+}
+
+func parseDecimal(s string) (Money, error) {
+	mantissa, exp10 := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		var err error
+		if exp10, err = strconv.Atoi(s[i+1:]); err != nil || exp10 < -64 || exp10 > 64 {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+		}
+	}
+
+	intPart, frac, _ := strings.Cut(mantissa, ".")
+	digits := strings.TrimLeft(intPart, "+-") + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" || strings.Count(intPart, "-")+strings.Count(intPart, "+") > 1 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	units, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	if strings.HasPrefix(intPart, "-") {
+		units.Neg(units)
+	}
+
+	exponent := len(frac) - exp10
+	if exponent < 0 {
+		units.Mul(units, pow10(-exponent))
+		exponent = 0
+	}
+	return moneyFromBig(units, exponent)
+}
+
+func moneyFromBig(units *big.Int, exponent int) (Money, error) {
+	// лишние нули дробной части отбрасываются, чтобы сумма поместилась в int64
+	ten := big.NewInt(10)
+	for exponent > 0 && new(big.Int).Rem(units, ten).Sign() == 0 && !units.IsInt64() {
+		units.Quo(units, ten)
+		exponent--
+	}
+	if !units.IsInt64() || exponent > math.MaxInt32 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Units: units.Int64(), Exponent: exponent}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// WithCurrency задает валюту и приводит сумму к числу знаков валюты, если это возможно без потери точности
+func (m Money) WithCurrency(currency string) Money {
+	m.Currency = currency
+	if currency != "" {
+		if scaled, ok := m.rescale(CurrencyExponent(currency)); ok {
+			return scaled
+		}
+	}
+	if m.Exponent > MaxMoneyExponent {
+		if scaled, ok := m.rescale(MaxMoneyExponent); ok {
+			return scaled
+		}
+	}
+	return m
+}
+
+// rescale меняет число знаков после запятой; false - если значение изменилось бы или не помещается в int64
+func (m Money) rescale(exponent int) (Money, bool) {
+	if exponent == m.Exponent {
+		return m, true
+	}
+	units := big.NewInt(m.Units)
+	if exponent > m.Exponent {
+		units.Mul(units, pow10(exponent-m.Exponent))
+	} else {
+		var rem big.Int
+		units.QuoRem(units, pow10(m.Exponent-exponent), &rem)
+		if rem.Sign() != 0 {
+			return m, false
+		}
+	}
+	if !units.IsInt64() {
+		return m, false
+	}
+	return Money{Units: units.Int64(), Exponent: exponent, Currency: m.Currency}, true
+}
+
+// align приводит две суммы к общей валюте и числу знаков
+func align(a, b Money) (Money, Money, error) {
+	switch {
+	case a.Currency == "":
+		a.Currency = b.Currency
+	case b.Currency == "":
+		b.Currency = a.Currency
+	case !strings.EqualFold(a.Currency, b.Currency):
+		return a, b, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency, b.Currency)
+	}
+
+	exponent := max(a.Exponent, b.Exponent)
+	a, okA := a.rescale(exponent)
+	b, okB := b.rescale(exponent)
+	if !okA || !okB {
+		return a, b, ErrMoneyOverflow
+	}
+	return a, b, nil
+}
+
+func (m Money) Add(b Money) (Money, error) {
+	a, b, err := align(m, b)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := a.Units + b.Units
+	if (b.Units > 0 && sum < a.Units) || (b.Units < 0 && sum > a.Units) {
+		return Money{}, ErrMoneyOverflow
+	}
+	a.Units = sum
+	return a, nil
+}
+
+func (m Money) Sub(b Money) (Money, error) {
+	if b.Units == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	b.Units = -b.Units
+	return m.Add(b)
+}
+
+// Cmp сравнивает суммы одной валюты: -1, 0 или 1
+func (m Money) Cmp(b Money) (int, error) {
+	a, b, err := align(m, b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case a.Units < b.Units:
+		return -1, nil
+	case a.Units > b.Units:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Equal - равенство значений и валют без учета числа знаков(1.5 == 1.50)
+func (m Money) Equal(b Money) bool {
+	c, err := m.Cmp(b)
+	return err == nil && c == 0 && strings.EqualFold(m.Currency, b.Currency)
+}
+
+func (m Money) Sign() int {
+	switch {
+	case m.Units < 0:
+		return -1
+	case m.Units > 0:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) IsZero() bool {
+	return m.Units == 0
+}
+
+// Decimal возвращает сумму в основных единицах с Exponent знаками после запятой: "1817.50"
+func (m Money) Decimal() string {
+	r := new(big.Rat).SetFrac(big.NewInt(m.Units), pow10(max(m.Exponent, 0)))
+	return r.FloatString(max(m.Exponent, 0))
+}
+
+// Rat - точное значение суммы в основных единицах
+func (m Money) Rat() *big.Rat {
+	if m.Exponent < 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(big.NewInt(m.Units), pow10(-m.Exponent)))
+	}
+	return new(big.Rat).SetFrac(big.NewInt(m.Units), pow10(m.Exponent))
+}
+
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON принимает число или строку с десятичной записью; валюта не заполняется
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+
+	parsed, err := parseDecimal(string(data))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
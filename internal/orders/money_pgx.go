@@ -0,0 +1,38 @@
+package orders
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ScanNumeric позволяет сканировать NUMERIC из pgx напрямую в Money без потери точности.
+// NULL читается как нулевая сумма; валюта проставляется после чтения заказа(Order.ApplyCurrency)
+func (m *Money) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		*m = Money{}
+		return nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: non-finite numeric", ErrInvalidMoney)
+	}
+
+	units := new(big.Int).Set(n.Int)
+	exponent := -int(n.Exp)
+	if exponent < 0 {
+		units.Mul(units, pow10(-exponent))
+		exponent = 0
+	}
+	parsed, err := moneyFromBig(units, exponent)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// NumericValue передает Money в pgx как NUMERIC
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(m.Units), Exp: -int32(m.Exponent), Valid: true}, nil
+}
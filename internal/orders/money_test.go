@@ -0,0 +1,157 @@
+package orders
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in, currency string
+		want         Money
+	}{
+		{"1817", "USD", Money{181700, 2, "USD"}},
+		{"1817.5", "USD", Money{181750, 2, "USD"}},
+		{"-0.05", "RUB", Money{-5, 2, "RUB"}},
+		{"1.8e3", "USD", Money{180000, 2, "USD"}},
+		{"1500", "JPY", Money{1500, 0, "JPY"}},
+		{"12.345", "KWD", Money{12345, 3, "KWD"}},
+		// точность выше валюты сохраняется
+		{"0.005", "USD", Money{5, 3, "USD"}},
+		{"1.50000", "USD", Money{150, 2, "USD"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, tt.currency)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.in, tt.want, got)
+		}
+	}
+
+	for _, in := range []string{"", "-", "1.2.3", "1,5", "abc", "--1", "1e", "1e999"} {
+		if _, err := ParseMoney(in, "USD"); !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("%q: expected ErrInvalidMoney, got %v", in, err)
+		}
+	}
+	if _, err := ParseMoney("99999999999999999999", "USD"); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("expected ErrMoneyOverflow, got %v", err)
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := NewMoney(10, 1, "USD") // 1.0
+	b := NewMoney(5, 2, "usd")  // 0.05
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sum.Decimal() != "1.05" {
+		t.Errorf("expected 1.05, got %s", sum.Decimal())
+	}
+
+	diff, err := b.Sub(a)
+	if err != nil || diff.Decimal() != "-0.95" || diff.Sign() != -1 {
+		t.Errorf("expected -0.95, got %s, %v", diff.Decimal(), err)
+	}
+
+	if c, err := a.Cmp(NewMoney(100, 2, "")); err != nil || c != 0 {
+		t.Errorf("expected 1.0 == 1.00, got %d, %v", c, err)
+	}
+	if _, err := a.Add(MoneyFromMajor(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := NewMoney(1<<62, 0, "").Add(NewMoney(1<<62, 0, "")); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("expected ErrMoneyOverflow, got %v", err)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Amount Money }{NewMoney(181750, 2, "USD")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"Amount":1817.50}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	var got struct{ A, B, C Money }
+	if err := json.Unmarshal([]byte(`{"A":0.1,"B":"12.345","C":null}`), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.A != (Money{1, 1, ""}) || got.B != (Money{12345, 3, ""}) || got.C != (Money{}) {
+		t.Errorf("unexpected values: %+v", got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"A":true}`), &got); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("expected ErrInvalidMoney, got %v", err)
+	}
+}
+
+func TestOrderJSONAppliesCurrency(t *testing.T) {
+	// снимки версий и кэш, записанные до появления Money, хранят целые суммы
+	legacy := `{"Payment":{"Currency":"USD","Amount":1817,"Paid":1817},"Items":[{"Price":453.5,"TotalPrice":317}]}`
+
+	var o Order
+	if err := json.Unmarshal([]byte(legacy), &o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o.Payment.Amount != (Money{181700, 2, "USD"}) || o.Items[0].Price != (Money{45350, 2, "USD"}) {
+		t.Errorf("unexpected amounts: %+v %+v", o.Payment.Amount, o.Items[0].Price)
+	}
+
+	data, err := json.Marshal(&o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var again Order
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.Payment != o.Payment || again.Items[0] != o.Items[0] {
+		t.Errorf("round-trip mismatch: %+v / %+v", again.Payment, o.Payment)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Payment":{"Currency":"USD","Amount":0.000001}}`), &o); !errors.Is(err, ErrMoneyPrecision) {
+		t.Errorf("expected ErrMoneyPrecision, got %v", err)
+	}
+}
+
+func TestMoneyNumeric(t *testing.T) {
+	for _, m := range []Money{NewMoney(181750, 2, ""), NewMoney(-5, 4, ""), NewMoney(1500, 0, "")} {
+		n, err := m.NumericValue()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got Money
+		if err := got.ScanNumeric(n); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != m {
+			t.Errorf("expected %+v, got %+v", m, got)
+		}
+	}
+
+	// NUMERIC(18,4) возвращает лишние нули, ApplyCurrency приводит сумму к знакам валюты
+	var m Money
+	if err := m.ScanNumeric(pgtype.Numeric{Int: big.NewInt(18175000), Exp: -4, Valid: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := m.WithCurrency("USD"); got != (Money{181750, 2, "USD"}) {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	if err := m.ScanNumeric(pgtype.Numeric{}); err != nil || m != (Money{}) {
+		t.Errorf("expected NULL to scan as zero, got %+v, %v", m, err)
+	}
+	if err := m.ScanNumeric(pgtype.Numeric{NaN: true, Valid: true}); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("expected ErrInvalidMoney for NaN, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package orders
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Имена полей для слепых индексов(HMAC нормализованного значения), по которым ищутся заказы
+// с зашифрованными контактами
+const (
+	IndexFieldEmail = "email"
+	IndexFieldPhone = "phone"
+)
+
+// MapPII применяет fn к персональным полям доставки(телефон, email, адрес) - используется
+// для шифрования и расшифровки при записи в БД и кэш
+func (d *Delivery) MapPII(fn func(string) (string, error)) error {
+	for _, field := range []*string{&d.Phone, &d.Email, &d.Address} {
+		v, err := fn(*field)
+		if err != nil {
+			return err
+		}
+		*field = v
+	}
+	return nil
+}
+
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizePhone оставляет только цифры: "+7 (999) 123-45-67" и "79991234567" дают один индекс
+func NormalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+}
@@ -0,0 +1,18 @@
+package orders
+
+// SearchCriteria - условия поиска заказов; заданные условия объединяются через AND.
+// Телефон и email сравниваются после нормализации(NormalizePhone, NormalizeEmail)
+type SearchCriteria struct {
+	TrackNumber string
+	Transaction string
+	RequestID   string
+	Rid         string
+	NmID        int
+	Brand       string
+	Phone       string
+	Email       string
+}
+
+func (c SearchCriteria) Empty() bool {
+	return c == SearchCriteria{}
+}
@@ -0,0 +1,210 @@
+package orders
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// StatsDimension - измерение группировки статистики заказов
+type StatsDimension string
+
+const (
+	StatsByDay             StatsDimension = "day"
+	StatsByWeek            StatsDimension = "week"
+	StatsByMonth           StatsDimension = "month"
+	StatsByDeliveryService StatsDimension = "delivery_service"
+	StatsByProvider        StatsDimension = "provider"
+	StatsByBank            StatsDimension = "bank"
+	StatsByCurrency        StatsDimension = "currency"
+	StatsByRegion          StatsDimension = "region"
+	StatsByBrand           StatsDimension = "brand"
+)
+
+// DefaultStatsDays - период статистики по умолчанию, если date_from не задан
+const DefaultStatsDays = 30
+
+func (d StatsDimension) IsPeriod() bool {
+	return d == StatsByDay || d == StatsByWeek || d == StatsByMonth
+}
+
+// ParseStatsGroupBy разбирает список измерений через запятую("month,currency"). Допускается одно измерение периода;
+// пустая строка - группировка по дням
+func ParseStatsGroupBy(s string) ([]StatsDimension, error) {
+	if strings.TrimSpace(s) == "" {
+		return []StatsDimension{StatsByDay}, nil
+	}
+
+	var (
+		dims   []StatsDimension
+		period bool
+	)
+	for _, part := range strings.Split(s, ",") {
+		d := StatsDimension(strings.ToLower(strings.TrimSpace(part)))
+		switch d {
+		case StatsByDay, StatsByWeek, StatsByMonth:
+			if period {
+				return nil, fmt.Errorf("%w: only one of day, week, month is allowed", ErrInvalidStatsGroup)
+			}
+			period = true
+		case StatsByDeliveryService, StatsByProvider, StatsByBank, StatsByCurrency, StatsByRegion, StatsByBrand:
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidStatsGroup, part)
+		}
+		if slices.Contains(dims, d) {
+			return nil, fmt.Errorf("%w: duplicate %q", ErrInvalidStatsGroup, d)
+		}
+		dims = append(dims, d)
+	}
+	return dims, nil
+}
+
+// StatsQuery - запрос статистики за дни [DateFrom, DateTo](UTC)
+type StatsQuery struct {
+	GroupBy  []StatsDimension
+	DateFrom time.Time
+	DateTo   time.Time
+	// ByDay - дополнительно группировать по дням, чтобы пересчитать выручку по курсу каждого дня
+	ByDay bool
+}
+
+// Period - измерение периода из GroupBy или пустая строка
+func (q StatsQuery) Period() StatsDimension {
+	for _, d := range q.GroupBy {
+		if d.IsPeriod() {
+			return d
+		}
+	}
+	return ""
+}
+
+// StatsRow - строка агрегатов: значения измерений, валюта и суммы в этой валюте.
+// Day заполняется при StatsQuery.ByDay
+type StatsRow struct {
+	Period   *time.Time
+	Day      time.Time
+	Keys     map[StatsDimension]string
+	Currency string
+	Orders   int64
+	Items    int64
+	Revenue  Money
+	Refunded Money
+}
+
+// StatsGroup - группа статистики. Выручка и возвраты - по валютам, т.к. суммы разных валют не складываются;
+// Reporting - итог в валюте отчетности, если она запрошена
+type StatsGroup struct {
+	Period    *time.Time
+	Keys      map[StatsDimension]string
+	Orders    int64
+	Items     int64
+	Revenue   []CurrencyAmount
+	Refunded  []CurrencyAmount
+	Reporting *StatsReporting
+}
+
+type StatsReporting struct {
+	Currency string
+	Revenue  Money
+	Refunded Money
+}
+
+// StatsResult - группы статистики в порядке периода и значений измерений
+type StatsResult struct {
+	GroupBy  []StatsDimension
+	DateFrom time.Time
+	DateTo   time.Time
+	Groups   []*StatsGroup
+}
+
+// GroupStats сворачивает строки агрегатов в группы по измерениям запроса, складывая суммы одной валюты.
+// Порядок групп - порядок их первых строк. Если задана валюта отчетности reporting, суммы каждой строки
+// пересчитываются по курсу из rates на день строки(Day) и складываются в StatsGroup.Reporting
+func GroupStats(rows []StatsRow, rates *RateBook, reporting string) ([]*StatsGroup, error) {
+	groups := []*StatsGroup{}
+	index := map[string]*StatsGroup{}
+	for _, row := range rows {
+		key := statsKey(row)
+		g, ok := index[key]
+		if !ok {
+			g = &StatsGroup{Period: row.Period, Keys: row.Keys, Revenue: []CurrencyAmount{}, Refunded: []CurrencyAmount{}}
+			index[key] = g
+			groups = append(groups, g)
+		}
+
+		g.Orders += row.Orders
+		g.Items += row.Items
+		var err error
+		if g.Revenue, err = addCurrencyAmount(g.Revenue, row.Currency, row.Revenue); err != nil {
+			return nil, err
+		}
+		if g.Refunded, err = addCurrencyAmount(g.Refunded, row.Currency, row.Refunded); err != nil {
+			return nil, err
+		}
+		if reporting != "" {
+			if err := g.addReporting(row, rates, reporting); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return groups, nil
+}
+
+func (g *StatsGroup) addReporting(row StatsRow, rates *RateBook, currency string) error {
+	if g.Reporting == nil {
+		g.Reporting = &StatsReporting{
+			Currency: currency,
+			Revenue:  MoneyFromMajor(0, currency),
+			Refunded: MoneyFromMajor(0, currency),
+		}
+	}
+
+	conv, err := rates.Lookup(row.Currency, currency, row.Day)
+	if err != nil {
+		return err
+	}
+	for _, pr := range []struct {
+		dst *Money
+		src Money
+	}{{&g.Reporting.Revenue, row.Revenue}, {&g.Reporting.Refunded, row.Refunded}} {
+		converted, err := conv.Convert(pr.src.WithCurrency(row.Currency))
+		if err != nil {
+			return err
+		}
+		if *pr.dst, err = pr.dst.Add(converted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func statsKey(row StatsRow) string {
+	var b strings.Builder
+	if row.Period != nil {
+		b.WriteString(row.Period.Format(time.DateOnly))
+	}
+	dims := make([]string, 0, len(row.Keys))
+	for d := range row.Keys {
+		dims = append(dims, string(d))
+	}
+	slices.Sort(dims)
+	for _, d := range dims {
+		b.WriteString("\x00" + d + "=" + row.Keys[StatsDimension(d)])
+	}
+	return b.String()
+}
+
+func addCurrencyAmount(list []CurrencyAmount, currency string, amount Money) ([]CurrencyAmount, error) {
+	for i := range list {
+		if list[i].Currency == currency {
+			sum, err := list[i].Amount.Add(amount)
+			if err != nil {
+				return nil, err
+			}
+			list[i].Amount = sum
+			return list, nil
+		}
+	}
+	return append(list, CurrencyAmount{Currency: currency, Amount: amount.WithCurrency(currency)}), nil
+}
@@ -0,0 +1,72 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseStatsGroupBy(t *testing.T) {
+	dims, err := ParseStatsGroupBy(" Month, currency ,brand")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dims) != 3 || dims[0] != StatsByMonth || dims[1] != StatsByCurrency || dims[2] != StatsByBrand {
+		t.Errorf("unexpected dimensions: %v", dims)
+	}
+	if dims, _ := ParseStatsGroupBy(""); len(dims) != 1 || dims[0] != StatsByDay {
+		t.Errorf("expected day by default, got %v", dims)
+	}
+
+	for _, s := range []string{"day,week", "region,region", "country", "month,"} {
+		if _, err := ParseStatsGroupBy(s); !errors.Is(err, ErrInvalidStatsGroup) {
+			t.Errorf("%q: expected ErrInvalidStatsGroup, got %v", s, err)
+		}
+	}
+}
+
+func TestGroupStats(t *testing.T) {
+	d1 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	d2 := d1.AddDate(0, 0, 1)
+	keys := func(provider string) map[StatsDimension]string {
+		return map[StatsDimension]string{StatsByProvider: provider}
+	}
+	rows := []StatsRow{
+		{Day: d1, Keys: keys("wbpay"), Currency: "USD", Orders: 1, Revenue: NewMoney(1000, 2, "USD")},
+		{Day: d1, Keys: keys("alfa"), Currency: "USD", Orders: 1, Revenue: NewMoney(500, 2, "USD")},
+		{Day: d2, Keys: keys("wbpay"), Currency: "USD", Orders: 2, Revenue: NewMoney(2000, 2, "USD"), Refunded: NewMoney(100, 2, "USD")},
+		{Day: d2, Keys: keys("wbpay"), Currency: "EUR", Orders: 1, Revenue: NewMoney(1000, 2, "EUR")},
+	}
+
+	groups, err := GroupStats(rows, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Keys[StatsByProvider] != "wbpay" || groups[1].Keys[StatsByProvider] != "alfa" {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	g := groups[0]
+	if g.Orders != 4 || len(g.Revenue) != 2 || g.Revenue[0].Amount.String() != "30.00 USD" || g.Revenue[1].Amount.String() != "10.00 EUR" {
+		t.Errorf("unexpected revenue: %+v", g.Revenue)
+	}
+	if g.Refunded[0].Amount.String() != "1.00 USD" || g.Reporting != nil {
+		t.Errorf("unexpected refunds or reporting: %+v %+v", g.Refunded, g.Reporting)
+	}
+
+	// курс каждого дня: 1 USD = 0.90 EUR 1 октября, 0.80 EUR 2 октября
+	rates := NewRateBook([]FXRate{
+		mustRate(t, "2026-10-01", "USD", "EUR", "0.9"),
+		mustRate(t, "2026-10-02", "USD", "EUR", "0.8"),
+	}, 0)
+	groups, err = GroupStats(rows, rates, "EUR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rep := groups[0].Reporting; rep == nil || rep.Revenue.String() != "35.00 EUR" || rep.Refunded.String() != "0.80 EUR" {
+		t.Errorf("unexpected reporting totals: %+v", rep)
+	}
+
+	if _, err := GroupStats(rows, rates, "KZT"); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("expected ErrRateNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package orders
+
+import "fmt"
+
+type Status string
+
+const (
+	StatusCreated    Status = "created"
+	StatusPaid       Status = "paid"
+	StatusAssembling Status = "assembling"
+	StatusShipped    Status = "shipped"
+	StatusDelivered  Status = "delivered"
+	StatusCancelled  Status = "cancelled"
+	StatusReturned   Status = "returned"
+)
+
+// transitions - допустимые переходы жизненного цикла заказа
+var transitions = map[Status][]Status{
+	StatusCreated:    {StatusPaid, StatusCancelled},
+	StatusPaid:       {StatusAssembling, StatusCancelled},
+	StatusAssembling: {StatusShipped, StatusCancelled},
+	StatusShipped:    {StatusDelivered, StatusReturned},
+	StatusDelivered:  {StatusReturned},
+	StatusCancelled:  {},
+	StatusReturned:   {},
+}
+
+func ParseStatus(s string) (Status, error) {
+	st := Status(s)
+	if !st.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrUnknownStatus, s)
+	}
+	return st, nil
+}
+
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition возвращает *TransitionError, если переход from -> to не допускается
+func CheckTransition(from, to Status) error {
+	if !to.Valid() {
+		return fmt.Errorf("%w: %q", ErrUnknownStatus, to)
+	}
+	if !from.CanTransitionTo(to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid order status transition from %q to %q", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
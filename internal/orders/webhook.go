@@ -0,0 +1,85 @@
+package orders
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MinWebhookSecretLen - минимальная длина секрета подписи, заданного вручную
+const MinWebhookSecretLen = 16
+
+// WebhookEventTypes - события, на которые можно подписать вебхук. Алерты антифрода партнерам не отправляются
+var WebhookEventTypes = []EventType{
+	EventOrderCreated, EventOrderStatusChanged, EventOrderCancelled,
+	EventOrderItemsCancelled, EventOrderRefunded, EventOrderPIIErased,
+}
+
+// Webhook - подписка партнера на события заказов. Failures - количество неудачных доставок подряд;
+// после WEBHOOK_DISABLE_AFTER неудач подписка отключается(Active = false) с причиной DisabledReason
+type Webhook struct {
+	ID             int64
+	URL            string
+	Secret         string
+	Filter         EventFilter
+	Active         bool
+	Failures       int
+	DisabledReason string
+	DisabledAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Validate проверяет адрес и фильтр подписки и приводит значения фильтра к каноническому виду
+func (w *Webhook) Validate() error {
+	u, err := url.Parse(strings.TrimSpace(w.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be absolute http(s) URL", ErrInvalidWebhook)
+	}
+	w.URL = u.String()
+	if w.Secret != "" && len(w.Secret) < MinWebhookSecretLen {
+		return fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidWebhook, MinWebhookSecretLen)
+	}
+
+	for i, t := range w.Filter.EventTypes {
+		t = EventType(strings.ToLower(strings.TrimSpace(string(t))))
+		if !slices.Contains(WebhookEventTypes, t) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, w.Filter.EventTypes[i])
+		}
+		w.Filter.EventTypes[i] = t
+	}
+	w.Filter.EventTypes = compactValues(w.Filter.EventTypes)
+	w.Filter.DeliveryServices = compactValues(w.Filter.DeliveryServices)
+	w.Filter.CustomerIDs = compactValues(w.Filter.CustomerIDs)
+	return nil
+}
+
+// compactValues убирает пустые значения и повторы, сохраняя порядок
+func compactValues[T ~string](list []T) []T {
+	res := make([]T, 0, len(list))
+	for _, v := range list {
+		v = T(strings.TrimSpace(string(v)))
+		if v != "" && !slices.Contains(res, v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// WebhookDelivery - запись журнала доставки: одна попытка отправки события подписке.
+// DeliveryID общий для всех попыток одного события
+type WebhookDelivery struct {
+	ID         int64
+	WebhookID  int64
+	DeliveryID string
+	EventType  EventType
+	OrderUID   string
+	Attempt    int
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+	Success    bool
+	CreatedAt  time.Time
+}
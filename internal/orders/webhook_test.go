@@ -0,0 +1,60 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	w := &Webhook{
+		URL: " https://partner.example.com/hooks ",
+		Filter: EventFilter{
+			EventTypes:       []EventType{" Order.Created", "order.created", "order.refunded"},
+			DeliveryServices: []string{"meest", "", " meest "},
+		},
+	}
+	if err := w.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.URL != "https://partner.example.com/hooks" {
+		t.Errorf("unexpected url %q", w.URL)
+	}
+	if len(w.Filter.EventTypes) != 2 || w.Filter.EventTypes[0] != EventOrderCreated || len(w.Filter.DeliveryServices) != 1 {
+		t.Errorf("unexpected filter: %+v", w.Filter)
+	}
+	if w.Filter.CustomerIDs == nil {
+		t.Error("empty filter lists must be non-nil")
+	}
+
+	for name, bad := range map[string]*Webhook{
+		"relative url":  {URL: "/hooks"},
+		"ftp url":       {URL: "ftp://example.com"},
+		"short secret":  {URL: "https://example.com", Secret: "short"},
+		"unknown event": {URL: "https://example.com", Filter: EventFilter{EventTypes: []EventType{"order.deleted"}}},
+		"fraud alert":   {URL: "https://example.com", Filter: EventFilter{EventTypes: []EventType{EventOrderFraudAlert}}},
+	} {
+		if err := bad.Validate(); !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("%s: expected ErrInvalidWebhook, got %v", name, err)
+		}
+	}
+}
+
+func TestEventFilterMatches(t *testing.T) {
+	e := Event{Type: EventOrderCreated, OrderUID: "o1", CustomerID: "c1", DeliveryService: "Meest"}
+	for _, tc := range []struct {
+		filter EventFilter
+		want   bool
+	}{
+		{EventFilter{}, true},
+		{EventFilter{EventTypes: []EventType{EventOrderCreated}, DeliveryServices: []string{"meest"}, CustomerIDs: []string{"c1"}}, true},
+		{EventFilter{EventTypes: []EventType{EventOrderRefunded}}, false},
+		{EventFilter{DeliveryServices: []string{"cdek"}}, false},
+		{EventFilter{CustomerIDs: []string{"c2"}}, false},
+		{EventFilter{OrderUIDs: []string{"o1", "o2"}}, true},
+		{EventFilter{OrderUIDs: []string{"o2"}}, false},
+	} {
+		if got := tc.filter.Matches(e); got != tc.want {
+			t.Errorf("%+v: expected %v, got %v", tc.filter, tc.want, got)
+		}
+	}
+}
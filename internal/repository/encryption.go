@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/envelope"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FieldCipher шифрует персональные данные доставки перед записью в БД.
+// nil - шифрование выключено, данные хранятся открытыми
+type FieldCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ctx context.Context, value string) (string, error)
+	BlindIndex(field, normalized string) string
+}
+
+// sealDelivery возвращает копию доставки с зашифрованными полями и слепые индексы email и телефона
+func (r *OrdersRepository) sealDelivery(d orders.Delivery) (orders.Delivery, *string, *string, error) {
+	if r.cipher == nil {
+		return d, nil, nil, nil
+	}
+
+	emailIdx := r.blindIndex(orders.IndexFieldEmail, orders.NormalizeEmail(d.Email))
+	phoneIdx := r.blindIndex(orders.IndexFieldPhone, orders.NormalizePhone(d.Phone))
+	if err := d.MapPII(r.cipher.Encrypt); err != nil {
+		return d, nil, nil, err
+	}
+	return d, emailIdx, phoneIdx, nil
+}
+
+func (r *OrdersRepository) openDelivery(ctx context.Context, d *orders.Delivery) error {
+	if r.cipher == nil {
+		return nil
+	}
+	return d.MapPII(func(v string) (string, error) { return r.cipher.Decrypt(ctx, v) })
+}
+
+func (r *OrdersRepository) blindIndex(field, normalized string) *string {
+	if normalized == "" {
+		return nil
+	}
+	idx := r.cipher.BlindIndex(field, normalized)
+	return &idx
+}
+
+// ReencryptDeliveries перешифровывает активным ключом данных доставки, зашифрованные другими ключами
+// или записанные открытыми до включения шифрования, и пересчитывает их слепые индексы.
+// Строки обрабатываются пачками по batchSize, каждая пачка - отдельная транзакция
+func (r *OrdersRepository) ReencryptDeliveries(ctx context.Context, activeKeyID, batchSize int) (int, error) {
+	updated, lastID := 0, 0
+	for {
+		n, next, err := r.reencryptDeliveriesBatch(ctx, activeKeyID, lastID, batchSize)
+		updated += n
+		if err != nil || next == lastID {
+			return updated, err
+		}
+		lastID = next
+	}
+}
+
+func (r *OrdersRepository) reencryptDeliveriesBatch(ctx context.Context, activeKeyID, afterID, batchSize int) (updated, lastID int, err error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, afterID, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, order_id, COALESCE(phone, ''), COALESCE(email, ''), COALESCE(address, '')
+		FROM deliveries
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE;
+	`, afterID, batchSize)
+	if err != nil {
+		return 0, afterID, err
+	}
+	type row struct {
+		id      int
+		orderID int
+		d       orders.Delivery
+	}
+	var batch []row
+	for rows.Next() {
+		var rw row
+		if err = rows.Scan(&rw.id, &rw.orderID, &rw.d.Phone, &rw.d.Email, &rw.d.Address); err != nil {
+			rows.Close()
+			return 0, afterID, err
+		}
+		batch = append(batch, rw)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, afterID, err
+	}
+
+	lastID = afterID
+	var orderIDs []int
+	for _, rw := range batch {
+		lastID = rw.id
+		if !needsReencryption(rw.d, activeKeyID) {
+			continue
+		}
+		if err = r.openDelivery(ctx, &rw.d); err != nil {
+			return updated, lastID, err
+		}
+		sealed, emailIdx, phoneIdx, err := r.sealDelivery(rw.d)
+		if err != nil {
+			return updated, lastID, err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE deliveries SET phone = $2, email = $3, address = $4, email_bidx = $5, phone_bidx = $6
+			WHERE id = $1
+		`, rw.id, sealed.Phone, sealed.Email, sealed.Address, emailIdx, phoneIdx)
+		if err != nil {
+			return updated, lastID, err
+		}
+		updated++
+		orderIDs = append(orderIDs, rw.orderID)
+	}
+
+	// адрес зашифрован - поисковые документы пересобираются без открытого текста
+	if len(orderIDs) > 0 {
+		if _, err = tx.Exec(ctx, refreshSearchDocument, orderIDs); err != nil {
+			return updated, lastID, err
+		}
+	}
+	return updated, lastID, nil
+}
+
+// ReencryptVersions перешифровывает активным ключом данные доставки в сохраненных снимках версий
+func (r *OrdersRepository) ReencryptVersions(ctx context.Context, activeKeyID, batchSize int) (int, error) {
+	updated := 0
+	var lastID int64
+	for {
+		rows, err := r.db.Query(ctx, `
+			SELECT id, snapshot->'Delivery'
+			FROM order_versions
+			WHERE id > $1
+			ORDER BY id
+			LIMIT $2;
+		`, lastID, batchSize)
+		if err != nil {
+			return updated, err
+		}
+
+		type row struct {
+			id int64
+			d  orders.Delivery
+		}
+		var batch []row
+		for rows.Next() {
+			var (
+				rw  row
+				raw []byte
+			)
+			if err := rows.Scan(&rw.id, &raw); err != nil {
+				rows.Close()
+				return updated, err
+			}
+			if raw != nil {
+				if err := json.Unmarshal(raw, &rw.d); err != nil {
+					rows.Close()
+					return updated, err
+				}
+			}
+			batch = append(batch, rw)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, err
+		}
+		if len(batch) == 0 {
+			return updated, nil
+		}
+
+		for _, rw := range batch {
+			lastID = rw.id
+			if !needsReencryption(rw.d, activeKeyID) {
+				continue
+			}
+			if err := r.openDelivery(ctx, &rw.d); err != nil {
+				return updated, err
+			}
+			sealed, _, _, err := r.sealDelivery(rw.d)
+			if err != nil {
+				return updated, err
+			}
+			_, err = r.db.Exec(ctx, `
+				UPDATE order_versions
+				SET snapshot = jsonb_set(snapshot, '{Delivery}',
+					COALESCE(snapshot->'Delivery', '{}'::jsonb) || jsonb_build_object(
+						'Phone', $2::TEXT, 'Email', $3::TEXT, 'Address', $4::TEXT
+					))
+				WHERE id = $1
+			`, rw.id, sealed.Phone, sealed.Email, sealed.Address)
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
+
+// needsReencryption - есть ли в доставке непустое поле, не зашифрованное активным ключом
+func needsReencryption(d orders.Delivery, activeKeyID int) bool {
+	for _, v := range []string{d.Phone, d.Email, d.Address} {
+		if v != "" && envelope.KeyID(v) != activeKeyID {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyStore хранит обернутые ключи данных в таблице encryption_keys
+type KeyStore struct {
+	db *pgxpool.Pool
+}
+
+func NewKeyStore(db *pgxpool.Pool) *KeyStore {
+	return &KeyStore{db: db}
+}
+
+func (s *KeyStore) LoadKeys(ctx context.Context) ([]envelope.WrappedKey, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, purpose, master_key_id, wrapped_key, active
+		FROM encryption_keys
+		ORDER BY id;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []envelope.WrappedKey
+	for rows.Next() {
+		var k envelope.WrappedKey
+		if err := rows.Scan(&k.ID, &k.Purpose, &k.MasterKeyID, &k.Wrapped, &k.Active); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *KeyStore) CreateKey(ctx context.Context, key envelope.WrappedKey) (id int, err error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	if key.Active {
+		_, err = tx.Exec(ctx, `UPDATE encryption_keys SET active = false WHERE purpose = $1 AND active`, key.Purpose)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO encryption_keys (purpose, wrapped_key, master_key_id, active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, key.Purpose, key.Wrapped, key.MasterKeyID, key.Active).Scan(&id)
+	return id, err
+}
+
+func (s *KeyStore) UpdateWrapped(ctx context.Context, id int, masterKeyID string, wrapped []byte) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE encryption_keys SET wrapped_key = $2, master_key_id = $3
+		WHERE id = $1
+	`, id, wrapped, masterKeyID)
+	return err
+}
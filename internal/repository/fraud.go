@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+)
+
+// maxDuplicateUIDs - сколько заказов с той же транзакцией попадает в пояснение правила
+const maxDuplicateUIDs = 10
+
+// GetFraudSignals собирает данные о предыдущих заказах для правил оценки риска: заказы покупателя в валюте заказа,
+// заказы с тем же телефоном за phoneWindow до создания заказа и заказы с той же транзакцией оплаты.
+// Сам заказ(order_uid) не учитывается
+func (r *OrdersRepository) GetFraudSignals(ctx context.Context, order *orders.Order, phoneWindow time.Duration) (orders.FraudSignals, error) {
+	var s orders.FraudSignals
+
+	if order.CustomerID != "" {
+		err := r.db.QueryRow(ctx, `
+			SELECT COUNT(*), COALESCE(SUM(p.amount), 0)
+			FROM orders o
+			JOIN payments p ON o.id = p.order_id
+			WHERE o.customer_id = $1 AND p.currency = $2 AND o.order_uid <> $3;
+		`, order.CustomerID, order.Payment.Currency, order.OrderUID).Scan(&s.CustomerOrders, &s.CustomerTotal)
+		if err != nil {
+			return s, err
+		}
+		s.CustomerTotal = s.CustomerTotal.WithCurrency(order.Payment.Currency)
+	}
+
+	if phone := orders.NormalizePhone(order.Delivery.Phone); phone != "" && phoneWindow > 0 {
+		at := time.Now().UTC()
+		if order.DateCreated != nil {
+			at = *order.DateCreated
+		}
+		var c conditions
+		if r.cipher != nil {
+			c.add("d.phone_bidx = ?", r.cipher.BlindIndex(orders.IndexFieldPhone, phone))
+		} else {
+			c.add(`regexp_replace(d.phone, '\D', '', 'g') = ?`, phone)
+		}
+		c.add("o.date_created >= ?", at.Add(-phoneWindow))
+		c.add("o.date_created <= ?", at)
+		c.add("o.order_uid <> ?", order.OrderUID)
+
+		err := r.db.QueryRow(ctx, `
+			SELECT COUNT(*)
+			FROM orders o
+			JOIN deliveries d ON o.id = d.order_id
+		`+c.sql(), c.args...).Scan(&s.PhoneOrders)
+		if err != nil {
+			return s, err
+		}
+	}
+
+	if order.Payment.Transaction != "" {
+		rows, err := r.db.Query(ctx, `
+			SELECT o.order_uid
+			FROM payments p
+			JOIN orders o ON o.id = p.order_id
+			WHERE p.transaction = $1 AND o.order_uid <> $2
+			ORDER BY o.id
+			LIMIT $3;
+		`, order.Payment.Transaction, order.OrderUID, maxDuplicateUIDs)
+		if err != nil {
+			return s, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var uid string
+			if err := rows.Scan(&uid); err != nil {
+				return s, err
+			}
+			s.DuplicateUIDs = append(s.DuplicateUIDs, uid)
+		}
+		if err := rows.Err(); err != nil {
+			return s, err
+		}
+	}
+
+	return s, nil
+}
+
+// fraudColumns - значения fraud_score и fraud_rules; заказ без оценки сохраняется с NULL
+func fraudColumns(f *orders.FraudScore) (*int, []byte, error) {
+	if f == nil {
+		return nil, nil, nil
+	}
+	rules, err := json.Marshal(f.Rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &f.Score, rules, nil
+}
+
+func scanFraud(o *orders.Order, score *int, rules []byte) error {
+	if score == nil {
+		return nil
+	}
+	o.Fraud = &orders.FraudScore{Score: *score, Rules: []orders.FraudHit{}}
+	if len(rules) == 0 {
+		return nil
+	}
+	return json.Unmarshal(rules, &o.Fraud.Rules)
+}
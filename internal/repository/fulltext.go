@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"html"
+	"strings"
+	"wb_tech_level_zero/internal/orders"
+)
+
+// refreshSearchDocument пересобирает поисковые документы заказов из позиций и доставки.
+// Зашифрованный адрес в документ не попадает, чтобы открытый текст не оказался в индексе
+const refreshSearchDocument = `
+	INSERT INTO order_search (order_id, document, content)
+	SELECT
+		o.id,
+		setweight(to_tsvector('russian', COALESCE(it.names, '')), 'A') ||
+		setweight(to_tsvector('russian', COALESCE(it.brands, '')), 'A') ||
+		setweight(to_tsvector('russian', COALESCE(dl.city, '')), 'B') ||
+		setweight(to_tsvector('russian', COALESCE(dl.address, '')), 'C'),
+		concat_ws(' ', it.names, it.brands, dl.city, dl.address)
+	FROM orders o
+	LEFT JOIN LATERAL (
+		SELECT string_agg(i.name, ' ') AS names, string_agg(DISTINCT i.brand, ' ') AS brands
+		FROM items i WHERE i.order_id = o.id
+	) it ON TRUE
+	LEFT JOIN LATERAL (
+		SELECT d.city, CASE WHEN d.address LIKE 'enc:%' THEN NULL ELSE d.address END AS address
+		FROM deliveries d WHERE d.order_id = o.id
+		LIMIT 1
+	) dl ON TRUE
+	WHERE o.id = ANY($1)
+	ON CONFLICT (order_id) DO UPDATE SET document = EXCLUDED.document, content = EXCLUDED.content
+`
+
+// textFields - поля найденных заказов, в которых подсвечиваются совпадения
+const textFields = `
+	SELECT i.order_id, '` + orders.TextFieldItemName + `' AS field, i.name AS value FROM items i WHERE i.order_id = ANY($1)
+	UNION
+	SELECT i.order_id, '` + orders.TextFieldItemBrand + `', i.brand FROM items i WHERE i.order_id = ANY($1)
+	UNION
+	SELECT d.order_id, '` + orders.TextFieldDeliveryCity + `', d.city FROM deliveries d WHERE d.order_id = ANY($1)
+	UNION
+	SELECT d.order_id, '` + orders.TextFieldDeliveryAddress + `', d.address FROM deliveries d
+	WHERE d.order_id = ANY($1) AND d.address NOT LIKE 'enc:%'
+`
+
+// fuzzyHighlightThreshold - минимальное сходство поля с запросом, при котором поле считается совпавшим в нечетком поиске
+const fuzzyHighlightThreshold = "0.3"
+
+// textMatch возвращает условие совпадения и выражение ранга: по словам(tsvector) или по триграммам
+func textMatch(fuzzy bool) (cond, rank string) {
+	if fuzzy {
+		return "$1 <% s.content", "word_similarity($1, s.content)"
+	}
+	return "s.document @@ websearch_to_tsquery('russian', $1)", "ts_rank_cd(s.document, websearch_to_tsquery('russian', $1))"
+}
+
+// SearchOrdersText ищет заказы по словам запроса и сортирует по релевантности.
+// Если ни одно слово не нашлось, выполняется нечеткий поиск по триграммам(опечатки)
+func (r *OrdersRepository) SearchOrdersText(ctx context.Context, q orders.TextQuery) (*orders.TextSearchResult, error) {
+	res := &orders.TextSearchResult{Hits: []orders.TextHit{}}
+	for _, fuzzy := range []bool{false, true} {
+		cond, _ := textMatch(fuzzy)
+		if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM order_search s WHERE `+cond, q.Text).Scan(&res.Total); err != nil {
+			return nil, err
+		}
+		if res.Total > 0 {
+			res.Fuzzy = fuzzy
+			break
+		}
+	}
+	if res.Total == 0 {
+		return res, nil
+	}
+
+	cond, rank := textMatch(res.Fuzzy)
+	rows, err := r.db.Query(ctx, `
+		SELECT s.order_id, `+rank+` AS rank
+		FROM order_search s
+		JOIN orders o ON o.id = s.order_id
+		WHERE `+cond+`
+		ORDER BY rank DESC, o.date_created DESC NULLS LAST, o.id DESC
+		LIMIT $2 OFFSET $3
+	`, q.Text, q.Limit, q.Offset)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ids   []int
+		ranks []float64
+	)
+	for rows.Next() {
+		var (
+			id   int
+			rank float64
+		)
+		if err := rows.Scan(&id, &rank); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		ranks = append(ranks, rank)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	list, err := r.queryOrders(ctx, ordersSelect+`
+		WHERE o.id = ANY($1);
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*orders.Order, len(list))
+	for _, o := range list {
+		byID[o.ID] = o
+	}
+
+	highlights, err := r.textHighlights(ctx, ids, q.Text, res.Fuzzy)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		o, ok := byID[id]
+		if !ok {
+			continue
+		}
+		hl := highlights[id]
+		if hl == nil {
+			hl = []orders.Highlight{}
+		}
+		res.Hits = append(res.Hits, orders.TextHit{Order: o, Rank: ranks[i], Highlights: hl})
+	}
+	return res, nil
+}
+
+// Границы совпадений, которые ставит ts_headline: символы из области частного использования Unicode,
+// удаляются из значений полей, поэтому разметку нельзя подделать содержимым позиций
+const (
+	markStart = "\uE000"
+	markStop  = "\uE001"
+)
+
+// textHighlights возвращает совпавшие поля заказов. В поиске по словам совпадения размечаются ts_headline,
+// в нечетком - поле возвращается целиком без разметки. Текст полей экранируется как HTML
+func (r *OrdersRepository) textHighlights(ctx context.Context, orderIDs []int, text string, fuzzy bool) (map[int][]orders.Highlight, error) {
+	query := `
+		SELECT f.order_id, f.field, h.fragment
+		FROM (` + textFields + `) f,
+			websearch_to_tsquery('russian', $2) AS q(tsq),
+			ts_headline('russian', translate(f.value, $3 || $4, ''), q.tsq,
+				format('StartSel=%s, StopSel=%s, HighlightAll=true', $3::text, $4::text)) AS h(fragment)
+		WHERE strpos(h.fragment, $3) > 0
+		ORDER BY f.order_id, f.field, h.fragment
+	`
+	args := []any{orderIDs, text, markStart, markStop}
+	if fuzzy {
+		query = `
+			SELECT f.order_id, f.field, f.value
+			FROM (` + textFields + `) f
+			WHERE word_similarity($2, f.value) >= ` + fuzzyHighlightThreshold + `
+			ORDER BY f.order_id, f.field, f.value
+		`
+		args = args[:2]
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int][]orders.Highlight)
+	for rows.Next() {
+		var (
+			id int
+			hl orders.Highlight
+		)
+		if err := rows.Scan(&id, &hl.Field, &hl.Fragment); err != nil {
+			return nil, err
+		}
+		hl.Fragment = markFragment(hl.Fragment, !fuzzy)
+		result[id] = append(result[id], hl)
+	}
+	return result, rows.Err()
+}
+
+// markFragment экранирует текст фрагмента и заменяет границы совпадений на <mark></mark>;
+// в неразмеченном фрагменте(нечеткий поиск) границы удаляются
+func markFragment(fragment string, marked bool) string {
+	fragment = html.EscapeString(strings.TrimSpace(fragment))
+	if !marked {
+		return strings.NewReplacer(markStart, "", markStop, "").Replace(fragment)
+	}
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(fragment)
+}
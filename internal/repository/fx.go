@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+)
+
+// SaveFXRates сохраняет курсы одним запросом; курс на ту же дату и пару валют перезаписывается
+func (r *OrdersRepository) SaveFXRates(ctx context.Context, rates []orders.FXRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	var (
+		dates   = make([]time.Time, len(rates))
+		bases   = make([]string, len(rates))
+		quotes  = make([]string, len(rates))
+		values  = make([]string, len(rates))
+		sources = make([]string, len(rates))
+	)
+	for i, rt := range rates {
+		dates[i], bases[i], quotes[i] = rt.Date, rt.Base, rt.Quote
+		values[i], sources[i] = orders.RateString(rt.Rate), rt.Source
+	}
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO fx_rates (rate_date, base, quote, rate, source)
+		SELECT * FROM unnest($1::date[], $2::text[], $3::text[], $4::numeric[], $5::text[])
+		ON CONFLICT (base, quote, rate_date) DO UPDATE
+		SET rate = EXCLUDED.rate, source = EXCLUDED.source, loaded_at = now();
+	`, dates, bases, quotes, values, sources)
+	return err
+}
+
+// GetFXRates возвращает курсы с датой в [from, to], в которых участвует хотя бы одна из валют.
+// Пары с другой базовой валютой нужны для кросс-курсов
+func (r *OrdersRepository) GetFXRates(ctx context.Context, currencies []string, from, to time.Time) ([]orders.FXRate, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT rate_date, base, quote, rate::text, source
+		FROM fx_rates
+		WHERE (quote = ANY($1) OR base = ANY($1)) AND rate_date BETWEEN $2 AND $3;
+	`, currencies, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []orders.FXRate
+	for rows.Next() {
+		var (
+			rt   orders.FXRate
+			rate string
+		)
+		if err := rows.Scan(&rt.Date, &rt.Base, &rt.Quote, &rate, &rt.Source); err != nil {
+			return nil, err
+		}
+		if rt.Rate, err = orders.ParseRate(rate); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rt)
+	}
+	return rates, rows.Err()
+}
@@ -2,108 +2,288 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"slices"
+	"strconv"
+	"time"
 	"wb_tech_level_zero/internal/orders"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// querier - общий интерфейс пула и транзакции, чтобы чтение заказа работало и внутри транзакции
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type OrdersRepository struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	cipher FieldCipher
 }
 
-func NewOrdersRepository(db *pgxpool.Pool) *OrdersRepository {
-	return &OrdersRepository{db: db}
+// NewOrdersRepository создает репозиторий; cipher может быть nil, тогда данные доставки хранятся открытыми
+func NewOrdersRepository(db *pgxpool.Pool, cipher FieldCipher) *OrdersRepository {
+	return &OrdersRepository{db: db, cipher: cipher}
 }
 
-func (r *OrdersRepository) GetOrderByUID(ctx context.Context, orderUID string) (*orders.Order, error) {
-	const query = `
+const ordersSelect = `
 		SELECT 
-			o.id, o.order_uid, o.track_number, o.entry, 
+			o.id, o.order_uid, o.track_number, o.entry, o.status,
 			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
 			p.transaction, p.request_id, p.currency, p.provider, p.amount, p.payment_dt, 
-			p.bank, p.delivery_cost, p.goods_total, p.custom_fee,
+			p.bank, p.delivery_cost, p.goods_total, p.custom_fee, p.paid_amount, p.refunded_amount,
 			o.locale, o.internal_signature, o.customer_id, o.delivery_service,
-			o.shardkey, o.sm_id, o.date_created, o.oof_shard
+			o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.fraud_score, o.fraud_rules
 		FROM orders o
 		JOIN deliveries d ON o.id = d.order_id
 		JOIN payments p ON o.id = p.order_id
+`
+
+func (r *OrdersRepository) GetOrderByUID(ctx context.Context, orderUID string) (*orders.Order, error) {
+	const query = ordersSelect + `
 		WHERE o.order_uid = $1;
 	`
+	return r.getOrder(ctx, r.db, query, orderUID)
+}
 
+func (r *OrdersRepository) getOrder(ctx context.Context, q querier, query string, args ...any) (*orders.Order, error) {
 	var o orders.Order
-	err := r.db.QueryRow(ctx, query, orderUID).Scan(
-		&o.ID, &o.OrderUID, &o.TrackNumber, &o.Entry,
-		&o.Delivery.Name, &o.Delivery.Phone, &o.Delivery.Zip, &o.Delivery.City,
-		&o.Delivery.Address, &o.Delivery.Region, &o.Delivery.Email,
-		&o.Payment.Transaction, &o.Payment.RequestID, &o.Payment.Currency, &o.Payment.Provider,
-		&o.Payment.Amount, &o.Payment.PaymentDT, &o.Payment.Bank, &o.Payment.DeliveryCost,
-		&o.Payment.GoodsTotal, &o.Payment.CustomFee,
-		&o.Locale, &o.InternalSignature, &o.CustomerID, &o.DeliveryService,
-		&o.Shardkey, &o.SmID, &o.DateCreated, &o.OofShard,
-	)
+	err := scanOrder(q.QueryRow(ctx, query, args...), &o)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, orders.ErrOrderNotFound
 		}
 		return nil, err
 	}
+	if err := r.openDelivery(ctx, &o.Delivery); err != nil {
+		return nil, err
+	}
 
 	// items
-	const itemsQuery = `
-		SELECT chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
-		FROM items
-		WHERE order_id = $1;
-	`
-	rows, err := r.db.Query(ctx, itemsQuery, o.ID)
-	if err != nil {
+	o.Items = []orders.Item{}
+	if err := loadItems(ctx, q, map[int]*orders.Order{o.ID: &o}, []int{o.ID}); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var item orders.Item
-		if err := rows.Scan(
-			&item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid, &item.Name,
-			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
-		); err != nil {
+	return &o, nil
+}
+
+// GetOrders возвращает страницу заказов с фильтрами и сортировкой. Значения фильтров передаются только
+// параметрами запроса, сортировка выбирается из фиксированного набора.
+// При сортировке по дате страница может начинаться с курсора(keyset по (date_created, id)) вместо OFFSET,
+// а в результат добавляются курсоры соседних страниц
+func (r *OrdersRepository) GetOrders(ctx context.Context, q orders.ListQuery) (*orders.ListResult, error) {
+	byDate := q.Sort.Field == "" || q.Sort.Field == orders.SortByDate
+	if q.Cursor != nil && (!byDate || q.Cursor.Asc != q.Sort.Asc) {
+		return nil, orders.ErrCursorSort
+	}
+
+	c := filterConditions(q.Filter)
+	res := &orders.ListResult{Total: orders.TotalUnknown}
+
+	switch q.Total {
+	case orders.TotalNone:
+	case orders.TotalEstimate:
+		total, err := r.estimateCount(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		res.Total, res.TotalEstimated = total, true
+	default:
+		total, err := r.count(ctx, c)
+		if err != nil {
 			return nil, err
 		}
-		o.Items = append(o.Items, item)
+		res.Total = total
 	}
 
-	return &o, nil
+	// страница "назад" выбирается в обратном порядке и затем разворачивается
+	backward := q.Cursor != nil && q.Cursor.Prev
+	if q.Cursor != nil {
+		// при сортировке по убыванию следующая страница - строки меньше курсора
+		op := "<"
+		if q.Sort.Asc != backward {
+			op = ">"
+		}
+		c.args = append(c.args, q.Cursor.DateCreated, q.Cursor.ID)
+		n := len(c.args)
+		c.where = append(c.where, "(o.date_created, o.id) "+op+" ($"+strconv.Itoa(n-1)+", $"+strconv.Itoa(n)+")")
+	}
+
+	sort := q.Sort
+	if backward {
+		sort.Asc = !sort.Asc
+	}
+	offset := q.Offset
+	if q.Cursor != nil {
+		offset = 0
+	}
+
+	// лишняя строка показывает, есть ли следующая страница
+	n := len(c.args)
+	query := ordersSelect + c.sql() + `
+		ORDER BY ` + orderBy(sort) + `
+		LIMIT $` + strconv.Itoa(n+1) + ` OFFSET $` + strconv.Itoa(n+2)
+
+	list, err := r.queryOrders(ctx, query, append(c.args, q.Limit+1, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	more := len(list) > q.Limit
+	if more {
+		list = list[:q.Limit]
+	}
+	if backward {
+		slices.Reverse(list)
+	}
+	res.Orders = list
+
+	if byDate && len(list) > 0 {
+		first, last := list[0], list[len(list)-1]
+		hasNext := more || backward
+		hasPrev := (backward && more) || (!backward && (q.Cursor != nil || offset > 0))
+		if hasNext {
+			res.Next = orders.CursorAt(last, false, q.Sort.Asc)
+		}
+		if hasPrev {
+			res.Prev = orders.CursorAt(first, true, q.Sort.Asc)
+		}
+	}
+	return res, nil
 }
 
-func (r *OrdersRepository) GetOrders(ctx context.Context, limit, offset int) ([]*orders.Order, int, error) {
+func (r *OrdersRepository) GetOrdersByUIDs(ctx context.Context, orderUIDs []string) ([]*orders.Order, error) {
+	if len(orderUIDs) == 0 {
+		return []*orders.Order{}, nil
+	}
 
-	// TO DO: Подумать над оптимизацией
+	const query = ordersSelect + `
+		WHERE o.order_uid = ANY($1)
+		ORDER BY o.date_created DESC, o.id DESC;
+	`
+	return r.queryOrders(ctx, query, orderUIDs)
+}
 
-	const countQuery = `SELECT COUNT(*) FROM orders;`
+func (r *OrdersRepository) GetOrdersByCustomerIDs(ctx context.Context, customerIDs []string, limit, offset int) ([]*orders.Order, error) {
+	if len(customerIDs) == 0 {
+		return []*orders.Order{}, nil
+	}
+
+	const query = ordersSelect + `
+		WHERE o.customer_id = ANY($1)
+		ORDER BY o.date_created DESC, o.id DESC
+		LIMIT $2 OFFSET $3;
+	`
+	return r.queryOrders(ctx, query, customerIDs, limit, offset)
+}
+
+// GetCustomerOrders возвращает страницу заказов покупателя(новые первыми) и общее количество его заказов
+func (r *OrdersRepository) GetCustomerOrders(ctx context.Context, customerID string, limit, offset int) ([]*orders.Order, int, error) {
 	var total int
-	if err := r.db.QueryRow(ctx, countQuery).Scan(&total); err != nil {
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM orders WHERE customer_id = $1`, customerID).Scan(&total)
+	if err != nil {
 		return nil, 0, err
 	}
+	if total == 0 {
+		return []*orders.Order{}, 0, nil
+	}
 
-	const query = `
-		SELECT 
-			o.id, o.order_uid, o.track_number, o.entry, 
-			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
-			p.transaction, p.request_id, p.currency, p.provider, p.amount, p.payment_dt, 
-			p.bank, p.delivery_cost, p.goods_total, p.custom_fee,
-			o.locale, o.internal_signature, o.customer_id, o.delivery_service,
-			o.shardkey, o.sm_id, o.date_created, o.oof_shard
+	const query = ordersSelect + `
+		WHERE o.customer_id = $1
+		ORDER BY o.date_created DESC, o.id DESC
+		LIMIT $2 OFFSET $3;
+	`
+	list, err := r.queryOrders(ctx, query, customerID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// GetCustomerSummary считает сводку по заказам покупателя; brandsLimit - количество любимых брендов в ответе
+func (r *OrdersRepository) GetCustomerSummary(ctx context.Context, customerID string, brandsLimit int) (*orders.CustomerSummary, error) {
+	summary := &orders.CustomerSummary{CustomerID: customerID}
+
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*), MIN(date_created), MAX(date_created)
+		FROM orders
+		WHERE customer_id = $1;
+	`, customerID).Scan(&summary.OrdersCount, &summary.FirstOrderAt, &summary.LastOrderAt)
+	if err != nil {
+		return nil, err
+	}
+	if summary.OrdersCount == 0 {
+		return nil, orders.ErrCustomerNotFound
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT p.currency, SUM(p.paid_amount - p.refunded_amount)
 		FROM orders o
-		JOIN deliveries d ON o.id = d.order_id
 		JOIN payments p ON o.id = p.order_id
-		ORDER BY o.date_created DESC
-		LIMIT $1 OFFSET $2;
+		WHERE o.customer_id = $1
+		GROUP BY p.currency
+		ORDER BY p.currency;
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	summary.Spend = []orders.CurrencyAmount{}
+	for rows.Next() {
+		var ca orders.CurrencyAmount
+		if err := rows.Scan(&ca.Currency, &ca.Amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ca.Amount = ca.Amount.WithCurrency(ca.Currency)
+		summary.Spend = append(summary.Spend, ca)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(ctx, `
+		SELECT i.brand, COUNT(*) AS cnt
+		FROM orders o
+		JOIN items i ON o.id = i.order_id
+		WHERE o.customer_id = $1 AND NOT i.cancelled AND COALESCE(i.brand, '') <> ''
+		GROUP BY i.brand
+		ORDER BY cnt DESC, i.brand
+		LIMIT $2;
+	`, customerID, brandsLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary.FavouriteBrands = []orders.BrandCount{}
+	for rows.Next() {
+		var bc orders.BrandCount
+		if err := rows.Scan(&bc.Brand, &bc.Items); err != nil {
+			return nil, err
+		}
+		summary.FavouriteBrands = append(summary.FavouriteBrands, bc)
+	}
+	return summary, rows.Err()
+}
+
+func (r *OrdersRepository) GetOrdersCreatedSince(ctx context.Context, since time.Time, limit, offset int) ([]*orders.Order, error) {
+	const query = ordersSelect + `
+		WHERE o.date_created >= $1
+		ORDER BY o.date_created DESC, o.id DESC
+		LIMIT $2 OFFSET $3;
 	`
+	return r.queryOrders(ctx, query, since, limit, offset)
+}
 
-	rows, err := r.db.Query(ctx, query, limit, offset)
+// queryOrders выполняет запрос, возвращающий колонки ordersSelect, и догружает позиции заказов одним запросом
+func (r *OrdersRepository) queryOrders(ctx context.Context, query string, args ...any) ([]*orders.Order, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -112,17 +292,11 @@ func (r *OrdersRepository) GetOrders(ctx context.Context, limit, offset int) ([]
 
 	for rows.Next() {
 		var o orders.Order
-		if err := rows.Scan(
-			&o.ID, &o.OrderUID, &o.TrackNumber, &o.Entry,
-			&o.Delivery.Name, &o.Delivery.Phone, &o.Delivery.Zip, &o.Delivery.City,
-			&o.Delivery.Address, &o.Delivery.Region, &o.Delivery.Email,
-			&o.Payment.Transaction, &o.Payment.RequestID, &o.Payment.Currency, &o.Payment.Provider,
-			&o.Payment.Amount, &o.Payment.PaymentDT, &o.Payment.Bank, &o.Payment.DeliveryCost,
-			&o.Payment.GoodsTotal, &o.Payment.CustomFee,
-			&o.Locale, &o.InternalSignature, &o.CustomerID, &o.DeliveryService,
-			&o.Shardkey, &o.SmID, &o.DateCreated, &o.OofShard,
-		); err != nil {
-			return nil, 0, err
+		if err := scanOrder(rows, &o); err != nil {
+			return nil, err
+		}
+		if err := r.openDelivery(ctx, &o.Delivery); err != nil {
+			return nil, err
 		}
 
 		o.Items = []orders.Item{}
@@ -130,23 +304,38 @@ func (r *OrdersRepository) GetOrders(ctx context.Context, limit, offset int) ([]
 		orderIDs = append(orderIDs, o.ID)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	if len(orderIDs) == 0 {
-		return []*orders.Order{}, total, nil
+		return []*orders.Order{}, nil
+	}
+
+	if err := loadItems(ctx, r.db, ordersMap, orderIDs); err != nil {
+		return nil, err
 	}
 
+	ordersList := make([]*orders.Order, len(orderIDs))
+	for i, id := range orderIDs {
+		ordersList[i] = ordersMap[id]
+	}
+
+	return ordersList, nil
+}
+
+func loadItems(ctx context.Context, q querier, ordersMap map[int]*orders.Order, orderIDs []int) error {
+
 	// TO DO: Подумать над оптимизацией
 
 	const itemsQuery = `
-		SELECT order_id, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+		SELECT order_id, chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status, cancelled
 		FROM items
-		WHERE order_id = ANY($1);
+		WHERE order_id = ANY($1)
+		ORDER BY id;
 	`
-	itemRows, err := r.db.Query(ctx, itemsQuery, orderIDs)
+	itemRows, err := q.Query(ctx, itemsQuery, orderIDs)
 	if err != nil {
-		return nil, 0, err
+		return err
 	}
 	defer itemRows.Close()
 
@@ -155,24 +344,46 @@ func (r *OrdersRepository) GetOrders(ctx context.Context, limit, offset int) ([]
 		var orderID int
 		if err := itemRows.Scan(
 			&orderID, &item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid, &item.Name,
-			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID, &item.Brand, &item.Status, &item.Cancelled,
 		); err != nil {
-			return nil, 0, err
+			return err
 		}
 		if order, ok := ordersMap[orderID]; ok {
 			order.Items = append(order.Items, item)
 		}
 	}
-	if err = itemRows.Err(); err != nil {
-		return nil, 0, err
+	if err := itemRows.Err(); err != nil {
+		return err
 	}
 
-	ordersList := make([]*orders.Order, len(orderIDs))
-	for i, id := range orderIDs {
-		ordersList[i] = ordersMap[id]
+	// NUMERIC читается без валюты - она проставляется из оплаты, когда заказ собран целиком
+	for _, order := range ordersMap {
+		if err := order.ApplyCurrency(); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return ordersList, total, nil
+func scanOrder(row pgx.Row, o *orders.Order) error {
+	var (
+		fraudScore *int
+		fraudRules []byte
+	)
+	err := row.Scan(
+		&o.ID, &o.OrderUID, &o.TrackNumber, &o.Entry, &o.Status,
+		&o.Delivery.Name, &o.Delivery.Phone, &o.Delivery.Zip, &o.Delivery.City,
+		&o.Delivery.Address, &o.Delivery.Region, &o.Delivery.Email,
+		&o.Payment.Transaction, &o.Payment.RequestID, &o.Payment.Currency, &o.Payment.Provider,
+		&o.Payment.Amount, &o.Payment.PaymentDT, &o.Payment.Bank, &o.Payment.DeliveryCost,
+		&o.Payment.GoodsTotal, &o.Payment.CustomFee, &o.Payment.Paid, &o.Payment.Refunded,
+		&o.Locale, &o.InternalSignature, &o.CustomerID, &o.DeliveryService,
+		&o.Shardkey, &o.SmID, &o.DateCreated, &o.OofShard, &fraudScore, &fraudRules,
+	)
+	if err != nil {
+		return err
+	}
+	return scanFraud(o, fraudScore, fraudRules)
 }
 
 func (r *OrdersRepository) SaveOrder(ctx context.Context, order *orders.Order) error {
@@ -197,17 +408,24 @@ func (r *OrdersRepository) SaveOrder(ctx context.Context, order *orders.Order) e
 		return err
 	}
 
+	fraudScore, fraudRules, err := fraudColumns(order.Fraud)
+	if err != nil {
+		return err
+	}
+
 	var orderID int
 	err = tx.QueryRow(ctx, `
 		INSERT INTO orders (
-			order_uid, track_number, entry, locale, internal_signature,
-			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+			order_uid, track_number, entry, status, locale, internal_signature,
+			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard,
+			fraud_score, fraud_rules
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
 		RETURNING id
 	`,
 		order.OrderUID,
 		order.TrackNumber,
 		order.Entry,
+		order.Status,
 		order.Locale,
 		order.InternalSignature,
 		order.CustomerID,
@@ -216,24 +434,40 @@ func (r *OrdersRepository) SaveOrder(ctx context.Context, order *orders.Order) e
 		order.SmID,
 		order.DateCreated,
 		order.OofShard,
+		fraudScore,
+		fraudRules,
 	).Scan(&orderID)
 	if err != nil {
 		return err
 	}
 
+	_, err = tx.Exec(ctx, `
+		INSERT INTO order_status_history (order_id, from_status, to_status)
+		VALUES ($1, NULL, $2)
+	`, orderID, order.Status)
+	if err != nil {
+		return err
+	}
+
+	delivery, emailIdx, phoneIdx, err := r.sealDelivery(order.Delivery)
+	if err != nil {
+		return err
+	}
 	_, err = tx.Exec(ctx, `
 		INSERT INTO deliveries (
-			order_id, name, phone, zip, city, address, region, email
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+			order_id, name, phone, zip, city, address, region, email, email_bidx, phone_bidx
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
 	`,
 		orderID,
-		order.Delivery.Name,
-		order.Delivery.Phone,
-		order.Delivery.Zip,
-		order.Delivery.City,
-		order.Delivery.Address,
-		order.Delivery.Region,
-		order.Delivery.Email,
+		delivery.Name,
+		delivery.Phone,
+		delivery.Zip,
+		delivery.City,
+		delivery.Address,
+		delivery.Region,
+		delivery.Email,
+		emailIdx,
+		phoneIdx,
 	)
 	if err != nil {
 		return err
@@ -242,8 +476,8 @@ func (r *OrdersRepository) SaveOrder(ctx context.Context, order *orders.Order) e
 	_, err = tx.Exec(ctx, `
 		INSERT INTO payments (
 			order_id, transaction, request_id, currency, provider, amount,
-			payment_dt, bank, delivery_cost, goods_total, custom_fee
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+			payment_dt, bank, delivery_cost, goods_total, custom_fee, paid_amount
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
 	`,
 		orderID,
 		order.Payment.Transaction,
@@ -256,6 +490,7 @@ func (r *OrdersRepository) SaveOrder(ctx context.Context, order *orders.Order) e
 		order.Payment.DeliveryCost,
 		order.Payment.GoodsTotal,
 		order.Payment.CustomFee,
+		order.Payment.Paid,
 	)
 	if err != nil {
 		return err
@@ -265,8 +500,8 @@ func (r *OrdersRepository) SaveOrder(ctx context.Context, order *orders.Order) e
 		_, err = tx.Exec(ctx, `
 			INSERT INTO items (
 				order_id, chrt_id, track_number, price, rid, name,
-				sale, size, total_price, nm_id, brand, status
-			) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+				sale, size, total_price, nm_id, brand, status, cancelled
+			) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
 		`,
 			orderID,
 			it.ChrtID,
@@ -280,11 +515,442 @@ func (r *OrdersRepository) SaveOrder(ctx context.Context, order *orders.Order) e
 			it.NmID,
 			it.Brand,
 			it.Status,
+			it.Cancelled,
 		)
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if _, err = tx.Exec(ctx, refreshSearchDocument, []int{orderID}); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, addOrderStats, orderID); err != nil {
+		return err
+	}
+
+	err = r.recordVersion(ctx, tx, orderID, orders.EventOrderCreated)
+	return err
+}
+
+// UpdateOrderStatus меняет статус заказа, только если текущий статус равен change.From,
+// и записывает переход в историю
+func (r *OrdersRepository) UpdateOrderStatus(ctx context.Context, orderUID string, change orders.StatusChange) (err error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	var orderID int
+	err = tx.QueryRow(ctx, `
+		UPDATE orders SET status = $1
+		WHERE order_uid = $2 AND status = $3
+		RETURNING id
+	`, change.To, orderUID, change.From).Scan(&orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = orders.ErrStatusConflict
+		}
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO order_status_history (order_id, from_status, to_status, reason)
+		VALUES ($1, $2, $3, $4)
+	`, orderID, change.From, change.To, change.Reason)
+	if err != nil {
+		return err
+	}
+
+	return r.recordVersion(ctx, tx, orderID, orders.EventOrderStatusChanged)
+}
+
+// CancelOrderItems отменяет позиции заказа(пустой refs - весь заказ) под блокировкой строки заказа,
+// сохраняет пересчитанные суммы и, если заказ отменен полностью, переход статуса
+func (r *OrdersRepository) CancelOrderItems(ctx context.Context, orderUID string, refs []orders.ItemRef, reason string) (order *orders.Order, err error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	order, err = r.getOrder(ctx, tx, ordersSelect+`
+		WHERE o.order_uid = $1
+		FOR UPDATE OF o;
+	`, orderUID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := order.Status
+	if err = order.CancelItems(refs); err != nil {
+		return nil, err
+	}
+
+	// вклад заказа в агрегаты статистики пересчитывается: до отмены вычитается, после - добавляется
+	if _, err = tx.Exec(ctx, removeOrderStats, order.ID); err != nil {
+		return nil, err
+	}
+
+	// позиции загружаются в порядке id, поэтому отмененные помечаются по номеру позиции:
+	// одинаковые rid и chrt_id у нескольких позиций не приводят к отмене лишних
+	var positions []int
+	for i, it := range order.Items {
+		if it.Cancelled {
+			positions = append(positions, i+1)
+		}
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE items i SET cancelled = true
+		FROM (SELECT id, row_number() OVER (ORDER BY id) AS n FROM items WHERE order_id = $1) p
+		WHERE i.id = p.id AND p.n = ANY($2)
+	`, order.ID, positions)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE payments SET goods_total = $2, amount = $3
+		WHERE order_id = $1
+	`, order.ID, order.Payment.GoodsTotal, order.Payment.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.Exec(ctx, addOrderStats, order.ID); err != nil {
+		return nil, err
+	}
+
+	change := orders.EventOrderItemsCancelled
+	if order.Status != from {
+		change = orders.EventOrderCancelled
+		_, err = tx.Exec(ctx, `UPDATE orders SET status = $2 WHERE id = $1`, order.ID, order.Status)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO order_status_history (order_id, from_status, to_status, reason)
+			VALUES ($1, $2, $3, $4)
+		`, order.ID, from, order.Status, reason)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = r.recordVersion(ctx, tx, order.ID, change); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// AddRefund сохраняет возврат по транзакции оплаты заказа. Проверка суммы выполняется под блокировкой
+// строки оплаты, поэтому параллельные возвраты не могут в сумме превысить оплаченное
+func (r *OrdersRepository) AddRefund(ctx context.Context, orderUID string, refund *orders.Refund) (order *orders.Order, err error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	order, err = r.getOrder(ctx, tx, ordersSelect+`
+		WHERE o.order_uid = $1
+		FOR UPDATE OF p;
+	`, orderUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = order.Payment.CheckRefund(*refund); err != nil {
+		return nil, err
+	}
+	refund.Transaction = order.Payment.Transaction
+	refund.Amount = refund.Amount.WithCurrency(order.Payment.Currency)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO refunds (order_id, transaction, amount, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, order.ID, refund.Transaction, refund.Amount, refund.Reason).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Payment.Refunded, err = order.Payment.Refunded.Add(refund.Amount); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE payments SET refunded_amount = $2
+		WHERE order_id = $1
+	`, order.ID, order.Payment.Refunded)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(ctx, addRefundStats, order.ID, refund.Amount); err != nil {
+		return nil, err
+	}
+
+	if err = r.recordVersion(ctx, tx, order.ID, orders.EventOrderRefunded); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (r *OrdersRepository) GetRefunds(ctx context.Context, orderUID string) ([]orders.Refund, error) {
+	var orderID int
+	err := r.db.QueryRow(ctx, `SELECT id FROM orders WHERE order_uid = $1`, orderUID).Scan(&orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, orders.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT rf.id, rf.transaction, rf.amount, COALESCE(p.currency, ''), COALESCE(rf.reason, ''), rf.created_at
+		FROM refunds rf
+		LEFT JOIN payments p ON p.order_id = rf.order_id
+		WHERE rf.order_id = $1
+		ORDER BY rf.id;
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []orders.Refund{}
+	for rows.Next() {
+		var (
+			rf       orders.Refund
+			currency string
+		)
+		if err := rows.Scan(&rf.ID, &rf.Transaction, &rf.Amount, &currency, &rf.Reason, &rf.CreatedAt); err != nil {
+			return nil, err
+		}
+		rf.Amount = rf.Amount.WithCurrency(currency)
+		refunds = append(refunds, rf)
+	}
+	return refunds, rows.Err()
+}
+
+// EraseCustomer обезличивает данные доставки во всех заказах покупателя и в их сохраненных версиях,
+// заменяет CustomerID псевдонимом erasure.CustomerRef и сохраняет запись аудита. Финансовые данные не изменяются
+func (r *OrdersRepository) EraseCustomer(ctx context.Context, customerID string, erasure *orders.Erasure) (err error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			err = tx.Commit(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, order_uid FROM orders
+		WHERE customer_id = $1
+		ORDER BY id
+		FOR UPDATE;
+	`, customerID)
+	if err != nil {
+		return err
+	}
+	var orderIDs []int
+	erasure.OrderUIDs = []string{}
+	for rows.Next() {
+		var (
+			id  int
+			uid string
+		)
+		if err = rows.Scan(&id, &uid); err != nil {
+			rows.Close()
+			return err
+		}
+		orderIDs = append(orderIDs, id)
+		erasure.OrderUIDs = append(erasure.OrderUIDs, uid)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if len(orderIDs) > 0 {
+		_, err = tx.Exec(ctx, `
+			UPDATE deliveries
+			SET name = $2, phone = '', email = '', address = '', zip = '', email_bidx = NULL, phone_bidx = NULL
+			WHERE order_id = ANY($1)
+		`, orderIDs, orders.ErasedName)
+		if err != nil {
+			return err
+		}
+
+		// адрес стерт - поисковые документы пересобираются без него
+		if _, err = tx.Exec(ctx, refreshSearchDocument, orderIDs); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE orders SET customer_id = $2 WHERE id = ANY($1)`, orderIDs, erasure.CustomerRef)
+		if err != nil {
+			return err
+		}
+
+		// в снимках версий обезличиваются те же поля, история изменений остальных данных сохраняется
+		_, err = tx.Exec(ctx, `
+			UPDATE order_versions
+			SET snapshot = snapshot || jsonb_build_object(
+				'CustomerID', $2::TEXT,
+				'Delivery', COALESCE(snapshot->'Delivery', '{}'::jsonb) || jsonb_build_object(
+					'Name', $3::TEXT, 'Phone', '', 'Email', '', 'Address', '', 'Zip', ''
+				)
+			)
+			WHERE order_id = ANY($1)
+		`, orderIDs, erasure.CustomerRef, orders.ErasedName)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range orderIDs {
+			if err = r.recordVersion(ctx, tx, id, orders.EventOrderPIIErased); err != nil {
+				return err
+			}
+		}
+	}
+
+	erasure.Source = orders.ChangeSourceFromContext(ctx)
+	err = tx.QueryRow(ctx, `
+		INSERT INTO customer_erasures (customer_ref, order_uids, reason, source, source_ref, actor)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`,
+		erasure.CustomerRef,
+		erasure.OrderUIDs,
+		erasure.Reason,
+		erasure.Source.Kind,
+		erasure.Source.Ref,
+		erasure.Source.Actor,
+	).Scan(&erasure.ID, &erasure.CreatedAt)
+	return err
+}
+
+// recordVersion сохраняет снимок заказа в том виде, в каком он находится внутри транзакции tx.
+// Вызывается последним шагом каждой изменяющей заказ транзакции; источник изменения берется из ctx.
+// Данные доставки в снимке шифруются так же, как в таблице deliveries
+func (r *OrdersRepository) recordVersion(ctx context.Context, tx pgx.Tx, orderID int, change orders.EventType) error {
+	const query = ordersSelect + `
+		WHERE o.id = $1;
+	`
+	order, err := r.getOrder(ctx, tx, query, orderID)
+	if err != nil {
+		return err
+	}
+	if order.Delivery, _, _, err = r.sealDelivery(order.Delivery); err != nil {
+		return err
+	}
+
+	snapshot, err := orders.MarshalStoredJSON(order)
+	if err != nil {
+		return err
+	}
+
+	src := orders.ChangeSourceFromContext(ctx)
+	_, err = tx.Exec(ctx, `
+		INSERT INTO order_versions (order_id, version, change_type, source, source_ref, actor, snapshot)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6
+		FROM order_versions WHERE order_id = $1
+	`, orderID, change, src.Kind, src.Ref, src.Actor, snapshot)
+	return err
+}
+
+func (r *OrdersRepository) GetOrderHistory(ctx context.Context, orderUID string) ([]orders.OrderVersion, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT v.version, v.change_type, v.source, COALESCE(v.source_ref, ''), COALESCE(v.actor, ''),
+			v.created_at, v.snapshot
+		FROM order_versions v
+		JOIN orders o ON o.id = v.order_id
+		WHERE o.order_uid = $1
+		ORDER BY v.version;
+	`, orderUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []orders.OrderVersion
+	for rows.Next() {
+		v, err := r.scanVersion(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, *v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(history) == 0 {
+		return nil, orders.ErrOrderNotFound
+	}
+	return history, nil
+}
+
+// GetOrderAsOf возвращает последнюю версию заказа, сохраненную не позднее asOf
+func (r *OrdersRepository) GetOrderAsOf(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT v.version, v.change_type, v.source, COALESCE(v.source_ref, ''), COALESCE(v.actor, ''),
+			v.created_at, v.snapshot
+		FROM order_versions v
+		JOIN orders o ON o.id = v.order_id
+		WHERE o.order_uid = $1 AND v.created_at <= $2
+		ORDER BY v.version DESC
+		LIMIT 1;
+	`, orderUID, asOf)
+
+	v, err := r.scanVersion(ctx, row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, orders.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+func (r *OrdersRepository) scanVersion(ctx context.Context, row pgx.Row) (*orders.OrderVersion, error) {
+	var (
+		v        orders.OrderVersion
+		snapshot []byte
+	)
+	err := row.Scan(&v.Version, &v.Change, &v.Source.Kind, &v.Source.Ref, &v.Source.Actor, &v.CreatedAt, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Order = &orders.Order{}
+	if err := json.Unmarshal(snapshot, v.Order); err != nil {
+		return nil, err
+	}
+	if err := r.openDelivery(ctx, &v.Order.Delivery); err != nil {
+		return nil, err
+	}
+	return &v, nil
 }
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"wb_tech_level_zero/internal/orders"
+)
+
+// conditions собирает WHERE с позиционными параметрами pgx
+type conditions struct {
+	where []string
+	args  []any
+}
+
+// add добавляет условие; каждый "?" в cond по порядку заменяется номером параметра из args
+func (c *conditions) add(cond string, args ...any) {
+	for _, arg := range args {
+		c.args = append(c.args, arg)
+		cond = strings.Replace(cond, "?", "$"+strconv.Itoa(len(c.args)), 1)
+	}
+	c.where = append(c.where, cond)
+}
+
+func (c *conditions) sql() string {
+	if len(c.where) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(c.where, " AND ")
+}
+
+const countFrom = `
+	FROM orders o
+	JOIN deliveries d ON o.id = d.order_id
+	JOIN payments p ON o.id = p.order_id
+`
+
+func (r *OrdersRepository) count(ctx context.Context, c conditions) (int, error) {
+	var total int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*)`+countFrom+c.sql(), c.args...).Scan(&total)
+	return total, err
+}
+
+// estimateCount берет оценку количества строк из плана запроса(EXPLAIN) - без чтения таблиц
+func (r *OrdersRepository) estimateCount(ctx context.Context, c conditions) (int, error) {
+	var plan []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	err := r.db.QueryRow(ctx, `EXPLAIN (FORMAT JSON) SELECT 1`+countFrom+c.sql(), c.args...).Scan(&plan)
+	if err != nil {
+		return 0, err
+	}
+	if len(plan) == 0 {
+		return 0, nil
+	}
+	return int(plan[0].Plan.Rows), nil
+}
+
+// filterConditions переводит фильтры списка заказов в условия по колонкам ordersSelect
+func filterConditions(f orders.ListFilter) conditions {
+	var c conditions
+	if f.DateFrom != nil {
+		c.add("o.date_created >= ?", *f.DateFrom)
+	}
+	if f.DateTo != nil {
+		c.add("o.date_created < ?", *f.DateTo)
+	}
+	for _, eq := range []struct{ column, value string }{
+		{"o.delivery_service", f.DeliveryService},
+		{"o.locale", f.Locale},
+		{"o.customer_id", f.CustomerID},
+		{"p.currency", f.Currency},
+		{"p.provider", f.Provider},
+		{"p.bank", f.Bank},
+		{"d.city", f.City},
+		{"d.region", f.Region},
+	} {
+		if eq.value != "" {
+			c.add(eq.column+" = ?", eq.value)
+		}
+	}
+	// границы суммы передаются как NUMERIC(Money.NumericValue), дробные значения сравниваются точно
+	if f.MinAmount != nil {
+		c.add("p.amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		c.add("p.amount <= ?", *f.MaxAmount)
+	}
+
+	// бренд и статус должны относиться к одной позиции
+	var item []string
+	if f.Brand != "" {
+		c.args = append(c.args, f.Brand)
+		item = append(item, "lower(i.brand) = lower($"+strconv.Itoa(len(c.args))+")")
+	}
+	if f.ItemStatus != nil {
+		c.args = append(c.args, *f.ItemStatus)
+		item = append(item, "i.status = $"+strconv.Itoa(len(c.args)))
+	}
+	if len(item) > 0 {
+		c.where = append(c.where, "EXISTS (SELECT 1 FROM items i WHERE i.order_id = o.id AND "+strings.Join(item, " AND ")+")")
+	}
+	return c
+}
+
+// orderBy возвращает выражение сортировки; o.id добавляется для стабильного порядка при равных значениях
+func orderBy(s orders.ListSort) string {
+	dir := "DESC"
+	if s.Asc {
+		dir = "ASC"
+	}
+
+	var column string
+	switch s.Field {
+	case orders.SortByAmount:
+		column = "p.amount"
+	case orders.SortByItems:
+		column = "(SELECT COUNT(*) FROM items i WHERE i.order_id = o.id)"
+	default:
+		column = "o.date_created"
+	}
+	return column + " " + dir + " NULLS LAST, o.id " + dir
+}
+
+// SearchOrders ищет заказы по точному совпадению полей заказа, оплаты, позиций и контактов доставки.
+// Поиск по телефону и email при включенном шифровании идет по слепым индексам
+func (r *OrdersRepository) SearchOrders(ctx context.Context, criteria orders.SearchCriteria, limit, offset int) ([]*orders.Order, int, error) {
+	var c conditions
+	if criteria.TrackNumber != "" {
+		c.add("o.track_number = ?", criteria.TrackNumber)
+	}
+	if criteria.Transaction != "" {
+		c.add("p.transaction = ?", criteria.Transaction)
+	}
+	if criteria.RequestID != "" {
+		c.add("p.request_id = ?", criteria.RequestID)
+	}
+	if criteria.Rid != "" {
+		c.add("EXISTS (SELECT 1 FROM items i WHERE i.order_id = o.id AND i.rid = ?)", criteria.Rid)
+	}
+	if criteria.NmID != 0 {
+		c.add("EXISTS (SELECT 1 FROM items i WHERE i.order_id = o.id AND i.nm_id = ?)", criteria.NmID)
+	}
+	if criteria.Brand != "" {
+		c.add("EXISTS (SELECT 1 FROM items i WHERE i.order_id = o.id AND lower(i.brand) = lower(?))", criteria.Brand)
+	}
+	// строки, сохраненные до включения шифрования и еще не перешифрованные rotate_keys,
+	// не имеют слепого индекса - для них сравнивается открытое значение
+	if email := orders.NormalizeEmail(criteria.Email); email != "" {
+		if r.cipher != nil {
+			c.add(`(d.email_bidx = ? OR (d.email_bidx IS NULL AND d.email NOT LIKE 'enc:%' AND lower(d.email) = ?))`,
+				r.cipher.BlindIndex(orders.IndexFieldEmail, email), email)
+		} else {
+			c.add("lower(d.email) = ?", email)
+		}
+	}
+	if phone := orders.NormalizePhone(criteria.Phone); phone != "" {
+		if r.cipher != nil {
+			c.add(`(d.phone_bidx = ? OR (d.phone_bidx IS NULL AND d.phone NOT LIKE 'enc:%' AND regexp_replace(d.phone, '\D', '', 'g') = ?))`,
+				r.cipher.BlindIndex(orders.IndexFieldPhone, phone), phone)
+		} else {
+			c.add(`regexp_replace(d.phone, '\D', '', 'g') = ?`, phone)
+		}
+	}
+	if len(c.where) == 0 {
+		return nil, 0, orders.ErrEmptySearch
+	}
+
+	total, err := r.count(ctx, c)
+	if err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return []*orders.Order{}, 0, nil
+	}
+
+	n := len(c.args)
+	query := ordersSelect + c.sql() + `
+		ORDER BY o.date_created DESC, o.id DESC
+		LIMIT $` + strconv.Itoa(n+1) + ` OFFSET $` + strconv.Itoa(n+2)
+	list, err := r.queryOrders(ctx, query, append(c.args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
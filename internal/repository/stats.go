@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// statsDimensions - ключ строки агрегатов заказа; вычисляется одинаково при сохранении заказа и возврате
+const statsDimensions = `
+	(o.date_created AT TIME ZONE 'UTC')::date AS day, COALESCE(o.delivery_service, '') AS delivery_service,
+	COALESCE(p.provider, '') AS provider, COALESCE(p.bank, '') AS bank,
+	COALESCE(p.currency, '') AS currency, COALESCE(d.region, '') AS region
+`
+
+const (
+	statsJoins = `
+	FROM orders o
+	JOIN payments p ON o.id = p.order_id
+	JOIN deliveries d ON o.id = d.order_id
+`
+	statsWhere = `
+	WHERE o.id = $1 AND o.date_created IS NOT NULL
+`
+	statsFrom = statsJoins + statsWhere
+)
+
+// orderStats возвращает запрос, который добавляет(sign "+") или вычитает(sign "-") вклад заказа $1 в агрегаты
+// order_stats_daily и order_brand_stats_daily. Позиции бренда учитываются только неотмененные, поэтому отмена
+// позиций вычитает вклад заказа до изменения и добавляет после
+func orderStats(sign string) string {
+	return `
+	WITH order_stats AS (
+		INSERT INTO order_stats_daily (day, delivery_service, provider, bank, currency, region, orders_count, revenue)
+		SELECT ` + statsDimensions + `, ` + sign + `1, ` + sign + `COALESCE(p.amount, 0)` + statsFrom + `
+		ON CONFLICT (day, delivery_service, provider, bank, currency, region) DO UPDATE
+		SET orders_count = order_stats_daily.orders_count + EXCLUDED.orders_count,
+			revenue = order_stats_daily.revenue + EXCLUDED.revenue
+	)
+	INSERT INTO order_brand_stats_daily (day, brand, delivery_service, provider, bank, currency, region, orders_count, items_count, revenue)
+	SELECT day, brand, delivery_service, provider, bank, currency, region, ` + sign + `1, ` + sign + `items_count, ` + sign + `revenue
+	FROM (
+		SELECT ` + statsDimensions + `, COALESCE(i.brand, '') AS brand,
+			COUNT(*) AS items_count, SUM(COALESCE(i.total_price, 0)) AS revenue
+		` + statsJoins + `JOIN items i ON o.id = i.order_id AND NOT i.cancelled` + statsWhere + `
+		GROUP BY 1, 2, 3, 4, 5, 6, 7
+	) b
+	ON CONFLICT (day, brand, delivery_service, provider, bank, currency, region) DO UPDATE
+	SET orders_count = order_brand_stats_daily.orders_count + EXCLUDED.orders_count,
+		items_count = order_brand_stats_daily.items_count + EXCLUDED.items_count,
+		revenue = order_brand_stats_daily.revenue + EXCLUDED.revenue;
+`
+}
+
+var (
+	addOrderStats    = orderStats("+")
+	removeOrderStats = orderStats("-")
+)
+
+// addRefundStats учитывает возврат $2 заказа $1 в агрегатах order_stats_daily
+const addRefundStats = `
+	UPDATE order_stats_daily s SET refunded = s.refunded + $2
+	FROM (SELECT ` + statsDimensions + statsFrom + `) k
+	WHERE s.day = k.day AND s.delivery_service = k.delivery_service AND s.provider = k.provider
+		AND s.bank = k.bank AND s.currency = k.currency AND s.region = k.region;
+`
+
+// GetOrderStats читает агрегаты за период с группировкой по измерениям запроса и валюте.
+// Статистика по брендам берется из order_brand_stats_daily, возвраты в ней не учитываются
+func (r *OrdersRepository) GetOrderStats(ctx context.Context, q orders.StatsQuery) ([]orders.StatsRow, error) {
+	table, items, refunded := "order_stats_daily", "0::bigint", "SUM(refunded)"
+	var (
+		dims       []orders.StatsDimension
+		byCurrency bool
+	)
+	for _, d := range q.GroupBy {
+		switch {
+		case d == orders.StatsByBrand:
+			table, items, refunded = "order_brand_stats_daily", "SUM(items_count)::bigint", "0::numeric"
+		case d == orders.StatsByCurrency:
+			// валюта группируется всегда
+			byCurrency = true
+			continue
+		case d.IsPeriod():
+			continue
+		}
+		dims = append(dims, d)
+	}
+
+	period, day := "NULL::date", "NULL::date"
+	if p := q.Period(); p != "" {
+		period = "date_trunc('" + string(p) + "', day)::date"
+	}
+	if q.ByDay {
+		day = "day"
+	}
+
+	// измерения - фиксированные имена колонок из ParseStatsGroupBy, значения передаются параметрами
+	groupBy := []string{"1", "2"}
+	columns := make([]string, 0, len(dims))
+	for i, d := range dims {
+		columns = append(columns, string(d))
+		groupBy = append(groupBy, fmt.Sprint(i+3))
+	}
+	groupBy = append(groupBy, "currency")
+	orderBy := append(append([]string{"1"}, groupBy[2:]...), "2")
+
+	query := `
+		SELECT ` + period + `, ` + day + `, ` + strings.Join(append(columns, "currency"), ", ") + `,
+			SUM(orders_count)::bigint, ` + items + `, SUM(revenue), ` + refunded + `
+		FROM ` + table + `
+		WHERE day BETWEEN $1 AND $2
+		GROUP BY ` + strings.Join(groupBy, ", ") + `
+		ORDER BY ` + strings.Join(orderBy, ", ") + `;
+	`
+	rows, err := r.db.Query(ctx, query, q.DateFrom, q.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []orders.StatsRow
+	for rows.Next() {
+		row, err := scanStatsRow(rows, dims)
+		if err != nil {
+			return nil, err
+		}
+		if byCurrency {
+			row.Keys[orders.StatsByCurrency] = row.Currency
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func scanStatsRow(rows pgx.Rows, dims []orders.StatsDimension) (orders.StatsRow, error) {
+	var (
+		row    = orders.StatsRow{Keys: make(map[orders.StatsDimension]string, len(dims))}
+		day    *time.Time
+		values = make([]string, len(dims))
+		dest   = []any{&row.Period, &day}
+	)
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	dest = append(dest, &row.Currency, &row.Orders, &row.Items, &row.Revenue, &row.Refunded)
+
+	if err := rows.Scan(dest...); err != nil {
+		return row, err
+	}
+	if day != nil {
+		row.Day = *day
+	}
+	for i, d := range dims {
+		row.Keys[d] = values[i]
+	}
+	row.Revenue = row.Revenue.WithCurrency(row.Currency)
+	row.Refunded = row.Refunded.WithCurrency(row.Currency)
+	return row, nil
+}
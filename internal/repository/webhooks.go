@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const webhooksSelect = `
+	SELECT id, url, secret, event_types, delivery_services, customer_ids, active, failures,
+		COALESCE(disabled_reason, ''), disabled_at, created_at, updated_at
+	FROM webhooks
+`
+
+// CreateWebhook сохраняет подписку и заполняет ее ID и даты
+func (r *OrdersRepository) CreateWebhook(ctx context.Context, w *orders.Webhook) error {
+	secret, err := r.sealSecret(w.Secret)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRow(ctx, `
+		INSERT INTO webhooks (url, secret, event_types, delivery_services, customer_ids, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at;
+	`, w.URL, secret, eventTypeStrings(w.Filter.EventTypes), w.Filter.DeliveryServices, w.Filter.CustomerIDs, w.Active,
+	).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+}
+
+func (r *OrdersRepository) GetWebhook(ctx context.Context, id int64) (*orders.Webhook, error) {
+	w, err := r.scanWebhook(ctx, r.db.QueryRow(ctx, webhooksSelect+`WHERE id = $1;`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, orders.ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func (r *OrdersRepository) GetWebhooks(ctx context.Context) ([]*orders.Webhook, error) {
+	return r.queryWebhooks(ctx, webhooksSelect+`ORDER BY id;`)
+}
+
+// GetActiveWebhooks - подписки, которым отправляются события
+func (r *OrdersRepository) GetActiveWebhooks(ctx context.Context) ([]*orders.Webhook, error) {
+	return r.queryWebhooks(ctx, webhooksSelect+`WHERE active ORDER BY id;`)
+}
+
+// UpdateWebhook заменяет адрес, фильтр и активность подписки; пустой Secret оставляет прежний.
+// Включение подписки сбрасывает счетчик неудач и причину отключения
+func (r *OrdersRepository) UpdateWebhook(ctx context.Context, w *orders.Webhook) error {
+	var secret *string
+	if w.Secret != "" {
+		sealed, err := r.sealSecret(w.Secret)
+		if err != nil {
+			return err
+		}
+		secret = &sealed
+	}
+
+	err := r.db.QueryRow(ctx, `
+		UPDATE webhooks SET
+			url = $2, secret = COALESCE($3, secret),
+			event_types = $4, delivery_services = $5, customer_ids = $6,
+			failures = CASE WHEN $7 AND NOT active THEN 0 ELSE failures END,
+			disabled_reason = CASE WHEN $7 THEN NULL ELSE COALESCE(disabled_reason, 'disabled manually') END,
+			disabled_at = CASE WHEN $7 THEN NULL ELSE COALESCE(disabled_at, now()) END,
+			active = $7, updated_at = now()
+		WHERE id = $1
+		RETURNING failures, COALESCE(disabled_reason, ''), disabled_at, created_at, updated_at;
+	`, w.ID, secret, eventTypeStrings(w.Filter.EventTypes), w.Filter.DeliveryServices, w.Filter.CustomerIDs, w.Active,
+	).Scan(&w.Failures, &w.DisabledReason, &w.DisabledAt, &w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return orders.ErrWebhookNotFound
+	}
+	return err
+}
+
+// DeleteWebhook удаляет подписку вместе с журналом доставки
+func (r *OrdersRepository) DeleteWebhook(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return orders.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// RecordWebhookResult учитывает итог доставки события: успех сбрасывает счетчик неудач, неудача увеличивает его
+// и после disableAfter неудач подряд отключает подписку(disableAfter <= 0 - не отключать). Возвращает true, если
+// подписка отключена этим вызовом
+func (r *OrdersRepository) RecordWebhookResult(ctx context.Context, id int64, success bool, disableAfter int, reason string) (bool, error) {
+	if success {
+		_, err := r.db.Exec(ctx, `UPDATE webhooks SET failures = 0 WHERE id = $1 AND failures > 0`, id)
+		return false, err
+	}
+
+	// old - состояние до обновления: RETURNING видит только новые значения
+	var disabled bool
+	err := r.db.QueryRow(ctx, `
+		UPDATE webhooks w SET
+			failures = w.failures + 1,
+			active = w.active AND NOT ($2 > 0 AND w.failures + 1 >= $2),
+			disabled_reason = CASE WHEN w.active AND $2 > 0 AND w.failures + 1 >= $2 THEN $3 ELSE w.disabled_reason END,
+			disabled_at = CASE WHEN w.active AND $2 > 0 AND w.failures + 1 >= $2 THEN now() ELSE w.disabled_at END,
+			updated_at = now()
+		FROM (SELECT id, active FROM webhooks WHERE id = $1 FOR UPDATE) old
+		WHERE w.id = old.id
+		RETURNING old.active AND NOT w.active;
+	`, id, disableAfter, reason).Scan(&disabled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, orders.ErrWebhookNotFound
+	}
+	return disabled, err
+}
+
+func (r *OrdersRepository) LogWebhookDelivery(ctx context.Context, d *orders.WebhookDelivery) error {
+	var statusCode *int
+	if d.StatusCode != 0 {
+		statusCode = &d.StatusCode
+	}
+	return r.db.QueryRow(ctx, `
+		INSERT INTO webhook_deliveries (
+			webhook_id, delivery_id, event_type, order_uid, attempt, status_code, error, duration_ms, success
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9)
+		RETURNING id, created_at;
+	`, d.WebhookID, d.DeliveryID, d.EventType, d.OrderUID, d.Attempt, statusCode, d.Error,
+		d.Duration.Milliseconds(), d.Success,
+	).Scan(&d.ID, &d.CreatedAt)
+}
+
+// GetWebhookDeliveries - последние limit попыток доставки подписки, новые первыми
+func (r *OrdersRepository) GetWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]orders.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, webhook_id, delivery_id, event_type, order_uid, attempt, COALESCE(status_code, 0),
+			COALESCE(error, ''), duration_ms, success, created_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT $2;
+	`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []orders.WebhookDelivery{}
+	for rows.Next() {
+		var (
+			d          orders.WebhookDelivery
+			durationMs int64
+		)
+		if err := rows.Scan(
+			&d.ID, &d.WebhookID, &d.DeliveryID, &d.EventType, &d.OrderUID, &d.Attempt, &d.StatusCode,
+			&d.Error, &durationMs, &d.Success, &d.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		d.Duration = time.Duration(durationMs) * time.Millisecond
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+func (r *OrdersRepository) queryWebhooks(ctx context.Context, query string, args ...any) ([]*orders.Webhook, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*orders.Webhook{}
+	for rows.Next() {
+		w, err := r.scanWebhook(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
+
+func (r *OrdersRepository) scanWebhook(ctx context.Context, row pgx.Row) (*orders.Webhook, error) {
+	var (
+		w          orders.Webhook
+		eventTypes []string
+	)
+	err := row.Scan(
+		&w.ID, &w.URL, &w.Secret, &eventTypes, &w.Filter.DeliveryServices, &w.Filter.CustomerIDs,
+		&w.Active, &w.Failures, &w.DisabledReason, &w.DisabledAt, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range eventTypes {
+		w.Filter.EventTypes = append(w.Filter.EventTypes, orders.EventType(t))
+	}
+	if r.cipher != nil {
+		if w.Secret, err = r.cipher.Decrypt(ctx, w.Secret); err != nil {
+			return nil, err
+		}
+	}
+	return &w, nil
+}
+
+// sealSecret шифрует секрет подписи, если включено шифрование
+func (r *OrdersRepository) sealSecret(secret string) (string, error) {
+	if r.cipher == nil {
+		return secret, nil
+	}
+	return r.cipher.Encrypt(secret)
+}
+
+func eventTypeStrings(types []orders.EventType) []string {
+	res := make([]string, len(types))
+	for i, t := range types {
+		res[i] = string(t)
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wb_tech_level_zero/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	accessQueueSize = 1000
+	accessTimeout   = 2 * time.Second
+)
+
+// accessTracker учитывает обращения к заказам(стратегия прогрева popular) вне пути запроса:
+// запросы только ставят UID в ограниченную очередь, счетчики в Redis увеличивает один воркер.
+// При заполненной очереди обращение не учитывается - счетчик приблизительный
+type accessTracker struct {
+	cache OrdersCache
+	log   logger.Logger
+	queue chan string
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	dropped atomic.Int64
+}
+
+func newAccessTracker(cache OrdersCache, log logger.Logger) *accessTracker {
+	t := &accessTracker{
+		cache: cache,
+		log:   log,
+		queue: make(chan string, accessQueueSize),
+		done:  make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *accessTracker) Track(orderUID string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return
+	}
+
+	select {
+	case t.queue <- orderUID:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+func (t *accessTracker) run() {
+	defer close(t.done)
+
+	for orderUID := range t.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), accessTimeout)
+		if err := t.cache.IncrAccess(ctx, orderUID); err != nil {
+			t.log.Warn(ctx, "Failed to track order access", zap.String("order_uid", orderUID), zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// Close прекращает прием обращений и дожидается записи уже принятых
+func (t *accessTracker) Close(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.queue)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if n := t.dropped.Load(); n > 0 {
+		t.log.Info(ctx, "Order access tracker stopped", zap.Int64("dropped", n))
+	}
+	return nil
+}
@@ -2,10 +2,26 @@ package service
 
 import (
 	"context"
+	"time"
 	"wb_tech_level_zero/internal/orders"
 )
 
 type OrdersCache interface {
 	Get(ctx context.Context, key string) (*orders.Order, error)
 	Set(ctx context.Context, key string, value *orders.Order) error
+	Delete(ctx context.Context, keys ...string) error
+	GetMany(ctx context.Context, keys []string) (map[string]*orders.Order, error)
+	SetMany(ctx context.Context, items map[string]*orders.Order) error
+
+	GetListVersion(ctx context.Context) (int64, error)
+	BumpListVersion(ctx context.Context) error
+	GetListPage(ctx context.Context, key string) (*orders.ListPage, error)
+	SetListPage(ctx context.Context, key string, page *orders.ListPage) error
+
+	IncrAccess(ctx context.Context, orderUID string) error
+	TopAccessed(ctx context.Context, window time.Duration, limit int) ([]string, error)
+
+	GetCheckpoint(ctx context.Context, name string) (string, error)
+	SetCheckpoint(ctx context.Context, name, value string) error
+	DeleteCheckpoint(ctx context.Context, name string) error
 }
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+	"wb_tech_level_zero/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Политики поведения при заполненной очереди записи в кэш
+const (
+	CacheWriterPolicyDropNew    = "drop_new"
+	CacheWriterPolicyDropOldest = "drop_oldest"
+	CacheWriterPolicyBlock      = "block"
+)
+
+const (
+	defaultCacheWriterWorkers   = 4
+	defaultCacheWriterQueueSize = 1000
+	defaultCacheWriterTimeout   = 2 * time.Second
+)
+
+type CacheWriterConfig struct {
+	Workers    int
+	QueueSize  int
+	Policy     string
+	Coalesce   bool
+	MaxRetries int
+	RetryDelay time.Duration
+	Timeout    time.Duration
+}
+
+type CacheWriterStats struct {
+	Enqueued  int64 `json:"enqueued"`
+	Written   int64 `json:"written"`
+	Coalesced int64 `json:"coalesced"`
+	Dropped   int64 `json:"dropped"`
+	Retried   int64 `json:"retried"`
+	Failed    int64 `json:"failed"`
+	Queued    int   `json:"queued"`
+}
+
+// seq - порядковый номер записи: Discard отменяет записи с номером не больше текущего.
+// items - пакетная запись(EnqueueMany) одним SetMany, key и order у нее не заполнены
+type cacheWriteTask struct {
+	key   string
+	order *orders.Order
+	items map[string]*orders.Order
+	seq   uint64
+}
+
+func (t *cacheWriteTask) keys() []string {
+	if t.items == nil {
+		return []string{t.key}
+	}
+	keys := make([]string, 0, len(t.items))
+	for key := range t.items {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// cacheWriter - пул воркеров асинхронной записи в кэш с ограниченной очередью.
+// pending учитывает принятые, но еще не завершенные записи(ожидается при остановке приложения).
+// keys - число незавершенных записей по ключу, discarded - номер, до которого записи ключа отменены
+type cacheWriter struct {
+	cfg     CacheWriterConfig
+	cache   OrdersCache
+	log     logger.Logger
+	pending *sync.WaitGroup
+
+	queue chan *cacheWriteTask
+
+	mu        sync.Mutex
+	inflight  map[string]*cacheWriteTask
+	keys      map[string]int
+	discarded map[string]uint64
+	seq       uint64
+	closed    bool
+	space     *sync.Cond
+
+	stop      chan struct{}
+	stopOnce  sync.Once
+	workersWg sync.WaitGroup
+
+	enqueued, written, coalesced, dropped, retried, failed atomic.Int64
+}
+
+func newCacheWriter(cfg CacheWriterConfig, cache OrdersCache, pending *sync.WaitGroup, log logger.Logger) *cacheWriter {
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultCacheWriterWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultCacheWriterQueueSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultCacheWriterTimeout
+	}
+	if cfg.Policy == "" {
+		cfg.Policy = CacheWriterPolicyDropOldest
+	}
+
+	w := &cacheWriter{
+		cfg:       cfg,
+		cache:     cache,
+		log:       log,
+		pending:   pending,
+		queue:     make(chan *cacheWriteTask, cfg.QueueSize),
+		inflight:  make(map[string]*cacheWriteTask),
+		keys:      make(map[string]int),
+		discarded: make(map[string]uint64),
+		stop:      make(chan struct{}),
+	}
+	w.space = sync.NewCond(&w.mu)
+
+	for i := 0; i < cfg.Workers; i++ {
+		w.workersWg.Add(1)
+		go w.run()
+	}
+	return w
+}
+
+func (w *cacheWriter) Enqueue(key string, order *orders.Order) {
+	w.mu.Lock()
+
+	if w.closed {
+		w.mu.Unlock()
+		w.dropped.Add(1)
+		w.log.Warn(context.Background(), "Cache writer is closed, write dropped", zap.String("key", key))
+		return
+	}
+
+	if w.cfg.Coalesce {
+		if task, ok := w.inflight[key]; ok {
+			w.seq++
+			task.order, task.seq = order, w.seq
+			w.mu.Unlock()
+			w.coalesced.Add(1)
+			return
+		}
+	}
+
+	w.enqueue(&cacheWriteTask{key: key, order: order})
+}
+
+// EnqueueMany ставит в очередь одну пакетную запись заказов(один пайплайн Redis). Пакет не объединяется
+// с записями отдельных ключей, Discard отменяет запись только отмененных ключей пакета
+func (w *cacheWriter) EnqueueMany(items map[string]*orders.Order) {
+	if len(items) == 0 {
+		return
+	}
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		w.dropped.Add(1)
+		w.log.Warn(context.Background(), "Cache writer is closed, write dropped", zap.Int("keys", len(items)))
+		return
+	}
+	w.enqueue(&cacheWriteTask{items: items})
+}
+
+// enqueue вызывается под w.mu и освобождает его
+func (w *cacheWriter) enqueue(task *cacheWriteTask) {
+	w.track(task)
+
+	select {
+	case w.queue <- task:
+		w.mu.Unlock()
+		w.enqueued.Add(1)
+		return
+	default:
+	}
+
+	switch w.cfg.Policy {
+	case CacheWriterPolicyBlock:
+		// место ожидается под w.mu и closed проверяется перед каждой отправкой:
+		// после Close задача не попадет в очередь, которую уже некому разбирать
+		for !w.closed {
+			select {
+			case w.queue <- task:
+				w.mu.Unlock()
+				w.enqueued.Add(1)
+				return
+			default:
+				w.space.Wait()
+			}
+		}
+		w.release(task)
+		w.mu.Unlock()
+		w.dropped.Add(1)
+		return
+
+	case CacheWriterPolicyDropOldest:
+		select {
+		case oldest := <-w.queue:
+			w.release(oldest)
+			w.dropped.Add(1)
+			w.log.Warn(context.Background(), "Cache writer queue is full, oldest write dropped", zap.Strings("keys", oldest.keys()))
+		default:
+		}
+		// все отправки в очередь выполняются под w.mu, поэтому место после извлечения гарантировано
+		w.queue <- task
+		w.mu.Unlock()
+		w.enqueued.Add(1)
+		return
+	}
+
+	w.release(task)
+	w.mu.Unlock()
+	w.dropped.Add(1)
+	w.log.Warn(context.Background(), "Cache writer queue is full, write dropped", zap.Strings("keys", task.keys()))
+}
+
+// Discard отменяет принятые до вызова записи ключей, в том числе уже выполняющиеся: запись, завершившаяся
+// после Discard, удаляется из кэша. Вызывается перед удалением ключей из кэша, чтобы в него не попали устаревшие данные
+func (w *cacheWriter) Discard(keys ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, key := range keys {
+		if w.keys[key] > 0 {
+			w.discarded[key] = w.seq
+		}
+	}
+}
+
+// track, release и finish вызываются под w.mu
+func (w *cacheWriter) track(task *cacheWriteTask) {
+	w.pending.Add(1)
+	w.seq++
+	task.seq = w.seq
+	for _, key := range task.keys() {
+		w.keys[key]++
+	}
+	if w.cfg.Coalesce && task.items == nil {
+		w.inflight[task.key] = task
+	}
+}
+
+func (w *cacheWriter) release(task *cacheWriteTask) {
+	if task.items == nil && w.inflight[task.key] == task {
+		delete(w.inflight, task.key)
+	}
+	w.finish(task)
+}
+
+func (w *cacheWriter) finish(task *cacheWriteTask) {
+	for _, key := range task.keys() {
+		if w.keys[key]--; w.keys[key] <= 0 {
+			delete(w.keys, key)
+			delete(w.discarded, key)
+		}
+	}
+	w.pending.Done()
+}
+
+// stale сообщает, что запись отменена вызовом Discard
+func (w *cacheWriter) stale(key string, seq uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.discarded[key] >= seq
+}
+
+// staleKeys - ключи пакета, отмененные вызовом Discard
+func (w *cacheWriter) staleKeys(items map[string]*orders.Order, seq uint64) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var keys []string
+	for key := range items {
+		if w.discarded[key] >= seq {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (w *cacheWriter) run() {
+	defer w.workersWg.Done()
+
+	for {
+		select {
+		case task := <-w.queue:
+			w.process(task)
+		case <-w.stop:
+			for {
+				select {
+				case task := <-w.queue:
+					w.process(task)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *cacheWriter) process(task *cacheWriteTask) {
+	w.mu.Lock()
+	w.space.Signal()
+	if task.items == nil && w.inflight[task.key] == task {
+		delete(w.inflight, task.key)
+	}
+	order, seq := task.order, task.seq
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.finish(task)
+		w.mu.Unlock()
+	}()
+
+	if task.items != nil {
+		w.processMany(task.items, seq)
+		return
+	}
+
+	if w.stale(task.key, seq) {
+		w.dropped.Add(1)
+		return
+	}
+
+	var err error
+	for attempt := 0; attempt <= w.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			w.retried.Add(1)
+			time.Sleep(w.cfg.RetryDelay * time.Duration(attempt))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Timeout)
+		err = w.cache.Set(ctx, task.key, order)
+		if err == nil && w.stale(task.key, seq) {
+			// Discard вызван во время записи - записанное значение удаляется
+			err = w.cache.Delete(ctx, task.key)
+			cancel()
+			w.dropped.Add(1)
+			if err != nil {
+				w.log.Warn(context.Background(), "Failed to delete discarded cache write", zap.String("key", task.key), zap.Error(err))
+			}
+			return
+		}
+		cancel()
+		if err == nil {
+			w.written.Add(1)
+			return
+		}
+	}
+
+	w.failed.Add(1)
+	w.log.Warn(context.Background(), "Async cache order failed",
+		zap.String("key", task.key),
+		zap.Int("attempts", w.cfg.MaxRetries+1),
+		zap.Error(err),
+	)
+}
+
+// processMany записывает пакет одним SetMany без отмененных ключей; ключи, отмененные во время записи, удаляются
+func (w *cacheWriter) processMany(items map[string]*orders.Order, seq uint64) {
+	if stale := w.staleKeys(items, seq); len(stale) > 0 {
+		items = maps.Clone(items)
+		for _, key := range stale {
+			delete(items, key)
+		}
+		if len(items) == 0 {
+			w.dropped.Add(1)
+			return
+		}
+	}
+
+	var err error
+	for attempt := 0; attempt <= w.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			w.retried.Add(1)
+			time.Sleep(w.cfg.RetryDelay * time.Duration(attempt))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Timeout)
+		err = w.cache.SetMany(ctx, items)
+		if err == nil {
+			if stale := w.staleKeys(items, seq); len(stale) > 0 {
+				if err := w.cache.Delete(ctx, stale...); err != nil {
+					w.log.Warn(context.Background(), "Failed to delete discarded cache write", zap.Strings("keys", stale), zap.Error(err))
+				}
+			}
+			cancel()
+			w.written.Add(1)
+			return
+		}
+		cancel()
+	}
+
+	w.failed.Add(1)
+	w.log.Warn(context.Background(), "Async cache orders failed",
+		zap.Int("keys", len(items)),
+		zap.Int("attempts", w.cfg.MaxRetries+1),
+		zap.Error(err),
+	)
+}
+
+// Close прекращает прием новых записей и дожидается записи уже принятых
+func (w *cacheWriter) Close(ctx context.Context) error {
+	w.stopOnce.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.space.Broadcast()
+		w.mu.Unlock()
+		close(w.stop)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		w.workersWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *cacheWriter) Stats() CacheWriterStats {
+	return CacheWriterStats{
+		Enqueued:  w.enqueued.Load(),
+		Written:   w.written.Load(),
+		Coalesced: w.coalesced.Load(),
+		Dropped:   w.dropped.Load(),
+		Retried:   w.retried.Load(),
+		Failed:    w.failed.Load(),
+		Queued:    len(w.queue),
+	}
+}
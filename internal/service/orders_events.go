@@ -0,0 +1,10 @@
+package service
+
+import (
+	"context"
+	"wb_tech_level_zero/internal/orders"
+)
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event orders.Event) error
+}
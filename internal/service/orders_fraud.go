@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"time"
+	"wb_tech_level_zero/internal/config"
+	"wb_tech_level_zero/internal/orders"
+
+	"go.uber.org/zap"
+)
+
+func fraudRules(cfg *config.Config) orders.FraudRules {
+	return orders.FraudRules{
+		AmountFactor:    cfg.FraudAmountFactor,
+		AmountMinOrders: cfg.FraudAmountMinOrders,
+		AmountWeight:    cfg.FraudAmountWeight,
+		LocaleRegions:   orders.ParseLocaleRegions(cfg.FraudLocaleRegions),
+		LocaleWeight:    cfg.FraudLocaleWeight,
+		PhoneWindow:     time.Duration(cfg.FraudPhoneWindowMinutes) * time.Minute,
+		PhoneMaxOrders:  cfg.FraudPhoneMaxOrders,
+		PhoneWeight:     cfg.FraudPhoneWeight,
+		DuplicateWeight: cfg.FraudDuplicateWeight,
+		AlertScore:      cfg.FraudAlertScore,
+	}
+}
+
+// scoreOrder оценивает риск нового заказа перед сохранением. Ошибка сбора сигналов не останавливает прием:
+// заказ сохраняется без оценки
+func (s *ordersService) scoreOrder(ctx context.Context, order *orders.Order) {
+	if !s.fraud.Enabled() {
+		return
+	}
+
+	signals, err := s.repo.GetFraudSignals(ctx, order, s.fraud.PhoneWindow)
+	if err != nil {
+		s.log.Warn(ctx, "Failed to collect fraud signals, order is saved without score",
+			zap.String("order_uid", order.OrderUID), zap.Error(err))
+		return
+	}
+	order.Fraud = s.fraud.Score(order, signals)
+}
+
+// alertFraud публикует алерт о сохраненном заказе, оценка которого достигла порога
+func (s *ordersService) alertFraud(ctx context.Context, order *orders.Order) {
+	if !s.fraud.IsAlert(order.Fraud) {
+		return
+	}
+
+	s.log.Warn(ctx, "Order fraud score reached alert threshold",
+		zap.String("order_uid", order.OrderUID), zap.Int("score", order.Fraud.Score))
+	event := orders.NewEvent(orders.EventOrderFraudAlert, order)
+	event.Fraud = order.Fraud
+	event.Amount = &order.Payment.Amount
+	event.Currency = order.Payment.Currency
+	s.publish(ctx, event)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"wb_tech_level_zero/internal/orders"
+
+	"go.uber.org/zap"
+)
+
+// LoadFXRates сохраняет курсы валют из файла или Kafka
+func (s *ordersService) LoadFXRates(ctx context.Context, rates []orders.FXRate) error {
+	if err := s.repo.SaveFXRates(ctx, rates); err != nil {
+		return fmt.Errorf("failed to save fx rates to repository: %w", err)
+	}
+	s.log.Info(ctx, "FX rates loaded", zap.Int("count", len(rates)))
+	return nil
+}
+
+// ConvertOrders пересчитывает суммы оплаты заказов в валюту currency по курсу на дату создания каждого заказа
+// (заказ без даты - по текущему курсу). Результат соответствует list по индексу, сами заказы не меняются.
+// Для заказа без курса результат nil: остальные заказы страницы пересчитываются
+func (s *ordersService) ConvertOrders(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error) {
+	currency, err := orders.ParseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return []*orders.ConvertedPayment{}, nil
+	}
+
+	now := time.Now()
+	dates := make([]time.Time, len(list))
+	currencies := []string{currency}
+	from, to := now, now
+	for i, o := range list {
+		dates[i] = now
+		if o.DateCreated != nil {
+			dates[i] = *o.DateCreated
+		}
+		from, to = minTime(from, dates[i]), maxTime(to, dates[i])
+		// курсы хранятся с кодами в верхнем регистре
+		if c := strings.ToUpper(o.Payment.Currency); !slices.Contains(currencies, c) {
+			currencies = append(currencies, c)
+		}
+	}
+
+	maxAge := time.Duration(s.cfg.FXMaxRateAgeDays) * 24 * time.Hour
+	rates, err := s.repo.GetFXRates(ctx, currencies, from.Add(-maxAge), to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fx rates from repository: %w", err)
+	}
+	book := orders.NewRateBook(rates, maxAge)
+
+	res := make([]*orders.ConvertedPayment, len(list))
+	var noRate []string
+	for i, o := range list {
+		conv, err := book.Lookup(o.Payment.Currency, currency, dates[i])
+		if errors.Is(err, orders.ErrRateNotFound) {
+			noRate = append(noRate, o.OrderUID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("order %s: %w", o.OrderUID, err)
+		}
+		if res[i], err = conv.ConvertPayment(o.Payment); err != nil {
+			return nil, fmt.Errorf("order %s: %w", o.OrderUID, err)
+		}
+	}
+	if len(noRate) > 0 {
+		s.log.Warn(ctx, "Exchange rate not found, orders returned without reporting amounts",
+			zap.String("currency", currency),
+			zap.Strings("order_uids", noRate),
+		)
+	}
+	return res, nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -1,13 +1,101 @@
 package service
 
 import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 	"wb_tech_level_zero/internal/delivery/kafkadelivery"
 	"wb_tech_level_zero/internal/orders"
 )
 
+// GetOrdersParams - параметры списка заказов. Cursor заменяет Page(keyset-пагинация);
+// Total по умолчанию - точный подсчет в режиме страниц и без подсчета в режиме курсора
 type GetOrdersParams struct {
-	Page  int
-	Limit int
+	Page   int
+	Limit  int
+	Filter orders.ListFilter
+	Sort   orders.ListSort
+	Cursor *orders.Cursor
+	Total  orders.TotalMode
+}
+
+func (p GetOrdersParams) listQuery() orders.ListQuery {
+	q := orders.ListQuery{
+		Filter: p.Filter,
+		Sort:   p.Sort,
+		Limit:  p.Limit,
+		Cursor: p.Cursor,
+		Total:  p.totalMode(),
+	}
+	if p.Cursor == nil {
+		q.Offset = (p.Page - 1) * p.Limit
+	}
+	return q
+}
+
+func (p GetOrdersParams) totalMode() orders.TotalMode {
+	switch {
+	case p.Total != "":
+		return p.Total
+	case p.Cursor != nil:
+		return orders.TotalNone
+	default:
+		return orders.TotalExact
+	}
+}
+
+// cacheKey - нормализованное представление параметров для ключа кэша страницы списка
+func (p GetOrdersParams) cacheKey() string {
+	v := url.Values{}
+	if p.Cursor != nil {
+		v.Set("cursor", p.Cursor.Encode())
+	} else {
+		v.Set("page", strconv.Itoa(p.Page))
+	}
+	v.Set("limit", strconv.Itoa(p.Limit))
+	v.Set("total", string(p.totalMode()))
+
+	f := p.Filter
+	if f.DateFrom != nil {
+		v.Set("date_from", f.DateFrom.UTC().Format(time.RFC3339Nano))
+	}
+	if f.DateTo != nil {
+		v.Set("date_to", f.DateTo.UTC().Format(time.RFC3339Nano))
+	}
+	for key, value := range map[string]string{
+		"delivery_service": f.DeliveryService,
+		"locale":           f.Locale,
+		"currency":         f.Currency,
+		"provider":         f.Provider,
+		"bank":             f.Bank,
+		"customer_id":      f.CustomerID,
+		"city":             f.City,
+		"region":           f.Region,
+		"brand":            strings.ToLower(f.Brand),
+	} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	if f.MinAmount != nil {
+		v.Set("min_amount", f.MinAmount.Rat().RatString())
+	}
+	if f.MaxAmount != nil {
+		v.Set("max_amount", f.MaxAmount.Rat().RatString())
+	}
+	if f.ItemStatus != nil {
+		v.Set("item_status", strconv.Itoa(*f.ItemStatus))
+	}
+
+	if p.Sort.Field != "" && p.Sort.Field != orders.SortByDate {
+		v.Set("sort", string(p.Sort.Field))
+	}
+	if p.Sort.Asc {
+		v.Set("order", "asc")
+	}
+	// Encode сортирует ключи, поэтому одинаковые параметры дают одинаковый ключ
+	return v.Encode()
 }
 
 func mapEventOrderToDomain(eo *kafkadelivery.EventOrder) orders.Order {
@@ -15,6 +103,7 @@ func mapEventOrderToDomain(eo *kafkadelivery.EventOrder) orders.Order {
 		OrderUID:          eo.OrderUID,
 		TrackNumber:       eo.TrackNumber,
 		Entry:             eo.Entry,
+		Status:            orders.StatusCreated,
 		Locale:            eo.Locale,
 		InternalSignature: eo.InternalSignature,
 		CustomerID:        eo.CustomerID,
@@ -43,6 +132,7 @@ func mapEventOrderToDomain(eo *kafkadelivery.EventOrder) orders.Order {
 			DeliveryCost: eo.Payment.DeliveryCost,
 			GoodsTotal:   eo.Payment.GoodsTotal,
 			CustomFee:    eo.Payment.CustomFee,
+			Paid:         eo.Payment.Amount,
 		},
 	}
 
@@ -2,12 +2,41 @@ package service
 
 import (
 	"context"
+	"time"
 	"wb_tech_level_zero/internal/orders"
 )
 
 type OrdersRepository interface {
 	GetOrderByUID(ctx context.Context, orderUID string) (*orders.Order, error)
 	SaveOrder(ctx context.Context, order *orders.Order) error
+	GetOrderHistory(ctx context.Context, orderUID string) ([]orders.OrderVersion, error)
+	GetOrderAsOf(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error)
+	UpdateOrderStatus(ctx context.Context, orderUID string, change orders.StatusChange) error
+	CancelOrderItems(ctx context.Context, orderUID string, refs []orders.ItemRef, reason string) (*orders.Order, error)
+	AddRefund(ctx context.Context, orderUID string, refund *orders.Refund) (*orders.Order, error)
+	GetRefunds(ctx context.Context, orderUID string) ([]orders.Refund, error)
+	EraseCustomer(ctx context.Context, customerID string, erasure *orders.Erasure) error
+	GetOrders(ctx context.Context, query orders.ListQuery) (*orders.ListResult, error)
+	SearchOrders(ctx context.Context, criteria orders.SearchCriteria, limit, offset int) ([]*orders.Order, int, error)
+	SearchOrdersText(ctx context.Context, query orders.TextQuery) (*orders.TextSearchResult, error)
+	GetCustomerOrders(ctx context.Context, customerID string, limit, offset int) ([]*orders.Order, int, error)
+	GetCustomerSummary(ctx context.Context, customerID string, brandsLimit int) (*orders.CustomerSummary, error)
 
-	GetOrders(ctx context.Context, limit, offset int) ([]*orders.Order, int, error)
+	GetOrdersByUIDs(ctx context.Context, orderUIDs []string) ([]*orders.Order, error)
+	GetOrdersByCustomerIDs(ctx context.Context, customerIDs []string, limit, offset int) ([]*orders.Order, error)
+	GetOrdersCreatedSince(ctx context.Context, since time.Time, limit, offset int) ([]*orders.Order, error)
+
+	SaveFXRates(ctx context.Context, rates []orders.FXRate) error
+	GetFXRates(ctx context.Context, currencies []string, from, to time.Time) ([]orders.FXRate, error)
+
+	GetOrderStats(ctx context.Context, query orders.StatsQuery) ([]orders.StatsRow, error)
+
+	GetFraudSignals(ctx context.Context, order *orders.Order, phoneWindow time.Duration) (orders.FraudSignals, error)
+
+	CreateWebhook(ctx context.Context, w *orders.Webhook) error
+	GetWebhook(ctx context.Context, id int64) (*orders.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*orders.Webhook, error)
+	UpdateWebhook(ctx context.Context, w *orders.Webhook) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]orders.WebhookDelivery, error)
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 	"wb_tech_level_zero/internal/delivery/kafkadelivery"
 	"wb_tech_level_zero/internal/orders"
 )
@@ -9,7 +10,34 @@ import (
 type OrdersService interface {
 	WarmOrdersCache(ctx context.Context) error
 	GetOrderByUID(ctx context.Context, uid string) (*orders.Order, error)
+	GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error)
 	ProcessEventOrder(ctx context.Context, eo *kafkadelivery.EventOrder) error
+	ChangeOrderStatus(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error)
+	CancelOrder(ctx context.Context, orderUID string, items []orders.ItemRef, reason string) (*orders.Order, error)
+	RefundOrder(ctx context.Context, orderUID string, refund orders.Refund) (*orders.Refund, error)
+	GetOrderRefunds(ctx context.Context, orderUID string) ([]orders.Refund, error)
+	ForgetCustomer(ctx context.Context, customerID, reason string) (*orders.Erasure, error)
+	GetOrderHistory(ctx context.Context, orderUID string) ([]orders.OrderVersion, error)
+	GetOrderAsOf(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error)
 
-	GetOrders(ctx context.Context, params GetOrdersParams) ([]*orders.Order, int, error)
+	GetOrders(ctx context.Context, params GetOrdersParams) (*orders.ListResult, error)
+	SearchOrders(ctx context.Context, criteria orders.SearchCriteria, params GetOrdersParams) ([]*orders.Order, int, error)
+	SearchOrdersText(ctx context.Context, text string, params GetOrdersParams) (*orders.TextSearchResult, error)
+	GetCustomerOrders(ctx context.Context, customerID string, params GetOrdersParams) ([]*orders.Order, int, error)
+	GetCustomerSummary(ctx context.Context, customerID string) (*orders.CustomerSummary, error)
+
+	LoadFXRates(ctx context.Context, rates []orders.FXRate) error
+	ConvertOrders(ctx context.Context, list []*orders.Order, currency string) ([]*orders.ConvertedPayment, error)
+
+	GetOrderStats(ctx context.Context, params StatsParams) (*orders.StatsResult, error)
+
+	CreateWebhook(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*orders.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*orders.Webhook, error)
+	UpdateWebhook(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetWebhookDeliveries(ctx context.Context, id int64, limit int) ([]orders.WebhookDelivery, error)
+
+	CacheWriterStats() CacheWriterStats
+	Close(ctx context.Context) error
 }
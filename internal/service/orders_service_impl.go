@@ -15,24 +15,49 @@ import (
 	"go.uber.org/zap"
 )
 
-const orderCachePrefix = "order:"
+const (
+	orderCachePrefix      = "order:"
+	ordersListCachePrefix = "orders:list:"
+)
 
 type ordersService struct {
-	cfg   *config.Config
-	repo  OrdersRepository
-	cache OrdersCache
-	wg    *sync.WaitGroup
-	log   logger.Logger
+	cfg    *config.Config
+	repo   OrdersRepository
+	cache  OrdersCache
+	events EventPublisher
+	fraud  orders.FraudRules
+	writer *cacheWriter
+	access *accessTracker // nil - обращения не учитываются(стратегия прогрева не popular)
+	wg     *sync.WaitGroup
+	log    logger.Logger
 }
 
-func NewOrdersService(cfg *config.Config, repo OrdersRepository, cache OrdersCache, wg *sync.WaitGroup, log logger.Logger) OrdersService {
-	return &ordersService{
-		cfg:   cfg,
-		repo:  repo,
-		cache: cache,
-		wg:    wg,
-		log:   log,
+func NewOrdersService(cfg *config.Config, repo OrdersRepository, cache OrdersCache, events EventPublisher, wg *sync.WaitGroup, log logger.Logger) OrdersService {
+	writerCfg := CacheWriterConfig{
+		Workers:    cfg.CacheWriterWorkers,
+		QueueSize:  cfg.CacheWriterQueueSize,
+		Policy:     cfg.CacheWriterPolicy,
+		Coalesce:   cfg.CacheWriterCoalesce,
+		MaxRetries: cfg.CacheWriterMaxRetries,
+		RetryDelay: time.Duration(cfg.CacheWriterRetryDelayMs) * time.Millisecond,
+		Timeout:    time.Duration(cfg.CacheWriterTimeoutMs) * time.Millisecond,
+	}
+
+	s := &ordersService{
+		cfg:    cfg,
+		repo:   repo,
+		cache:  cache,
+		events: events,
+		fraud:  fraudRules(cfg),
+		writer: newCacheWriter(writerCfg, cache, wg, log),
+		wg:     wg,
+		log:    log,
 	}
+	// счетчики обращений читает только прогрев popular, при других стратегиях они лишь нагружали бы Redis
+	if cfg.CacheWarmupStrategy == WarmupStrategyPopular {
+		s.access = newAccessTracker(cache, log)
+	}
+	return s
 }
 
 func (s *ordersService) GetOrderByUID(ctx context.Context, orderUID string) (*orders.Order, error) {
@@ -42,6 +67,7 @@ func (s *ordersService) GetOrderByUID(ctx context.Context, orderUID string) (*or
 		s.log.Warn(ctx, "Failed to get order from cache", zap.String("key", key), zap.Error(err))
 	}
 	if cached != nil {
+		s.trackAccess(orderUID)
 		return cached, nil
 	}
 
@@ -51,16 +77,119 @@ func (s *ordersService) GetOrderByUID(ctx context.Context, orderUID string) (*or
 	}
 
 	s.asyncCacheOrder(dbOrder)
+	s.trackAccess(orderUID)
 	return dbOrder, nil
 
 }
 
-func (s *ordersService) GetOrders(ctx context.Context, params GetOrdersParams) ([]*orders.Order, int, error) {
+// GetOrdersByUIDs возвращает найденные заказы в порядке uids: из кэша одним MGET, отсутствующие в кэше -
+// одним запросом к БД. Ненайденные заказы в результат не попадают
+func (s *ordersService) GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error) {
+	if len(uids) == 0 {
+		return []*orders.Order{}, nil
+	}
+	return s.hydrateOrders(ctx, uids)
+}
+
+func (s *ordersService) GetOrders(ctx context.Context, params GetOrdersParams) (*orders.ListResult, error) {
+	query := params.listQuery()
+
+	if s.cfg.OrdersListTTLSec <= 0 {
+		return s.repo.GetOrders(ctx, query)
+	}
+
+	// Версия списка увеличивается при каждом новом заказе, поэтому страницы предыдущих версий
+	// просто перестают читаться и истекают по TTL
+	version, err := s.cache.GetListVersion(ctx)
+	if err != nil {
+		s.log.Warn(ctx, "Failed to get orders list version from cache", zap.Error(err))
+		return s.repo.GetOrders(ctx, query)
+	}
+	key := fmt.Sprintf("%sv%d:%s", ordersListCachePrefix, version, params.cacheKey())
 
-	// TO DO: Переделать после уточнения требований(вызывать кэш, или удалить вообще)
+	page, err := s.cache.GetListPage(ctx, key)
+	if err != nil {
+		s.log.Warn(ctx, "Failed to get orders list page from cache", zap.String("key", key), zap.Error(err))
+	}
+	if page != nil {
+		list, err := s.hydrateOrders(ctx, page.UIDs)
+		if err == nil {
+			return &orders.ListResult{
+				Orders:         list,
+				Total:          page.Total,
+				TotalEstimated: page.TotalEstimated,
+				Next:           page.Next,
+				Prev:           page.Prev,
+			}, nil
+		}
+		s.log.Warn(ctx, "Failed to hydrate cached orders list page", zap.String("key", key), zap.Error(err))
+	}
+
+	res, err := s.repo.GetOrders(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, len(res.Orders))
+	for i, o := range res.Orders {
+		uids[i] = o.OrderUID
+		s.asyncCacheOrder(o)
+	}
+	page = &orders.ListPage{
+		UIDs:           uids,
+		Total:          res.Total,
+		TotalEstimated: res.TotalEstimated,
+		Next:           res.Next,
+		Prev:           res.Prev,
+	}
+	if err := s.cache.SetListPage(ctx, key, page); err != nil {
+		s.log.Warn(ctx, "Failed to cache orders list page", zap.String("key", key), zap.Error(err))
+	}
+
+	return res, nil
+}
+
+// hydrateOrders собирает заказы по UID: из кэша заказов, недостающие - одним запросом к БД
+func (s *ordersService) hydrateOrders(ctx context.Context, uids []string) ([]*orders.Order, error) {
+	keys := make([]string, len(uids))
+	for i, uid := range uids {
+		keys[i] = orderCachePrefix + uid
+	}
+
+	found, err := s.cache.GetMany(ctx, keys)
+	if err != nil {
+		s.log.Warn(ctx, "Failed to get orders from cache", zap.Error(err))
+		found = map[string]*orders.Order{}
+	}
+
+	var missing []string
+	for i, uid := range uids {
+		if found[keys[i]] == nil {
+			missing = append(missing, uid)
+		}
+	}
+
+	if len(missing) > 0 {
+		dbOrders, err := s.repo.GetOrdersByUIDs(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orders from repository: %w", err)
+		}
+		items := make(map[string]*orders.Order, len(dbOrders))
+		for _, o := range dbOrders {
+			items[orderCachePrefix+o.OrderUID] = o
+			found[orderCachePrefix+o.OrderUID] = o
+		}
+		// промахи кэшируются одной пакетной записью
+		s.writer.EnqueueMany(items)
+	}
 
-	offset := (params.Page - 1) * params.Limit
-	return s.repo.GetOrders(ctx, params.Limit, offset)
+	list := make([]*orders.Order, 0, len(uids))
+	for _, key := range keys {
+		if o := found[key]; o != nil {
+			list = append(list, o)
+		}
+	}
+	return list, nil
 }
 
 func (s *ordersService) ProcessEventOrder(ctx context.Context, eo *kafkadelivery.EventOrder) error {
@@ -71,41 +200,256 @@ func (s *ordersService) ProcessEventOrder(ctx context.Context, eo *kafkadelivery
 	}
 
 	order := mapEventOrderToDomain(eo)
+	if err := order.ApplyCurrency(); err != nil {
+		return fmt.Errorf("invalid order amounts: %w", err)
+	}
+
+	// сбор сигналов антифрода - несколько запросов к БД, поэтому повторно присланный заказ
+	// отсеивается до оценки; SaveOrder все равно проверяет дубликат при одновременной обработке
+	if s.fraud.Enabled() {
+		stored, err := s.repo.GetOrderByUID(ctx, order.OrderUID)
+		if err != nil && !errors.Is(err, orders.ErrOrderNotFound) {
+			return fmt.Errorf("failed to check existing order: %w", err)
+		}
+		if stored != nil {
+			s.log.Info(ctx, "Order already exists (found in DB), skipping", zap.String("order_uid", order.OrderUID))
+			s.asyncCacheOrder(stored)
+			return orders.ErrOrderAlreadyExists
+		}
+		s.scoreOrder(ctx, &order)
+	}
 
 	err := s.repo.SaveOrder(ctx, &order)
 	if err != nil {
 		if errors.Is(err, orders.ErrOrderAlreadyExists) {
 			s.log.Info(ctx, "Order already exists (found in DB), skipping save", zap.String("order_uid", order.OrderUID))
 
-			s.asyncCacheOrder(&order)
+			// в кэш попадает сохраненный заказ, а не повторно присланный: его данные могут отличаться
+			stored, err := s.repo.GetOrderByUID(ctx, order.OrderUID)
+			if err != nil {
+				s.log.Warn(ctx, "Failed to get existing order for cache", zap.String("order_uid", order.OrderUID), zap.Error(err))
+				return orders.ErrOrderAlreadyExists
+			}
+			s.asyncCacheOrder(stored)
 			return orders.ErrOrderAlreadyExists
 		}
 		return fmt.Errorf("failed to save order: %w", err)
 	}
 
 	s.asyncCacheOrder(&order)
+	s.invalidateLists(ctx)
+
+	// сумма и трек-номер нужны потоку новых заказов(SSE) и подписчикам событий
+	created := orders.NewEvent(orders.EventOrderCreated, &order)
+	created.TrackNumber = order.TrackNumber
+	created.Amount = &order.Payment.Amount
+	created.Currency = order.Payment.Currency
+	s.publish(ctx, created)
+	s.alertFraud(ctx, &order)
 	return nil
 }
 
-func (s *ordersService) WarmOrdersCache(ctx context.Context) error {
-	s.log.Info(ctx, "Warming up cache...")
+func (s *ordersService) ChangeOrderStatus(ctx context.Context, orderUID string, to orders.Status, reason string) (*orders.Order, error) {
+	order, err := s.repo.GetOrderByUID(ctx, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order from repository: %w", err)
+	}
 
-	// TO DO: Логика прогрева кэша(переработать после уточнения требований)
+	// повторная доставка того же перехода не считается ошибкой
+	if order.Status == to {
+		return order, nil
+	}
+
+	if err := orders.CheckTransition(order.Status, to); err != nil {
+		return nil, err
+	}
+
+	// отмена должна пересчитать суммы и статистику заказа, поэтому выполняется как отмена всех позиций
+	if to == orders.StatusCancelled {
+		return s.CancelOrder(ctx, orderUID, nil, reason)
+	}
 
-	orders, _, err := s.repo.GetOrders(ctx, s.cfg.CacheWarmupSize, 0)
+	change := orders.StatusChange{From: order.Status, To: to, Reason: reason}
+	if err := s.repo.UpdateOrderStatus(ctx, orderUID, change); err != nil {
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	order.Status = to
+	s.asyncCacheOrder(order)
+	s.invalidateLists(ctx)
+
+	event := orders.NewEvent(orders.EventOrderStatusChanged, order)
+	event.From = change.From
+	event.Reason = reason
+	s.publish(ctx, event)
+
+	return order, nil
+}
+
+// CancelOrder отменяет заказ целиком(items пуст) или отдельные позиции
+func (s *ordersService) CancelOrder(ctx context.Context, orderUID string, items []orders.ItemRef, reason string) (*orders.Order, error) {
+	order, err := s.repo.CancelOrderItems(ctx, orderUID, items, reason)
 	if err != nil {
-		return fmt.Errorf("failed to load orders for warmup: %w", err)
+		return nil, fmt.Errorf("failed to cancel order: %w", err)
 	}
 
-	failed := 0
-	for _, order := range orders {
-		if err := s.cacheOrder(ctx, order); err != nil {
-			failed++
-		}
+	s.asyncCacheOrder(order)
+	s.invalidateLists(ctx)
+
+	eventType := orders.EventOrderItemsCancelled
+	if order.Status == orders.StatusCancelled {
+		eventType = orders.EventOrderCancelled
 	}
-	s.log.Info(ctx, "Cache warmup completed", zap.Int("count", len(orders)), zap.Int("failed", failed))
+	event := orders.NewEvent(eventType, order)
+	event.Items = items
+	event.Amount, event.Currency = &order.Payment.Amount, order.Payment.Currency
+	event.Reason = reason
+	s.publish(ctx, event)
 
-	return nil
+	return order, nil
+}
+
+func (s *ordersService) RefundOrder(ctx context.Context, orderUID string, refund orders.Refund) (*orders.Refund, error) {
+	order, err := s.repo.AddRefund(ctx, orderUID, &refund)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund order: %w", err)
+	}
+
+	s.asyncCacheOrder(order)
+	s.invalidateLists(ctx)
+
+	event := orders.NewEvent(orders.EventOrderRefunded, order)
+	event.Amount, event.Currency = &refund.Amount, order.Payment.Currency
+	event.Reason = refund.Reason
+	s.publish(ctx, event)
+
+	return &refund, nil
+}
+
+func (s *ordersService) GetOrderRefunds(ctx context.Context, orderUID string) ([]orders.Refund, error) {
+	refunds, err := s.repo.GetRefunds(ctx, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order refunds from repository: %w", err)
+	}
+	return refunds, nil
+}
+
+// SearchOrders ищет заказы в БД; найденные заказы кэшируются асинхронно
+func (s *ordersService) SearchOrders(ctx context.Context, criteria orders.SearchCriteria, params GetOrdersParams) ([]*orders.Order, int, error) {
+	if criteria.Empty() {
+		return nil, 0, orders.ErrEmptySearch
+	}
+
+	list, total, err := s.repo.SearchOrders(ctx, criteria, params.Limit, (params.Page-1)*params.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search orders in repository: %w", err)
+	}
+	for _, o := range list {
+		s.asyncCacheOrder(o)
+	}
+	return list, total, nil
+}
+
+// SearchOrdersText выполняет полнотекстовый поиск заказов; найденные заказы кэшируются асинхронно
+func (s *ordersService) SearchOrdersText(ctx context.Context, text string, params GetOrdersParams) (*orders.TextSearchResult, error) {
+	text, err := orders.ParseTextQuery(text)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.repo.SearchOrdersText(ctx, orders.TextQuery{
+		Text:   text,
+		Limit:  params.Limit,
+		Offset: (params.Page - 1) * params.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search orders text in repository: %w", err)
+	}
+	for _, hit := range res.Hits {
+		s.asyncCacheOrder(hit.Order)
+	}
+	return res, nil
+}
+
+// GetCustomerOrders возвращает страницу заказов покупателя из БД и кэширует их асинхронно
+func (s *ordersService) GetCustomerOrders(ctx context.Context, customerID string, params GetOrdersParams) ([]*orders.Order, int, error) {
+	if customerID == "" {
+		return nil, 0, orders.ErrInvalidCustomerID
+	}
+
+	list, total, err := s.repo.GetCustomerOrders(ctx, customerID, params.Limit, (params.Page-1)*params.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get customer orders from repository: %w", err)
+	}
+	for _, o := range list {
+		s.asyncCacheOrder(o)
+	}
+	return list, total, nil
+}
+
+func (s *ordersService) GetCustomerSummary(ctx context.Context, customerID string) (*orders.CustomerSummary, error) {
+	if customerID == "" {
+		return nil, orders.ErrInvalidCustomerID
+	}
+
+	summary, err := s.repo.GetCustomerSummary(ctx, customerID, s.cfg.CustomerSummaryTopBrands)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer summary from repository: %w", err)
+	}
+	return summary, nil
+}
+
+// ForgetCustomer обезличивает персональные данные покупателя в БД и удаляет его заказы из кэша
+func (s *ordersService) ForgetCustomer(ctx context.Context, customerID, reason string) (*orders.Erasure, error) {
+	if customerID == "" {
+		return nil, orders.ErrInvalidCustomerID
+	}
+	if s.cfg.ErasurePseudonymKey == "" {
+		return nil, orders.ErrErasureKeyMissing
+	}
+
+	erasure := &orders.Erasure{
+		CustomerRef: orders.CustomerPseudonym(s.cfg.ErasurePseudonymKey, customerID),
+		Reason:      reason,
+	}
+	if err := s.repo.EraseCustomer(ctx, customerID, erasure); err != nil {
+		return nil, fmt.Errorf("failed to erase customer data: %w", err)
+	}
+
+	keys := make([]string, len(erasure.OrderUIDs))
+	for i, uid := range erasure.OrderUIDs {
+		keys[i] = orderCachePrefix + uid
+	}
+	s.writer.Discard(keys...)
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		// данные в БД уже обезличены; ключи истекут по TTL, но об этом нужно знать
+		s.log.Error(ctx, "Failed to evict erased orders from cache", zap.Int("orders", len(keys)), zap.Error(err))
+	}
+	s.invalidateLists(ctx)
+
+	s.log.Info(ctx, "Customer personal data erased",
+		zap.Int("erasure_id", erasure.ID),
+		zap.String("customer_ref", erasure.CustomerRef),
+		zap.Int("orders", len(erasure.OrderUIDs)),
+	)
+	return erasure, nil
+}
+
+// История версий читается только из БД: кэш хранит лишь текущее состояние заказа
+func (s *ordersService) GetOrderHistory(ctx context.Context, orderUID string) ([]orders.OrderVersion, error) {
+	history, err := s.repo.GetOrderHistory(ctx, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order history from repository: %w", err)
+	}
+	return history, nil
+}
+
+func (s *ordersService) GetOrderAsOf(ctx context.Context, orderUID string, asOf time.Time) (*orders.OrderVersion, error) {
+	version, err := s.repo.GetOrderAsOf(ctx, orderUID, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order version from repository: %w", err)
+	}
+	return version, nil
 }
 
 // //////////////
@@ -116,21 +460,56 @@ func (s *ordersService) asyncCacheOrder(order *orders.Order) {
 }
 
 func (s *ordersService) asyncCacheWithData(key string, order *orders.Order) {
+	s.writer.Enqueue(key, order)
+}
 
-	// TO DO: М,б.переделать логирование, канал ошибок и т.п.(для асинхронных операций)
+func (s *ordersService) CacheWriterStats() CacheWriterStats {
+	return s.writer.Stats()
+}
 
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
+// Close дожидается завершения отложенных записей в кэш и учета обращений
+func (s *ordersService) Close(ctx context.Context) error {
+	var accessErr error
+	if s.access != nil {
+		accessErr = s.access.Close(ctx)
+	}
+	err := s.writer.Close(ctx)
+	stats := s.writer.Stats()
+	s.log.Info(ctx, "Cache writer stopped",
+		zap.Int64("written", stats.Written),
+		zap.Int64("dropped", stats.Dropped),
+		zap.Int64("failed", stats.Failed),
+		zap.Int("queued", stats.Queued),
+	)
+	return errors.Join(accessErr, err)
+}
 
-		ctxCache, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
+// invalidateLists сбрасывает закэшированные страницы списка заказов: изменение заказа может поменять
+// его попадание в фильтры и место в сортировке
+func (s *ordersService) invalidateLists(ctx context.Context) {
+	if err := s.cache.BumpListVersion(ctx); err != nil {
+		s.log.Warn(ctx, "Failed to bump orders list version", zap.Error(err))
+	}
+}
 
-		if err := s.cache.Set(ctxCache, key, order); err != nil {
-			s.log.Warn(ctxCache, "Async cache order failed", zap.String("key", key), zap.Error(err))
-		}
+// trackAccess учитывает обращение к заказу в фоне, если включен прогрев popular
+func (s *ordersService) trackAccess(orderUID string) {
+	if s.access != nil {
+		s.access.Track(orderUID)
+	}
+}
 
-	}()
+func (s *ordersService) publish(ctx context.Context, event orders.Event) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.log.Warn(ctx, "Failed to publish order event",
+			zap.String("type", string(event.Type)),
+			zap.String("order_uid", event.OrderUID),
+			zap.Error(err),
+		)
+	}
 }
 
 func (s *ordersService) cacheOrder(ctx context.Context, order *orders.Order) error {
@@ -145,3 +524,20 @@ func (s *ordersService) cacheOrder(ctx context.Context, order *orders.Order) err
 
 	return nil
 }
+
+func (s *ordersService) cacheOrders(ctx context.Context, list []*orders.Order) error {
+	items := make(map[string]*orders.Order, len(list))
+	for _, order := range list {
+		items[orderCachePrefix+order.OrderUID] = order
+	}
+
+	ctxCache, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := s.cache.SetMany(ctxCache, items); err != nil {
+		s.log.Warn(ctx, "Failed to set orders in cache", zap.Int("count", len(items)), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -3,8 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"wb_tech_level_zero/internal/config"
 	"wb_tech_level_zero/internal/delivery/kafkadelivery"
@@ -14,38 +19,305 @@ import (
 )
 
 type mockRepo struct {
-	saveCalled bool
-	saveErr    error
-	getOrder   *orders.Order
-	getOrders  []*orders.Order
-	getErr     error
+	saveCalled     bool
+	getOrdersCalls int
+	saveErr        error
+	getOrder       *orders.Order
+	getOrders      []*orders.Order
+	getErr         error
+	statusChanges  []orders.StatusChange
+	updateErr      error
+	history        []orders.OrderVersion
+	refunds        []orders.Refund
+	erasedUIDs     []string
+	summary        *orders.CustomerSummary
+	lastQuery      orders.ListQuery
+	lastTextQuery  orders.TextQuery
+	fxRates        []orders.FXRate
+	fxCurrencies   []string
+	statsRows      []orders.StatsRow
+	lastStatsQuery orders.StatsQuery
+	saved          *orders.Order
+	fraudSignals   orders.FraudSignals
+	fraudErr       error
+	phoneWindow    time.Duration
+	webhooks       map[int64]*orders.Webhook
+	deliveries     []orders.WebhookDelivery
+	deliveryLimit  int
+	byUIDs         [][]string
 }
 
 func (m *mockRepo) SaveOrder(ctx context.Context, o *orders.Order) error {
 	m.saveCalled = true
+	m.saved = o
 	return m.saveErr
 }
 
+func (m *mockRepo) GetFraudSignals(ctx context.Context, o *orders.Order, phoneWindow time.Duration) (orders.FraudSignals, error) {
+	m.phoneWindow = phoneWindow
+	return m.fraudSignals, m.fraudErr
+}
+
+func (m *mockRepo) UpdateOrderStatus(ctx context.Context, uid string, change orders.StatusChange) error {
+	if m.updateErr != nil {
+		return m.updateErr
+	}
+	m.statusChanges = append(m.statusChanges, change)
+	return nil
+}
+
+func (m *mockRepo) CancelOrderItems(ctx context.Context, uid string, refs []orders.ItemRef, reason string) (*orders.Order, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	if err := m.getOrder.CancelItems(refs); err != nil {
+		return nil, err
+	}
+	return m.getOrder, nil
+}
+
+func (m *mockRepo) AddRefund(ctx context.Context, uid string, refund *orders.Refund) (*orders.Order, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	if err := m.getOrder.Payment.CheckRefund(*refund); err != nil {
+		return nil, err
+	}
+	refund.Transaction = m.getOrder.Payment.Transaction
+	refunded, err := m.getOrder.Payment.Refunded.Add(refund.Amount)
+	if err != nil {
+		return nil, err
+	}
+	m.getOrder.Payment.Refunded = refunded
+	m.refunds = append(m.refunds, *refund)
+	return m.getOrder, nil
+}
+
+func (m *mockRepo) GetRefunds(ctx context.Context, uid string) ([]orders.Refund, error) {
+	return m.refunds, m.getErr
+}
+
+func (m *mockRepo) EraseCustomer(ctx context.Context, customerID string, erasure *orders.Erasure) error {
+	erasure.OrderUIDs = m.erasedUIDs
+	erasure.Source = orders.ChangeSourceFromContext(ctx)
+	return m.getErr
+}
+
+func (m *mockRepo) GetOrderHistory(ctx context.Context, uid string) ([]orders.OrderVersion, error) {
+	if len(m.history) == 0 {
+		return nil, orders.ErrOrderNotFound
+	}
+	return m.history, nil
+}
+
+func (m *mockRepo) GetOrderAsOf(ctx context.Context, uid string, asOf time.Time) (*orders.OrderVersion, error) {
+	for i := len(m.history) - 1; i >= 0; i-- {
+		if !m.history[i].CreatedAt.After(asOf) {
+			return &m.history[i], nil
+		}
+	}
+	return nil, orders.ErrOrderNotFound
+}
+
 func (m *mockRepo) GetOrderByUID(ctx context.Context, uid string) (*orders.Order, error) {
 	return m.getOrder, m.getErr
 }
 
-func (m *mockRepo) GetOrders(ctx context.Context, limit, offset int) ([]*orders.Order, int, error) {
-	return m.getOrders, len(m.getOrders), m.getErr
+func (m *mockRepo) GetOrders(ctx context.Context, query orders.ListQuery) (*orders.ListResult, error) {
+	m.getOrdersCalls++
+	m.lastQuery = query
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	return &orders.ListResult{Orders: m.getOrders, Total: len(m.getOrders)}, nil
+}
+
+func (m *mockRepo) SearchOrders(ctx context.Context, criteria orders.SearchCriteria, limit, offset int) ([]*orders.Order, int, error) {
+	if m.getErr != nil {
+		return nil, 0, m.getErr
+	}
+	var all []*orders.Order
+	for _, o := range m.getOrders {
+		if criteria.TrackNumber == "" || o.TrackNumber == criteria.TrackNumber {
+			all = append(all, o)
+		}
+	}
+	page := all[min(offset, len(all)):min(offset+limit, len(all))]
+	return page, len(all), nil
+}
+
+func (m *mockRepo) SearchOrdersText(ctx context.Context, query orders.TextQuery) (*orders.TextSearchResult, error) {
+	m.lastTextQuery = query
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	res := &orders.TextSearchResult{Hits: []orders.TextHit{}, Total: len(m.getOrders)}
+	for _, o := range m.getOrders {
+		res.Hits = append(res.Hits, orders.TextHit{Order: o, Rank: 1})
+	}
+	return res, nil
+}
+
+func (m *mockRepo) GetCustomerOrders(ctx context.Context, customerID string, limit, offset int) ([]*orders.Order, int, error) {
+	if m.getErr != nil {
+		return nil, 0, m.getErr
+	}
+	var all []*orders.Order
+	for _, o := range m.getOrders {
+		if o.CustomerID == customerID {
+			all = append(all, o)
+		}
+	}
+	page := all[min(offset, len(all)):min(offset+limit, len(all))]
+	return page, len(all), nil
+}
+
+func (m *mockRepo) GetCustomerSummary(ctx context.Context, customerID string, brandsLimit int) (*orders.CustomerSummary, error) {
+	if m.summary == nil {
+		return nil, orders.ErrCustomerNotFound
+	}
+	return m.summary, m.getErr
+}
+
+func (m *mockRepo) GetOrdersByUIDs(ctx context.Context, uids []string) ([]*orders.Order, error) {
+	m.byUIDs = append(m.byUIDs, slices.Clone(uids))
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	var result []*orders.Order
+	for _, o := range m.getOrders {
+		if slices.Contains(uids, o.OrderUID) {
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepo) GetOrdersByCustomerIDs(ctx context.Context, customerIDs []string, limit, offset int) ([]*orders.Order, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	var result []*orders.Order
+	for _, o := range m.getOrders {
+		if slices.Contains(customerIDs, o.CustomerID) {
+			result = append(result, o)
+		}
+	}
+	return paginate(result, limit, offset), nil
+}
+
+func (m *mockRepo) GetOrdersCreatedSince(ctx context.Context, since time.Time, limit, offset int) ([]*orders.Order, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	var result []*orders.Order
+	for _, o := range m.getOrders {
+		if o.DateCreated != nil && !o.DateCreated.Before(since) {
+			result = append(result, o)
+		}
+	}
+	return paginate(result, limit, offset), nil
+}
+
+func (m *mockRepo) SaveFXRates(ctx context.Context, rates []orders.FXRate) error {
+	m.fxRates = append(m.fxRates, rates...)
+	return m.saveErr
+}
+
+func (m *mockRepo) GetFXRates(ctx context.Context, currencies []string, from, to time.Time) ([]orders.FXRate, error) {
+	m.fxCurrencies = currencies
+	var result []orders.FXRate
+	for _, r := range m.fxRates {
+		if !r.Date.Before(from) && !r.Date.After(to) {
+			result = append(result, r)
+		}
+	}
+	return result, m.getErr
+}
+
+func (m *mockRepo) GetOrderStats(ctx context.Context, query orders.StatsQuery) ([]orders.StatsRow, error) {
+	m.lastStatsQuery = query
+	return m.statsRows, m.getErr
+}
+
+func (m *mockRepo) CreateWebhook(ctx context.Context, w *orders.Webhook) error {
+	if m.webhooks == nil {
+		m.webhooks = map[int64]*orders.Webhook{}
+	}
+	w.ID = int64(len(m.webhooks) + 1)
+	m.webhooks[w.ID] = w
+	return nil
+}
+
+func (m *mockRepo) GetWebhook(ctx context.Context, id int64) (*orders.Webhook, error) {
+	w, ok := m.webhooks[id]
+	if !ok {
+		return nil, orders.ErrWebhookNotFound
+	}
+	return w, nil
+}
+
+func (m *mockRepo) GetWebhooks(ctx context.Context) ([]*orders.Webhook, error) {
+	list := []*orders.Webhook{}
+	for _, w := range m.webhooks {
+		list = append(list, w)
+	}
+	return list, nil
+}
+
+func (m *mockRepo) UpdateWebhook(ctx context.Context, w *orders.Webhook) error {
+	old, ok := m.webhooks[w.ID]
+	if !ok {
+		return orders.ErrWebhookNotFound
+	}
+	if w.Secret == "" {
+		w.Secret = old.Secret
+	}
+	m.webhooks[w.ID] = w
+	return nil
+}
+
+func (m *mockRepo) DeleteWebhook(ctx context.Context, id int64) error {
+	if _, ok := m.webhooks[id]; !ok {
+		return orders.ErrWebhookNotFound
+	}
+	delete(m.webhooks, id)
+	return nil
+}
+
+func (m *mockRepo) GetWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]orders.WebhookDelivery, error) {
+	m.deliveryLimit = limit
+	return m.deliveries, nil
+}
+
+func paginate(list []*orders.Order, limit, offset int) []*orders.Order {
+	if offset >= len(list) {
+		return nil
+	}
+	return list[offset:min(offset+limit, len(list))]
 }
 
 /////////////////////////////
 
 type mockCache struct {
-	data   map[string]*orders.Order
-	setErr error
-	getErr error
+	mu          sync.Mutex
+	data        map[string]*orders.Order
+	setErr      error
+	getErr      error
+	access      map[string]int
+	checkpoints map[string]string
+	listVersion int64
+	listPages   map[string]*orders.ListPage
+
+	setManyCalls int
 }
 
 func (m *mockCache) Get(ctx context.Context, key string) (*orders.Order, error) {
 	if m.getErr != nil {
 		return nil, m.getErr
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.data[key], nil
 }
 
@@ -53,6 +325,8 @@ func (m *mockCache) Set(ctx context.Context, key string, value *orders.Order) er
 	if m.setErr != nil {
 		return m.setErr
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.data == nil {
 		m.data = map[string]*orders.Order{}
 	}
@@ -60,6 +334,118 @@ func (m *mockCache) Set(ctx context.Context, key string, value *orders.Order) er
 	return nil
 }
 
+func (m *mockCache) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.data, key)
+	}
+	return nil
+}
+
+func (m *mockCache) GetMany(ctx context.Context, keys []string) (map[string]*orders.Order, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := map[string]*orders.Order{}
+	for _, key := range keys {
+		if o, ok := m.data[key]; ok {
+			result[key] = o
+		}
+	}
+	return result, nil
+}
+
+func (m *mockCache) SetMany(ctx context.Context, items map[string]*orders.Order) error {
+	m.mu.Lock()
+	m.setManyCalls++
+	m.mu.Unlock()
+	for key, value := range items {
+		if err := m.Set(ctx, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockCache) GetListVersion(ctx context.Context) (int64, error) {
+	return m.listVersion, nil
+}
+
+func (m *mockCache) BumpListVersion(ctx context.Context) error {
+	m.listVersion++
+	return nil
+}
+
+func (m *mockCache) GetListPage(ctx context.Context, key string) (*orders.ListPage, error) {
+	return m.listPages[key], nil
+}
+
+func (m *mockCache) SetListPage(ctx context.Context, key string, page *orders.ListPage) error {
+	if m.listPages == nil {
+		m.listPages = map[string]*orders.ListPage{}
+	}
+	m.listPages[key] = page
+	return nil
+}
+
+func (m *mockCache) IncrAccess(ctx context.Context, orderUID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.access == nil {
+		m.access = map[string]int{}
+	}
+	m.access[orderUID]++
+	return nil
+}
+
+func (m *mockCache) TopAccessed(ctx context.Context, window time.Duration, limit int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	uids := make([]string, 0, len(m.access))
+	for uid := range m.access {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool { return m.access[uids[i]] > m.access[uids[j]] })
+	if len(uids) > limit {
+		uids = uids[:limit]
+	}
+	return uids, nil
+}
+
+func (m *mockCache) GetCheckpoint(ctx context.Context, name string) (string, error) {
+	return m.checkpoints[name], nil
+}
+
+func (m *mockCache) SetCheckpoint(ctx context.Context, name, value string) error {
+	if m.checkpoints == nil {
+		m.checkpoints = map[string]string{}
+	}
+	m.checkpoints[name] = value
+	return nil
+}
+
+func (m *mockCache) DeleteCheckpoint(ctx context.Context, name string) error {
+	delete(m.checkpoints, name)
+	return nil
+}
+
+type mockPublisher struct {
+	mu     sync.Mutex
+	events []orders.Event
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, event orders.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+	return nil
+}
+
+/////////////////////////////
+
 type mockLogger struct{}
 
 func (m *mockLogger) Info(ctx context.Context, msg string, fields ...zap.Field)  {}
@@ -79,7 +465,7 @@ func TestGetOrderByUID(t *testing.T) {
 	t.Run("success: get from cache", func(t *testing.T) {
 		cache := &mockCache{data: map[string]*orders.Order{"order:o1": order}}
 		repo := &mockRepo{}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
 
 		got, err := svc.GetOrderByUID(ctx, "o1")
 		if err != nil {
@@ -93,7 +479,7 @@ func TestGetOrderByUID(t *testing.T) {
 	t.Run("success: get from repo when cache is empty", func(t *testing.T) {
 		cache := &mockCache{data: map[string]*orders.Order{}}
 		repo := &mockRepo{getOrder: order}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
 
 		got, err := svc.GetOrderByUID(ctx, "o1")
 		if err != nil {
@@ -113,13 +499,96 @@ func TestGetOrderByUID(t *testing.T) {
 	t.Run("error: not found in repo", func(t *testing.T) {
 		cache := &mockCache{data: map[string]*orders.Order{}}
 		repo := &mockRepo{getErr: orders.ErrOrderNotFound}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
 
 		_, err := svc.GetOrderByUID(ctx, "o1")
 		if !errors.Is(err, orders.ErrOrderNotFound) {
 			t.Errorf("expected ErrOrderNotFound, got %v", err)
 		}
 	})
+
+	t.Run("access: slow counter does not block the request", func(t *testing.T) {
+		cache := &gatedCache{mockCache: &mockCache{data: map[string]*orders.Order{"order:o1": order}}, release: make(chan struct{})}
+		cfg := &config.Config{CacheWarmupStrategy: WarmupStrategyPopular}
+		svc := NewOrdersService(cfg, &mockRepo{}, cache, nil, wg, logger)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 3 {
+				if _, err := svc.GetOrderByUID(ctx, "o1"); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("request waited for access counter")
+		}
+
+		close(cache.release)
+		if err := svc.Close(ctx); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+		if got := cache.access["o1"]; got != 3 {
+			t.Errorf("expected 3 tracked accesses after close, got %d", got)
+		}
+	})
+
+	t.Run("access: not tracked unless popular warmup is configured", func(t *testing.T) {
+		for _, strategy := range []string{WarmupStrategyRecent, WarmupStrategyCustomers, WarmupStrategySince} {
+			cache := &mockCache{data: map[string]*orders.Order{"order:o1": order}}
+			svc := NewOrdersService(&config.Config{CacheWarmupStrategy: strategy}, &mockRepo{}, cache, nil, wg, logger)
+
+			if _, err := svc.GetOrderByUID(ctx, "o1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := svc.Close(ctx); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+			if len(cache.access) != 0 {
+				t.Errorf("%s: expected no tracked accesses, got %v", strategy, cache.access)
+			}
+		}
+	})
+}
+
+func TestGetOrdersByUIDs(t *testing.T) {
+	ctx := context.Background()
+	wg := &sync.WaitGroup{}
+	o1, o2, o3 := &orders.Order{OrderUID: "o1"}, &orders.Order{OrderUID: "o2"}, &orders.Order{OrderUID: "o3"}
+
+	cache := &mockCache{data: map[string]*orders.Order{"order:o2": o2}}
+	repo := &mockRepo{getOrders: []*orders.Order{o1, o3}}
+	svc := NewOrdersService(&config.Config{}, repo, cache, nil, wg, &mockLogger{})
+
+	got, err := svc.GetOrdersByUIDs(ctx, []string{"o3", "missing", "o2", "o1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var uids []string
+	for _, o := range got {
+		uids = append(uids, o.OrderUID)
+	}
+	if !slices.Equal(uids, []string{"o3", "o2", "o1"}) {
+		t.Errorf("expected found orders in request order, got %v", uids)
+	}
+	if len(repo.byUIDs) != 1 || !slices.Equal(repo.byUIDs[0], []string{"o3", "missing", "o1"}) {
+		t.Errorf("expected one repository query for cache misses, got %v", repo.byUIDs)
+	}
+
+	wg.Wait()
+	if cached, _ := cache.Get(ctx, "order:o1"); cached == nil {
+		t.Error("order from repository was not cached")
+	}
+	if cache.setManyCalls != 1 {
+		t.Errorf("expected cache misses written with one SetMany, got %d", cache.setManyCalls)
+	}
+
+	if got, err := svc.GetOrdersByUIDs(ctx, nil); err != nil || len(got) != 0 || len(repo.byUIDs) != 1 {
+		t.Errorf("expected empty result without queries, got %v, %v", got, err)
+	}
 }
 
 func TestProcessEventOrder(t *testing.T) {
@@ -134,7 +603,7 @@ func TestProcessEventOrder(t *testing.T) {
 	t.Run("success: new order is saved and cached", func(t *testing.T) {
 		repo := &mockRepo{}
 		cache := &mockCache{}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
 
 		err := svc.ProcessEventOrder(ctx, eventOrder)
 		if err != nil {
@@ -156,7 +625,7 @@ func TestProcessEventOrder(t *testing.T) {
 		repo := &mockRepo{}
 		order := &orders.Order{OrderUID: eventOrder.OrderUID}
 		cache := &mockCache{data: map[string]*orders.Order{"order:" + eventOrder.OrderUID: order}}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
 
 		err := svc.ProcessEventOrder(ctx, eventOrder)
 		if !errors.Is(err, orders.ErrOrderAlreadyExists) {
@@ -169,9 +638,10 @@ func TestProcessEventOrder(t *testing.T) {
 	})
 
 	t.Run("duplicate: order already in db, cache is updated", func(t *testing.T) {
-		repo := &mockRepo{saveErr: orders.ErrOrderAlreadyExists}
+		stored := &orders.Order{OrderUID: eventOrder.OrderUID, TrackNumber: "STORED", Status: orders.StatusShipped}
+		repo := &mockRepo{saveErr: orders.ErrOrderAlreadyExists, getOrder: stored}
 		cache := &mockCache{}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
 
 		err := svc.ProcessEventOrder(ctx, eventOrder)
 		if !errors.Is(err, orders.ErrOrderAlreadyExists) {
@@ -183,13 +653,30 @@ func TestProcessEventOrder(t *testing.T) {
 		if cachedVal == nil {
 			t.Error("order should be cached even if it already exists in db (cache self-healing)")
 		}
+		if cachedVal != stored {
+			t.Errorf("expected stored order in cache instead of the incoming payload, got %+v", cachedVal)
+		}
+	})
+
+	t.Run("duplicate: stored order is unavailable, cache is not filled", func(t *testing.T) {
+		repo := &mockRepo{saveErr: orders.ErrOrderAlreadyExists, getErr: errors.New("db is down")}
+		cache := &mockCache{}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		if err := svc.ProcessEventOrder(ctx, eventOrder); !errors.Is(err, orders.ErrOrderAlreadyExists) {
+			t.Errorf("expected ErrOrderAlreadyExists, got %v", err)
+		}
+		wg.Wait()
+		if len(cache.data) > 0 {
+			t.Error("incoming payload must not be cached for an existing order")
+		}
 	})
 
 	t.Run("error: failed to save order in db", func(t *testing.T) {
 		dbErr := errors.New("db is down")
 		repo := &mockRepo{saveErr: dbErr}
 		cache := &mockCache{}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
 
 		err := svc.ProcessEventOrder(ctx, eventOrder)
 		if !errors.Is(err, dbErr) {
@@ -202,88 +689,735 @@ func TestProcessEventOrder(t *testing.T) {
 	})
 }
 
-func TestWarmOrdersCache(t *testing.T) {
+func TestProcessEventOrderFraud(t *testing.T) {
 	ctx := context.Background()
-	wg := &sync.WaitGroup{}
-	logger := &mockLogger{}
-	cfg := &config.Config{CacheWarmupSize: 2}
-	ordersToWarm := []*orders.Order{
-		{OrderUID: "warm1"},
-		{OrderUID: "warm2"},
+	cfg := &config.Config{
+		FraudDuplicateWeight:    60,
+		FraudPhoneWeight:        30,
+		FraudPhoneMaxOrders:     2,
+		FraudPhoneWindowMinutes: 30,
+		FraudAlertScore:         70,
 	}
+	eventOrder := &kafkadelivery.EventOrder{OrderUID: "uid-fraud", Payment: kafkadelivery.Payment{Transaction: "tx-1", Currency: "USD"}}
 
-	t.Run("success: all orders are cached", func(t *testing.T) {
-		repo := &mockRepo{getOrders: ordersToWarm}
-		cache := &mockCache{}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+	t.Run("high score is stored and alerted", func(t *testing.T) {
+		repo := &mockRepo{fraudSignals: orders.FraudSignals{PhoneOrders: 4, DuplicateUIDs: []string{"uid-old"}}}
+		publisher := &mockPublisher{}
+		svc := NewOrdersService(cfg, repo, &mockCache{}, publisher, &sync.WaitGroup{}, &mockLogger{})
 
-		err := svc.WarmOrdersCache(ctx)
-		if err != nil {
-			t.Fatalf("unexpected error during cache warmup: %v", err)
+		if err := svc.ProcessEventOrder(ctx, eventOrder); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.phoneWindow != 30*time.Minute {
+			t.Errorf("unexpected phone window: %s", repo.phoneWindow)
+		}
+		if repo.saved == nil || repo.saved.Fraud == nil || repo.saved.Fraud.Score != 90 || len(repo.saved.Fraud.Rules) != 2 {
+			t.Fatalf("expected saved fraud score 90, got %+v", repo.saved)
 		}
+		if len(publisher.events) != 2 || publisher.events[1].Type != orders.EventOrderFraudAlert {
+			t.Fatalf("expected created and fraud alert events, got %+v", publisher.events)
+		}
+		if alert := publisher.events[1]; alert.Fraud != repo.saved.Fraud || alert.Currency != "USD" {
+			t.Errorf("unexpected alert: %+v", alert)
+		}
+	})
 
-		if len(cache.data) != 2 {
-			t.Errorf("expected 2 items in cache, got %d", len(cache.data))
+	t.Run("low score is stored without alert", func(t *testing.T) {
+		repo := &mockRepo{fraudSignals: orders.FraudSignals{PhoneOrders: 4}}
+		publisher := &mockPublisher{}
+		svc := NewOrdersService(cfg, repo, &mockCache{}, publisher, &sync.WaitGroup{}, &mockLogger{})
+
+		if err := svc.ProcessEventOrder(ctx, eventOrder); err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
-		if _, ok := cache.data["order:warm1"]; !ok {
-			t.Error("order warm1 not found in cache")
+		if repo.saved.Fraud == nil || repo.saved.Fraud.Score != 30 {
+			t.Errorf("expected fraud score 30, got %+v", repo.saved.Fraud)
+		}
+		if len(publisher.events) != 1 || publisher.events[0].Type != orders.EventOrderCreated {
+			t.Fatalf("expected only created event, got %+v", publisher.events)
+		}
+		if e := publisher.events[0]; e.Amount == nil || e.Currency != "USD" || e.TrackNumber != eventOrder.TrackNumber {
+			t.Errorf("created event must carry order summary, got %+v", e)
 		}
 	})
 
-	t.Run("error: repo fails to get orders", func(t *testing.T) {
-		repoErr := errors.New("db is down")
-		repo := &mockRepo{getErr: repoErr}
+	t.Run("duplicate is skipped before scoring", func(t *testing.T) {
+		stored := &orders.Order{OrderUID: eventOrder.OrderUID, TrackNumber: "STORED"}
+		repo := &mockRepo{getOrder: stored, fraudSignals: orders.FraudSignals{PhoneOrders: 4}}
 		cache := &mockCache{}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		wg := &sync.WaitGroup{}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, &mockLogger{})
 
-		err := svc.WarmOrdersCache(ctx)
-		if !errors.Is(err, repoErr) {
-			t.Errorf("expected wrapped db error, got %v", err)
+		if err := svc.ProcessEventOrder(ctx, eventOrder); !errors.Is(err, orders.ErrOrderAlreadyExists) {
+			t.Fatalf("expected ErrOrderAlreadyExists, got %v", err)
 		}
-		if len(cache.data) > 0 {
-			t.Error("cache should be empty when repo fails")
+		if repo.phoneWindow != 0 || repo.saveCalled {
+			t.Error("duplicate order must not be scored or saved")
+		}
+		wg.Wait()
+		if cached, _ := cache.Get(ctx, "order:"+eventOrder.OrderUID); cached != stored {
+			t.Errorf("expected stored order in cache, got %+v", cached)
 		}
 	})
 
-	t.Run("partial success: cache set fails", func(t *testing.T) {
-		repo := &mockRepo{getOrders: ordersToWarm}
-		// This mock will fail on every Set call
-		cache := &mockCache{setErr: errors.New("redis is flaky")}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+	t.Run("signals error does not stop ingestion", func(t *testing.T) {
+		repo := &mockRepo{fraudErr: errors.New("db is slow")}
+		svc := NewOrdersService(cfg, repo, &mockCache{}, nil, &sync.WaitGroup{}, &mockLogger{})
 
-		// The function should not return an error, as it only logs cache failures during warmup
-		err := svc.WarmOrdersCache(ctx)
-		if err != nil {
-			t.Fatalf("expected no error from WarmOrdersCache on cache.Set failure, got %v", err)
+		if err := svc.ProcessEventOrder(ctx, eventOrder); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !repo.saveCalled || repo.saved.Fraud != nil {
+			t.Errorf("order must be saved without score, got %+v", repo.saved)
 		}
 	})
 }
 
-func TestGetOrders(t *testing.T) {
-	ctx := context.Background()
-	wg := &sync.WaitGroup{}
-	logger := &mockLogger{}
+func TestChangeOrderStatus(t *testing.T) {
 	cfg := &config.Config{}
-	ordersList := []*orders.Order{
-		{OrderUID: "o1"},
-		{OrderUID: "o2"},
-	}
+	logger := &mockLogger{}
 
-	t.Run("success: get orders list", func(t *testing.T) {
-		repo := &mockRepo{getOrders: ordersList}
+	t.Run("valid transition is saved and published", func(t *testing.T) {
+		repo := &mockRepo{getOrder: &orders.Order{OrderUID: "uid1", Status: orders.StatusCreated}}
+		publisher := &mockPublisher{}
 		cache := &mockCache{}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		wg := &sync.WaitGroup{}
+		svc := NewOrdersService(cfg, repo, cache, publisher, wg, logger)
 
-		params := GetOrdersParams{Page: 1, Limit: 10}
-		got, total, err := svc.GetOrders(ctx, params)
+		order, err := svc.ChangeOrderStatus(context.Background(), "uid1", orders.StatusPaid, "payment received")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if total != 2 {
-			t.Errorf("expected total 2, got %d", total)
-		}
-		if len(got) != 2 {
-			t.Errorf("expected 2 orders, got %d", len(got))
+		wg.Wait()
+
+		if order.Status != orders.StatusPaid {
+			t.Errorf("expected status %q, got %q", orders.StatusPaid, order.Status)
+		}
+		if len(repo.statusChanges) != 1 || repo.statusChanges[0].From != orders.StatusCreated {
+			t.Errorf("unexpected status changes: %+v", repo.statusChanges)
+		}
+		if len(publisher.events) != 1 || publisher.events[0].Type != orders.EventOrderStatusChanged {
+			t.Fatalf("expected one status_changed event, got %+v", publisher.events)
+		}
+		if publisher.events[0].From != orders.StatusCreated || publisher.events[0].To != orders.StatusPaid {
+			t.Errorf("unexpected event transition: %+v", publisher.events[0])
+		}
+		if cache.listVersion != 1 {
+			t.Errorf("expected list version to be bumped once, got %d", cache.listVersion)
+		}
+	})
+
+	t.Run("invalid transition returns typed error", func(t *testing.T) {
+		repo := &mockRepo{getOrder: &orders.Order{OrderUID: "uid1", Status: orders.StatusCreated}}
+		publisher := &mockPublisher{}
+		cache := &mockCache{}
+		svc := NewOrdersService(cfg, repo, cache, publisher, &sync.WaitGroup{}, logger)
+
+		_, err := svc.ChangeOrderStatus(context.Background(), "uid1", orders.StatusDelivered, "")
+		if !errors.Is(err, orders.ErrInvalidTransition) {
+			t.Fatalf("expected ErrInvalidTransition, got %v", err)
+		}
+		var te *orders.TransitionError
+		if !errors.As(err, &te) || te.From != orders.StatusCreated || te.To != orders.StatusDelivered {
+			t.Errorf("expected TransitionError created->delivered, got %v", err)
+		}
+		if len(repo.statusChanges) != 0 || len(publisher.events) != 0 || cache.listVersion != 0 {
+			t.Error("invalid transition must not be saved, published or invalidate lists")
+		}
+	})
+
+	t.Run("same status is a no-op", func(t *testing.T) {
+		repo := &mockRepo{getOrder: &orders.Order{OrderUID: "uid1", Status: orders.StatusShipped}}
+		publisher := &mockPublisher{}
+		svc := NewOrdersService(cfg, repo, &mockCache{}, publisher, &sync.WaitGroup{}, logger)
+
+		if _, err := svc.ChangeOrderStatus(context.Background(), "uid1", orders.StatusShipped, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(repo.statusChanges) != 0 || len(publisher.events) != 0 {
+			t.Error("repeated status must not be saved or published")
+		}
+	})
+
+	t.Run("cancelled status cancels all items", func(t *testing.T) {
+		repo := &mockRepo{getOrder: &orders.Order{
+			OrderUID: "uid1",
+			Status:   orders.StatusPaid,
+			Payment:  orders.Payment{Amount: orders.MoneyFromMajor(100, ""), GoodsTotal: orders.MoneyFromMajor(100, "")},
+			Items:    []orders.Item{{Rid: "r1", TotalPrice: orders.MoneyFromMajor(100, "")}},
+		}}
+		publisher := &mockPublisher{}
+		cache := &mockCache{}
+		svc := NewOrdersService(cfg, repo, cache, publisher, &sync.WaitGroup{}, logger)
+
+		order, err := svc.ChangeOrderStatus(context.Background(), "uid1", orders.StatusCancelled, "customer request")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if order.Status != orders.StatusCancelled || !order.Items[0].Cancelled || order.Payment.Amount.Sign() != 0 {
+			t.Errorf("expected cancelled order with zero amount, got %q %+v", order.Status, order.Payment)
+		}
+		if len(repo.statusChanges) != 0 {
+			t.Errorf("cancellation must not go through status update, got %+v", repo.statusChanges)
+		}
+		if len(publisher.events) != 1 || publisher.events[0].Type != orders.EventOrderCancelled {
+			t.Fatalf("expected cancelled event, got %+v", publisher.events)
+		}
+		if cache.listVersion != 1 {
+			t.Errorf("expected list version to be bumped once, got %d", cache.listVersion)
+		}
+	})
+
+	t.Run("order not found", func(t *testing.T) {
+		repo := &mockRepo{getErr: orders.ErrOrderNotFound}
+		svc := NewOrdersService(cfg, repo, &mockCache{}, nil, &sync.WaitGroup{}, logger)
+
+		_, err := svc.ChangeOrderStatus(context.Background(), "missing", orders.StatusPaid, "")
+		if !errors.Is(err, orders.ErrOrderNotFound) {
+			t.Fatalf("expected ErrOrderNotFound, got %v", err)
+		}
+	})
+}
+
+func TestCancelOrder(t *testing.T) {
+	newOrder := func() *orders.Order {
+		return &orders.Order{
+			OrderUID: "uid1",
+			Status:   orders.StatusPaid,
+			Payment: orders.Payment{
+				Transaction: "tx1",
+				Amount:      orders.MoneyFromMajor(300, ""),
+				GoodsTotal:  orders.MoneyFromMajor(300, ""),
+				Paid:        orders.MoneyFromMajor(300, ""),
+			},
+			Items: []orders.Item{
+				{Rid: "r1", ChrtID: 1, TotalPrice: orders.MoneyFromMajor(100, "")},
+				{Rid: "r2", ChrtID: 2, TotalPrice: orders.MoneyFromMajor(200, "")},
+			},
+		}
+	}
+
+	t.Run("partial cancellation publishes items_cancelled", func(t *testing.T) {
+		repo := &mockRepo{getOrder: newOrder()}
+		publisher := &mockPublisher{}
+		cache := &mockCache{}
+		wg := &sync.WaitGroup{}
+		svc := NewOrdersService(&config.Config{}, repo, cache, publisher, wg, &mockLogger{})
+
+		order, err := svc.CancelOrder(context.Background(), "uid1", []orders.ItemRef{{Rid: "r1"}}, "out of stock")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		wg.Wait()
+
+		if !order.Payment.Amount.Equal(orders.MoneyFromMajor(200, "")) {
+			t.Errorf("expected recalculated amount 200, got %s", order.Payment.Amount)
+		}
+		if len(publisher.events) != 1 || publisher.events[0].Type != orders.EventOrderItemsCancelled {
+			t.Fatalf("expected items_cancelled event, got %+v", publisher.events)
+		}
+		if len(publisher.events[0].Items) != 1 || publisher.events[0].Items[0].Rid != "r1" {
+			t.Errorf("expected cancelled item refs in event, got %+v", publisher.events[0].Items)
+		}
+		if cache.listVersion != 1 {
+			t.Errorf("expected list version to be bumped once, got %d", cache.listVersion)
+		}
+	})
+
+	t.Run("full cancellation publishes cancelled", func(t *testing.T) {
+		repo := &mockRepo{getOrder: newOrder()}
+		publisher := &mockPublisher{}
+		svc := NewOrdersService(&config.Config{}, repo, &mockCache{}, publisher, &sync.WaitGroup{}, &mockLogger{})
+
+		order, err := svc.CancelOrder(context.Background(), "uid1", nil, "customer request")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if order.Status != orders.StatusCancelled {
+			t.Errorf("expected cancelled status, got %q", order.Status)
+		}
+		if len(publisher.events) != 1 || publisher.events[0].Type != orders.EventOrderCancelled {
+			t.Fatalf("expected cancelled event, got %+v", publisher.events)
+		}
+	})
+}
+
+func TestRefundOrder(t *testing.T) {
+	repo := &mockRepo{getOrder: &orders.Order{
+		OrderUID: "uid1",
+		Payment:  orders.Payment{Transaction: "tx1", Paid: orders.MoneyFromMajor(300, "")},
+	}}
+	publisher := &mockPublisher{}
+	cache := &mockCache{}
+	svc := NewOrdersService(&config.Config{}, repo, cache, publisher, &sync.WaitGroup{}, &mockLogger{})
+
+	refund, err := svc.RefundOrder(context.Background(), "uid1", orders.Refund{Amount: orders.MoneyFromMajor(200, ""), Reason: "damaged"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refund.Transaction != "tx1" {
+		t.Errorf("expected refund linked to tx1, got %q", refund.Transaction)
+	}
+
+	_, err = svc.RefundOrder(context.Background(), "uid1", orders.Refund{Amount: orders.MoneyFromMajor(101, "")})
+	if !errors.Is(err, orders.ErrRefundExceedsPaid) {
+		t.Fatalf("expected ErrRefundExceedsPaid, got %v", err)
+	}
+
+	if len(publisher.events) != 1 || publisher.events[0].Type != orders.EventOrderRefunded || publisher.events[0].Amount == nil || !publisher.events[0].Amount.Equal(orders.MoneyFromMajor(200, "")) {
+		t.Errorf("expected single refunded event, got %+v", publisher.events)
+	}
+	if cache.listVersion != 1 {
+		t.Errorf("expected list version to be bumped only by the successful refund, got %d", cache.listVersion)
+	}
+}
+
+func TestForgetCustomer(t *testing.T) {
+	repo := &mockRepo{erasedUIDs: []string{"uid1", "uid2"}}
+	cache := &mockCache{data: map[string]*orders.Order{
+		"order:uid1": {OrderUID: "uid1"},
+		"order:uid2": {OrderUID: "uid2"},
+		"order:uid3": {OrderUID: "uid3"},
+	}}
+	cfg := &config.Config{ErasurePseudonymKey: "secret"}
+	svc := NewOrdersService(cfg, repo, cache, nil, &sync.WaitGroup{}, &mockLogger{})
+
+	ctx := orders.WithChangeSource(context.Background(), orders.ChangeSource{Kind: orders.SourceCLI, Actor: "dpo"})
+	erasure, err := svc.ForgetCustomer(ctx, "customer1", "request")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if erasure.CustomerRef == "customer1" || erasure.CustomerRef != orders.CustomerPseudonym("secret", "customer1") {
+		t.Errorf("expected pseudonymized customer ref, got %q", erasure.CustomerRef)
+	}
+	if erasure.Source.Actor != "dpo" || erasure.Reason != "request" {
+		t.Errorf("unexpected audit data: %+v", erasure)
+	}
+	if _, ok := cache.data["order:uid1"]; ok {
+		t.Error("erased order uid1 must be evicted from cache")
+	}
+	if _, ok := cache.data["order:uid3"]; !ok {
+		t.Error("order of another customer must stay in cache")
+	}
+	if cache.listVersion != 1 {
+		t.Errorf("expected list version to be bumped once, got %d", cache.listVersion)
+	}
+
+	if _, err := svc.ForgetCustomer(ctx, "", ""); !errors.Is(err, orders.ErrInvalidCustomerID) {
+		t.Errorf("expected ErrInvalidCustomerID, got %v", err)
+	}
+
+	noKey := NewOrdersService(&config.Config{}, repo, cache, nil, &sync.WaitGroup{}, &mockLogger{})
+	if _, err := noKey.ForgetCustomer(ctx, "customer1", ""); !errors.Is(err, orders.ErrErasureKeyMissing) {
+		t.Errorf("expected ErrErasureKeyMissing, got %v", err)
+	}
+	if cache.listVersion != 1 {
+		t.Errorf("failed erasures must not bump list version, got %d", cache.listVersion)
+	}
+}
+
+func TestGetCustomerOrders(t *testing.T) {
+	repo := &mockRepo{getOrders: []*orders.Order{
+		{OrderUID: "uid1", CustomerID: "customer1"},
+		{OrderUID: "uid2", CustomerID: "customer2"},
+		{OrderUID: "uid3", CustomerID: "customer1"},
+	}}
+	svc := NewOrdersService(&config.Config{}, repo, &mockCache{}, nil, &sync.WaitGroup{}, &mockLogger{})
+
+	list, total, err := svc.GetCustomerOrders(context.Background(), "customer1", GetOrdersParams{Page: 2, Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 2 || len(list) != 1 || list[0].OrderUID != "uid3" {
+		t.Errorf("expected second page with uid3 of 2 orders, got %d orders, total %d", len(list), total)
+	}
+
+	if _, _, err := svc.GetCustomerOrders(context.Background(), "", GetOrdersParams{Page: 1, Limit: 1}); !errors.Is(err, orders.ErrInvalidCustomerID) {
+		t.Errorf("expected ErrInvalidCustomerID, got %v", err)
+	}
+
+	if _, err := svc.GetCustomerSummary(context.Background(), "unknown"); !errors.Is(err, orders.ErrCustomerNotFound) {
+		t.Errorf("expected ErrCustomerNotFound, got %v", err)
+	}
+}
+
+func TestSearchOrders(t *testing.T) {
+	repo := &mockRepo{getOrders: []*orders.Order{
+		{OrderUID: "uid1", TrackNumber: "WBILMTESTTRACK"},
+		{OrderUID: "uid2", TrackNumber: "OTHER"},
+	}}
+	svc := NewOrdersService(&config.Config{}, repo, &mockCache{}, nil, &sync.WaitGroup{}, &mockLogger{})
+
+	list, total, err := svc.SearchOrders(context.Background(), orders.SearchCriteria{TrackNumber: "WBILMTESTTRACK"}, GetOrdersParams{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 1 || len(list) != 1 || list[0].OrderUID != "uid1" {
+		t.Errorf("expected uid1, got %d orders, total %d", len(list), total)
+	}
+
+	if _, _, err := svc.SearchOrders(context.Background(), orders.SearchCriteria{}, GetOrdersParams{Page: 1, Limit: 10}); !errors.Is(err, orders.ErrEmptySearch) {
+		t.Errorf("expected ErrEmptySearch, got %v", err)
+	}
+}
+
+func TestSearchOrdersText(t *testing.T) {
+	repo := &mockRepo{getOrders: []*orders.Order{{OrderUID: "uid1"}}}
+	svc := NewOrdersService(&config.Config{}, repo, &mockCache{}, nil, &sync.WaitGroup{}, &mockLogger{})
+
+	res, err := svc.SearchOrdersText(context.Background(), "  vivienne \t mascara ", GetOrdersParams{Page: 3, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Hits) != 1 || res.Hits[0].Order.OrderUID != "uid1" {
+		t.Errorf("unexpected hits: %+v", res.Hits)
+	}
+	if q := repo.lastTextQuery; q.Text != "vivienne mascara" || q.Limit != 10 || q.Offset != 20 {
+		t.Errorf("unexpected repository query: %+v", q)
+	}
+
+	if _, err := svc.SearchOrdersText(context.Background(), "   ", GetOrdersParams{Page: 1, Limit: 10}); !errors.Is(err, orders.ErrEmptyTextQuery) {
+		t.Errorf("expected ErrEmptyTextQuery, got %v", err)
+	}
+	long := strings.Repeat("я", orders.MaxTextQueryLength+1)
+	if _, err := svc.SearchOrdersText(context.Background(), long, GetOrdersParams{Page: 1, Limit: 10}); !errors.Is(err, orders.ErrTextQueryTooLong) {
+		t.Errorf("expected ErrTextQueryTooLong, got %v", err)
+	}
+}
+
+func TestConvertOrders(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	rate := func(d int, base, quote, value string) orders.FXRate {
+		r, err := orders.NewFXRate(day(d), base, quote, value, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	repo := &mockRepo{fxRates: []orders.FXRate{
+		rate(16, "EUR", "RUB", "100"),
+		rate(18, "EUR", "RUB", "90"),
+		rate(18, "EUR", "USD", "1.08"),
+	}}
+	svc := NewOrdersService(&config.Config{FXMaxRateAgeDays: 7}, repo, &mockCache{}, nil, &sync.WaitGroup{}, &mockLogger{})
+
+	created := day(17).Add(12 * time.Hour)
+	rub := &orders.Order{OrderUID: "uid1", DateCreated: &created, Payment: orders.Payment{
+		Currency: "RUB", Amount: orders.NewMoney(181750, 2, "RUB"), Paid: orders.NewMoney(181750, 2, "RUB"),
+	}}
+	latest := day(18).Add(time.Hour)
+	usd := &orders.Order{OrderUID: "uid2", DateCreated: &latest, Payment: orders.Payment{
+		Currency: "USD", Amount: orders.NewMoney(1080, 2, "USD"),
+	}}
+
+	res, err := svc.ConvertOrders(context.Background(), []*orders.Order{rub, usd}, "eur")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("expected 2 conversions, got %d", len(res))
+	}
+	// заказ 17 числа пересчитывается по курсу 16 числа
+	if res[0].Amount.String() != "18.18 EUR" || res[0].Paid.String() != "18.18 EUR" || res[0].RateDate != day(16) {
+		t.Errorf("unexpected RUB conversion: %s, %s", res[0].Amount, res[0].RateDate)
+	}
+	if res[1].Amount.String() != "10.00 EUR" || res[1].RateDate != day(18) {
+		t.Errorf("unexpected USD conversion: %s, %s", res[1].Amount, res[1].RateDate)
+	}
+	if rub.Payment.Amount != orders.NewMoney(181750, 2, "RUB") {
+		t.Errorf("stored order must not change, got %s", rub.Payment.Amount)
+	}
+	if !slices.Equal(repo.fxCurrencies, []string{"EUR", "RUB", "USD"}) {
+		t.Errorf("unexpected currencies requested: %v", repo.fxCurrencies)
+	}
+
+	// код валюты заказа в нижнем регистре запрашивается в верхнем
+	usd.Payment.Currency = "usd"
+	if res, err = svc.ConvertOrders(context.Background(), []*orders.Order{usd}, "EUR"); err != nil || res[0] == nil {
+		t.Errorf("expected lowercase order currency to be converted, got %v, %v", res, err)
+	}
+	if !slices.Equal(repo.fxCurrencies, []string{"EUR", "USD"}) {
+		t.Errorf("unexpected currencies requested: %v", repo.fxCurrencies)
+	}
+
+	// заказ без курса возвращается без пересчета, остальные пересчитываются
+	kzt := &orders.Order{OrderUID: "uid3", DateCreated: &latest, Payment: orders.Payment{Currency: "KZT", Amount: orders.NewMoney(500, 0, "KZT")}}
+	res, err = svc.ConvertOrders(context.Background(), []*orders.Order{kzt, usd}, "EUR")
+	if err != nil || len(res) != 2 || res[0] != nil || res[1] == nil {
+		t.Errorf("expected only the order without rate to be skipped, got %v, %v", res, err)
+	}
+	if _, err := svc.ConvertOrders(context.Background(), []*orders.Order{rub}, "euro"); !errors.Is(err, orders.ErrInvalidCurrency) {
+		t.Errorf("expected ErrInvalidCurrency, got %v", err)
+	}
+}
+
+func TestGetOrderStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	month := day(1)
+	rate, err := orders.NewFXRate(day(1), "EUR", "RUB", "100", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &mockRepo{
+		fxRates: []orders.FXRate{rate},
+		statsRows: []orders.StatsRow{
+			{Period: &month, Day: day(2), Keys: map[orders.StatsDimension]string{}, Currency: "EUR", Orders: 2, Revenue: orders.NewMoney(1050, 2, "EUR")},
+			{Period: &month, Day: day(2), Keys: map[orders.StatsDimension]string{}, Currency: "RUB", Orders: 1, Revenue: orders.NewMoney(181750, 2, "RUB")},
+			{Period: &month, Day: day(3), Keys: map[orders.StatsDimension]string{}, Currency: "EUR", Orders: 1, Revenue: orders.NewMoney(100, 2, "EUR"), Refunded: orders.NewMoney(50, 2, "EUR")},
+		},
+	}
+	svc := NewOrdersService(&config.Config{FXMaxRateAgeDays: 7, StatsMaxDays: 366}, repo, &mockCache{}, nil, &sync.WaitGroup{}, &mockLogger{})
+
+	from, to := day(1), day(31)
+	res, err := svc.GetOrderStats(context.Background(), StatsParams{
+		GroupBy: []orders.StatsDimension{orders.StatsByMonth}, DateFrom: &from, DateTo: &to, ReportingCurrency: "eur",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := repo.lastStatsQuery; !q.ByDay || q.DateFrom != from || q.DateTo != to {
+		t.Errorf("unexpected repository query: %+v", q)
+	}
+	if len(res.Groups) != 1 {
+		t.Fatalf("expected one month group, got %d", len(res.Groups))
+	}
+	g := res.Groups[0]
+	if g.Orders != 4 || len(g.Revenue) != 2 || g.Revenue[0].Amount.String() != "11.50 EUR" || g.Revenue[1].Amount.String() != "1817.50 RUB" {
+		t.Errorf("unexpected group totals: %+v", g)
+	}
+	if g.Reporting == nil || g.Reporting.Revenue.String() != "29.68 EUR" || g.Reporting.Refunded.String() != "0.50 EUR" {
+		t.Errorf("unexpected reporting totals: %+v", g.Reporting)
+	}
+
+	// период по умолчанию - последние 30 дней
+	if _, err := svc.GetOrderStats(context.Background(), StatsParams{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := repo.lastStatsQuery; q.ByDay || q.DateTo.Sub(q.DateFrom) != 29*24*time.Hour || q.GroupBy[0] != orders.StatsByDay {
+		t.Errorf("unexpected default query: %+v", q)
+	}
+
+	if _, err := svc.GetOrderStats(context.Background(), StatsParams{DateFrom: &to, DateTo: &from}); !errors.Is(err, orders.ErrInvalidStatsRange) {
+		t.Errorf("expected ErrInvalidStatsRange, got %v", err)
+	}
+	longAgo := from.AddDate(-2, 0, 0)
+	if _, err := svc.GetOrderStats(context.Background(), StatsParams{DateFrom: &longAgo, DateTo: &to}); !errors.Is(err, orders.ErrInvalidStatsRange) {
+		t.Errorf("expected ErrInvalidStatsRange for too long range, got %v", err)
+	}
+	if _, err := svc.GetOrderStats(context.Background(), StatsParams{DateFrom: &from, DateTo: &to, ReportingCurrency: "KZT"}); !errors.Is(err, orders.ErrRateNotFound) {
+		t.Errorf("expected ErrRateNotFound, got %v", err)
+	}
+}
+
+func TestGetOrderAsOf(t *testing.T) {
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	repo := &mockRepo{history: []orders.OrderVersion{
+		{Version: 1, CreatedAt: created, Order: &orders.Order{Status: orders.StatusCreated}},
+		{Version: 2, CreatedAt: created.Add(time.Hour), Order: &orders.Order{Status: orders.StatusPaid}},
+	}}
+	svc := NewOrdersService(&config.Config{}, repo, &mockCache{}, nil, &sync.WaitGroup{}, &mockLogger{})
+
+	v, err := svc.GetOrderAsOf(context.Background(), "uid1", created.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Version != 1 || v.Order.Status != orders.StatusCreated {
+		t.Errorf("expected version 1, got %+v", v)
+	}
+
+	_, err = svc.GetOrderAsOf(context.Background(), "uid1", created.Add(-time.Minute))
+	if !errors.Is(err, orders.ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound before creation, got %v", err)
+	}
+}
+
+func TestWarmOrdersCache(t *testing.T) {
+	ctx := context.Background()
+	wg := &sync.WaitGroup{}
+	logger := &mockLogger{}
+	cfg := &config.Config{CacheWarmupSize: 2}
+	ordersToWarm := []*orders.Order{
+		{OrderUID: "warm1"},
+		{OrderUID: "warm2"},
+	}
+
+	t.Run("success: all orders are cached", func(t *testing.T) {
+		repo := &mockRepo{getOrders: ordersToWarm}
+		cache := &mockCache{}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		err := svc.WarmOrdersCache(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error during cache warmup: %v", err)
+		}
+
+		if len(cache.data) != 2 {
+			t.Errorf("expected 2 items in cache, got %d", len(cache.data))
+		}
+		if _, ok := cache.data["order:warm1"]; !ok {
+			t.Error("order warm1 not found in cache")
+		}
+	})
+
+	t.Run("error: repo fails to get orders", func(t *testing.T) {
+		repoErr := errors.New("db is down")
+		repo := &mockRepo{getErr: repoErr}
+		cache := &mockCache{}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		err := svc.WarmOrdersCache(ctx)
+		if !errors.Is(err, repoErr) {
+			t.Errorf("expected wrapped db error, got %v", err)
+		}
+		if len(cache.data) > 0 {
+			t.Error("cache should be empty when repo fails")
+		}
+	})
+
+	t.Run("partial success: cache set fails", func(t *testing.T) {
+		repo := &mockRepo{getOrders: ordersToWarm}
+		// This mock will fail on every Set call
+		cache := &mockCache{setErr: errors.New("redis is flaky")}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		// The function should not return an error, as it only logs cache failures during warmup
+		err := svc.WarmOrdersCache(ctx)
+		if err != nil {
+			t.Fatalf("expected no error from WarmOrdersCache on cache.Set failure, got %v", err)
+		}
+	})
+
+	t.Run("strategy popular: most accessed orders are cached", func(t *testing.T) {
+		repo := &mockRepo{getOrders: ordersToWarm}
+		cache := &mockCache{access: map[string]int{"warm2": 5, "warm1": 1}}
+		cfg := &config.Config{CacheWarmupStrategy: WarmupStrategyPopular, CacheWarmupSize: 1, CacheWarmupWindowMinutes: 60}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		if err := svc.WarmOrdersCache(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cache.data) != 1 || cache.data["order:warm2"] == nil {
+			t.Errorf("expected only warm2 in cache, got %v", cache.data)
+		}
+	})
+
+	t.Run("strategy customers: orders of configured customers are cached", func(t *testing.T) {
+		repo := &mockRepo{getOrders: []*orders.Order{
+			{OrderUID: "c1", CustomerID: "alice"},
+			{OrderUID: "c2", CustomerID: "bob"},
+			{OrderUID: "c3", CustomerID: "alice"},
+		}}
+		cache := &mockCache{}
+		cfg := &config.Config{CacheWarmupStrategy: WarmupStrategyCustomers, CacheWarmupCustomers: []string{" alice "}, CacheWarmupPageSize: 1}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		if err := svc.WarmOrdersCache(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cache.data) != 2 || cache.data["order:c2"] != nil {
+			t.Errorf("expected c1 and c3 in cache, got %v", cache.data)
+		}
+		if len(cache.checkpoints) != 0 {
+			t.Errorf("checkpoint should be cleared after completion, got %v", cache.checkpoints)
+		}
+	})
+
+	t.Run("strategy since: only newer orders are cached", func(t *testing.T) {
+		old := time.Now().Add(-48 * time.Hour)
+		fresh := time.Now().Add(-time.Hour)
+		repo := &mockRepo{getOrders: []*orders.Order{
+			{OrderUID: "old", DateCreated: &old},
+			{OrderUID: "fresh", DateCreated: &fresh},
+		}}
+		cache := &mockCache{}
+		cfg := &config.Config{CacheWarmupStrategy: WarmupStrategySince, CacheWarmupSince: "24h"}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		if err := svc.WarmOrdersCache(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cache.data) != 1 || cache.data["order:fresh"] == nil {
+			t.Errorf("expected only fresh order in cache, got %v", cache.data)
+		}
+	})
+
+	t.Run("resume: warmup continues from saved checkpoint", func(t *testing.T) {
+		repo := &mockRepo{getOrders: []*orders.Order{
+			{OrderUID: "r1", CustomerID: "alice"},
+			{OrderUID: "r2", CustomerID: "alice"},
+		}}
+		cache := &mockCache{checkpoints: map[string]string{
+			WarmupStrategyCustomers: `{"fingerprint":"alice","page":1}`,
+		}}
+		cfg := &config.Config{CacheWarmupStrategy: WarmupStrategyCustomers, CacheWarmupCustomers: []string{"alice"}, CacheWarmupPageSize: 1}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		if err := svc.WarmOrdersCache(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cache.data) != 1 || cache.data["order:r2"] == nil {
+			t.Errorf("expected warmup to resume from page 1, got %v", cache.data)
+		}
+	})
+
+	t.Run("resume: since checkpoint matches the parsed duration", func(t *testing.T) {
+		fresh1 := time.Now().Add(-2 * time.Hour)
+		fresh2 := time.Now().Add(-time.Hour)
+		repo := &mockRepo{getOrders: []*orders.Order{
+			{OrderUID: "s1", DateCreated: &fresh1},
+			{OrderUID: "s2", DateCreated: &fresh2},
+		}}
+		cache := &mockCache{checkpoints: map[string]string{
+			WarmupStrategySince: `{"fingerprint":"duration:24h0m0s","page":1}`,
+		}}
+		cfg := &config.Config{CacheWarmupStrategy: WarmupStrategySince, CacheWarmupSince: "1440m", CacheWarmupPageSize: 1}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		if err := svc.WarmOrdersCache(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cache.data) != 1 || cache.data["order:s2"] == nil {
+			t.Errorf("expected warmup to resume from page 1, got %v", cache.data)
+		}
+	})
+
+	t.Run("error: unknown strategy", func(t *testing.T) {
+		cfg := &config.Config{CacheWarmupStrategy: "random"}
+		svc := NewOrdersService(cfg, &mockRepo{}, &mockCache{}, nil, wg, logger)
+
+		err := svc.WarmOrdersCache(ctx)
+		if !errors.Is(err, ErrUnknownWarmupStrategy) {
+			t.Errorf("expected ErrUnknownWarmupStrategy, got %v", err)
+		}
+	})
+}
+
+func TestGetOrders(t *testing.T) {
+	ctx := context.Background()
+	wg := &sync.WaitGroup{}
+	logger := &mockLogger{}
+	cfg := &config.Config{}
+	ordersList := []*orders.Order{
+		{OrderUID: "o1"},
+		{OrderUID: "o2"},
+	}
+
+	t.Run("success: get orders list", func(t *testing.T) {
+		repo := &mockRepo{getOrders: ordersList}
+		cache := &mockCache{}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		params := GetOrdersParams{Page: 1, Limit: 10}
+		res, err := svc.GetOrders(ctx, params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Total != 2 {
+			t.Errorf("expected total 2, got %d", res.Total)
+		}
+		if len(res.Orders) != 2 {
+			t.Errorf("expected 2 orders, got %d", len(res.Orders))
 		}
 	})
 
@@ -291,12 +1425,389 @@ func TestGetOrders(t *testing.T) {
 		repoErr := errors.New("db is down")
 		repo := &mockRepo{getErr: repoErr}
 		cache := &mockCache{}
-		svc := NewOrdersService(cfg, repo, cache, wg, logger)
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
 
 		params := GetOrdersParams{Page: 1, Limit: 10}
-		_, _, err := svc.GetOrders(ctx, params)
+		_, err := svc.GetOrders(ctx, params)
 		if !errors.Is(err, repoErr) {
 			t.Errorf("expected db error, got %v", err)
 		}
 	})
+
+	t.Run("cache: repeated page is served from list cache", func(t *testing.T) {
+		repo := &mockRepo{getOrders: ordersList}
+		cache := &mockCache{}
+		cfg := &config.Config{OrdersListTTLSec: 60}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+		params := GetOrdersParams{Page: 1, Limit: 10}
+
+		if _, err := svc.GetOrders(ctx, params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		wg.Wait()
+
+		res, err := svc.GetOrders(ctx, params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.getOrdersCalls != 1 {
+			t.Errorf("expected 1 repository call, got %d", repo.getOrdersCalls)
+		}
+		got := res.Orders
+		if res.Total != 2 || len(got) != 2 || got[0].OrderUID != "o1" || got[1].OrderUID != "o2" {
+			t.Errorf("unexpected cached page: total=%d orders=%v", res.Total, got)
+		}
+	})
+
+	t.Run("cache: filters and sort are part of the page key", func(t *testing.T) {
+		repo := &mockRepo{getOrders: ordersList}
+		cache := &mockCache{}
+		cfg := &config.Config{OrdersListTTLSec: 60}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+
+		minAmount := orders.NewMoney(1050, 2, "")
+		filtered := GetOrdersParams{
+			Page:   1,
+			Limit:  10,
+			Filter: orders.ListFilter{Currency: "USD", MinAmount: &minAmount},
+			Sort:   orders.ListSort{Field: orders.SortByAmount, Asc: true},
+		}
+		for _, params := range []GetOrdersParams{{Page: 1, Limit: 10}, filtered, filtered} {
+			if _, err := svc.GetOrders(ctx, params); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			wg.Wait()
+		}
+
+		if repo.getOrdersCalls != 2 {
+			t.Errorf("expected filtered page to be cached separately, got %d repository calls", repo.getOrdersCalls)
+		}
+		if f := repo.lastQuery.Filter; f.Currency != "USD" || !f.MinAmount.Equal(minAmount) || repo.lastQuery.Sort.Field != orders.SortByAmount {
+			t.Errorf("filter and sort must reach repository, got %+v %+v", f, repo.lastQuery.Sort)
+		}
+	})
+
+	t.Run("cursor: replaces offset and disables exact total by default", func(t *testing.T) {
+		repo := &mockRepo{getOrders: ordersList}
+		svc := NewOrdersService(cfg, repo, &mockCache{}, nil, wg, logger)
+
+		if _, err := svc.GetOrders(ctx, GetOrdersParams{Page: 3, Limit: 10}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if q := repo.lastQuery; q.Offset != 20 || q.Limit != 10 || q.Total != orders.TotalExact || q.Cursor != nil {
+			t.Errorf("unexpected page query: %+v", q)
+		}
+
+		cursor := &orders.Cursor{DateCreated: time.Now(), ID: 42}
+		if _, err := svc.GetOrders(ctx, GetOrdersParams{Page: 3, Limit: 10, Cursor: cursor}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if q := repo.lastQuery; q.Offset != 0 || q.Total != orders.TotalNone || q.Cursor != cursor {
+			t.Errorf("unexpected cursor query: %+v", q)
+		}
+
+		if _, err := svc.GetOrders(ctx, GetOrdersParams{Limit: 10, Cursor: cursor, Total: orders.TotalEstimate}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.lastQuery.Total != orders.TotalEstimate {
+			t.Errorf("expected explicit total mode to be kept, got %q", repo.lastQuery.Total)
+		}
+	})
+
+	t.Run("cache: new order invalidates cached pages", func(t *testing.T) {
+		repo := &mockRepo{getOrders: ordersList}
+		cache := &mockCache{}
+		cfg := &config.Config{OrdersListTTLSec: 60}
+		svc := NewOrdersService(cfg, repo, cache, nil, wg, logger)
+		params := GetOrdersParams{Page: 1, Limit: 10}
+
+		if _, err := svc.GetOrders(ctx, params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := svc.ProcessEventOrder(ctx, &kafkadelivery.EventOrder{OrderUID: "o3"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		wg.Wait()
+
+		if _, err := svc.GetOrders(ctx, params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if repo.getOrdersCalls != 2 {
+			t.Errorf("expected list to be reloaded after new order, got %d repository calls", repo.getOrdersCalls)
+		}
+	})
+}
+
+// gatedCache блокирует запись до закрытия release и сообщает о начале каждой записи в started
+type gatedCache struct {
+	*mockCache
+	started chan string
+	release chan struct{}
+	failN   atomic.Int32
+}
+
+func (g *gatedCache) Set(ctx context.Context, key string, value *orders.Order) error {
+	if g.started != nil {
+		g.started <- key
+	}
+	if g.release != nil {
+		<-g.release
+	}
+	if g.failN.Add(-1) >= 0 {
+		return errors.New("redis is flaky")
+	}
+	return g.mockCache.Set(ctx, key, value)
+}
+
+func (g *gatedCache) SetMany(ctx context.Context, items map[string]*orders.Order) error {
+	if g.started != nil {
+		g.started <- "batch"
+	}
+	if g.release != nil {
+		<-g.release
+	}
+	return g.mockCache.SetMany(ctx, items)
+}
+
+func (g *gatedCache) IncrAccess(ctx context.Context, orderUID string) error {
+	if g.release != nil {
+		<-g.release
+	}
+	return g.mockCache.IncrAccess(ctx, orderUID)
+}
+
+func TestCacheWriter(t *testing.T) {
+	logger := &mockLogger{}
+
+	newGated := func() *gatedCache {
+		return &gatedCache{mockCache: &mockCache{}, started: make(chan string, 10), release: make(chan struct{})}
+	}
+
+	t.Run("coalesce and drop_new: full queue rejects new keys", func(t *testing.T) {
+		cache := newGated()
+		wg := &sync.WaitGroup{}
+		w := newCacheWriter(CacheWriterConfig{Workers: 1, QueueSize: 1, Policy: CacheWriterPolicyDropNew, Coalesce: true}, cache, wg, logger)
+
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		<-cache.started
+		w.Enqueue("b", &orders.Order{OrderUID: "b", TrackNumber: "v1"})
+		w.Enqueue("b", &orders.Order{OrderUID: "b", TrackNumber: "v2"})
+		w.Enqueue("c", &orders.Order{OrderUID: "c"})
+
+		close(cache.release)
+		wg.Wait()
+
+		if cache.data["b"] == nil || cache.data["b"].TrackNumber != "v2" {
+			t.Errorf("expected coalesced value v2 for b, got %+v", cache.data["b"])
+		}
+		if cache.data["c"] != nil {
+			t.Error("c should be dropped with drop_new policy")
+		}
+		stats := w.Stats()
+		if stats.Coalesced != 1 || stats.Dropped != 1 || stats.Written != 2 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("drop_oldest: oldest queued write is replaced", func(t *testing.T) {
+		cache := newGated()
+		wg := &sync.WaitGroup{}
+		w := newCacheWriter(CacheWriterConfig{Workers: 1, QueueSize: 1, Policy: CacheWriterPolicyDropOldest}, cache, wg, logger)
+
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		<-cache.started
+		w.Enqueue("b", &orders.Order{OrderUID: "b"})
+		w.Enqueue("c", &orders.Order{OrderUID: "c"})
+
+		close(cache.release)
+		wg.Wait()
+
+		if cache.data["b"] != nil || cache.data["c"] == nil {
+			t.Errorf("expected b dropped and c written, got %v", cache.data)
+		}
+	})
+
+	t.Run("retry: failed write is retried", func(t *testing.T) {
+		cache := &gatedCache{mockCache: &mockCache{}}
+		cache.failN.Store(1)
+		wg := &sync.WaitGroup{}
+		w := newCacheWriter(CacheWriterConfig{Workers: 1, MaxRetries: 2, RetryDelay: time.Millisecond}, cache, wg, logger)
+
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		wg.Wait()
+
+		stats := w.Stats()
+		if cache.data["a"] == nil || stats.Retried != 1 || stats.Failed != 0 {
+			t.Errorf("expected write to succeed after one retry, stats: %+v", stats)
+		}
+	})
+
+	t.Run("discard: queued write is cancelled without coalescing", func(t *testing.T) {
+		cache := newGated()
+		wg := &sync.WaitGroup{}
+		w := newCacheWriter(CacheWriterConfig{Workers: 1, QueueSize: 10}, cache, wg, logger)
+
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		<-cache.started
+		w.Enqueue("b", &orders.Order{OrderUID: "b"})
+		w.Discard("b")
+		close(cache.release)
+		wg.Wait()
+
+		if cache.data["a"] == nil || cache.data["b"] != nil {
+			t.Errorf("expected a written and b discarded, got %v", cache.data)
+		}
+	})
+
+	t.Run("discard: write in progress is removed after completion", func(t *testing.T) {
+		cache := newGated()
+		wg := &sync.WaitGroup{}
+		w := newCacheWriter(CacheWriterConfig{Workers: 1, QueueSize: 10, Coalesce: true}, cache, wg, logger)
+
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		<-cache.started
+		w.Discard("a")
+		close(cache.release)
+		wg.Wait()
+
+		if _, ok := cache.data["a"]; ok {
+			t.Error("discarded write must not stay in cache")
+		}
+
+		cache.started = nil
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		wg.Wait()
+		if cache.data["a"] == nil {
+			t.Error("write enqueued after Discard must be stored")
+		}
+	})
+
+	t.Run("discard: batch write skips and removes discarded keys", func(t *testing.T) {
+		cache := newGated()
+		wg := &sync.WaitGroup{}
+		w := newCacheWriter(CacheWriterConfig{Workers: 1, QueueSize: 10}, cache, wg, logger)
+
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		<-cache.started
+		w.EnqueueMany(map[string]*orders.Order{"b": {OrderUID: "b"}, "c": {OrderUID: "c"}})
+		w.Discard("b")
+		cache.release <- struct{}{}
+		<-cache.started
+		w.Discard("c")
+		close(cache.release)
+		wg.Wait()
+
+		if cache.data["a"] == nil || cache.data["b"] != nil || cache.data["c"] != nil {
+			t.Errorf("expected a written, b skipped and c removed, got %v", cache.data)
+		}
+		if stats := w.Stats(); stats.Written != 2 || cache.setManyCalls != 1 {
+			t.Errorf("expected one batch write, stats: %+v, SetMany calls: %d", stats, cache.setManyCalls)
+		}
+	})
+
+	t.Run("block: close releases blocked writers", func(t *testing.T) {
+		cache := newGated()
+		wg := &sync.WaitGroup{}
+		w := newCacheWriter(CacheWriterConfig{Workers: 1, QueueSize: 1, Policy: CacheWriterPolicyBlock}, cache, wg, logger)
+
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		<-cache.started
+		w.Enqueue("b", &orders.Order{OrderUID: "b"})
+
+		blocked := make(chan struct{})
+		go func() {
+			w.Enqueue("c", &orders.Order{OrderUID: "c"})
+			close(blocked)
+		}()
+
+		closed := make(chan error, 1)
+		go func() { closed <- w.Close(context.Background()) }()
+		select {
+		case <-blocked:
+		case <-time.After(time.Second):
+			t.Fatal("blocked Enqueue must return after Close")
+		}
+
+		close(cache.release)
+		if err := <-closed; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("pending writes must be released after Close")
+		}
+		if cache.data["b"] == nil {
+			t.Error("write queued before Close must be flushed")
+		}
+	})
+
+	t.Run("close: pending writes are flushed", func(t *testing.T) {
+		cache := newGated()
+		wg := &sync.WaitGroup{}
+		w := newCacheWriter(CacheWriterConfig{Workers: 1, QueueSize: 10}, cache, wg, logger)
+
+		w.Enqueue("a", &orders.Order{OrderUID: "a"})
+		w.Enqueue("b", &orders.Order{OrderUID: "b"})
+		close(cache.release)
+
+		if err := w.Close(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cache.data) != 2 {
+			t.Errorf("expected 2 flushed writes, got %d", len(cache.data))
+		}
+
+		w.Enqueue("late", &orders.Order{OrderUID: "late"})
+		if cache.data["late"] != nil || w.Stats().Dropped != 1 {
+			t.Error("writes after Close should be dropped")
+		}
+	})
+}
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepo{}
+	svc := NewOrdersService(&config.Config{}, repo, &mockCache{}, nil, &sync.WaitGroup{}, &mockLogger{})
+
+	if _, err := svc.CreateWebhook(ctx, &orders.Webhook{URL: "not a url"}); !errors.Is(err, orders.ErrInvalidWebhook) {
+		t.Fatalf("expected ErrInvalidWebhook, got %v", err)
+	}
+
+	w, err := svc.CreateWebhook(ctx, &orders.Webhook{URL: "https://partner.example.com/hooks"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(w.Secret, "whsec_") || len(w.Secret) != len("whsec_")+2*webhookSecretBytes || !w.Active {
+		t.Errorf("expected generated secret and active webhook, got %+v", w)
+	}
+
+	own, err := svc.CreateWebhook(ctx, &orders.Webhook{URL: "https://partner.example.com/other", Secret: "my-own-secret-value"})
+	if err != nil || own.Secret != "my-own-secret-value" {
+		t.Errorf("expected provided secret to be kept, got %+v, %v", own, err)
+	}
+
+	updated, err := svc.UpdateWebhook(ctx, &orders.Webhook{ID: w.ID, URL: "https://partner.example.com/v2"})
+	if err != nil || updated.Secret != w.Secret {
+		t.Errorf("empty secret must keep the previous one, got %+v, %v", updated, err)
+	}
+	if _, err := svc.UpdateWebhook(ctx, &orders.Webhook{ID: 99, URL: "https://example.com"}); !errors.Is(err, orders.ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+
+	if _, err := svc.GetWebhookDeliveries(ctx, w.ID, 0); err != nil || repo.deliveryLimit != defaultWebhookDeliveriesLimit {
+		t.Errorf("expected default limit, got %d, %v", repo.deliveryLimit, err)
+	}
+	if _, err := svc.GetWebhookDeliveries(ctx, w.ID, 10000); err != nil || repo.deliveryLimit != maxWebhookDeliveriesLimit {
+		t.Errorf("expected limit to be capped, got %d, %v", repo.deliveryLimit, err)
+	}
+	if _, err := svc.GetWebhookDeliveries(ctx, 99, 10); !errors.Is(err, orders.ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
 }
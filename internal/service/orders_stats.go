@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"wb_tech_level_zero/internal/orders"
+)
+
+// StatsParams - параметры статистики заказов. Даты - дни(UTC) включительно; пустые даты - последние
+// orders.DefaultStatsDays дней. ReportingCurrency - валюта отчетности для итогов выручки
+type StatsParams struct {
+	GroupBy           []orders.StatsDimension
+	DateFrom          *time.Time
+	DateTo            *time.Time
+	ReportingCurrency string
+}
+
+// GetOrderStats возвращает статистику из агрегатов заказов. При заданной валюте отчетности выручка
+// каждого дня пересчитывается по курсу этого дня
+func (s *ordersService) GetOrderStats(ctx context.Context, params StatsParams) (*orders.StatsResult, error) {
+	q, err := s.statsQuery(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var reporting string
+	if params.ReportingCurrency != "" {
+		if reporting, err = orders.ParseCurrency(params.ReportingCurrency); err != nil {
+			return nil, err
+		}
+		q.ByDay = true
+	}
+
+	rows, err := s.repo.GetOrderStats(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order stats from repository: %w", err)
+	}
+
+	var book *orders.RateBook
+	if reporting != "" {
+		currencies := []string{reporting}
+		for _, row := range rows {
+			if !slices.Contains(currencies, row.Currency) {
+				currencies = append(currencies, row.Currency)
+			}
+		}
+		maxAge := time.Duration(s.cfg.FXMaxRateAgeDays) * 24 * time.Hour
+		rates, err := s.repo.GetFXRates(ctx, currencies, q.DateFrom.Add(-maxAge), q.DateTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get fx rates from repository: %w", err)
+		}
+		book = orders.NewRateBook(rates, maxAge)
+	}
+
+	groups, err := orders.GroupStats(rows, book, reporting)
+	if err != nil {
+		return nil, err
+	}
+	return &orders.StatsResult{GroupBy: q.GroupBy, DateFrom: q.DateFrom, DateTo: q.DateTo, Groups: groups}, nil
+}
+
+func (s *ordersService) statsQuery(params StatsParams) (orders.StatsQuery, error) {
+	q := orders.StatsQuery{GroupBy: params.GroupBy}
+	if len(q.GroupBy) == 0 {
+		q.GroupBy = []orders.StatsDimension{orders.StatsByDay}
+	}
+
+	now := time.Now().UTC()
+	q.DateTo = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if params.DateTo != nil {
+		q.DateTo = *params.DateTo
+	}
+	q.DateFrom = q.DateTo.AddDate(0, 0, 1-orders.DefaultStatsDays)
+	if params.DateFrom != nil {
+		q.DateFrom = *params.DateFrom
+	}
+
+	if q.DateFrom.After(q.DateTo) {
+		return q, fmt.Errorf("%w: date_from is after date_to", orders.ErrInvalidStatsRange)
+	}
+	if days := int(q.DateTo.Sub(q.DateFrom).Hours()/24) + 1; s.cfg.StatsMaxDays > 0 && days > s.cfg.StatsMaxDays {
+		return q, fmt.Errorf("%w: %d days requested, at most %d allowed", orders.ErrInvalidStatsRange, days, s.cfg.StatsMaxDays)
+	}
+	return q, nil
+}
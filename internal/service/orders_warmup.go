@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"wb_tech_level_zero/internal/orders"
+
+	"go.uber.org/zap"
+)
+
+const (
+	WarmupStrategyRecent    = "recent"
+	WarmupStrategyPopular   = "popular"
+	WarmupStrategyCustomers = "customers"
+	WarmupStrategySince     = "since"
+
+	defaultWarmupPageSize = 50
+)
+
+var ErrUnknownWarmupStrategy = errors.New("unknown cache warmup strategy")
+
+type warmupStrategy interface {
+	name() string
+	// fingerprint - параметры стратегии; прерванный прогрев продолжается только при их совпадении
+	fingerprint() string
+	// fetch возвращает страницу заказов и признак того, что страница последняя
+	fetch(ctx context.Context, page, pageSize int) ([]*orders.Order, bool, error)
+}
+
+type warmupCheckpoint struct {
+	Fingerprint string `json:"fingerprint"`
+	Page        int    `json:"page"`
+}
+
+type warmupProgress struct {
+	strategy string
+	pages    int
+	loaded   int
+	cached   atomic.Int64
+	failed   atomic.Int64
+}
+
+func (p *warmupProgress) fields() []zap.Field {
+	return []zap.Field{
+		zap.String("strategy", p.strategy),
+		zap.Int("pages", p.pages),
+		zap.Int("count", p.loaded),
+		zap.Int64("cached", p.cached.Load()),
+		zap.Int64("failed", p.failed.Load()),
+	}
+}
+
+func (s *ordersService) WarmOrdersCache(ctx context.Context) error {
+	s.log.Info(ctx, "Warming up cache...")
+
+	strategy, err := s.newWarmupStrategy(time.Now())
+	if err != nil {
+		return err
+	}
+
+	pageSize := s.cfg.CacheWarmupPageSize
+	if pageSize <= 0 {
+		pageSize = defaultWarmupPageSize
+	}
+	concurrency := s.cfg.CacheWarmupConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	progress := &warmupProgress{strategy: strategy.name()}
+
+	startPage := s.loadWarmupCheckpoint(ctx, strategy)
+	if startPage > 0 {
+		s.log.Info(ctx, "Resuming cache warmup from checkpoint",
+			zap.String("strategy", strategy.name()), zap.Int("page", startPage))
+	}
+
+	for page := startPage; ; page++ {
+		if err := ctx.Err(); err != nil {
+			s.log.Warn(ctx, "Cache warmup interrupted", progress.fields()...)
+			return err
+		}
+
+		batch, last, err := strategy.fetch(ctx, page, pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to load orders for warmup: %w", err)
+		}
+
+		s.warmupBatch(ctx, batch, concurrency, progress)
+		progress.pages++
+		progress.loaded += len(batch)
+
+		if last || len(batch) == 0 {
+			break
+		}
+
+		s.saveWarmupCheckpoint(ctx, strategy, page+1)
+		s.log.Info(ctx, "Cache warmup progress", progress.fields()...)
+	}
+
+	if err := s.cache.DeleteCheckpoint(ctx, strategy.name()); err != nil {
+		s.log.Warn(ctx, "Failed to clear cache warmup checkpoint", zap.Error(err))
+	}
+
+	s.log.Info(ctx, "Cache warmup completed", progress.fields()...)
+	return nil
+}
+
+// warmupBatch делит страницу на части по числу допустимых параллельных операций и пишет каждую одним пайплайном
+func (s *ordersService) warmupBatch(ctx context.Context, batch []*orders.Order, concurrency int, progress *warmupProgress) {
+	if len(batch) == 0 {
+		return
+	}
+	chunkSize := (len(batch) + concurrency - 1) / concurrency
+
+	var wg sync.WaitGroup
+	for start := 0; start < len(batch); start += chunkSize {
+		chunk := batch[start:min(start+chunkSize, len(batch))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.cacheOrders(ctx, chunk); err != nil {
+				progress.failed.Add(int64(len(chunk)))
+				return
+			}
+			progress.cached.Add(int64(len(chunk)))
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *ordersService) loadWarmupCheckpoint(ctx context.Context, strategy warmupStrategy) int {
+	raw, err := s.cache.GetCheckpoint(ctx, strategy.name())
+	if err != nil {
+		s.log.Warn(ctx, "Failed to load cache warmup checkpoint", zap.Error(err))
+		return 0
+	}
+	if raw == "" {
+		return 0
+	}
+
+	var cp warmupCheckpoint
+	if err := json.Unmarshal([]byte(raw), &cp); err != nil || cp.Fingerprint != strategy.fingerprint() {
+		return 0
+	}
+	return cp.Page
+}
+
+func (s *ordersService) saveWarmupCheckpoint(ctx context.Context, strategy warmupStrategy, page int) {
+	data, _ := json.Marshal(warmupCheckpoint{Fingerprint: strategy.fingerprint(), Page: page})
+	if err := s.cache.SetCheckpoint(ctx, strategy.name(), string(data)); err != nil {
+		s.log.Warn(ctx, "Failed to save cache warmup checkpoint", zap.Error(err))
+	}
+}
+
+func (s *ordersService) newWarmupStrategy(now time.Time) (warmupStrategy, error) {
+	switch s.cfg.CacheWarmupStrategy {
+	case "", WarmupStrategyRecent:
+		return &recentWarmup{repo: s.repo, size: s.cfg.CacheWarmupSize}, nil
+
+	case WarmupStrategyPopular:
+		return &popularWarmup{
+			repo:   s.repo,
+			cache:  s.cache,
+			size:   s.cfg.CacheWarmupSize,
+			window: time.Duration(s.cfg.CacheWarmupWindowMinutes) * time.Minute,
+		}, nil
+
+	case WarmupStrategyCustomers:
+		customers := make([]string, 0, len(s.cfg.CacheWarmupCustomers))
+		for _, c := range s.cfg.CacheWarmupCustomers {
+			if c = strings.TrimSpace(c); c != "" {
+				customers = append(customers, c)
+			}
+		}
+		sort.Strings(customers)
+		return &customersWarmup{repo: s.repo, customers: customers}, nil
+
+	case WarmupStrategySince:
+		since, key, err := parseWarmupSince(s.cfg.CacheWarmupSince, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_WARMUP_SINCE %q: %w", s.cfg.CacheWarmupSince, err)
+		}
+		return &sinceWarmup{repo: s.repo, key: key, since: since}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownWarmupStrategy, s.cfg.CacheWarmupStrategy)
+}
+
+// parseWarmupSince возвращает границу прогрева и ее нормализованную запись для отпечатка: "24h" и "1440m"
+// дают один отпечаток, а относительная граница не меняет его от запуска к запуску
+func parseWarmupSince(value string, now time.Time) (time.Time, string, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), "duration:" + d.String(), nil
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return since, since.UTC().Format(time.RFC3339Nano), nil
+}
+
+// //////////////
+
+// recentWarmup - последние size заказов по дате создания
+type recentWarmup struct {
+	repo OrdersRepository
+	size int
+}
+
+func (w *recentWarmup) name() string        { return WarmupStrategyRecent }
+func (w *recentWarmup) fingerprint() string { return strconv.Itoa(w.size) }
+
+func (w *recentWarmup) fetch(ctx context.Context, page, pageSize int) ([]*orders.Order, bool, error) {
+	offset := page * pageSize
+	if offset >= w.size {
+		return nil, true, nil
+	}
+	limit := min(pageSize, w.size-offset)
+
+	res, err := w.repo.GetOrders(ctx, orders.ListQuery{Limit: limit, Offset: offset, Total: orders.TotalNone})
+	if err != nil {
+		return nil, false, err
+	}
+	return res.Orders, len(res.Orders) < limit || offset+limit >= w.size, nil
+}
+
+// popularWarmup - size наиболее запрашиваемых заказов за окно window
+type popularWarmup struct {
+	repo   OrdersRepository
+	cache  OrdersCache
+	size   int
+	window time.Duration
+
+	uids []string
+}
+
+func (w *popularWarmup) name() string { return WarmupStrategyPopular }
+func (w *popularWarmup) fingerprint() string {
+	return strconv.Itoa(w.size) + "/" + w.window.String()
+}
+
+func (w *popularWarmup) fetch(ctx context.Context, page, pageSize int) ([]*orders.Order, bool, error) {
+	if w.uids == nil {
+		uids, err := w.cache.TopAccessed(ctx, w.window, w.size)
+		if err != nil {
+			return nil, false, err
+		}
+		w.uids = uids
+	}
+
+	start := page * pageSize
+	if start >= len(w.uids) {
+		return nil, true, nil
+	}
+	end := min(start+pageSize, len(w.uids))
+
+	list, err := w.repo.GetOrdersByUIDs(ctx, w.uids[start:end])
+	if err != nil {
+		return nil, false, err
+	}
+	return list, end >= len(w.uids), nil
+}
+
+// customersWarmup - все заказы заданного списка покупателей
+type customersWarmup struct {
+	repo      OrdersRepository
+	customers []string
+}
+
+func (w *customersWarmup) name() string        { return WarmupStrategyCustomers }
+func (w *customersWarmup) fingerprint() string { return strings.Join(w.customers, ",") }
+
+func (w *customersWarmup) fetch(ctx context.Context, page, pageSize int) ([]*orders.Order, bool, error) {
+	if len(w.customers) == 0 {
+		return nil, true, nil
+	}
+	list, err := w.repo.GetOrdersByCustomerIDs(ctx, w.customers, pageSize, page*pageSize)
+	if err != nil {
+		return nil, false, err
+	}
+	return list, len(list) < pageSize, nil
+}
+
+// sinceWarmup - все заказы, созданные после since
+type sinceWarmup struct {
+	repo  OrdersRepository
+	key   string
+	since time.Time
+}
+
+func (w *sinceWarmup) name() string        { return WarmupStrategySince }
+func (w *sinceWarmup) fingerprint() string { return w.key }
+
+func (w *sinceWarmup) fetch(ctx context.Context, page, pageSize int) ([]*orders.Order, bool, error) {
+	list, err := w.repo.GetOrdersCreatedSince(ctx, w.since, pageSize, page*pageSize)
+	if err != nil {
+		return nil, false, err
+	}
+	return list, len(list) < pageSize, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"wb_tech_level_zero/internal/orders"
+)
+
+const (
+	// webhookSecretBytes - длина сгенерированного секрета подписи
+	webhookSecretBytes = 32
+
+	defaultWebhookDeliveriesLimit = 50
+	maxWebhookDeliveriesLimit     = 500
+)
+
+// CreateWebhook создает подписку; если секрет не передан, он генерируется и возвращается только в ответе
+func (s *ordersService) CreateWebhook(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	if w.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		w.Secret = secret
+	}
+	w.Active = true
+
+	if err := s.repo.CreateWebhook(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *ordersService) GetWebhooks(ctx context.Context) ([]*orders.Webhook, error) {
+	return s.repo.GetWebhooks(ctx)
+}
+
+func (s *ordersService) GetWebhook(ctx context.Context, id int64) (*orders.Webhook, error) {
+	return s.repo.GetWebhook(ctx, id)
+}
+
+// UpdateWebhook заменяет адрес, фильтр и активность подписки; непустой Secret заменяет секрет подписи
+func (s *ordersService) UpdateWebhook(ctx context.Context, w *orders.Webhook) (*orders.Webhook, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateWebhook(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *ordersService) DeleteWebhook(ctx context.Context, id int64) error {
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// GetWebhookDeliveries - журнал попыток доставки подписки, новые первыми
+func (s *ordersService) GetWebhookDeliveries(ctx context.Context, id int64, limit int) ([]orders.WebhookDelivery, error) {
+	if _, err := s.repo.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultWebhookDeliveriesLimit
+	}
+	return s.repo.GetWebhookDeliveries(ctx, id, min(limit, maxWebhookDeliveriesLimit))
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,17 @@
+-- Жизненный цикл заказа
+ALTER TABLE orders ADD COLUMN status TEXT NOT NULL DEFAULT 'created';
+
+-- История переходов статусов заказа
+CREATE TABLE order_status_history (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id);
+
+INSERT INTO order_status_history (order_id, from_status, to_status, created_at)
+SELECT id, NULL, status, COALESCE(date_created, now()) FROM orders;
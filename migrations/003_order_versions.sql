@@ -0,0 +1,61 @@
+-- Версии заказа: полный снимок после каждого изменения и его источник
+CREATE TABLE order_versions (
+    id BIGSERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    change_type TEXT NOT NULL,
+    source TEXT NOT NULL,
+    source_ref TEXT,
+    actor TEXT,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (order_id, version)
+);
+
+CREATE INDEX idx_order_versions_order_created ON order_versions(order_id, created_at);
+
+-- Первая версия для уже существующих заказов(формат снимка совпадает с JSON-представлением orders.Order)
+INSERT INTO order_versions (order_id, version, change_type, source, snapshot, created_at)
+SELECT
+    o.id, 1, 'order.created', 'system',
+    jsonb_build_object(
+        'ID', o.id,
+        'OrderUID', o.order_uid,
+        'TrackNumber', o.track_number,
+        'Entry', COALESCE(o.entry, ''),
+        'Status', o.status,
+        'Delivery', jsonb_build_object(
+            'Name', COALESCE(d.name, ''), 'Phone', COALESCE(d.phone, ''), 'Zip', COALESCE(d.zip, ''),
+            'City', COALESCE(d.city, ''), 'Address', COALESCE(d.address, ''),
+            'Region', COALESCE(d.region, ''), 'Email', COALESCE(d.email, '')
+        ),
+        'Payment', jsonb_build_object(
+            'Transaction', COALESCE(p.transaction, ''), 'RequestID', COALESCE(p.request_id, ''),
+            'Currency', COALESCE(p.currency, ''), 'Provider', COALESCE(p.provider, ''),
+            'Amount', COALESCE(p.amount, 0)::BIGINT, 'PaymentDT', COALESCE(p.payment_dt, 0),
+            'Bank', COALESCE(p.bank, ''), 'DeliveryCost', COALESCE(p.delivery_cost, 0)::BIGINT,
+            'GoodsTotal', COALESCE(p.goods_total, 0)::BIGINT, 'CustomFee', COALESCE(p.custom_fee, 0)::BIGINT
+        ),
+        'Items', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'ChrtID', i.chrt_id, 'TrackNumber', COALESCE(i.track_number, ''),
+                'Price', COALESCE(i.price, 0)::BIGINT, 'Rid', COALESCE(i.rid, ''), 'Name', COALESCE(i.name, ''),
+                'Sale', COALESCE(i.sale, 0), 'Size', COALESCE(i.size, ''),
+                'TotalPrice', COALESCE(i.total_price, 0)::BIGINT, 'NmID', i.nm_id,
+                'Brand', COALESCE(i.brand, ''), 'Status', COALESCE(i.status, 0)
+            ) ORDER BY i.id)
+            FROM items i WHERE i.order_id = o.id
+        ), '[]'::jsonb),
+        'Locale', COALESCE(o.locale, ''),
+        'InternalSignature', COALESCE(o.internal_signature, ''),
+        'CustomerID', COALESCE(o.customer_id, ''),
+        'DeliveryService', COALESCE(o.delivery_service, ''),
+        'Shardkey', COALESCE(o.shardkey, ''),
+        'SmID', COALESCE(o.sm_id, 0),
+        'DateCreated', o.date_created,
+        'OofShard', COALESCE(o.oof_shard, '')
+    ),
+    COALESCE(o.date_created, now())
+FROM orders o
+JOIN deliveries d ON o.id = d.order_id
+JOIN payments p ON o.id = p.order_id;
@@ -0,0 +1,20 @@
+-- Отмена позиций заказа
+ALTER TABLE items ADD COLUMN cancelled BOOLEAN NOT NULL DEFAULT false;
+
+-- Оплаченная сумма(не меняется при пересчете) и сумма выполненных возвратов
+ALTER TABLE payments ADD COLUMN paid_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN refunded_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+UPDATE payments SET paid_amount = COALESCE(amount, 0);
+
+-- Возвраты, привязанные к транзакции оплаты
+CREATE TABLE refunds (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    transaction TEXT NOT NULL,
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_refunds_order_id ON refunds(order_id);
+CREATE INDEX idx_refunds_transaction ON refunds(transaction);
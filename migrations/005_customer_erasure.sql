@@ -0,0 +1,14 @@
+-- Аудит удаления персональных данных покупателей
+CREATE TABLE customer_erasures (
+    id SERIAL PRIMARY KEY,
+    customer_ref TEXT NOT NULL,
+    order_uids TEXT[] NOT NULL,
+    reason TEXT,
+    source TEXT NOT NULL,
+    source_ref TEXT,
+    actor TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_customer_erasures_customer_ref ON customer_erasures(customer_ref);
+CREATE INDEX idx_orders_customer_id ON orders(customer_id);
@@ -0,0 +1,20 @@
+-- Ключи данных для шифрования персональных данных доставки. Ключи хранятся обернутыми мастер-ключом,
+-- master_key_id указывает, каким мастер-ключом их разворачивать
+CREATE TABLE encryption_keys (
+    id SERIAL PRIMARY KEY,
+    purpose TEXT NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    master_key_id TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_encryption_keys_active ON encryption_keys(purpose) WHERE active;
+
+-- Слепые индексы для точного поиска по зашифрованным email и телефону
+ALTER TABLE deliveries
+    ADD COLUMN email_bidx TEXT,
+    ADD COLUMN phone_bidx TEXT;
+
+CREATE INDEX idx_deliveries_email_bidx ON deliveries(email_bidx);
+CREATE INDEX idx_deliveries_phone_bidx ON deliveries(phone_bidx);
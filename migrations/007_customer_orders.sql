@@ -0,0 +1,6 @@
+-- Заказы покупателя выбираются по customer_id в порядке date_created, составной индекс заменяет одиночный
+CREATE INDEX idx_orders_customer_date ON orders(customer_id, date_created DESC, id DESC);
+DROP INDEX IF EXISTS idx_orders_customer_id;
+
+-- Подсчет любимых брендов покупателя
+CREATE INDEX idx_items_order_brand ON items(order_id, brand) WHERE NOT cancelled;
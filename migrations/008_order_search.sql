@@ -0,0 +1,11 @@
+-- Индексы поиска заказов(GET /admin/orders/search)
+CREATE INDEX idx_orders_track_number ON orders(track_number);
+CREATE INDEX idx_payments_transaction ON payments(transaction);
+CREATE INDEX idx_payments_request_id ON payments(request_id);
+CREATE INDEX idx_items_rid ON items(rid);
+CREATE INDEX idx_items_nm_id ON items(nm_id);
+CREATE INDEX idx_items_brand_lower ON items(lower(brand));
+
+-- Поиск по открытым контактам, пока шифрование не включено
+CREATE INDEX idx_deliveries_email_lower ON deliveries(lower(email));
+CREATE INDEX idx_deliveries_phone_digits ON deliveries(regexp_replace(phone, '\D', '', 'g'));
@@ -0,0 +1,4 @@
+-- Сортировка и фильтры списка заказов(GET /orders)
+CREATE INDEX idx_orders_date_created ON orders(date_created DESC, id DESC);
+CREATE INDEX idx_orders_delivery_service ON orders(delivery_service);
+CREATE INDEX idx_payments_currency_amount ON payments(currency, amount);
@@ -0,0 +1,32 @@
+-- Полнотекстовый поиск заказов(GET /orders/search)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Поисковый документ заказа: названия и бренды позиций, город и адрес доставки.
+-- Зашифрованный адрес(enc:...) в документ не попадает
+CREATE TABLE order_search (
+    order_id INTEGER PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL,
+    content TEXT NOT NULL
+);
+
+CREATE INDEX idx_order_search_document ON order_search USING GIN (document);
+CREATE INDEX idx_order_search_content_trgm ON order_search USING GIN (content gin_trgm_ops);
+
+INSERT INTO order_search (order_id, document, content)
+SELECT
+    o.id,
+    setweight(to_tsvector('russian', COALESCE(it.names, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(it.brands, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(dl.city, '')), 'B') ||
+    setweight(to_tsvector('russian', COALESCE(dl.address, '')), 'C'),
+    concat_ws(' ', it.names, it.brands, dl.city, dl.address)
+FROM orders o
+LEFT JOIN LATERAL (
+    SELECT string_agg(i.name, ' ') AS names, string_agg(DISTINCT i.brand, ' ') AS brands
+    FROM items i WHERE i.order_id = o.id
+) it ON TRUE
+LEFT JOIN LATERAL (
+    SELECT d.city, CASE WHEN d.address LIKE 'enc:%' THEN NULL ELSE d.address END AS address
+    FROM deliveries d WHERE d.order_id = o.id
+    LIMIT 1
+) dl ON TRUE;
@@ -0,0 +1,16 @@
+-- Суммы хранятся в основных единицах валюты с точностью до 4 знаков(валюты с exponent 3-4: KWD, CLF и т.д.).
+-- Расширение NUMERIC(12,2) -> NUMERIC(18,4) не меняет существующие значения
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE NUMERIC(18,4),
+    ALTER COLUMN delivery_cost TYPE NUMERIC(18,4),
+    ALTER COLUMN goods_total TYPE NUMERIC(18,4),
+    ALTER COLUMN custom_fee TYPE NUMERIC(18,4),
+    ALTER COLUMN paid_amount TYPE NUMERIC(18,4),
+    ALTER COLUMN refunded_amount TYPE NUMERIC(18,4);
+
+ALTER TABLE items
+    ALTER COLUMN price TYPE NUMERIC(18,4),
+    ALTER COLUMN total_price TYPE NUMERIC(18,4);
+
+ALTER TABLE refunds
+    ALTER COLUMN amount TYPE NUMERIC(18,4);
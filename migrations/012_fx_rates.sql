@@ -0,0 +1,12 @@
+-- Курсы валют по дням: 1 base = rate quote. Загружаются из файла или Kafka, повторная загрузка обновляет курс
+CREATE TABLE fx_rates (
+    rate_date DATE NOT NULL,
+    base TEXT NOT NULL,
+    quote TEXT NOT NULL,
+    rate NUMERIC(24,10) NOT NULL CHECK (rate > 0),
+    source TEXT NOT NULL DEFAULT '',
+    loaded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (base, quote, rate_date)
+);
+
+CREATE INDEX idx_fx_rates_quote ON fx_rates(quote, rate_date);
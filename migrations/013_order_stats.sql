@@ -0,0 +1,53 @@
+-- Агрегаты заказов по дням(UTC) для GET /stats/orders. Обновляются в транзакциях сохранения заказа, отмены позиций
+-- и возврата. revenue - сумма оплаты с учетом отмененных позиций, refunded - выполненные возвраты; суммы в валюте currency
+CREATE TABLE order_stats_daily (
+    day DATE NOT NULL,
+    delivery_service TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    bank TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    region TEXT NOT NULL,
+    orders_count BIGINT NOT NULL DEFAULT 0,
+    revenue NUMERIC(24,4) NOT NULL DEFAULT 0,
+    refunded NUMERIC(24,4) NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, delivery_service, provider, bank, currency, region)
+);
+
+-- Агрегаты неотмененных позиций по брендам: orders_count - заказы с позициями бренда, revenue - сумма total_price позиций
+CREATE TABLE order_brand_stats_daily (
+    day DATE NOT NULL,
+    brand TEXT NOT NULL,
+    delivery_service TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    bank TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    region TEXT NOT NULL,
+    orders_count BIGINT NOT NULL DEFAULT 0,
+    items_count BIGINT NOT NULL DEFAULT 0,
+    revenue NUMERIC(24,4) NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, brand, delivery_service, provider, bank, currency, region)
+);
+
+-- Заполнение по существующим заказам; заказы без даты создания в статистику не попадают
+INSERT INTO order_stats_daily (day, delivery_service, provider, bank, currency, region, orders_count, revenue, refunded)
+SELECT
+    (o.date_created AT TIME ZONE 'UTC')::date, COALESCE(o.delivery_service, ''), COALESCE(p.provider, ''),
+    COALESCE(p.bank, ''), COALESCE(p.currency, ''), COALESCE(d.region, ''),
+    COUNT(*), SUM(COALESCE(p.amount, 0)), SUM(p.refunded_amount)
+FROM orders o
+JOIN payments p ON o.id = p.order_id
+JOIN deliveries d ON o.id = d.order_id
+WHERE o.date_created IS NOT NULL
+GROUP BY 1, 2, 3, 4, 5, 6;
+
+INSERT INTO order_brand_stats_daily (day, brand, delivery_service, provider, bank, currency, region, orders_count, items_count, revenue)
+SELECT
+    (o.date_created AT TIME ZONE 'UTC')::date, COALESCE(i.brand, ''), COALESCE(o.delivery_service, ''),
+    COALESCE(p.provider, ''), COALESCE(p.bank, ''), COALESCE(p.currency, ''), COALESCE(d.region, ''),
+    COUNT(DISTINCT o.id), COUNT(*), SUM(COALESCE(i.total_price, 0))
+FROM orders o
+JOIN payments p ON o.id = p.order_id
+JOIN deliveries d ON o.id = d.order_id
+JOIN items i ON o.id = i.order_id AND NOT i.cancelled
+WHERE o.date_created IS NOT NULL
+GROUP BY 1, 2, 3, 4, 5, 6, 7;
@@ -0,0 +1,5 @@
+-- Оценка риска заказа при приеме: сумма весов сработавших правил и сами правила с пояснениями.
+-- NULL - заказ не оценивался(сохранен до включения правил или при ошибке сбора сигналов)
+ALTER TABLE orders
+    ADD COLUMN fraud_score INT,
+    ADD COLUMN fraud_rules JSONB;
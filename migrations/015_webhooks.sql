@@ -0,0 +1,33 @@
+-- Подписки партнеров на события заказов. Пустой массив фильтра - без ограничения по полю.
+-- secret хранится зашифрованным, если настроен мастер-ключ шифрования
+CREATE TABLE webhooks (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    delivery_services TEXT[] NOT NULL DEFAULT '{}',
+    customer_ids TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    failures INT NOT NULL DEFAULT 0,
+    disabled_reason TEXT,
+    disabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Журнал доставки: каждая попытка отправки события подписке
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    delivery_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    order_uid TEXT NOT NULL,
+    attempt INT NOT NULL,
+    status_code INT,
+    error TEXT,
+    duration_ms INT NOT NULL,
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id DESC);
@@ -0,0 +1,354 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Назначение ключей: ключи данных шифруют значения, ключ индекса используется для слепых индексов
+const (
+	PurposeData  = "data"
+	PurposeIndex = "index"
+)
+
+// Формат зашифрованного значения: "enc:v1:<id ключа данных>:<base64(nonce|ciphertext)>".
+// Значения без префикса считаются открытыми(записанными до включения шифрования).
+const ciphertextPrefix = "enc:v1:"
+
+var (
+	ErrUnknownKey          = errors.New("unknown data key")
+	ErrUnknownMasterKey    = errors.New("unknown master key")
+	ErrMalformedCiphertext = errors.New("malformed ciphertext")
+)
+
+// WrappedKey - ключ данных, зашифрованный мастер-ключом, в том виде, в каком он хранится в KeyStore
+type WrappedKey struct {
+	ID          int
+	Purpose     string
+	MasterKeyID string
+	Wrapped     []byte
+	Active      bool
+}
+
+type KeyStore interface {
+	LoadKeys(ctx context.Context) ([]WrappedKey, error)
+	// CreateKey сохраняет новый ключ; если он активен, остальные ключи того же назначения становятся неактивными
+	CreateKey(ctx context.Context, key WrappedKey) (int, error)
+	UpdateWrapped(ctx context.Context, id int, masterKeyID string, wrapped []byte) error
+}
+
+// Keyring хранит развернутые ключи данных и шифрует отдельные поля(envelope encryption)
+type Keyring struct {
+	store    KeyStore
+	master   MasterKey
+	previous map[string]MasterKey
+
+	mu       sync.RWMutex
+	keys     map[int]cipher.AEAD
+	wrapped  []WrappedKey
+	activeID int
+	indexKey []byte
+}
+
+// Open загружает ключи из хранилища и создает ключ данных и ключ индекса, если их еще нет.
+// previous - прежние мастер-ключи, которыми могут быть обернуты ключи до ротации
+func Open(ctx context.Context, store KeyStore, master MasterKey, previous ...MasterKey) (*Keyring, error) {
+	k := &Keyring{
+		store:    store,
+		master:   master,
+		previous: make(map[string]MasterKey, len(previous)),
+	}
+	for _, m := range previous {
+		k.previous[m.ID] = m
+	}
+
+	if err := k.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	needData, needIndex := k.activeID == 0, k.indexKey == nil
+	k.mu.RUnlock()
+
+	// ошибку создания можно получить, если ключ одновременно создал другой экземпляр сервиса,
+	// поэтому после перезагрузки проверяется только наличие ключей
+	if needData {
+		_ = k.createKey(ctx, PurposeData)
+	}
+	if needIndex {
+		_ = k.createKey(ctx, PurposeIndex)
+	}
+	if needData || needIndex {
+		if err := k.Reload(ctx); err != nil {
+			return nil, err
+		}
+		k.mu.RLock()
+		missing := k.activeID == 0 || k.indexKey == nil
+		k.mu.RUnlock()
+		if missing {
+			return nil, errors.New("failed to create initial data keys")
+		}
+	}
+	return k, nil
+}
+
+// Reload перечитывает ключи из хранилища(например, после ротации другим экземпляром сервиса)
+func (k *Keyring) Reload(ctx context.Context) error {
+	stored, err := k.store.LoadKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load data keys: %w", err)
+	}
+
+	keys := make(map[int]cipher.AEAD, len(stored))
+	var (
+		activeID int
+		indexKey []byte
+	)
+	for _, wk := range stored {
+		raw, err := k.unwrap(wk)
+		if err != nil {
+			return err
+		}
+		switch wk.Purpose {
+		case PurposeIndex:
+			if wk.Active || indexKey == nil {
+				indexKey = raw
+			}
+		default:
+			aead, err := newAEAD(raw)
+			if err != nil {
+				return err
+			}
+			keys[wk.ID] = aead
+			if wk.Active {
+				activeID = wk.ID
+			}
+		}
+	}
+
+	k.mu.Lock()
+	k.keys, k.wrapped, k.activeID, k.indexKey = keys, stored, activeID, indexKey
+	k.mu.Unlock()
+	return nil
+}
+
+// CreateDataKey создает новый активный ключ данных; новые значения шифруются им, старые остаются читаемыми
+func (k *Keyring) CreateDataKey(ctx context.Context) (int, error) {
+	if err := k.createKey(ctx, PurposeData); err != nil {
+		return 0, err
+	}
+	if err := k.Reload(ctx); err != nil {
+		return 0, err
+	}
+	return k.ActiveKeyID(), nil
+}
+
+// RewrapKeys перешифровывает текущим мастер-ключом ключи, обернутые прежними мастер-ключами
+func (k *Keyring) RewrapKeys(ctx context.Context) (int, error) {
+	k.mu.RLock()
+	stored := append([]WrappedKey(nil), k.wrapped...)
+	k.mu.RUnlock()
+
+	rewrapped := 0
+	for _, wk := range stored {
+		if wk.MasterKeyID == k.master.ID {
+			continue
+		}
+		raw, err := k.unwrap(wk)
+		if err != nil {
+			return rewrapped, err
+		}
+		wrapped, err := seal(k.master.Key, raw, []byte(k.master.ID))
+		if err != nil {
+			return rewrapped, err
+		}
+		if err := k.store.UpdateWrapped(ctx, wk.ID, k.master.ID, wrapped); err != nil {
+			return rewrapped, err
+		}
+		rewrapped++
+	}
+	return rewrapped, k.Reload(ctx)
+}
+
+func (k *Keyring) ActiveKeyID() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.activeID
+}
+
+// Encrypt шифрует значение активным ключом данных; пустая строка не шифруется
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	k.mu.RLock()
+	id, aead := k.activeID, k.keys[k.activeID]
+	k.mu.RUnlock()
+	if aead == nil {
+		return "", ErrUnknownKey
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return ciphertextPrefix + strconv.Itoa(id) + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt расшифровывает значение любым известным ключом данных; открытые значения возвращаются как есть.
+// Неизвестный ключ(созданный ротацией в другом процессе) приводит к однократной перезагрузке ключей в контексте ctx
+func (k *Keyring) Decrypt(ctx context.Context, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	idPart, payload, ok := strings.Cut(strings.TrimPrefix(value, ciphertextPrefix), ":")
+	id, err := strconv.Atoi(idPart)
+	if !ok || err != nil {
+		return "", ErrMalformedCiphertext
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrMalformedCiphertext
+	}
+
+	aead, err := k.dataKey(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedCiphertext
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plain), nil
+}
+
+// KeyID возвращает id ключа данных, которым зашифровано значение(0 - значение не зашифровано)
+func KeyID(value string) int {
+	if !IsEncrypted(value) {
+		return 0
+	}
+	idPart, _, _ := strings.Cut(strings.TrimPrefix(value, ciphertextPrefix), ":")
+	id, _ := strconv.Atoi(idPart)
+	return id
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, ciphertextPrefix)
+}
+
+// BlindIndex - детерминированный HMAC нормализованного значения для точного поиска по зашифрованному полю.
+// field разделяет пространства индексов разных полей
+func (k *Keyring) BlindIndex(field, normalized string) string {
+	if normalized == "" {
+		return ""
+	}
+
+	k.mu.RLock()
+	key := k.indexKey
+	k.mu.RUnlock()
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func (k *Keyring) dataKey(ctx context.Context, id int) (cipher.AEAD, error) {
+	k.mu.RLock()
+	aead := k.keys[id]
+	k.mu.RUnlock()
+	if aead != nil {
+		return aead, nil
+	}
+
+	if err := k.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if aead = k.keys[id]; aead == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, id)
+	}
+	return aead, nil
+}
+
+func (k *Keyring) createKey(ctx context.Context, purpose string) error {
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	wrapped, err := seal(k.master.Key, raw, []byte(k.master.ID))
+	if err != nil {
+		return err
+	}
+	_, err = k.store.CreateKey(ctx, WrappedKey{
+		Purpose:     purpose,
+		MasterKeyID: k.master.ID,
+		Wrapped:     wrapped,
+		Active:      true,
+	})
+	return err
+}
+
+func (k *Keyring) unwrap(wk WrappedKey) ([]byte, error) {
+	master := k.master
+	if wk.MasterKeyID != master.ID {
+		var ok bool
+		if master, ok = k.previous[wk.MasterKeyID]; !ok {
+			return nil, fmt.Errorf("%w %q for key %d", ErrUnknownMasterKey, wk.MasterKeyID, wk.ID)
+		}
+	}
+
+	aead, err := newAEAD(master.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wk.Wrapped) < aead.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+	raw, err := aead.Open(nil, wk.Wrapped[:aead.NonceSize()], wk.Wrapped[aead.NonceSize():], []byte(master.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key %d: %w", wk.ID, err)
+	}
+	return raw, nil
+}
+
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,215 @@
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type memStore struct {
+	keys []WrappedKey
+}
+
+func (s *memStore) LoadKeys(ctx context.Context) ([]WrappedKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return append([]WrappedKey(nil), s.keys...), nil
+}
+
+func (s *memStore) CreateKey(_ context.Context, key WrappedKey) (int, error) {
+	if key.Active {
+		for i := range s.keys {
+			if s.keys[i].Purpose == key.Purpose {
+				s.keys[i].Active = false
+			}
+		}
+	}
+	key.ID = len(s.keys) + 1
+	s.keys = append(s.keys, key)
+	return key.ID, nil
+}
+
+func (s *memStore) UpdateWrapped(_ context.Context, id int, masterKeyID string, wrapped []byte) error {
+	for i := range s.keys {
+		if s.keys[i].ID == id {
+			s.keys[i].MasterKeyID, s.keys[i].Wrapped = masterKeyID, wrapped
+		}
+	}
+	return nil
+}
+
+func newMasterKey(t *testing.T, id string) MasterKey {
+	t.Helper()
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return MasterKey{ID: id, Key: key}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	k, err := Open(ctx, &memStore{}, newMasterKey(t, "m1"))
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	enc, err := k.Encrypt("+9720000000")
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if !IsEncrypted(enc) || strings.Contains(enc, "9720000000") {
+		t.Fatalf("value is not encrypted: %s", enc)
+	}
+	if KeyID(enc) != k.ActiveKeyID() {
+		t.Errorf("expected key id %d, got %d", k.ActiveKeyID(), KeyID(enc))
+	}
+
+	got, err := k.Decrypt(ctx, enc)
+	if err != nil || got != "+9720000000" {
+		t.Errorf("decrypt: got %q, err %v", got, err)
+	}
+
+	// пустые и открытые(записанные до включения шифрования) значения возвращаются как есть
+	if v, _ := k.Encrypt(""); v != "" {
+		t.Errorf("empty value must stay empty, got %q", v)
+	}
+	if v, err := k.Decrypt(ctx, "test@gmail.com"); err != nil || v != "test@gmail.com" {
+		t.Errorf("legacy plaintext: got %q, err %v", v, err)
+	}
+
+	if _, err := k.Decrypt(ctx, enc[:len(enc)-4]+"AAAA"); err == nil {
+		t.Error("expected error for tampered ciphertext")
+	}
+}
+
+func TestDecryptReloadsUnknownKey(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{}
+	master := newMasterKey(t, "m1")
+	k, err := Open(ctx, store, master)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	// ключ создан ротацией в другом процессе
+	other, err := Open(ctx, store, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.CreateDataKey(ctx); err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := other.Encrypt("+9720000000")
+
+	// перезагрузка ключей выполняется в контексте вызывающего
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := k.Decrypt(cancelled, enc); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from reload, got %v", err)
+	}
+	if v, err := k.Decrypt(ctx, enc); err != nil || v != "+9720000000" {
+		t.Errorf("expected value after reload, got %q, err %v", v, err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{}
+	master := newMasterKey(t, "m1")
+	k, err := Open(ctx, store, master)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	idx := k.BlindIndex("email", "test@gmail.com")
+	if idx == "" || idx != k.BlindIndex("email", "test@gmail.com") {
+		t.Fatalf("blind index must be deterministic, got %q", idx)
+	}
+	if idx == k.BlindIndex("phone", "test@gmail.com") {
+		t.Error("blind indexes of different fields must differ")
+	}
+
+	// индекс не меняется после ротации ключа данных и при повторном открытии
+	if _, err := k.CreateDataKey(ctx); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(ctx, store, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.BlindIndex("email", "test@gmail.com") != idx {
+		t.Error("blind index changed after data key rotation")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{}
+	oldMaster := newMasterKey(t, "m1")
+
+	k, err := Open(ctx, store, oldMaster)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	oldValue, _ := k.Encrypt("Ploshad Mira 15")
+	oldKeyID := k.ActiveKeyID()
+
+	// новый мастер-ключ: без прежнего ключи не разворачиваются
+	newMaster := newMasterKey(t, "m2")
+	if _, err := Open(ctx, store, newMaster); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Fatalf("expected ErrUnknownMasterKey, got %v", err)
+	}
+
+	k, err = Open(ctx, store, newMaster, oldMaster)
+	if err != nil {
+		t.Fatalf("open with previous master failed: %v", err)
+	}
+	n, err := k.RewrapKeys(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("rewrap: n=%d, err=%v", n, err)
+	}
+	newKeyID, err := k.CreateDataKey(ctx)
+	if err != nil || newKeyID == oldKeyID {
+		t.Fatalf("create data key: id=%d, err=%v", newKeyID, err)
+	}
+
+	// после перешифровки ключей прежний мастер-ключ больше не нужен
+	k, err = Open(ctx, store, newMaster)
+	if err != nil {
+		t.Fatalf("open after rewrap failed: %v", err)
+	}
+	if v, err := k.Decrypt(ctx, oldValue); err != nil || v != "Ploshad Mira 15" {
+		t.Errorf("old value: got %q, err %v", v, err)
+	}
+	newValue, _ := k.Encrypt("Ploshad Mira 15")
+	if KeyID(newValue) != newKeyID {
+		t.Errorf("expected new values to use key %d, got %d", newKeyID, KeyID(newValue))
+	}
+}
+
+func TestLoadMasterKey(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, keySize))
+
+	if _, err := LoadMasterKey("m1", "", ""); !errors.Is(err, ErrNoMasterKey) {
+		t.Errorf("expected ErrNoMasterKey, got %v", err)
+	}
+	if _, err := LoadMasterKey("m1", "", "c2hvcnQ="); err == nil {
+		t.Error("expected error for short key")
+	}
+	if m, err := LoadMasterKey("m1", "", key+"\n"); err != nil || len(m.Key) != keySize {
+		t.Errorf("load: %v", err)
+	}
+
+	prev, err := ParseMasterKeys([]string{"m0:" + key, " "})
+	if err != nil || len(prev) != 1 || prev[0].ID != "m0" {
+		t.Errorf("parse: %+v, %v", prev, err)
+	}
+	if _, err := ParseMasterKeys([]string{key}); err == nil {
+		t.Error("expected error for entry without id")
+	}
+}
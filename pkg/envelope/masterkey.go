@@ -0,0 +1,95 @@
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const keySize = 32
+
+var ErrNoMasterKey = errors.New("master key is not configured")
+
+// MasterKey - ключ шифрования ключей данных(KEK). ID сохраняется вместе с обернутым ключом данных,
+// чтобы после смены мастер-ключа было понятно, каким ключом разворачивать старые ключи данных
+type MasterKey struct {
+	ID  string
+	Key []byte
+}
+
+// LoadMasterKey читает мастер-ключ(32 байта в base64) из файла или, если файл не задан, из значения переменной окружения
+func LoadMasterKey(id, file, value string) (MasterKey, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return MasterKey{}, fmt.Errorf("failed to read master key file: %w", err)
+		}
+		value = string(data)
+	}
+	if strings.TrimSpace(value) == "" {
+		return MasterKey{}, ErrNoMasterKey
+	}
+
+	key, err := decodeKey(value)
+	if err != nil {
+		return MasterKey{}, fmt.Errorf("invalid master key %q: %w", id, err)
+	}
+	return MasterKey{ID: id, Key: key}, nil
+}
+
+// ParseMasterKeys разбирает список предыдущих мастер-ключей в формате "id:base64"
+func ParseMasterKeys(entries []string) ([]MasterKey, error) {
+	keys := make([]MasterKey, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid master key entry, expected id:base64")
+		}
+		key, err := decodeKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key %q: %w", id, err)
+		}
+		keys = append(keys, MasterKey{ID: id, Key: key})
+	}
+	return keys, nil
+}
+
+func decodeKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+type Config struct {
+	MasterKeyID   string
+	MasterKeyFile string
+	MasterKey     string
+	// PreviousMasterKeys - прежние мастер-ключи в формате "id:base64", нужны до завершения ротации
+	PreviousMasterKeys []string
+}
+
+// OpenConfig загружает мастер-ключи из конфигурации и открывает Keyring.
+// Если мастер-ключ не задан, возвращает ErrNoMasterKey - шифрование выключено
+func OpenConfig(ctx context.Context, store KeyStore, cfg Config) (*Keyring, error) {
+	master, err := LoadMasterKey(cfg.MasterKeyID, cfg.MasterKeyFile, cfg.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := ParseMasterKeys(cfg.PreviousMasterKeys)
+	if err != nil {
+		return nil, err
+	}
+	return Open(ctx, store, master, previous...)
+}
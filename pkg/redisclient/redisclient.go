@@ -2,29 +2,136 @@ package redisclient
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	ModeSingle   = "single"
+	ModeSentinel = "sentinel"
+	ModeCluster  = "cluster"
+)
+
 type RedisConfig struct {
+	Mode     string
 	Host     string
 	Port     int
+	Username string
 	Password string
 	DB       int
+
+	SentinelMasterName string
+	SentinelAddrs      []string
+	SentinelUsername   string
+	SentinelPassword   string
+
+	ClusterNodes []string
+
+	TLS TLSConfig
 }
 
-func New(ctx context.Context, cfg RedisConfig) (*redis.Client, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+type TLSConfig struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
 
-	_, err := client.Ping(ctx).Result()
+// New создает клиент Redis в зависимости от режима: одиночный узел, Sentinel или Cluster
+func New(ctx context.Context, cfg RedisConfig) (redis.UniversalClient, error) {
+	tlsCfg, err := newTLSConfig(cfg.TLS)
 	if err != nil {
+		return nil, fmt.Errorf("failed to configure Redis TLS: %w", err)
+	}
+
+	var client redis.UniversalClient
+
+	switch cfg.Mode {
+	case "", ModeSingle:
+		client = redis.NewClient(&redis.Options{
+			Addr:      fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+			Username:  cfg.Username,
+			Password:  cfg.Password,
+			DB:        cfg.DB,
+			TLSConfig: tlsCfg,
+		})
+
+	case ModeSentinel:
+		if cfg.SentinelMasterName == "" || len(cfg.SentinelAddrs) == 0 {
+			return nil, errors.New("redis sentinel mode requires master name and sentinel addresses")
+		}
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.SentinelMasterName,
+			SentinelAddrs:    cfg.SentinelAddrs,
+			SentinelUsername: cfg.SentinelUsername,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			TLSConfig:        tlsCfg,
+		})
+
+	case ModeCluster:
+		if len(cfg.ClusterNodes) == 0 {
+			return nil, errors.New("redis cluster mode requires cluster nodes")
+		}
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     cfg.ClusterNodes,
+			Username:  cfg.Username,
+			Password:  cfg.Password,
+			TLSConfig: tlsCfg,
+		})
+
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", cfg.Mode)
+	}
+
+	_, err = client.Ping(ctx).Result()
+	if err != nil {
+		_ = client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	return client, nil
 }
+
+func newTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}